@@ -1,23 +1,37 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata" // Time zones for quick-add without a system database
 
+	"taskmanager/internal/clock"
 	"taskmanager/internal/config"
 	"taskmanager/internal/controllers"
 	"taskmanager/internal/db"
+	"taskmanager/internal/events"
+	"taskmanager/internal/graph"
 	"taskmanager/internal/logging"
+	"taskmanager/internal/realtime"
 	"taskmanager/internal/repository"
 	"taskmanager/internal/routes"
+	"taskmanager/internal/rpc"
+	"taskmanager/internal/search"
 	"taskmanager/internal/service"
+	"taskmanager/internal/storage"
 	customValidator "taskmanager/internal/validator" // Alias for custom validator
 
 	v10 "github.com/go-playground/validator/v10" // Alias for third-party validator
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -41,10 +55,124 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Initialize repository, service, and handler
-	repo := repository.NewTaskRepository(dbConn)
-	svc := service.NewTaskService(repo)
-	handler := controllers.NewTaskHandler(svc)
+	// Initialize attachment storage
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+	signingKey := []byte(cfg.DownloadSigningKey)
+	if len(signingKey) == 0 {
+		log.Println("DOWNLOAD_SIGNING_KEY not set, download links will not survive a restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			log.Fatalf("Failed to generate signing key: %v", err)
+		}
+	}
+
+	// Initialize repositories, services, and handlers
+	taskRepo := repository.NewTaskRepository(dbConn)
+	userRepo := repository.NewUserRepository(dbConn)
+	commentRepo := repository.NewCommentRepository(dbConn)
+	attachmentRepo := repository.NewAttachmentRepository(dbConn)
+	projectRepo := repository.NewProjectRepository(dbConn)
+	calendarRepo := repository.NewCalendarRepository(dbConn)
+	notificationService := service.NewNotificationService(repository.NewNotificationRepository(dbConn), taskRepo, userRepo, realtime.NewHub(), service.NewLogNotifier(), clock.System())
+	bus := events.NewBus()
+
+	attachmentService := service.NewAttachmentService(attachmentRepo, taskRepo, blobs, cfg.AttachmentMaxBytes, signingKey, cfg.DownloadURLTTL)
+	customFieldService := service.NewCustomFieldService(repository.NewCustomFieldRepository(dbConn), projectRepo, userRepo)
+	sprintRepo := repository.NewSprintRepository(dbConn)
+	taskService := service.NewTaskService(taskRepo, projectRepo, sprintRepo, customFieldService, attachmentService, bus)
+	calendarService := service.NewCalendarService(calendarRepo, projectRepo)
+
+	// Initialize search and keep it in sync with task changes
+	searchIndex, err := newSearchIndex(cfg, dbConn)
+	if err != nil {
+		log.Fatalf("Failed to initialize search index: %v", err)
+	}
+	searchService := service.NewSearchService(searchIndex, taskRepo, commentRepo)
+	bus.Subscribe(searchService.HandleEvent)
+	if count, err := searchIndex.Count(context.Background()); err != nil {
+		log.Fatalf("Failed to read search index: %v", err)
+	} else if count == 0 {
+		go func() {
+			if err := searchService.Reindex(context.Background()); err != nil {
+				log.Printf("Failed to build search index: %v", err)
+			}
+		}()
+	}
+
+	// Watchers hear of changes to the tasks they watch
+	memberService := service.NewTaskMemberService(repository.NewTaskMemberRepository(dbConn), taskRepo, userRepo, notificationService)
+	bus.Subscribe(memberService.HandleEvent)
+
+	// Run automation rules on task changes, off the request path
+	automationService := service.NewAutomationService(repository.NewAutomationRepository(dbConn), taskRepo, taskService, memberService, userRepo, &http.Client{Timeout: 10 * time.Second}, clock.System())
+	bus.Subscribe(automationService.HandleEvent)
+	go automationService.Run(context.Background())
+
+	// Remind assignees of the tasks coming due, and run the rules on tasks
+	// past due
+	go func() {
+		for range time.Tick(cfg.DueSoonInterval) {
+			if err := notificationService.NotifyDueSoon(); err != nil {
+				log.Printf("Failed to send due soon notifications: %v", err)
+			}
+			if err := automationService.CheckDueDates(); err != nil {
+				log.Printf("Failed to check due dates for automation rules: %v", err)
+			}
+		}
+	}()
+
+	// Watch for SLA breaches and escalate them
+	slaService := service.NewSLAService(repository.NewSLARepository(dbConn), projectRepo, taskRepo, taskService, calendarService, notificationService, clock.System())
+	go func() {
+		for range time.Tick(cfg.SLAInterval) {
+			if err := slaService.Evaluate(); err != nil {
+				log.Printf("Failed to evaluate SLA policies: %v", err)
+			}
+		}
+	}()
+
+	projectService := service.NewProjectService(projectRepo, calendarRepo, userRepo)
+	commentService := service.NewCommentService(commentRepo, taskRepo, userRepo, notificationService, bus)
+	userService := service.NewUserService(userRepo)
+
+	// Serve GraphQL over the same services, following changes on the bus
+	graphServer, err := graph.NewServer(graph.Services{
+		Tasks:    taskService,
+		Projects: projectService,
+		Comments: commentService,
+		Users:    userService,
+	}, graph.Limits{MaxDepth: cfg.GraphQLMaxDepth, MaxComplexity: cfg.GraphQLMaxComplexity}, bus)
+	if err != nil {
+		log.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+
+	handlers := routes.Handlers{
+		Task:         controllers.NewTaskHandler(taskService),
+		Comment:      controllers.NewCommentHandler(commentService),
+		Attachment:   controllers.NewAttachmentHandler(attachmentService, cfg.AttachmentMaxBytes),
+		Checklist:    controllers.NewChecklistHandler(service.NewChecklistService(repository.NewChecklistRepository(dbConn), taskService)),
+		Project:      controllers.NewProjectHandler(projectService, customFieldService),
+		User:         controllers.NewUserHandler(userService),
+		Template:     controllers.NewTemplateHandler(service.NewTemplateService(repository.NewTemplateRepository(dbConn), taskService, calendarService)),
+		View:         controllers.NewViewHandler(service.NewViewService(repository.NewViewRepository(dbConn), taskService)),
+		Search:       controllers.NewSearchHandler(searchService),
+		QuickAdd:     controllers.NewQuickAddHandler(service.NewQuickAddService(taskService, clock.System())),
+		Calendar:     controllers.NewCalendarHandler(calendarService),
+		Time:         controllers.NewTimeHandler(service.NewTimeService(repository.NewTimeEntryRepository(dbConn), taskRepo, projectRepo, clock.System())),
+		Member:       controllers.NewTaskMemberHandler(memberService),
+		Notification: controllers.NewNotificationHandler(notificationService),
+		Automation:   controllers.NewAutomationHandler(automationService),
+		TaskIO:       controllers.NewTaskIOHandler(service.NewTaskIOService(taskService, customFieldService)),
+		ToolImport:   controllers.NewToolImportHandler(service.NewToolImportService(projectService, taskService, taskRepo)),
+		Feed:         controllers.NewFeedHandler(service.NewFeedService(userRepo, taskService)),
+		CalDAV:       controllers.NewCalDAVHandler(service.NewCalDAVService(repository.NewDAVRepository(dbConn), userRepo, projectService, taskService)),
+		SLA:          controllers.NewSLAHandler(slaService),
+		Sprint:       controllers.NewSprintHandler(service.NewSprintService(sprintRepo, projectRepo, taskRepo, taskService, clock.System(), bus)),
+		GraphQL:      controllers.NewGraphQLHandler(graphServer),
+	}
 
 	// Initialize and register validator
 	validate := v10.New()
@@ -60,7 +188,24 @@ func main() {
 	e.Validator = &customValidator.CustomValidator{Validator: validate}
 
 	// Register routes
-	routes.RegisterRoutes(e, handler)
+	routes.RegisterRoutes(e, handlers)
+	if err := routes.RegisterDocs(e); err != nil {
+		log.Fatalf("Failed to describe the API: %v", err)
+	}
+
+	// Serve the task service over gRPC on a port of its own
+	grpcPort := getEnv("GRPC_PORT", "9090")
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
+	if err != nil {
+		log.Fatalf("Failed to listen for gRPC: %v", err)
+	}
+	grpcServer := rpc.NewServer(taskService, userService, bus, e.Validator.Validate)
+	go func() {
+		log.Printf("Starting gRPC server on :%s", grpcPort)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
 
 	// Start server
 	port := getEnv("PORT", "8080")
@@ -70,6 +215,37 @@ func main() {
 	}
 }
 
+// newBlobStore selects the attachment storage backend from the configuration.
+func newBlobStore(cfg *config.Config) (storage.BlobStore, error) {
+	switch cfg.StorageBackend {
+	case "local":
+		return storage.NewLocalStore(cfg.StoragePath)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", cfg.StorageBackend)
+	}
+}
+
+// newSearchIndex selects the full-text search backend from the
+// configuration. The memory index starts empty and is built on start.
+func newSearchIndex(cfg *config.Config, dbConn *gorm.DB) (search.Index, error) {
+	switch cfg.SearchBackend {
+	case "postgres":
+		return search.NewPostgresIndex(dbConn), nil
+	case "memory":
+		return search.NewMemoryIndex(), nil
+	default:
+		return nil, fmt.Errorf("unknown SEARCH_BACKEND %q", cfg.SearchBackend)
+	}
+}
+
 // getEnv retrieves an environment variable or returns a default value.
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -5,6 +5,7 @@ import (
 	"os"
 	"taskmanager/internal/config"
 	"taskmanager/internal/models"
+	"taskmanager/internal/search"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
@@ -36,7 +37,11 @@ func main() {
 	)
 
 	// Connect to database using GORM with PostgreSQL
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Deleted tasks can be restored, so rows referring to them must survive
+	// the delete; relationships are maintained by the repositories instead.
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
@@ -45,11 +50,47 @@ func main() {
 	if err := db.Migrator().DropTable(&models.Task{}); err != nil {
 		log.Warn().Err(err).Msg("Failed to drop existing tasks table")
 	}
+	// The search index refers to the dropped tasks and is rebuilt on start
+	if err := db.Migrator().DropTable(&search.Record{}); err != nil {
+		log.Warn().Err(err).Msg("Failed to drop existing search index")
+	}
 
 	// Auto migrate the schema
-	if err := db.AutoMigrate(&models.Task{}); err != nil {
+	if err := db.AutoMigrate(
+		&models.Task{},
+		&models.ChecklistItem{},
+		&models.TaskRevision{},
+		&models.User{},
+		&models.Comment{},
+		&models.CommentRevision{},
+		&models.CommentMention{},
+		&models.Attachment{},
+		&models.Project{},
+		&models.CustomField{},
+		&models.CustomFieldValue{},
+		&models.TaskTemplate{},
+		&models.View{},
+		&models.Calendar{},
+		&models.Holiday{},
+		&models.TimeEntry{},
+		&models.Sprint{},
+		&models.TaskMember{},
+		&models.Notification{},
+		&models.AutomationRule{},
+		&models.AutomationRun{},
+		&models.SLAPolicy{},
+		&models.TaskSLA{},
+		&models.DAVResource{},
+		&search.Record{},
+	); err != nil {
 		log.Fatal().Err(err).Msg("Failed to migrate database")
 	}
 
+	// Revisions from before the sprint_id column hold it in their snapshot
+	if err := db.Exec(`UPDATE task_revisions SET sprint_id = snapshot::jsonb->>'sprint_id'
+		WHERE sprint_id IS NULL AND snapshot::jsonb->>'sprint_id' IS NOT NULL`).Error; err != nil {
+		log.Fatal().Err(err).Msg("Failed to backfill the sprints of task revisions")
+	}
+
 	log.Info().Msg("Migration completed successfully")
 }
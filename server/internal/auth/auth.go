@@ -0,0 +1,54 @@
+// Package auth identifies the user making a request, the same way for every
+// API. Requests name their user by ID, in the X-User-ID header over HTTP and
+// the x-user-id metadata over gRPC. Naming a user is optional on most
+// routes: requests that name nobody, or a user who does not exist, are
+// anonymous and take times in UTC.
+package auth
+
+import (
+	"context"
+
+	"taskmanager/internal/models"
+)
+
+// Header names the user making a request. gRPC metadata keys are the
+// header in lower case.
+const Header = "X-User-ID"
+
+// Identity is who makes a request. UserID is empty for anonymous requests,
+// and TimeZone is the time zone of the user, "" for UTC.
+type Identity struct {
+	UserID   string
+	TimeZone string
+}
+
+// Users finds the users requests name.
+type Users interface {
+	GetUserByID(id string) (models.User, error)
+}
+
+// Identify returns the identity of the user with the given ID, which is
+// anonymous when the ID is empty or names no user.
+func Identify(users Users, userID string) Identity {
+	if userID == "" {
+		return Identity{}
+	}
+	user, err := users.GetUserByID(userID)
+	if err != nil {
+		return Identity{}
+	}
+	return Identity{UserID: user.ID, TimeZone: user.TimeZone}
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying an identity.
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity ctx carries, anonymous when it has none.
+func FromContext(ctx context.Context) Identity {
+	identity, _ := ctx.Value(contextKey{}).(Identity)
+	return identity
+}
@@ -0,0 +1,114 @@
+// Package calendar does date arithmetic on business calendars: the
+// weekdays and hours people work, in a time zone, less holidays.
+package calendar
+
+import "time"
+
+// dateLayout is the layout of holiday dates.
+const dateLayout = "2006-01-02"
+
+// maxSearchDays bounds the search for a working day, so that a calendar
+// without one cannot loop forever.
+const maxSearchDays = 366 * 5
+
+// Calendar is a working calendar. Work runs from Start to End after
+// midnight on every working day that is not a holiday.
+type Calendar struct {
+	Location    *time.Location
+	WorkingDays [7]bool
+	Start       time.Duration
+	End         time.Duration
+	// Holidays holds the dates (YYYY-MM-DD) nobody works on.
+	Holidays map[string]bool
+}
+
+// Standard returns a calendar of Monday to Friday, 9:00 to 17:00 UTC,
+// without holidays.
+func Standard() Calendar {
+	return Calendar{
+		Location:    time.UTC,
+		WorkingDays: [7]bool{false, true, true, true, true, true, false},
+		Start:       9 * time.Hour,
+		End:         17 * time.Hour,
+	}
+}
+
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// Date returns midnight of a date in the calendar's time zone, for date
+// arithmetic on civil dates.
+func (c Calendar) Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location())
+}
+
+// IsWorkingDay reports whether the date of t in the calendar's time zone
+// is a working day.
+func (c Calendar) IsWorkingDay(t time.Time) bool {
+	t = t.In(c.location())
+	return c.WorkingDays[t.Weekday()] && !c.Holidays[t.Format(dateLayout)]
+}
+
+// IsWorkingTime reports whether t falls within the working hours of a
+// working day.
+func (c Calendar) IsWorkingTime(t time.Time) bool {
+	if !c.IsWorkingDay(t) {
+		return false
+	}
+	t = t.In(c.location())
+	return !t.Before(at(t, c.Start)) && t.Before(at(t, c.End))
+}
+
+// AddBusinessDays returns the date days working days after the date of t,
+// or before it when days is negative, at the same time of day. Days that
+// are not working days are not counted, so one business day after a
+// Friday is the next Monday.
+func (c Calendar) AddBusinessDays(t time.Time, days int) time.Time {
+	step := 1
+	if days < 0 {
+		step, days = -1, -days
+	}
+	local := t.In(c.location())
+	for searched := 0; days > 0 && searched < maxSearchDays; searched++ {
+		local = local.AddDate(0, 0, step)
+		if c.IsWorkingDay(local) {
+			days--
+		}
+	}
+	return local
+}
+
+// NextWorkingTime returns t when it is working time, and otherwise the
+// start of the next working hours.
+func (c Calendar) NextWorkingTime(t time.Time) time.Time {
+	local := t.In(c.location())
+	for searched := 0; searched < maxSearchDays; searched++ {
+		if c.IsWorkingDay(local) {
+			start, end := at(local, c.Start), at(local, c.End)
+			if local.Before(start) {
+				return start
+			}
+			if local.Before(end) {
+				return local
+			}
+		}
+		local = midnight(local).AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// midnight returns the start of the day of t in its location.
+func midnight(t time.Time) time.Time {
+	return at(t, 0)
+}
+
+// at returns the wall clock time offset after midnight on the day of t,
+// which differs from adding offset to midnight on daylight saving days.
+func at(t time.Time, offset time.Duration) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, t.Location())
+}
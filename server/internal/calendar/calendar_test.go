@@ -0,0 +1,114 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+const layout = "Mon 2006-01-02 15:04 MST"
+
+func berlin(t *testing.T) Calendar {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Standard()
+	c.Location = location
+	c.Holidays = map[string]bool{"2026-12-24": true, "2026-12-25": true}
+	return c
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	standard, berlin := Standard(), berlin(t)
+	// A Wednesday
+	wednesday := time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		t        time.Time
+		days     int
+		want     string
+	}{
+		{name: "none", calendar: standard, t: wednesday, days: 0, want: "Wed 2026-10-14 10:00 UTC"},
+		{name: "one", calendar: standard, t: wednesday, days: 1, want: "Thu 2026-10-15 10:00 UTC"},
+		{name: "a week", calendar: standard, t: wednesday, days: 5, want: "Wed 2026-10-21 10:00 UTC"},
+		{name: "over the weekend", calendar: standard, t: wednesday.AddDate(0, 0, 2), days: 1, want: "Mon 2026-10-19 10:00 UTC"},
+		{name: "from a Saturday", calendar: standard, t: wednesday.AddDate(0, 0, 3), days: 1, want: "Mon 2026-10-19 10:00 UTC"},
+		{name: "back over the weekend", calendar: standard, t: wednesday.AddDate(0, 0, 5), days: -1, want: "Fri 2026-10-16 10:00 UTC"},
+		{name: "back from a Saturday", calendar: standard, t: wednesday.AddDate(0, 0, 3), days: -1, want: "Fri 2026-10-16 10:00 UTC"},
+		{name: "over holidays", calendar: berlin, t: time.Date(2026, time.December, 23, 10, 0, 0, 0, berlin.Location), days: 1, want: "Mon 2026-12-28 10:00 CET"},
+		{name: "back over holidays", calendar: berlin, t: time.Date(2026, time.December, 28, 10, 0, 0, 0, berlin.Location), days: -2, want: "Tue 2026-12-22 10:00 CET"},
+		{name: "in the calendar's time zone", calendar: berlin, t: time.Date(2026, time.October, 16, 23, 30, 0, 0, time.UTC), days: 1, want: "Mon 2026-10-19 01:30 CEST"},
+		{name: "across a daylight saving change", calendar: berlin, t: time.Date(2026, time.October, 23, 9, 0, 0, 0, berlin.Location), days: 1, want: "Mon 2026-10-26 09:00 CET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.AddBusinessDays(tt.t, tt.days).Format(layout); got != tt.want {
+				t.Errorf("AddBusinessDays() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWorkingTime(t *testing.T) {
+	standard, berlin := Standard(), berlin(t)
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		t        time.Time
+		want     bool
+	}{
+		{name: "start of the day", calendar: standard, t: time.Date(2026, time.October, 14, 9, 0, 0, 0, time.UTC), want: true},
+		{name: "before the start", calendar: standard, t: time.Date(2026, time.October, 14, 8, 59, 0, 0, time.UTC), want: false},
+		{name: "before the end", calendar: standard, t: time.Date(2026, time.October, 14, 16, 59, 0, 0, time.UTC), want: true},
+		{name: "end of the day", calendar: standard, t: time.Date(2026, time.October, 14, 17, 0, 0, 0, time.UTC), want: false},
+		{name: "weekend", calendar: standard, t: time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "holiday", calendar: berlin, t: time.Date(2026, time.December, 24, 12, 0, 0, 0, berlin.Location), want: false},
+		{name: "in the calendar's time zone", calendar: berlin, t: time.Date(2026, time.October, 14, 7, 30, 0, 0, time.UTC), want: true},
+		{name: "after hours in the calendar's time zone", calendar: berlin, t: time.Date(2026, time.October, 14, 15, 30, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.IsWorkingTime(tt.t); got != tt.want {
+				t.Errorf("IsWorkingTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextWorkingTime(t *testing.T) {
+	standard, berlin := Standard(), berlin(t)
+	everyDay := berlin
+	everyDay.WorkingDays = [7]bool{true, true, true, true, true, true, true}
+	var never Calendar
+	wednesday := time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		t        time.Time
+		want     string
+	}{
+		{name: "working time", calendar: standard, t: wednesday, want: "Wed 2026-10-14 12:00 UTC"},
+		{name: "before the start", calendar: standard, t: wednesday.Add(-4 * time.Hour), want: "Wed 2026-10-14 09:00 UTC"},
+		{name: "after the end", calendar: standard, t: wednesday.Add(5 * time.Hour), want: "Thu 2026-10-15 09:00 UTC"},
+		{name: "weekend", calendar: standard, t: wednesday.AddDate(0, 0, 2).Add(6 * time.Hour), want: "Mon 2026-10-19 09:00 UTC"},
+		{name: "holidays", calendar: berlin, t: time.Date(2026, time.December, 23, 18, 0, 0, 0, berlin.Location), want: "Mon 2026-12-28 09:00 CET"},
+		{name: "wall clock time on a daylight saving day", calendar: everyDay, t: time.Date(2026, time.October, 25, 0, 30, 0, 0, berlin.Location), want: "Sun 2026-10-25 09:00 CET"},
+		{name: "no working days", calendar: never, t: wednesday, want: "Wed 2026-10-14 12:00 UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.NextWorkingTime(tt.t).Format(layout); got != tt.want {
+				t.Errorf("NextWorkingTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"taskmanager/internal/ical"
+)
+
+// Holiday is a day off read from an iCalendar file.
+type Holiday struct {
+	// Date is the day off as YYYY-MM-DD.
+	Date string
+	Name string
+	// UID identifies the event the day comes from.
+	UID string
+}
+
+// maxHolidayDays caps the length of one event, so that a mistaken end
+// date cannot produce years of holidays.
+const maxHolidayDays = 31
+
+// ParseHolidays reads the events of an iCalendar file (RFC 5545) as
+// holidays, one per day an event covers. Repeating events (RRULE) are
+// skipped and counted, as only their first day would be known.
+func ParseHolidays(r io.Reader) (holidays []Holiday, skipped int, err error) {
+	root, err := ical.Decode(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, event := range events(root) {
+		if _, ok := event.Prop("RRULE"); ok {
+			skipped++
+			continue
+		}
+		days, err := eventHolidays(event)
+		if err != nil {
+			uid, _ := event.Prop("UID")
+			return nil, 0, fmt.Errorf("event %q: %w", uid.Value, err)
+		}
+		holidays = append(holidays, days...)
+	}
+	return holidays, skipped, nil
+}
+
+// events lists the VEVENT components of a component and its
+// subcomponents, in file order.
+func events(component ical.Component) []ical.Component {
+	if component.Name == "VEVENT" {
+		return []ical.Component{component}
+	}
+	var found []ical.Component
+	for _, child := range component.Components {
+		found = append(found, events(child)...)
+	}
+	return found
+}
+
+// eventHolidays lists the days from DTSTART up to DTEND, which is
+// exclusive, or DTSTART alone when the event has no end.
+func eventHolidays(event ical.Component) ([]Holiday, error) {
+	start, ok := event.Prop("DTSTART")
+	if !ok {
+		return nil, fmt.Errorf("event without DTSTART")
+	}
+	first, err := parseDate(start)
+	if err != nil {
+		return nil, err
+	}
+	last := first
+	if end, ok := event.Prop("DTEND"); ok {
+		if last, err = parseDate(end); err != nil {
+			return nil, err
+		}
+		// All-day ends are exclusive, timed ones end within their day
+		if isDate(end) {
+			last = last.AddDate(0, 0, -1)
+		}
+	}
+	if last.Before(first) {
+		last = first
+	}
+
+	summary, _ := event.Prop("SUMMARY")
+	uid, _ := event.Prop("UID")
+	name := strings.ReplaceAll(ical.Unescape(summary.Value), "\n", " ")
+	var holidays []Holiday
+	for day := first; !day.After(last) && len(holidays) < maxHolidayDays; day = day.AddDate(0, 0, 1) {
+		holidays = append(holidays, Holiday{Date: day.Format(dateLayout), Name: name, UID: uid.Value})
+	}
+	return holidays, nil
+}
+
+func isDate(prop ical.Property) bool {
+	return strings.EqualFold(prop.Params["VALUE"], "DATE") || len(prop.Value) == len("20060102")
+}
+
+// parseDate returns the civil date of a DATE or DATE-TIME value, taking
+// date-times in their TZID when it is known.
+func parseDate(prop ical.Property) (time.Time, error) {
+	value := prop.Value
+	if isDate(prop) {
+		return time.Parse("20060102", value)
+	}
+	location := time.UTC
+	if tzid := prop.Params["TZID"]; tzid != "" && !strings.HasSuffix(value, "Z") {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(value, "Z"), location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHolidays(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Holidays//EN",
+		"BEGIN:VEVENT",
+		"UID:new-year@example.com",
+		"DTSTART;VALUE=DATE:20270101",
+		"DTEND;VALUE=DATE:20270102",
+		"SUMMARY:New Year\\, again",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:christmas@example.com",
+		"DTSTART;VALUE=DATE:20261224",
+		"DTEND;VALUE=DATE:20261227",
+		"SUMMARY:Christmas",
+		"  holidays",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:offsite@example.com",
+		`DTSTART;TZID="Asia/Tokyo":20261103T073000`,
+		`DTEND;TZID="Asia/Tokyo":20261103T180000`,
+		"SUMMARY:Offsite",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:release@example.com",
+		"DTSTART:20261120T233000Z",
+		"SUMMARY:Release day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:backwards@example.com",
+		"DTSTART:20261201",
+		"DTEND:20261130",
+		"SUMMARY:Ends first",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly@example.com",
+		"DTSTART;VALUE=DATE:20261106",
+		"RRULE:FREQ=WEEKLY",
+		"SUMMARY:Every Friday",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	holidays, skipped, err := ParseHolidays(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("ParseHolidays() error = %v", err)
+	}
+	want := []Holiday{
+		{Date: "2027-01-01", Name: "New Year, again", UID: "new-year@example.com"},
+		{Date: "2026-12-24", Name: "Christmas holidays", UID: "christmas@example.com"},
+		{Date: "2026-12-25", Name: "Christmas holidays", UID: "christmas@example.com"},
+		{Date: "2026-12-26", Name: "Christmas holidays", UID: "christmas@example.com"},
+		{Date: "2026-11-03", Name: "Offsite", UID: "offsite@example.com"},
+		{Date: "2026-11-20", Name: "Release day", UID: "release@example.com"},
+		{Date: "2026-12-01", Name: "Ends first", UID: "backwards@example.com"},
+	}
+	if !reflect.DeepEqual(holidays, want) {
+		t.Errorf("ParseHolidays() = %v, want %v", holidays, want)
+	}
+	if skipped != 1 {
+		t.Errorf("ParseHolidays() skipped = %d, want 1", skipped)
+	}
+}
+
+func TestParseHolidaysLongEvent(t *testing.T) {
+	ics := "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20260101\nDTEND;VALUE=DATE:20270101\nEND:VEVENT\n"
+	holidays, _, err := ParseHolidays(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("ParseHolidays() error = %v", err)
+	}
+	if len(holidays) != maxHolidayDays || holidays[maxHolidayDays-1].Date != "2026-01-31" {
+		t.Errorf("ParseHolidays() = %d days, want the %d from 2026-01-01", len(holidays), maxHolidayDays)
+	}
+}
+
+func TestParseHolidaysErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+	}{
+		{name: "line without a value", ics: "BEGIN:VEVENT\nDTSTART\nEND:VEVENT\n"},
+		{name: "event without a start", ics: "BEGIN:VEVENT\nSUMMARY:Someday\nEND:VEVENT\n"},
+		{name: "invalid start", ics: "BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n"},
+		{name: "invalid date", ics: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261340\nEND:VEVENT\n"},
+		{name: "invalid end", ics: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261224\nDTEND:soon\nEND:VEVENT\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if holidays, _, err := ParseHolidays(strings.NewReader(tt.ics)); err == nil {
+				t.Errorf("ParseHolidays() = %v, want an error", holidays)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package clock lets code that depends on the current time be given a
+// fixed one.
+package clock
+
+import "time"
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type system struct{}
+
+// System returns the clock of the machine.
+func System() Clock {
+	return system{}
+}
+
+func (system) Now() time.Time {
+	return time.Now()
+}
+
+type fixed struct {
+	now time.Time
+}
+
+// Fixed returns a clock that is always at now.
+func Fixed(now time.Time) Clock {
+	return fixed{now: now}
+}
+
+func (c fixed) Now() time.Time {
+	return c.now
+}
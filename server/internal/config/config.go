@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds the application configuration.
@@ -11,16 +14,79 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+
+	StorageBackend     string
+	StoragePath        string
+	S3Endpoint         string
+	S3Region           string
+	S3Bucket           string
+	S3AccessKey        string
+	S3SecretKey        string
+	AttachmentMaxBytes int64
+	DownloadSigningKey string
+	DownloadURLTTL     time.Duration
+
+	SearchBackend string
+
+	DueSoonInterval time.Duration
+	SLAInterval     time.Duration
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
 }
 
 // Load loads the configuration from environment variables.
 func Load() (*Config, error) {
+	maxBytes, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_BYTES", "26214400"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTACHMENT_MAX_BYTES: %w", err)
+	}
+	ttl, err := time.ParseDuration(getEnv("DOWNLOAD_URL_TTL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOWNLOAD_URL_TTL: %w", err)
+	}
+	dueSoon, err := time.ParseDuration(getEnv("DUE_SOON_INTERVAL", "15m"))
+	if err != nil || dueSoon <= 0 {
+		return nil, fmt.Errorf("invalid DUE_SOON_INTERVAL: %q", getEnv("DUE_SOON_INTERVAL", ""))
+	}
+	slaInterval, err := time.ParseDuration(getEnv("SLA_INTERVAL", "5m"))
+	if err != nil || slaInterval <= 0 {
+		return nil, fmt.Errorf("invalid SLA_INTERVAL: %q", getEnv("SLA_INTERVAL", ""))
+	}
+	maxDepth, err := strconv.Atoi(getEnv("GRAPHQL_MAX_DEPTH", "10"))
+	if err != nil || maxDepth <= 0 {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_DEPTH: %q", getEnv("GRAPHQL_MAX_DEPTH", ""))
+	}
+	maxComplexity, err := strconv.Atoi(getEnv("GRAPHQL_MAX_COMPLEXITY", "10000"))
+	if err != nil || maxComplexity <= 0 {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_COMPLEXITY: %q", getEnv("GRAPHQL_MAX_COMPLEXITY", ""))
+	}
+
 	return &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "3306"),
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", "password"),
 		DBName:     getEnv("DB_NAME", "taskmanager"),
+
+		StorageBackend:     getEnv("STORAGE_BACKEND", "local"),
+		StoragePath:        getEnv("STORAGE_PATH", "data/blobs"),
+		S3Endpoint:         getEnv("S3_ENDPOINT", ""),
+		S3Region:           getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:           getEnv("S3_BUCKET", ""),
+		S3AccessKey:        getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:        getEnv("S3_SECRET_KEY", ""),
+		AttachmentMaxBytes: maxBytes,
+		DownloadSigningKey: getEnv("DOWNLOAD_SIGNING_KEY", ""),
+		DownloadURLTTL:     ttl,
+
+		SearchBackend: getEnv("SEARCH_BACKEND", "postgres"),
+
+		DueSoonInterval: dueSoon,
+		SLAInterval:     slaInterval,
+
+		GraphQLMaxDepth:      maxDepth,
+		GraphQLMaxComplexity: maxComplexity,
 	}, nil
 }
 
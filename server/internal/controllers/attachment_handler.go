@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// multipartOverhead allows for boundaries and part headers on top of the
+// file itself when capping the request body.
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	service  service.AttachmentService
+	maxBytes int64
+}
+
+func NewAttachmentHandler(service service.AttachmentService, maxBytes int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxBytes: maxBytes}
+}
+
+func (h *AttachmentHandler) ListAttachments(c echo.Context) error {
+	taskID := c.Param("id")
+	attachments, err := h.service.ListAttachments(taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch attachments")
+		return attachmentError(c, "Failed to fetch attachments", err)
+	}
+	return c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment reads the "file" part of a multipart request as a stream
+// so that large uploads are never buffered in memory.
+func (h *AttachmentHandler) UploadAttachment(c echo.Context) error {
+	taskID := c.Param("id")
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxBytes+multipartOverhead)
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return attachmentError(c, "Failed to read upload", err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.service.UploadAttachment(req.Context(), taskID, currentUserID(c), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			log.Error().Err(err).Str("task_id", taskID).Msg("Failed to upload attachment")
+			return attachmentError(c, "Failed to upload attachment", err)
+		}
+		return c.JSON(http.StatusCreated, attachment)
+	}
+
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":   "Invalid input",
+		"message": `multipart field "file" is required`,
+	})
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c echo.Context) error {
+	id := c.Param("attachmentId")
+	if err := h.service.DeleteAttachment(c.Request().Context(), c.Param("id"), id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete attachment")
+		return attachmentError(c, "Failed to delete attachment", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AttachmentHandler) GetDownloadURL(c echo.Context) error {
+	id := c.Param("attachmentId")
+	url, err := h.service.SignDownloadURL(c.Param("id"), id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to sign download URL")
+		return attachmentError(c, "Failed to create download URL", err)
+	}
+	return c.JSON(http.StatusOK, url)
+}
+
+func (h *AttachmentHandler) DownloadAttachment(c echo.Context) error {
+	id := c.Param("id")
+	attachment, content, err := h.service.OpenDownload(c.Request().Context(), id, c.QueryParam("expires"), c.QueryParam("signature"))
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to download attachment")
+		return attachmentError(c, "Failed to download attachment", err)
+	}
+	defer content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(attachment.Size, 10))
+	header.Set("X-Content-Type-Options", "nosniff")
+	return c.Stream(http.StatusOK, attachment.ContentType, content)
+}
+
+func attachmentError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrEmptyAttachment):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrInvalidSignature):
+		status = http.StatusForbidden
+	}
+	return errorJSON(c, status, message, err)
+}
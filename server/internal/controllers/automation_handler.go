@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type AutomationHandler struct {
+	service service.AutomationService
+}
+
+func NewAutomationHandler(service service.AutomationService) *AutomationHandler {
+	return &AutomationHandler{service: service}
+}
+
+func (h *AutomationHandler) ListRules(c echo.Context) error {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch automation rules")
+		return errorJSON(c, statusFor(err), "Failed to fetch automation rules", err)
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (h *AutomationHandler) GetRule(c echo.Context) error {
+	id := c.Param("id")
+	rule, err := h.service.GetRule(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch automation rule")
+		return errorJSON(c, statusFor(err), "Failed to fetch automation rule", err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (h *AutomationHandler) CreateRule(c echo.Context) error {
+	var input models.AutomationRuleInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind AutomationRuleInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for AutomationRuleInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	rule, err := h.service.CreateRule(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create automation rule")
+		return errorJSON(c, statusFor(err), "Failed to create automation rule", err)
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AutomationHandler) UpdateRule(c echo.Context) error {
+	id := c.Param("id")
+	var input models.AutomationRuleInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind AutomationRuleInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for AutomationRuleInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	rule, err := h.service.UpdateRule(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update automation rule")
+		return errorJSON(c, statusFor(err), "Failed to update automation rule", err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (h *AutomationHandler) DeleteRule(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteRule(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete automation rule")
+		return errorJSON(c, statusFor(err), "Failed to delete automation rule", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AutomationHandler) ListRuns(c echo.Context) error {
+	id := c.Param("id")
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid pagination",
+			"message": err.Error(),
+		})
+	}
+
+	runs, err := h.service.ListRuns(id, page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch automation runs")
+		return errorJSON(c, statusFor(err), "Failed to fetch automation runs", err)
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// DryRun tries a rule on a task without changing anything.
+func (h *AutomationHandler) DryRun(c echo.Context) error {
+	id := c.Param("id")
+	var input models.DryRunInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind DryRunInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for DryRunInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	result, err := h.service.DryRun(id, input.TaskID)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to dry-run automation rule")
+		return errorJSON(c, statusFor(err), "Failed to dry-run automation rule", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,620 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// DAVPath is where CalDAV clients are served: the principal of the user
+// under principals/ and the calendar collections under calendars/.
+const DAVPath = "/dav"
+
+// davUsernameKey holds the username a CalDAV client signed in with
+const davUsernameKey = "dav_username"
+
+// maxDAVBody caps the size of CalDAV request bodies.
+const maxDAVBody = 1 << 20
+
+// XML namespaces of CalDAV properties
+const (
+	nsDAV            = "DAV:"
+	nsCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// davPrefixes are the prefixes multistatus bodies declare.
+var davPrefixes = map[string]string{
+	nsDAV:            "d",
+	nsCalDAV:         "c",
+	nsCalendarServer: "cs",
+}
+
+var (
+	propResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	propCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL          = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propPrivilegeSet          = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propSupportedReportSet    = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propSupportedComponentSet = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propGetCTag               = xml.Name{Space: nsCalendarServer, Local: "getctag"}
+)
+
+// davPrivileges are the privileges of every user on every resource.
+const davPrivileges = `<d:privilege><d:read/></d:privilege>` +
+	`<d:privilege><d:write/></d:privilege>` +
+	`<d:privilege><d:write-content/></d:privilege>` +
+	`<d:privilege><d:bind/></d:privilege>` +
+	`<d:privilege><d:unbind/></d:privilege>`
+
+// davObjectType is the content type of calendar object resources.
+const davObjectType = "text/calendar; charset=utf-8; component=VTODO"
+
+type CalDAVHandler struct {
+	service service.CalDAVService
+}
+
+func NewCalDAVHandler(service service.CalDAVService) *CalDAVHandler {
+	return &CalDAVHandler{service: service}
+}
+
+// IssuePassword gives the current user a new password for CalDAV clients,
+// revoking the previous one.
+func (h *CalDAVHandler) IssuePassword(c echo.Context) error {
+	credentials, err := h.service.IssuePassword(currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to issue CalDAV password")
+		return errorJSON(c, statusFor(err), "Failed to issue CalDAV password", err)
+	}
+	credentials.URL = c.Scheme() + "://" + c.Request().Host + DAVPath + "/"
+	return c.JSON(http.StatusCreated, credentials)
+}
+
+// RevokePassword signs the CalDAV clients of the current user out.
+func (h *CalDAVHandler) RevokePassword(c echo.Context) error {
+	if err := h.service.RevokePassword(currentUserID(c)); err != nil {
+		log.Error().Err(err).Msg("Failed to revoke CalDAV password")
+		return errorJSON(c, statusFor(err), "Failed to revoke CalDAV password", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Authenticate checks the basic auth credentials of a CalDAV client and
+// stores its user on the context.
+func (h *CalDAVHandler) Authenticate(username, password string, c echo.Context) (bool, error) {
+	user, ok, err := h.service.Authenticate(username, password)
+	if err != nil || !ok {
+		return false, err
+	}
+	c.Set(userIDKey, user.ID)
+	c.Set(timeZoneKey, user.TimeZone)
+	c.Set(davUsernameKey, user.Username)
+	return true, nil
+}
+
+// WellKnown sends clients looking for the CalDAV service to its root.
+func (h *CalDAVHandler) WellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, DAVPath+"/")
+}
+
+// Options advertises CalDAV support.
+func (h *CalDAVHandler) Options(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set("DAV", "1, 3, calendar-access")
+	header.Set(echo.HeaderAllow, "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	return c.NoContent(http.StatusOK)
+}
+
+// PropfindRoot points clients at the principal of their user.
+func (h *CalDAVHandler) PropfindRoot(c echo.Context) error {
+	requested, err := davPropfindRequest(c)
+	if err != nil {
+		return davBadRequest(c, err)
+	}
+	return davMultistatus(c, []davResponse{
+		davPropResponse(DAVPath+"/", requested, []davProp{
+			{propResourceType, `<d:collection/>`},
+			{propCurrentUserPrincipal, davHref(davPrincipalPath(c))},
+		}),
+	})
+}
+
+// PropfindPrincipal points clients at the calendar home.
+func (h *CalDAVHandler) PropfindPrincipal(c echo.Context) error {
+	user, err := davParam(c, "user")
+	if err != nil || user != c.Get(davUsernameKey) {
+		return c.NoContent(http.StatusNotFound)
+	}
+	requested, err := davPropfindRequest(c)
+	if err != nil {
+		return davBadRequest(c, err)
+	}
+	principal := davPrincipalPath(c)
+	return davMultistatus(c, []davResponse{
+		davPropResponse(principal, requested, []davProp{
+			{propResourceType, `<d:principal/>`},
+			{propDisplayName, davText(user)},
+			{propCurrentUserPrincipal, davHref(principal)},
+			{propPrincipalURL, davHref(principal)},
+			{propCalendarHomeSet, davHref(davHomePath())},
+		}),
+	})
+}
+
+// PropfindHome lists the calendar collections: the inbox and a collection
+// per project.
+func (h *CalDAVHandler) PropfindHome(c echo.Context) error {
+	requested, err := davPropfindRequest(c)
+	if err != nil {
+		return davBadRequest(c, err)
+	}
+	responses := []davResponse{
+		davPropResponse(davHomePath(), requested, []davProp{
+			{propResourceType, `<d:collection/>`},
+			{propDisplayName, davText("Tasks")},
+			{propCurrentUserPrincipal, davHref(davPrincipalPath(c))},
+			{propPrivilegeSet, davPrivileges},
+		}),
+	}
+	if c.Request().Header.Get("Depth") != "0" {
+		collections, err := h.service.Collections()
+		if err != nil {
+			return davError(c, err, "Failed to list calendars")
+		}
+		for _, collection := range collections {
+			responses = append(responses, davPropResponse(davCollectionPath(collection.ID), requested, davCollectionProps(c, collection)))
+		}
+	}
+	return davMultistatus(c, responses)
+}
+
+// PropfindCollection describes a calendar collection and, unless the
+// depth is 0, the to-dos in it.
+func (h *CalDAVHandler) PropfindCollection(c echo.Context) error {
+	collectionID, err := davParam(c, "collection")
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	requested, err := davPropfindRequest(c)
+	if err != nil {
+		return davBadRequest(c, err)
+	}
+	collection, err := h.service.Collection(collectionID)
+	if err != nil {
+		return davError(c, err, "Failed to find calendar")
+	}
+	responses := []davResponse{
+		davPropResponse(davCollectionPath(collection.ID), requested, davCollectionProps(c, collection)),
+	}
+	if c.Request().Header.Get("Depth") != "0" {
+		objects, err := h.service.Objects(collection.ID)
+		if err != nil {
+			return davError(c, err, "Failed to list to-dos")
+		}
+		for _, object := range objects {
+			responses = append(responses, davPropResponse(davObjectPath(collection.ID, object.Name), requested, davObjectProps(object, requested != nil)))
+		}
+	}
+	return davMultistatus(c, responses)
+}
+
+// PropfindObject describes a to-do.
+func (h *CalDAVHandler) PropfindObject(c echo.Context) error {
+	collectionID, name, err := davObjectParams(c)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	requested, err := davPropfindRequest(c)
+	if err != nil {
+		return davBadRequest(c, err)
+	}
+	object, err := h.service.Object(collectionID, name)
+	if err != nil {
+		return davError(c, err, "Failed to find to-do")
+	}
+	return davMultistatus(c, []davResponse{
+		davPropResponse(davObjectPath(collectionID, object.Name), requested, davObjectProps(object, requested != nil)),
+	})
+}
+
+// Report answers calendar-multiget and calendar-query reports on a
+// collection. Queries only tell to-dos from other components apart, so
+// time ranges and property filters match every to-do.
+func (h *CalDAVHandler) Report(c echo.Context) error {
+	collectionID, err := davParam(c, "collection")
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	var report davReport
+	if err := davDecode(c, &report); err != nil {
+		return davBadRequest(c, err)
+	}
+	requested := report.Prop.names()
+	if report.AllProp != nil || requested == nil {
+		requested = []xml.Name{propGetETag, propCalendarData}
+	}
+
+	var responses []davResponse
+	switch report.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		collectionPath := davCollectionPath(collectionID)
+		for _, href := range report.Hrefs {
+			name, ok := davHrefName(href, collectionPath)
+			if !ok {
+				responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			object, err := h.service.Object(collectionID, name)
+			if errors.Is(err, repository.ErrNotFound) {
+				responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			if err != nil {
+				return davError(c, err, "Failed to get to-dos")
+			}
+			responses = append(responses, davPropResponse(davObjectPath(collectionID, object.Name), requested, davObjectProps(object, true)))
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		objects, err := h.service.Objects(collectionID)
+		if err != nil {
+			return davError(c, err, "Failed to list to-dos")
+		}
+		if !report.Filter.matchesTodos() {
+			objects = nil
+		}
+		for _, object := range objects {
+			responses = append(responses, davPropResponse(davObjectPath(collectionID, object.Name), requested, davObjectProps(object, true)))
+		}
+	default:
+		return c.Blob(http.StatusForbidden, echo.MIMEApplicationXMLCharsetUTF8,
+			[]byte(xml.Header+`<d:error xmlns:d="DAV:"><d:supported-report/></d:error>`))
+	}
+	return davMultistatus(c, responses)
+}
+
+// GetObject serves a to-do.
+func (h *CalDAVHandler) GetObject(c echo.Context) error {
+	collectionID, name, err := davObjectParams(c)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	object, err := h.service.Object(collectionID, name)
+	if err != nil {
+		return davError(c, err, "Failed to get to-do")
+	}
+	c.Response().Header().Set("ETag", object.ETag)
+	if etagMatches(c.Request().Header.Get("If-None-Match"), object.ETag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, davObjectType, object.Data)
+}
+
+// PutObject creates or replaces the task of a to-do. No ETag is returned,
+// as the stored to-do is written from the task rather than kept as sent.
+func (h *CalDAVHandler) PutObject(c echo.Context) error {
+	collectionID, name, err := davObjectParams(c)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	req := c.Request()
+	body := http.MaxBytesReader(c.Response(), req.Body, maxDAVBody)
+	created, err := h.service.PutObject(collectionID, name, body, req.Header.Get("If-Match"), req.Header.Get("If-None-Match"), currentUserID(c))
+	if err != nil {
+		return davError(c, err, "Failed to save to-do")
+	}
+	if created {
+		return c.NoContent(http.StatusCreated)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteObject deletes the task of a to-do.
+func (h *CalDAVHandler) DeleteObject(c echo.Context) error {
+	collectionID, name, err := davObjectParams(c)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err := h.service.DeleteObject(collectionID, name, c.Request().Header.Get("If-Match"), currentUserID(c)); err != nil {
+		return davError(c, err, "Failed to delete to-do")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// davError writes the error of a CalDAV request, adding the statuses of
+// failed preconditions to those shared by every resource.
+func davError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return errorJSON(c, http.StatusPreconditionFailed, message, err)
+	case errors.Is(err, service.ErrUIDConflict):
+		return errorJSON(c, http.StatusConflict, message, err)
+	}
+	status := statusFor(err)
+	if status == http.StatusInternalServerError {
+		log.Error().Err(err).Msg(message)
+	}
+	return errorJSON(c, status, message, err)
+}
+
+func davBadRequest(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":   "Invalid input",
+		"message": err.Error(),
+	})
+}
+
+// davParam returns a path parameter, unescaped whether or not the router
+// matched the escaped path.
+func davParam(c echo.Context, name string) (string, error) {
+	value := c.Param(name)
+	if c.Request().URL.RawPath == "" {
+		return value, nil
+	}
+	return url.PathUnescape(value)
+}
+
+func davObjectParams(c echo.Context) (string, string, error) {
+	collectionID, err := davParam(c, "collection")
+	if err != nil {
+		return "", "", err
+	}
+	name, err := davParam(c, "name")
+	return collectionID, name, err
+}
+
+func davPrincipalPath(c echo.Context) string {
+	username, _ := c.Get(davUsernameKey).(string)
+	return DAVPath + "/principals/" + url.PathEscape(username) + "/"
+}
+
+func davHomePath() string {
+	return DAVPath + "/calendars/"
+}
+
+func davCollectionPath(collectionID string) string {
+	return davHomePath() + url.PathEscape(collectionID) + "/"
+}
+
+func davObjectPath(collectionID, name string) string {
+	return davCollectionPath(collectionID) + url.PathEscape(name)
+}
+
+// davHrefName returns the name of the resource an href of a multiget
+// names, and false for hrefs outside the collection.
+func davHrefName(href, collectionPath string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+	dir, name := path.Split(parsed.Path)
+	collection, err := url.PathUnescape(collectionPath)
+	if err != nil || dir != collection || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func davCollectionProps(c echo.Context, collection models.DAVCollection) []davProp {
+	return []davProp{
+		{propResourceType, `<d:collection/><c:calendar/>`},
+		{propDisplayName, davText(collection.Name)},
+		{propSupportedComponentSet, `<c:comp name="VTODO"/>`},
+		{propGetCTag, davText(collection.CTag)},
+		{propGetETag, davText(`"` + collection.CTag + `"`)},
+		{propSupportedReportSet, `<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>` +
+			`<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>`},
+		{propPrivilegeSet, davPrivileges},
+		{propCurrentUserPrincipal, davHref(davPrincipalPath(c))},
+	}
+}
+
+// davObjectProps lists the properties of a to-do. The calendar data is
+// only sent when asked for by name.
+func davObjectProps(object models.DAVObject, withData bool) []davProp {
+	props := []davProp{
+		{propResourceType, ""},
+		{propGetETag, davText(object.ETag)},
+		{propGetContentType, davText(davObjectType)},
+	}
+	if withData {
+		props = append(props, davProp{propCalendarData, davText(string(object.Data))})
+	}
+	return props
+}
+
+// davProp is a property of a resource, with its value written as XML.
+type davProp struct {
+	name  xml.Name
+	value string
+}
+
+// davResponse is a response of a multistatus body: the properties found
+// and not found on a resource, or the status of a resource that is not
+// there.
+type davResponse struct {
+	href    string
+	found   []davProp
+	missing []xml.Name
+	status  int
+}
+
+// davPropResponse answers a request for properties of a resource. A nil
+// request asks for all of them.
+func davPropResponse(href string, requested []xml.Name, props []davProp) davResponse {
+	response := davResponse{href: href}
+	if requested == nil {
+		response.found = props
+		return response
+	}
+	for _, name := range requested {
+		found := false
+		for _, prop := range props {
+			if prop.name == name {
+				response.found = append(response.found, prop)
+				found = true
+				break
+			}
+		}
+		if !found {
+			response.missing = append(response.missing, name)
+		}
+	}
+	return response
+}
+
+func davMultistatus(c echo.Context, responses []davResponse) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + nsCalDAV + `" xmlns:cs="` + nsCalendarServer + `">`)
+	for _, response := range responses {
+		b.WriteString(`<d:response>`)
+		b.WriteString(davHref(response.href))
+		if response.status != 0 {
+			b.WriteString(davStatus(response.status))
+		}
+		if len(response.found) > 0 {
+			b.WriteString(`<d:propstat><d:prop>`)
+			for _, prop := range response.found {
+				davElement(&b, prop.name, prop.value)
+			}
+			b.WriteString(`</d:prop>` + davStatus(http.StatusOK) + `</d:propstat>`)
+		}
+		if len(response.missing) > 0 {
+			b.WriteString(`<d:propstat><d:prop>`)
+			for _, name := range response.missing {
+				davElement(&b, name, "")
+			}
+			b.WriteString(`</d:prop>` + davStatus(http.StatusNotFound) + `</d:propstat>`)
+		}
+		b.WriteString(`</d:response>`)
+	}
+	b.WriteString(`</d:multistatus>`)
+	return c.Blob(http.StatusMultiStatus, echo.MIMEApplicationXMLCharsetUTF8, []byte(b.String()))
+}
+
+// davElement writes an element with the prefix of its namespace, or a
+// namespace of its own for properties of other namespaces.
+func davElement(b *strings.Builder, name xml.Name, value string) {
+	tag, open := name.Local, name.Local+` xmlns="`+davText(name.Space)+`"`
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+		open = tag
+	}
+	if value == "" {
+		b.WriteString("<" + open + "/>")
+		return
+	}
+	b.WriteString("<" + open + ">" + value + "</" + tag + ">")
+}
+
+func davHref(href string) string {
+	return `<d:href>` + davText(href) + `</d:href>`
+}
+
+func davStatus(status int) string {
+	return `<d:status>HTTP/1.1 ` + strconv.Itoa(status) + " " + http.StatusText(status) + `</d:status>`
+}
+
+// davText escapes text for XML.
+func davText(text string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// davPropNames are the properties a request names.
+type davPropNames struct {
+	Props []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// names returns the names of the properties, or nil when none are named.
+func (p davPropNames) names() []xml.Name {
+	if len(p.Props) == 0 {
+		return nil
+	}
+	names := make([]xml.Name, len(p.Props))
+	for i, prop := range p.Props {
+		names[i] = prop.XMLName
+	}
+	return names
+}
+
+type davPropfind struct {
+	XMLName xml.Name     `xml:"DAV: propfind"`
+	AllProp *struct{}    `xml:"DAV: allprop"`
+	Prop    davPropNames `xml:"DAV: prop"`
+}
+
+type davReport struct {
+	XMLName xml.Name
+	AllProp *struct{}    `xml:"DAV: allprop"`
+	Prop    davPropNames `xml:"DAV: prop"`
+	Hrefs   []string     `xml:"DAV: href"`
+	Filter  davFilter    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type davFilter struct {
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// matchesTodos reports whether a query filter can match to-dos, which is
+// the case unless it asks for another component of the calendars.
+func (f davFilter) matchesTodos() bool {
+	for _, calendar := range f.CompFilters {
+		if len(calendar.CompFilters) == 0 {
+			return true
+		}
+		for _, component := range calendar.CompFilters {
+			if strings.EqualFold(component.Name, "VTODO") {
+				return true
+			}
+		}
+	}
+	return len(f.CompFilters) == 0
+}
+
+// davPropfindRequest returns the properties a PROPFIND asks for, or nil
+// for all of them, as asked by an empty body.
+func davPropfindRequest(c echo.Context) ([]xml.Name, error) {
+	var propfind davPropfind
+	if err := davDecode(c, &propfind); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if propfind.AllProp != nil {
+		return nil, nil
+	}
+	return propfind.Prop.names(), nil
+}
+
+func davDecode(c echo.Context, v interface{}) error {
+	req := c.Request()
+	return xml.NewDecoder(http.MaxBytesReader(c.Response(), req.Body, maxDAVBody)).Decode(v)
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import "testing"
+
+func TestDAVHrefName(t *testing.T) {
+	collection := davCollectionPath("3f2a6c1e-inbox")
+	tests := []struct {
+		name     string
+		href     string
+		wantName string
+		wantOK   bool
+	}{
+		{name: "path", href: "/dav/calendars/3f2a6c1e-inbox/task.ics", wantName: "task.ics", wantOK: true},
+		{name: "absolute URL", href: "https://tasks.example.com/dav/calendars/3f2a6c1e-inbox/task.ics", wantName: "task.ics", wantOK: true},
+		{name: "surrounding space", href: "\n  /dav/calendars/3f2a6c1e-inbox/task.ics  ", wantName: "task.ics", wantOK: true},
+		{name: "escaped name", href: "/dav/calendars/3f2a6c1e-inbox/Einkauf%20M%C3%BCller.ics", wantName: "Einkauf Müller.ics", wantOK: true},
+		{name: "Apple UUID name", href: "/dav/calendars/3f2a6c1e-inbox/6F1D2C1A-4B3E-4E8F-9C55-1A2B3C4D5E6F.ics", wantName: "6F1D2C1A-4B3E-4E8F-9C55-1A2B3C4D5E6F.ics", wantOK: true},
+		{name: "other collection", href: "/dav/calendars/other/task.ics"},
+		{name: "collection itself", href: "/dav/calendars/3f2a6c1e-inbox/"},
+		{name: "nested", href: "/dav/calendars/3f2a6c1e-inbox/sub/task.ics"},
+		{name: "invalid URL", href: "/dav/calendars/3f2a6c1e-inbox/%zz.ics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := davHrefName(tt.href, collection)
+			if name != tt.wantName || ok != tt.wantOK {
+				t.Errorf("davHrefName(%q) = %q, %v; want %q, %v", tt.href, name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDAVHrefNameEscapedCollection(t *testing.T) {
+	name, ok := davHrefName("/dav/calendars/team%20a/task.ics", davCollectionPath("team a"))
+	if !ok || name != "task.ics" {
+		t.Errorf("davHrefName() = %q, %v; want %q, true", name, ok, "task.ics")
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"5d41402abc4b2a76"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{ifNoneMatch: "", want: false},
+		{ifNoneMatch: etag, want: true},
+		{ifNoneMatch: `W/"5d41402abc4b2a76"`, want: true},
+		{ifNoneMatch: "*", want: true},
+		{ifNoneMatch: `"0000" , "5d41402abc4b2a76"`, want: true},
+		{ifNoneMatch: `"0000"`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
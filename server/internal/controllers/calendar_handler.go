@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxCalendarFile caps the size of imported iCalendar files.
+const maxCalendarFile = 1 << 20
+
+type CalendarHandler struct {
+	service service.CalendarService
+}
+
+func NewCalendarHandler(service service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+func (h *CalendarHandler) GetAllCalendars(c echo.Context) error {
+	calendars, err := h.service.GetAllCalendars()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all calendars")
+		return errorJSON(c, statusFor(err), "Failed to fetch calendars", err)
+	}
+	return c.JSON(http.StatusOK, calendars)
+}
+
+func (h *CalendarHandler) GetCalendarByID(c echo.Context) error {
+	id := c.Param("id")
+	cal, err := h.service.GetCalendarByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch calendar")
+		return errorJSON(c, statusFor(err), "Failed to fetch calendar", err)
+	}
+	return c.JSON(http.StatusOK, cal)
+}
+
+func (h *CalendarHandler) CreateCalendar(c echo.Context) error {
+	var input models.CalendarInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CalendarInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CalendarInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	cal, err := h.service.CreateCalendar(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create calendar")
+		return errorJSON(c, statusFor(err), "Failed to create calendar", err)
+	}
+	return c.JSON(http.StatusCreated, cal)
+}
+
+func (h *CalendarHandler) UpdateCalendar(c echo.Context) error {
+	id := c.Param("id")
+	var input models.CalendarInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CalendarInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CalendarInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	cal, err := h.service.UpdateCalendar(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update calendar")
+		return errorJSON(c, statusFor(err), "Failed to update calendar", err)
+	}
+	return c.JSON(http.StatusOK, cal)
+}
+
+func (h *CalendarHandler) DeleteCalendar(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteCalendar(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete calendar")
+		return errorJSON(c, statusFor(err), "Failed to delete calendar", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *CalendarHandler) AddHoliday(c echo.Context) error {
+	calendarID := c.Param("id")
+	var input models.HolidayInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind HolidayInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for HolidayInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	holiday, err := h.service.AddHoliday(calendarID, input)
+	if err != nil {
+		log.Error().Err(err).Str("calendar_id", calendarID).Msg("Failed to add holiday")
+		return errorJSON(c, statusFor(err), "Failed to add holiday", err)
+	}
+	return c.JSON(http.StatusCreated, holiday)
+}
+
+func (h *CalendarHandler) DeleteHoliday(c echo.Context) error {
+	calendarID, id := c.Param("id"), c.Param("holidayId")
+	if err := h.service.DeleteHoliday(calendarID, id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete holiday")
+		return errorJSON(c, statusFor(err), "Failed to delete holiday", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ImportHolidays adds the events of an iCalendar file as holidays. The
+// file is the "file" part of a multipart request or the request body
+// itself.
+func (h *CalendarHandler) ImportHolidays(c echo.Context) error {
+	calendarID := c.Param("id")
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxCalendarFile+multipartOverhead)
+
+	var file io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		upload, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": `multipart field "file" is required`,
+			})
+		}
+		opened, err := upload.Open()
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, "Failed to read upload", err)
+		}
+		defer opened.Close()
+		file = opened
+	}
+
+	result, err := h.service.ImportHolidays(calendarID, file)
+	if err != nil {
+		log.Error().Err(err).Str("calendar_id", calendarID).Msg("Failed to import holidays")
+		return errorJSON(c, statusFor(err), "Failed to import holidays", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// BusinessDays returns the date that is days working days after from.
+func (h *CalendarHandler) BusinessDays(c echo.Context) error {
+	calendarID := c.Param("id")
+	days, err := strconv.Atoi(c.QueryParam("days"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": "days must be an integer",
+		})
+	}
+
+	result, err := h.service.BusinessDays(calendarID, c.QueryParam("from"), days)
+	if err != nil {
+		return errorJSON(c, statusFor(err), "Failed to add business days", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// WorkingTime tells whether the RFC 3339 "at" parameter, by default now,
+// is working time.
+func (h *CalendarHandler) WorkingTime(c echo.Context) error {
+	calendarID := c.Param("id")
+	at := time.Now()
+	if value := c.QueryParam("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": "at must be an RFC 3339 timestamp",
+			})
+		}
+		at = parsed
+	}
+
+	result, err := h.service.WorkingTime(calendarID, at)
+	if err != nil {
+		return errorJSON(c, statusFor(err), "Failed to check working time", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
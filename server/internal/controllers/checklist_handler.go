@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type ChecklistHandler struct {
+	service service.ChecklistService
+}
+
+func NewChecklistHandler(service service.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{service: service}
+}
+
+func (h *ChecklistHandler) ListItems(c echo.Context) error {
+	taskID := c.Param("id")
+	items, err := h.service.ListItems(taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch checklist")
+		return checklistError(c, "Failed to fetch checklist", err)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+func (h *ChecklistHandler) AddItem(c echo.Context) error {
+	var input models.CreateChecklistItemInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CreateChecklistItemInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateChecklistItemInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	item, err := h.service.AddItem(c.Param("id"), input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to add checklist item")
+		return checklistError(c, "Failed to add checklist item", err)
+	}
+	return c.JSON(http.StatusCreated, item)
+}
+
+func (h *ChecklistHandler) UpdateItem(c echo.Context) error {
+	var input models.UpdateChecklistItemInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind UpdateChecklistItemInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateChecklistItemInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	itemID := c.Param("itemId")
+	item, err := h.service.UpdateItem(c.Param("id"), itemID, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", itemID).Msg("Failed to update checklist item")
+		return checklistError(c, "Failed to update checklist item", err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+func (h *ChecklistHandler) ToggleItem(c echo.Context) error {
+	itemID := c.Param("itemId")
+	item, err := h.service.ToggleItem(c.Param("id"), itemID)
+	if err != nil {
+		log.Error().Err(err).Str("id", itemID).Msg("Failed to toggle checklist item")
+		return checklistError(c, "Failed to toggle checklist item", err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+func (h *ChecklistHandler) DeleteItem(c echo.Context) error {
+	itemID := c.Param("itemId")
+	if err := h.service.DeleteItem(c.Param("id"), itemID); err != nil {
+		log.Error().Err(err).Str("id", itemID).Msg("Failed to delete checklist item")
+		return checklistError(c, "Failed to delete checklist item", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ChecklistHandler) ReorderItems(c echo.Context) error {
+	var input models.ReorderChecklistInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind ReorderChecklistInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	taskID := c.Param("id")
+	items, err := h.service.ReorderItems(taskID, input)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to reorder checklist")
+		return checklistError(c, "Failed to reorder checklist", err)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+func (h *ChecklistHandler) ConvertItem(c echo.Context) error {
+	itemID := c.Param("itemId")
+	task, err := h.service.ConvertItem(c.Param("id"), itemID)
+	if err != nil {
+		log.Error().Err(err).Str("id", itemID).Msg("Failed to convert checklist item")
+		return checklistError(c, "Failed to convert checklist item", err)
+	}
+	return c.JSON(http.StatusCreated, task)
+}
+
+func checklistError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	if errors.Is(err, service.ErrChecklistMismatch) {
+		status = http.StatusBadRequest
+	}
+	return errorJSON(c, status, message, err)
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type CommentHandler struct {
+	service service.CommentService
+}
+
+func NewCommentHandler(service service.CommentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+func (h *CommentHandler) ListComments(c echo.Context) error {
+	taskID := c.Param("id")
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid pagination",
+			"message": err.Error(),
+		})
+	}
+
+	comments, err := h.service.ListComments(taskID, page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch comments")
+		return commentError(c, "Failed to fetch comments", err)
+	}
+	return c.JSON(http.StatusOK, comments)
+}
+
+func (h *CommentHandler) CreateComment(c echo.Context) error {
+	userID := currentUserID(c)
+
+	var input models.CreateCommentInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CreateCommentInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateCommentInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	comment, err := h.service.CreateComment(c.Param("id"), userID, input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create comment")
+		return commentError(c, "Failed to create comment", err)
+	}
+	return c.JSON(http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) UpdateComment(c echo.Context) error {
+	userID := currentUserID(c)
+
+	var input models.UpdateCommentInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind UpdateCommentInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateCommentInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	commentID := c.Param("commentId")
+	comment, err := h.service.UpdateComment(c.Param("id"), commentID, userID, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", commentID).Msg("Failed to update comment")
+		return commentError(c, "Failed to update comment", err)
+	}
+	return c.JSON(http.StatusOK, comment)
+}
+
+func (h *CommentHandler) DeleteComment(c echo.Context) error {
+	userID := currentUserID(c)
+	commentID := c.Param("commentId")
+	if err := h.service.DeleteComment(c.Param("id"), commentID, userID); err != nil {
+		log.Error().Err(err).Str("id", commentID).Msg("Failed to delete comment")
+		return commentError(c, "Failed to delete comment", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *CommentHandler) GetCommentHistory(c echo.Context) error {
+	commentID := c.Param("commentId")
+	revisions, err := h.service.GetCommentHistory(c.Param("id"), commentID)
+	if err != nil {
+		log.Error().Err(err).Str("id", commentID).Msg("Failed to fetch comment history")
+		return commentError(c, "Failed to fetch comment history", err)
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+func commentError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	switch {
+	case errors.Is(err, service.ErrCommentForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidParent):
+		status = http.StatusBadRequest
+	}
+	return errorJSON(c, status, message, err)
+}
+
+// parsePagination reads the optional "page" and "page_size" query parameters.
+func parsePagination(c echo.Context) (int, int, error) {
+	page, pageSize := 1, defaultPageSize
+	if value := c.QueryParam("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = n
+	}
+	if value := c.QueryParam("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			return 0, 0, errors.New("page_size must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		pageSize = n
+	}
+	return page, pageSize, nil
+}
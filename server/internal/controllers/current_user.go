@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderUserID identifies the user making the request.
+const HeaderUserID = auth.Header
+
+const (
+	userIDKey   = "user_id"
+	timeZoneKey = "time_zone"
+)
+
+// RequireUser rejects requests that do not identify the acting user and
+// stores the user ID on the context for handlers.
+func RequireUser() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID := c.Request().Header.Get(HeaderUserID)
+			if userID == "" {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"error":   "Unauthorized",
+					"message": "missing " + HeaderUserID + " header",
+				})
+			}
+			c.Set(userIDKey, userID)
+			return next(c)
+		}
+	}
+}
+
+// currentUserID returns the user ID stored by RequireUser or
+// UserHandler.ResolveTimeZone, or "" when there is none.
+func currentUserID(c echo.Context) string {
+	userID, _ := c.Get(userIDKey).(string)
+	return userID
+}
+
+// userTimeZone returns the time zone stored by UserHandler.ResolveTimeZone,
+// or "" for UTC.
+func userTimeZone(c echo.Context) string {
+	timeZone, _ := c.Get(timeZoneKey).(string)
+	return timeZone
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OpenAPIPath is where the OpenAPI document of the API is served
+const OpenAPIPath = "/api/openapi.json"
+
+// docsPage browses the OpenAPI document with Swagger UI
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Task Manager API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "` + OpenAPIPath + `", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// DocsHandler serves the description of the API.
+type DocsHandler struct {
+	spec []byte
+}
+
+func NewDocsHandler(spec []byte) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+// Spec returns the OpenAPI document.
+func (h *DocsHandler) Spec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.spec)
+}
+
+// UI returns a page to browse the OpenAPI document.
+func (h *DocsHandler) UI(c echo.Context) error {
+	return c.HTML(http.StatusOK, docsPage)
+}
@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	stderrors "errors"
+	"net/http"
+
 	"taskmanager/internal/errors"
+	"taskmanager/internal/filter"
+	"taskmanager/internal/repository"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
@@ -18,3 +24,36 @@ func ErrorMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// statusFor maps errors shared by every resource to an HTTP status code.
+func statusFor(err error) int {
+	var validationErrs validator.ValidationErrors
+	var validationErr *errors.ValidationError
+	var filterErr *filter.Error
+	switch {
+	case stderrors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case stderrors.As(err, &validationErrs), stderrors.As(err, &validationErr), stderrors.As(err, &filterErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorJSON writes the standard error body, adding per-field details for
+// validation errors and the column of filter errors.
+func errorJSON(c echo.Context, status int, message string, err error) error {
+	body := map[string]interface{}{
+		"error":   message,
+		"message": err.Error(),
+	}
+	var validationErr *errors.ValidationError
+	if stderrors.As(err, &validationErr) && len(validationErr.Details) > 0 {
+		body["details"] = validationErr.Details
+	}
+	var filterErr *filter.Error
+	if stderrors.As(err, &filterErr) {
+		body["column"] = filterErr.Col
+	}
+	return c.JSON(status, body)
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// feedPath is where feeds are served, with the token in place of ":token"
+const feedPath = "/api/v1/feeds/:token/tasks.ics"
+
+type FeedHandler struct {
+	service service.FeedService
+}
+
+func NewFeedHandler(service service.FeedService) *FeedHandler {
+	return &FeedHandler{service: service}
+}
+
+// IssueToken gives the current user a new calendar feed URL, revoking the
+// previous one.
+func (h *FeedHandler) IssueToken(c echo.Context) error {
+	token, err := h.service.IssueToken(currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to issue feed token")
+		return errorJSON(c, statusFor(err), "Failed to issue feed token", err)
+	}
+	url := c.Scheme() + "://" + c.Request().Host + strings.Replace(feedPath, ":token", token, 1)
+	return c.JSON(http.StatusCreated, models.FeedToken{Token: token, URL: url})
+}
+
+// RevokeToken turns the calendar feed of the current user off.
+func (h *FeedHandler) RevokeToken(c echo.Context) error {
+	if err := h.service.RevokeToken(currentUserID(c)); err != nil {
+		log.Error().Err(err).Msg("Failed to revoke feed token")
+		return errorJSON(c, statusFor(err), "Failed to revoke feed token", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TaskFeed serves the iCalendar feed of a token. project_id, tag and
+// assignee narrow the tasks, and events=true writes events instead of
+// to-dos. The ETag of the feed lets clients poll with If-None-Match.
+func (h *FeedHandler) TaskFeed(c echo.Context) error {
+	options := models.FeedOptions{
+		ProjectID: c.QueryParam("project_id"),
+		Tag:       c.QueryParam("tag"),
+		Assignee:  c.QueryParam("assignee"),
+	}
+	if value := c.QueryParam("events"); value != "" {
+		var err error
+		if options.Events, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": "events must be true or false",
+			})
+		}
+	}
+
+	feed, err := h.service.TaskFeed(c.Param("token"), options)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build task feed")
+		return errorJSON(c, statusFor(err), "Failed to build task feed", err)
+	}
+
+	sum := sha256.Sum256(feed)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "private, no-cache")
+	if etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
+
+// etagMatches reports whether an If-None-Match header names etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
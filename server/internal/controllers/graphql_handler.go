@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"taskmanager/internal/graph"
+	"taskmanager/internal/realtime"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/labstack/echo/v4"
+)
+
+// GraphQLPath is where GraphQL requests are served
+const GraphQLPath = "/api/graphql"
+
+// Server-sent events of subscriptions, as in the GraphQL over SSE protocol
+const (
+	graphQLEventNext     = "next"
+	graphQLEventComplete = "complete"
+)
+
+type GraphQLHandler struct {
+	server *graph.Server
+}
+
+func NewGraphQLHandler(server *graph.Server) *GraphQLHandler {
+	return &GraphQLHandler{server: server}
+}
+
+// Serve runs a GraphQL request, sent as a JSON body with POST or in the
+// query string with GET. Queries can use either, mutations need POST, and
+// subscriptions stream a server-sent "next" event per result for as long
+// as the client stays connected.
+func (h *GraphQLHandler) Serve(c echo.Context) error {
+	var req graph.Request
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+		if variables := c.QueryParam("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return graphQLError(c, http.StatusBadRequest, "Invalid variables: "+err.Error())
+			}
+		}
+	} else if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return graphQLError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+	if req.Query == "" {
+		return graphQLError(c, http.StatusBadRequest, "Missing query")
+	}
+
+	op, result := h.server.Prepare(req)
+	if result != nil {
+		return c.JSON(http.StatusBadRequest, result)
+	}
+	if op.Mutation() && c.Request().Method == http.MethodGet {
+		c.Response().Header().Set(echo.HeaderAllow, http.MethodPost)
+		return graphQLError(c, http.StatusMethodNotAllowed, "Mutations must be sent with POST")
+	}
+
+	viewer := graph.Viewer{UserID: currentUserID(c), TimeZone: userTimeZone(c), Validate: c.Validate}
+	ctx := c.Request().Context()
+	if !op.Subscription() {
+		return c.JSON(http.StatusOK, h.server.Execute(ctx, viewer, op))
+	}
+
+	results := h.server.Subscribe(ctx, viewer, op)
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case result, ok := <-results:
+			if !ok {
+				writeEvent(res, realtime.Message{Event: graphQLEventComplete})
+				return nil
+			}
+			if err := writeEvent(res, realtime.Message{Event: graphQLEventNext, Data: result}); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// graphQLError responds with a request error in the shape of a GraphQL
+// result, which is what GraphQL clients read.
+func graphQLError(c echo.Context, status int, message string) error {
+	return c.JSON(status, &graphql.Result{Errors: []gqlerrors.FormattedError{{Message: message}}})
+}
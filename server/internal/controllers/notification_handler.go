@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"taskmanager/internal/realtime"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// streamHeartbeat is how often an idle stream sends a comment to keep
+// proxies from closing it.
+const streamHeartbeat = 30 * time.Second
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(service service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// ListNotifications returns a page of the user's inbox; unread=true leaves
+// out the notifications already read.
+func (h *NotificationHandler) ListNotifications(c echo.Context) error {
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid pagination",
+			"message": err.Error(),
+		})
+	}
+
+	notifications, err := h.service.ListNotifications(currentUserID(c), c.QueryParam("unread") == "true", page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch notifications")
+		return errorJSON(c, statusFor(err), "Failed to fetch notifications", err)
+	}
+	return c.JSON(http.StatusOK, notifications)
+}
+
+func (h *NotificationHandler) UnreadCount(c echo.Context) error {
+	count, err := h.service.UnreadCount(currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count unread notifications")
+		return errorJSON(c, statusFor(err), "Failed to count unread notifications", err)
+	}
+	return c.JSON(http.StatusOK, count)
+}
+
+func (h *NotificationHandler) MarkRead(c echo.Context) error {
+	return h.mark(c, true)
+}
+
+func (h *NotificationHandler) MarkUnread(c echo.Context) error {
+	return h.mark(c, false)
+}
+
+func (h *NotificationHandler) mark(c echo.Context, read bool) error {
+	id := c.Param("id")
+	notification, err := h.service.MarkRead(currentUserID(c), id, read)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to mark notification")
+		return errorJSON(c, statusFor(err), "Failed to mark notification", err)
+	}
+	return c.JSON(http.StatusOK, notification)
+}
+
+func (h *NotificationHandler) MarkAllRead(c echo.Context) error {
+	count, err := h.service.MarkAllRead(currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark notifications read")
+		return errorJSON(c, statusFor(err), "Failed to mark notifications read", err)
+	}
+	return c.JSON(http.StatusOK, count)
+}
+
+// Stream sends the user's new notifications and unread count as
+// server-sent events for as long as the client stays connected. The
+// unread count is sent first.
+func (h *NotificationHandler) Stream(c echo.Context) error {
+	userID := currentUserID(c)
+	count, err := h.service.UnreadCount(userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count unread notifications")
+		return errorJSON(c, statusFor(err), "Failed to open notification stream", err)
+	}
+	messages, unsubscribe := h.service.Subscribe(userID)
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	if err := writeEvent(res, realtime.Message{Event: service.EventUnread, Data: count}); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case message := <-messages:
+			if err := writeEvent(res, message); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// writeEvent writes a message as a server-sent event and flushes it.
+func writeEvent(res *echo.Response, message realtime.Message) error {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", message.Event, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type ProjectHandler struct {
+	service service.ProjectService
+	fields  service.CustomFieldService
+}
+
+func NewProjectHandler(service service.ProjectService, fields service.CustomFieldService) *ProjectHandler {
+	return &ProjectHandler{service: service, fields: fields}
+}
+
+func (h *ProjectHandler) GetAllProjects(c echo.Context) error {
+	projects, err := h.service.GetAllProjects()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all projects")
+		return errorJSON(c, statusFor(err), "Failed to fetch projects", err)
+	}
+	return c.JSON(http.StatusOK, projects)
+}
+
+func (h *ProjectHandler) GetProjectByID(c echo.Context) error {
+	id := c.Param("id")
+	project, err := h.service.GetProjectByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch project")
+		return errorJSON(c, statusFor(err), "Project not found", err)
+	}
+	return c.JSON(http.StatusOK, project)
+}
+
+func (h *ProjectHandler) CreateProject(c echo.Context) error {
+	var input models.ProjectInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind ProjectInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ProjectInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	project, err := h.service.CreateProject(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create project")
+		return errorJSON(c, statusFor(err), "Failed to create project", err)
+	}
+	return c.JSON(http.StatusCreated, project)
+}
+
+func (h *ProjectHandler) UpdateProject(c echo.Context) error {
+	id := c.Param("id")
+	var input models.ProjectInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind ProjectInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ProjectInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	project, err := h.service.UpdateProject(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update project")
+		return errorJSON(c, statusFor(err), "Failed to update project", err)
+	}
+	return c.JSON(http.StatusOK, project)
+}
+
+func (h *ProjectHandler) DeleteProject(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteProject(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete project")
+		return errorJSON(c, statusFor(err), "Failed to delete project", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ProjectHandler) ListFields(c echo.Context) error {
+	projectID := c.Param("id")
+	fields, err := h.fields.ListFields(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch custom fields")
+		return fieldError(c, "Failed to fetch custom fields", err)
+	}
+	return c.JSON(http.StatusOK, fields)
+}
+
+func (h *ProjectHandler) CreateField(c echo.Context) error {
+	var input models.CreateCustomFieldInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CreateCustomFieldInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateCustomFieldInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	field, err := h.fields.CreateField(c.Param("id"), input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create custom field")
+		return fieldError(c, "Failed to create custom field", err)
+	}
+	return c.JSON(http.StatusCreated, field)
+}
+
+func (h *ProjectHandler) UpdateField(c echo.Context) error {
+	var input models.UpdateCustomFieldInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind UpdateCustomFieldInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateCustomFieldInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	fieldID := c.Param("fieldId")
+	field, err := h.fields.UpdateField(c.Param("id"), fieldID, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", fieldID).Msg("Failed to update custom field")
+		return fieldError(c, "Failed to update custom field", err)
+	}
+	return c.JSON(http.StatusOK, field)
+}
+
+func (h *ProjectHandler) DeleteField(c echo.Context) error {
+	fieldID := c.Param("fieldId")
+	if err := h.fields.DeleteField(c.Param("id"), fieldID); err != nil {
+		log.Error().Err(err).Str("id", fieldID).Msg("Failed to delete custom field")
+		return fieldError(c, "Failed to delete custom field", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func fieldError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	if errors.Is(err, service.ErrNoOptions) {
+		status = http.StatusBadRequest
+	}
+	return errorJSON(c, status, message, err)
+}
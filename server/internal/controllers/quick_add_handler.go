@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type QuickAddHandler struct {
+	service service.QuickAddService
+}
+
+func NewQuickAddHandler(service service.QuickAddService) *QuickAddHandler {
+	return &QuickAddHandler{service: service}
+}
+
+// QuickAdd creates a task from a line of text such as "Renew TLS cert next
+// friday 9am #ops !high every month".
+func (h *QuickAddHandler) QuickAdd(c echo.Context) error {
+	var input models.QuickAddInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind QuickAddInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for QuickAddInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+	if input.TimeZone == "" {
+		input.TimeZone = userTimeZone(c)
+	}
+
+	result, err := h.service.QuickAdd(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to quick-add task")
+		return errorJSON(c, statusFor(err), "Failed to create task", err)
+	}
+	return c.JSON(http.StatusCreated, result)
+}
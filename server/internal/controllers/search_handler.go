@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type SearchHandler struct {
+	service service.SearchService
+}
+
+func NewSearchHandler(service service.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Search finds tasks whose title, tags, description or comments contain
+// every word of the q parameter, best matches first.
+func (h *SearchHandler) Search(c echo.Context) error {
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid pagination",
+			"message": err.Error(),
+		})
+	}
+
+	results, err := h.service.Search(c.Request().Context(), models.SearchQuery{
+		Query:     c.QueryParam("q"),
+		ProjectID: c.QueryParam("project_id"),
+		Page:      page,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search tasks")
+		return errorJSON(c, statusFor(err), "Failed to search tasks", err)
+	}
+	return c.JSON(http.StatusOK, results)
+}
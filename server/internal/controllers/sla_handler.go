@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type SLAHandler struct {
+	service service.SLAService
+}
+
+func NewSLAHandler(service service.SLAService) *SLAHandler {
+	return &SLAHandler{service: service}
+}
+
+func (h *SLAHandler) ListPolicies(c echo.Context) error {
+	projectID := c.Param("id")
+	policies, err := h.service.ListPolicies(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch SLA policies")
+		return errorJSON(c, statusFor(err), "Failed to fetch SLA policies", err)
+	}
+	return c.JSON(http.StatusOK, policies)
+}
+
+func (h *SLAHandler) GetPolicy(c echo.Context) error {
+	id := c.Param("id")
+	policy, err := h.service.GetPolicy(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch SLA policy")
+		return errorJSON(c, statusFor(err), "Failed to fetch SLA policy", err)
+	}
+	return c.JSON(http.StatusOK, policy)
+}
+
+func (h *SLAHandler) CreatePolicy(c echo.Context) error {
+	projectID := c.Param("id")
+	var input models.SLAPolicyInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind SLAPolicyInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SLAPolicyInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	policy, err := h.service.CreatePolicy(projectID, input)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to create SLA policy")
+		return errorJSON(c, statusFor(err), "Failed to create SLA policy", err)
+	}
+	return c.JSON(http.StatusCreated, policy)
+}
+
+func (h *SLAHandler) UpdatePolicy(c echo.Context) error {
+	id := c.Param("id")
+	var input models.SLAPolicyInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind SLAPolicyInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SLAPolicyInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	policy, err := h.service.UpdatePolicy(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update SLA policy")
+		return errorJSON(c, statusFor(err), "Failed to update SLA policy", err)
+	}
+	return c.JSON(http.StatusOK, policy)
+}
+
+func (h *SLAHandler) DeletePolicy(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeletePolicy(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete SLA policy")
+		return errorJSON(c, statusFor(err), "Failed to delete SLA policy", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListTaskStatuses lists the at-risk and breached tasks of a project,
+// narrowed to one of the two by ?state=.
+func (h *SLAHandler) ListTaskStatuses(c echo.Context) error {
+	projectID := c.Param("id")
+	statuses, err := h.service.ListTaskStatuses(projectID, c.QueryParam("state"))
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch SLA statuses")
+		return errorJSON(c, statusFor(err), "Failed to fetch SLA statuses", err)
+	}
+	return c.JSON(http.StatusOK, statuses)
+}
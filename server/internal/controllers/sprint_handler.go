@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type SprintHandler struct {
+	service service.SprintService
+}
+
+func NewSprintHandler(service service.SprintService) *SprintHandler {
+	return &SprintHandler{service: service}
+}
+
+func (h *SprintHandler) ListSprints(c echo.Context) error {
+	projectID := c.Param("id")
+	sprints, err := h.service.ListSprints(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch sprints")
+		return errorJSON(c, statusFor(err), "Failed to fetch sprints", err)
+	}
+	return c.JSON(http.StatusOK, sprints)
+}
+
+func (h *SprintHandler) GetSprint(c echo.Context) error {
+	id := c.Param("id")
+	sprint, err := h.service.GetSprint(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch sprint")
+		return errorJSON(c, statusFor(err), "Failed to fetch sprint", err)
+	}
+	return c.JSON(http.StatusOK, sprint)
+}
+
+func (h *SprintHandler) CreateSprint(c echo.Context) error {
+	projectID := c.Param("id")
+	var input models.SprintInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind SprintInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SprintInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	sprint, err := h.service.CreateSprint(projectID, input)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to create sprint")
+		return errorJSON(c, statusFor(err), "Failed to create sprint", err)
+	}
+	return c.JSON(http.StatusCreated, sprint)
+}
+
+func (h *SprintHandler) UpdateSprint(c echo.Context) error {
+	id := c.Param("id")
+	var input models.SprintInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind SprintInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SprintInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	sprint, err := h.service.UpdateSprint(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update sprint")
+		return errorJSON(c, statusFor(err), "Failed to update sprint", err)
+	}
+	return c.JSON(http.StatusOK, sprint)
+}
+
+func (h *SprintHandler) DeleteSprint(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteSprint(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete sprint")
+		return errorJSON(c, statusFor(err), "Failed to delete sprint", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *SprintHandler) AddTasks(c echo.Context) error {
+	id := c.Param("id")
+	var input models.SprintTasksInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind SprintTasksInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SprintTasksInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	tasks, err := h.service.AddTasks(id, input.TaskIDs, currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to add tasks to sprint")
+		return errorJSON(c, statusFor(err), "Failed to add tasks to sprint", err)
+	}
+	return c.JSON(http.StatusOK, tasks)
+}
+
+func (h *SprintHandler) CloseSprint(c echo.Context) error {
+	id := c.Param("id")
+	var input models.CloseSprintInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CloseSprintInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	result, err := h.service.CloseSprint(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to close sprint")
+		return errorJSON(c, statusFor(err), "Failed to close sprint", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Burndown returns the daily burndown and burnup series of a sprint, in
+// the time zone given by the tz parameter or the user's.
+func (h *SprintHandler) Burndown(c echo.Context) error {
+	id := c.Param("id")
+	timeZone := c.QueryParam("tz")
+	if timeZone == "" {
+		timeZone = userTimeZone(c)
+	}
+	burndown, err := h.service.Burndown(id, timeZone)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to compute burndown")
+		return errorJSON(c, statusFor(err), "Failed to compute burndown", err)
+	}
+	return c.JSON(http.StatusOK, burndown)
+}
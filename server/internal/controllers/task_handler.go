@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
+
 	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
 	"taskmanager/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -18,20 +24,50 @@ func NewTaskHandler(service service.TaskService) *TaskHandler {
 }
 
 func (h *TaskHandler) GetAllTasks(c echo.Context) error {
-	tasks, err := h.service.GetAllTasks()
+	asOf, ok, err := parseAsOf(c)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to fetch all tasks")
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error":   "Failed to fetch tasks",
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid as_of",
 			"message": err.Error(),
 		})
 	}
+
+	if params := listParams(c); ok && len(params) > 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid as_of",
+			"message": "as_of cannot be combined with " + strings.Join(params, ", "),
+		})
+	}
+
+	var tasks []models.Task
+	if ok {
+		tasks, err = h.service.GetAllTasksAsOf(asOf)
+	} else {
+		tasks, err = h.service.GetAllTasks(parseTaskQuery(c))
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all tasks")
+		return errorJSON(c, statusFor(err), "Failed to fetch tasks", err)
+	}
 	return c.JSON(http.StatusOK, tasks)
 }
 
 func (h *TaskHandler) GetTaskByID(c echo.Context) error {
 	id := c.Param("id")
-	task, err := h.service.GetTaskByID(id)
+	asOf, ok, err := parseAsOf(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid as_of",
+			"message": err.Error(),
+		})
+	}
+
+	var task models.Task
+	if ok {
+		task, err = h.service.GetTaskByIDAsOf(id, asOf)
+	} else {
+		task, err = h.service.GetTaskByID(id)
+	}
 	if err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to fetch task")
 		return c.JSON(http.StatusNotFound, map[string]interface{}{
@@ -59,14 +95,14 @@ func (h *TaskHandler) CreateTask(c echo.Context) error {
 			"message": err.Error(),
 		})
 	}
+	if input.DueTimeZone == "" {
+		input.DueTimeZone = userTimeZone(c)
+	}
 
 	task, err := h.service.CreateTask(input)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create task")
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error":   "Failed to create task",
-			"message": err.Error(),
-		})
+		return taskError(c, "Failed to create task", err)
 	}
 	return c.JSON(http.StatusCreated, task)
 }
@@ -89,21 +125,34 @@ func (h *TaskHandler) UpdateTask(c echo.Context) error {
 			"message": err.Error(),
 		})
 	}
+	if input.DueTimeZone == "" {
+		input.DueTimeZone = userTimeZone(c)
+	}
 
-	task, err := h.service.UpdateTask(id, input)
+	task, err := h.service.UpdateTask(id, input, currentUserID(c))
 	if err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to update task")
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error":   "Failed to update task",
-			"message": err.Error(),
-		})
+		return taskError(c, "Failed to update task", err)
 	}
 	return c.JSON(http.StatusOK, task)
 }
 
+// DeleteTask deletes a task so that it can still be restored, or removes it
+// for good together with its history and attachments when permanent=true.
 func (h *TaskHandler) DeleteTask(c echo.Context) error {
 	id := c.Param("id")
-	if err := h.service.DeleteTask(id); err != nil {
+	if c.QueryParam("permanent") == "true" {
+		if err := h.service.PurgeTask(c.Request().Context(), id); err != nil {
+			log.Error().Err(err).Str("id", id).Msg("Failed to purge task")
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"error":   "Failed to delete task",
+				"message": err.Error(),
+			})
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err := h.service.DeleteTask(id, currentUserID(c)); err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to delete task")
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "Failed to delete task",
@@ -112,3 +161,126 @@ func (h *TaskHandler) DeleteTask(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+// MoveTask places a task in a board column between two neighbours.
+func (h *TaskHandler) MoveTask(c echo.Context) error {
+	id := c.Param("id")
+	var input models.MoveTaskInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind MoveTaskInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for MoveTaskInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	task, err := h.service.MoveTask(id, input, currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to move task")
+		return taskError(c, "Failed to move task", err)
+	}
+	return c.JSON(http.StatusOK, task)
+}
+
+// GetBoard returns a project's tasks grouped by status in board order.
+func (h *TaskHandler) GetBoard(c echo.Context) error {
+	projectID := c.Param("id")
+	board, err := h.service.GetBoard(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch board")
+		return errorJSON(c, statusFor(err), "Failed to fetch board", err)
+	}
+	return c.JSON(http.StatusOK, board)
+}
+
+func (h *TaskHandler) RestoreTask(c echo.Context) error {
+	id := c.Param("id")
+	task, err := h.service.RestoreTask(id, currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to restore task")
+		status := statusFor(err)
+		if errors.Is(err, repository.ErrTaskNotDeleted) {
+			status = http.StatusConflict
+		}
+		return errorJSON(c, status, "Failed to restore task", err)
+	}
+	return c.JSON(http.StatusOK, task)
+}
+
+// parseTaskQuery collects the list filters. Custom field filters use the
+// form cf.<key>=value or cf.<key>.<op>=value, and q takes a filter
+// language expression. tz sets the time zone of days such as today, which
+// defaults to the user's. assignee takes a user ID, "me" or "none".
+func parseTaskQuery(c echo.Context) models.TaskQuery {
+	query := models.TaskQuery{
+		ProjectID:    c.QueryParam("project_id"),
+		Filter:       c.QueryParam("q"),
+		Sort:         c.QueryParam("sort"),
+		Order:        c.QueryParam("order"),
+		TimeZone:     c.QueryParam("tz"),
+		UserID:       currentUserID(c),
+		Assignee:     c.QueryParam("assignee"),
+		CustomFields: make(map[string]string),
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = userTimeZone(c)
+	}
+	for name, values := range c.QueryParams() {
+		if key, ok := strings.CutPrefix(name, "cf."); ok && len(values) > 0 {
+			query.CustomFields[key] = values[0]
+		}
+	}
+	return query
+}
+
+// taskListParams are the list filters of parseTaskQuery besides the custom
+// field ones.
+var taskListParams = []string{"project_id", "q", "sort", "order", "tz", "assignee"}
+
+// listParams returns the list filters the request sets. as_of lists the
+// tasks of the revision history, which does not support them.
+func listParams(c echo.Context) []string {
+	var params []string
+	for _, name := range taskListParams {
+		if c.QueryParam(name) != "" {
+			params = append(params, name)
+		}
+	}
+	var custom []string
+	for name := range c.QueryParams() {
+		if strings.HasPrefix(name, "cf.") {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	return append(params, custom...)
+}
+
+// parseAsOf reads the optional RFC 3339 "as_of" query parameter.
+func parseAsOf(c echo.Context) (time.Time, bool, error) {
+	value := c.QueryParam("as_of")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return asOf, true, nil
+}
+
+func taskError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	if errors.Is(err, service.ErrTaskCycle) || errors.Is(err, service.ErrInvalidMove) {
+		status = http.StatusBadRequest
+	}
+	return errorJSON(c, status, message, err)
+}
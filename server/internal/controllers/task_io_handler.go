@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+	"taskmanager/internal/taskio"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxImportFile caps the size of imported task files.
+const maxImportFile = 10 << 20
+
+type TaskIOHandler struct {
+	service service.TaskIOService
+}
+
+func NewTaskIOHandler(service service.TaskIOService) *TaskIOHandler {
+	return &TaskIOHandler{service: service}
+}
+
+// ExportTasks streams the tasks the list parameters select as a file in
+// format csv, json (the default), ndjson or markdown.
+func (h *TaskIOHandler) ExportTasks(c echo.Context) error {
+	query := parseTaskQuery(c)
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = taskio.FormatJSON
+	}
+	if err := h.service.CheckExport(query, format); err != nil {
+		return errorJSON(c, statusFor(err), "Failed to export tasks", err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, taskio.ContentType(format))
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="tasks-%s.%s"`, time.Now().UTC().Format("2006-01-02"), taskio.Extension(format)))
+	res.WriteHeader(http.StatusOK)
+	// The status is sent, so a failure can only cut the file short
+	if err := h.service.ExportTasks(query, format, flushWriter{res}); err != nil {
+		log.Error().Err(err).Str("format", format).Msg("Failed to export tasks")
+	}
+	return nil
+}
+
+// flushWriter sends what is written to the client straight away.
+type flushWriter struct {
+	res *echo.Response
+}
+
+func (w flushWriter) Write(p []byte) (int, error) {
+	n, err := w.res.Write(p)
+	w.res.Flush()
+	return n, err
+}
+
+// ImportTasks upserts tasks from a file, the "file" part of a multipart
+// request or the request body itself. The format is given by the format
+// parameter, or else by the content type or extension of the file.
+// mapping is a JSON object of source columns to task fields, mode is
+// atomic (the default) or partial, and dry_run=true only validates.
+func (h *TaskIOHandler) ImportTasks(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxImportFile+multipartOverhead)
+
+	options := models.ImportOptions{
+		Format: strings.ToLower(c.FormValue("format")),
+		Mode:   c.FormValue("mode"),
+	}
+	var err error
+	if value := c.FormValue("dry_run"); value != "" {
+		if options.DryRun, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": "dry_run must be true or false",
+			})
+		}
+	}
+	if value := c.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &options.Mapping); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": "mapping must be a JSON object of column names to task fields",
+			})
+		}
+	}
+
+	var file io.Reader = req.Body
+	contentType := req.Header.Get(echo.HeaderContentType)
+	if strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		upload, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": `multipart field "file" is required`,
+			})
+		}
+		opened, err := upload.Open()
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, "Failed to read upload", err)
+		}
+		defer opened.Close()
+		file = opened
+		contentType = upload.Header.Get(echo.HeaderContentType)
+		if options.Format == "" {
+			options.Format = formatOfName(upload.Filename)
+		}
+	}
+	if options.Format == "" {
+		options.Format = taskio.FormatOf(contentType)
+	}
+
+	result, err := h.service.ImportTasks(file, options)
+	if err != nil {
+		log.Error().Err(err).Str("format", options.Format).Msg("Failed to import tasks")
+		return errorJSON(c, statusFor(err), "Failed to import tasks", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// formatOfName returns the format of a file by its extension, or "".
+func formatOfName(name string) string {
+	extension := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	for _, format := range taskio.Formats {
+		if taskio.Extension(format) == extension {
+			return format
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type TaskMemberHandler struct {
+	service service.TaskMemberService
+}
+
+func NewTaskMemberHandler(service service.TaskMemberService) *TaskMemberHandler {
+	return &TaskMemberHandler{service: service}
+}
+
+func (h *TaskMemberHandler) GetMembers(c echo.Context) error {
+	taskID := c.Param("id")
+	members, err := h.service.GetMembers(taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch task members")
+		return errorJSON(c, statusFor(err), "Failed to fetch task members", err)
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// Assign assigns the user in the body, or the current user, to a task.
+func (h *TaskMemberHandler) Assign(c echo.Context) error {
+	taskID := c.Param("id")
+	var input models.TaskMemberInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TaskMemberInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+	if input.UserID == "" {
+		input.UserID = currentUserID(c)
+	}
+
+	members, err := h.service.Assign(taskID, input.UserID, currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to assign task")
+		return errorJSON(c, statusFor(err), "Failed to assign task", err)
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+func (h *TaskMemberHandler) Unassign(c echo.Context) error {
+	taskID := c.Param("id")
+	members, err := h.service.Unassign(taskID, c.Param("userId"))
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to unassign task")
+		return errorJSON(c, statusFor(err), "Failed to unassign task", err)
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// Watch adds the user in the body, or the current user, to the watchers.
+func (h *TaskMemberHandler) Watch(c echo.Context) error {
+	taskID := c.Param("id")
+	var input models.TaskMemberInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TaskMemberInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+	if input.UserID == "" {
+		input.UserID = currentUserID(c)
+	}
+
+	members, err := h.service.Watch(taskID, input.UserID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to watch task")
+		return errorJSON(c, statusFor(err), "Failed to watch task", err)
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+func (h *TaskMemberHandler) Unwatch(c echo.Context) error {
+	taskID := c.Param("id")
+	members, err := h.service.Unwatch(taskID, c.Param("userId"))
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to unwatch task")
+		return errorJSON(c, statusFor(err), "Failed to unwatch task", err)
+	}
+	return c.JSON(http.StatusOK, members)
+}
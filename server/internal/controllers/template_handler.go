@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type TemplateHandler struct {
+	service service.TemplateService
+}
+
+func NewTemplateHandler(service service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{service: service}
+}
+
+func (h *TemplateHandler) GetAllTemplates(c echo.Context) error {
+	templates, err := h.service.GetAllTemplates()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all templates")
+		return errorJSON(c, statusFor(err), "Failed to fetch templates", err)
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+func (h *TemplateHandler) GetTemplateByID(c echo.Context) error {
+	id := c.Param("id")
+	template, err := h.service.GetTemplateByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch template")
+		return errorJSON(c, statusFor(err), "Template not found", err)
+	}
+	return c.JSON(http.StatusOK, template)
+}
+
+func (h *TemplateHandler) CreateTemplate(c echo.Context) error {
+	var input models.TemplateInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	template, err := h.service.CreateTemplate(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create template")
+		return errorJSON(c, statusFor(err), "Failed to create template", err)
+	}
+	return c.JSON(http.StatusCreated, template)
+}
+
+func (h *TemplateHandler) UpdateTemplate(c echo.Context) error {
+	id := c.Param("id")
+	var input models.TemplateInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	template, err := h.service.UpdateTemplate(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update template")
+		return errorJSON(c, statusFor(err), "Failed to update template", err)
+	}
+	return c.JSON(http.StatusOK, template)
+}
+
+func (h *TemplateHandler) DeleteTemplate(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteTemplate(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete template")
+		return errorJSON(c, statusFor(err), "Failed to delete template", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// InstantiateTemplate creates the template's tasks and returns them, parents
+// before their subtasks.
+func (h *TemplateHandler) InstantiateTemplate(c echo.Context) error {
+	id := c.Param("id")
+	var input models.InstantiateTemplateInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind InstantiateTemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for InstantiateTemplateInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	tasks, err := h.service.InstantiateTemplate(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to instantiate template")
+		return errorJSON(c, statusFor(err), "Failed to instantiate template", err)
+	}
+	return c.JSON(http.StatusCreated, tasks)
+}
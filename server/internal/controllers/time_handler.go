@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type TimeHandler struct {
+	service service.TimeService
+}
+
+func NewTimeHandler(service service.TimeService) *TimeHandler {
+	return &TimeHandler{service: service}
+}
+
+// StartTimer starts the user's timer on a task, stopping any other.
+func (h *TimeHandler) StartTimer(c echo.Context) error {
+	taskID := c.Param("id")
+	var input models.TimerInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TimerInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TimerInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	entry, err := h.service.StartTimer(currentUserID(c), taskID, input)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to start timer")
+		return timeError(c, "Failed to start timer", err)
+	}
+	return c.JSON(http.StatusCreated, entry)
+}
+
+func (h *TimeHandler) StopTimer(c echo.Context) error {
+	taskID := c.Param("id")
+	entry, err := h.service.StopTimer(currentUserID(c), taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to stop timer")
+		return timeError(c, "Failed to stop timer", err)
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+// RunningTimer returns the user's running timer, or 204 when none is.
+func (h *TimeHandler) RunningTimer(c echo.Context) error {
+	entry, err := h.service.RunningTimer(currentUserID(c))
+	if statusFor(err) == http.StatusNotFound {
+		return c.NoContent(http.StatusNoContent)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch running timer")
+		return timeError(c, "Failed to fetch timer", err)
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+func (h *TimeHandler) AddEntry(c echo.Context) error {
+	taskID := c.Param("id")
+	var input models.TimeEntryInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind TimeEntryInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TimeEntryInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	entry, err := h.service.AddEntry(currentUserID(c), taskID, input)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to add time entry")
+		return timeError(c, "Failed to add time entry", err)
+	}
+	return c.JSON(http.StatusCreated, entry)
+}
+
+func (h *TimeHandler) UpdateEntry(c echo.Context) error {
+	id := c.Param("id")
+	var input models.UpdateTimeEntryInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind UpdateTimeEntryInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateTimeEntryInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	entry, err := h.service.UpdateEntry(currentUserID(c), id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update time entry")
+		return timeError(c, "Failed to update time entry", err)
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+func (h *TimeHandler) DeleteEntry(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteEntry(currentUserID(c), id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete time entry")
+		return timeError(c, "Failed to delete time entry", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TaskTime lists the time entries of a task with its totals.
+func (h *TimeHandler) TaskTime(c echo.Context) error {
+	taskID := c.Param("id")
+	result, err := h.service.TaskTime(taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch task time")
+		return timeError(c, "Failed to fetch time", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *TimeHandler) ProjectTime(c echo.Context) error {
+	projectID := c.Param("id")
+	result, err := h.service.ProjectTime(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch project time")
+		return timeError(c, "Failed to fetch time", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Timesheet adds up the time entries from "from" to "to", grouped by the
+// comma-separated group_by parameter. format=csv returns a CSV file.
+func (h *TimeHandler) Timesheet(c echo.Context) error {
+	query := models.TimesheetQuery{
+		From:      c.QueryParam("from"),
+		To:        c.QueryParam("to"),
+		GroupBy:   parseGroupBy(c.QueryParam("group_by")),
+		UserID:    c.QueryParam("user_id"),
+		ProjectID: c.QueryParam("project_id"),
+		TimeZone:  c.QueryParam("tz"),
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = userTimeZone(c)
+	}
+
+	sheet, err := h.service.Timesheet(query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build timesheet")
+		return timeError(c, "Failed to build timesheet", err)
+	}
+	if strings.EqualFold(c.QueryParam("format"), "csv") {
+		return writeTimesheetCSV(c, sheet)
+	}
+	return c.JSON(http.StatusOK, sheet)
+}
+
+// writeTimesheetCSV writes one line per row, with a column per grouping
+// followed by hours, seconds and the number of entries.
+func writeTimesheetCSV(c echo.Context, sheet models.Timesheet) error {
+	var header []string
+	for _, group := range sheet.GroupBy {
+		switch group {
+		case models.GroupByUser:
+			header = append(header, "user_id", "username")
+		case models.GroupByProject:
+			header = append(header, "project_id", "project_name")
+		case models.GroupByTask:
+			header = append(header, "task_id", "task_title")
+		default:
+			header = append(header, group)
+		}
+	}
+	header = append(header, "hours", "seconds", "entries")
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="timesheet-%s-%s.csv"`, sheet.From, sheet.To))
+	c.Response().WriteHeader(http.StatusOK)
+	w := csv.NewWriter(c.Response())
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range sheet.Rows {
+		var record []string
+		for _, group := range sheet.GroupBy {
+			switch group {
+			case models.GroupByDay:
+				record = append(record, row.Day)
+			case models.GroupByWeek:
+				record = append(record, row.Week)
+			case models.GroupByUser:
+				record = append(record, row.UserID, row.Username)
+			case models.GroupByProject:
+				record = append(record, row.ProjectID, row.ProjectName)
+			case models.GroupByTask:
+				record = append(record, row.TaskID, row.TaskTitle)
+			}
+		}
+		record = append(record,
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+			strconv.FormatInt(row.Seconds, 10),
+			strconv.Itoa(row.Entries),
+		)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// parseGroupBy splits a comma-separated group_by parameter.
+func parseGroupBy(value string) []string {
+	var groups []string
+	for _, group := range strings.Split(value, ",") {
+		if group = strings.ToLower(strings.TrimSpace(group)); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func timeError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	switch {
+	case errors.Is(err, service.ErrNoRunningTimer):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrTimeEntryForbidden):
+		status = http.StatusForbidden
+	}
+	return errorJSON(c, status, message, err)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type ToolImportHandler struct {
+	service service.ToolImportService
+}
+
+func NewToolImportHandler(service service.ToolImportService) *ToolImportHandler {
+	return &ToolImportHandler{service: service}
+}
+
+// ListSources lists the tools tasks can be imported from.
+func (h *ToolImportHandler) ListSources(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{"sources": h.service.Sources()})
+}
+
+// Import creates the projects and tasks of the export of another tool,
+// read from the "file" part of a multipart request or the request body
+// itself. project_id puts every task in that project, project_name names
+// the project of tasks outside any project of the source, and
+// dry_run=true only reports what would be created.
+func (h *ToolImportHandler) Import(c echo.Context) error {
+	source := c.Param("source")
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxImportFile+multipartOverhead)
+
+	options := models.ToolImportOptions{
+		DefaultProject: strings.TrimSpace(c.FormValue("project_name")),
+	}
+	if projectID := c.FormValue("project_id"); projectID != "" {
+		options.ProjectID = &projectID
+	}
+	if value := c.FormValue("dry_run"); value != "" {
+		var err error
+		if options.DryRun, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": "dry_run must be true or false",
+			})
+		}
+	}
+
+	var file io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		upload, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid input",
+				"message": `multipart field "file" is required`,
+			})
+		}
+		opened, err := upload.Open()
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, "Failed to read upload", err)
+		}
+		defer opened.Close()
+		file = opened
+	}
+
+	report, err := h.service.Import(source, file, options)
+	if err != nil {
+		log.Error().Err(err).Str("source", source).Msg("Failed to import from another tool")
+		return errorJSON(c, statusFor(err), "Failed to import", err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
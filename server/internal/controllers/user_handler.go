@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"taskmanager/internal/auth"
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type UserHandler struct {
+	service service.UserService
+}
+
+func NewUserHandler(service service.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+func (h *UserHandler) GetAllUsers(c echo.Context) error {
+	users, err := h.service.GetAllUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all users")
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "Failed to fetch users",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+func (h *UserHandler) GetUserByID(c echo.Context) error {
+	id := c.Param("id")
+	user, err := h.service.GetUserByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch user")
+		return c.JSON(http.StatusNotFound, map[string]interface{}{
+			"error":   "User not found",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) CreateUser(c echo.Context) error {
+	var input models.CreateUserInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind CreateUserInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateUserInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.service.CreateUser(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create user")
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "Failed to create user",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, user)
+}
+
+func (h *UserHandler) UpdateUser(c echo.Context) error {
+	id := c.Param("id")
+	var input models.UpdateUserInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind UpdateUserInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateUserInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.service.UpdateUser(id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update user")
+		return errorJSON(c, statusFor(err), "Failed to update user", err)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// ResolveTimeZone stores the user named by the X-User-ID header and their
+// time zone on the context, for handlers reading times given without one
+// and for routes where signing in is optional. Requests without the
+// header, or from unknown users, use UTC.
+func (h *UserHandler) ResolveTimeZone(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if identity := auth.Identify(h.service, c.Request().Header.Get(HeaderUserID)); identity.UserID != "" {
+			c.Set(userIDKey, identity.UserID)
+			c.Set(timeZoneKey, identity.TimeZone)
+		}
+		return next(c)
+	}
+}
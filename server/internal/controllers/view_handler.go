@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type ViewHandler struct {
+	service service.ViewService
+}
+
+func NewViewHandler(service service.ViewService) *ViewHandler {
+	return &ViewHandler{service: service}
+}
+
+func (h *ViewHandler) ListViews(c echo.Context) error {
+	views, err := h.service.ListViews(currentUserID(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch views")
+		return viewError(c, "Failed to fetch views", err)
+	}
+	return c.JSON(http.StatusOK, views)
+}
+
+func (h *ViewHandler) GetView(c echo.Context) error {
+	id := c.Param("id")
+	view, err := h.service.GetView(currentUserID(c), id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch view")
+		return viewError(c, "View not found", err)
+	}
+	return c.JSON(http.StatusOK, view)
+}
+
+func (h *ViewHandler) CreateView(c echo.Context) error {
+	var input models.ViewInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind ViewInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ViewInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	view, err := h.service.CreateView(currentUserID(c), input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create view")
+		return viewError(c, "Failed to create view", err)
+	}
+	return c.JSON(http.StatusCreated, view)
+}
+
+func (h *ViewHandler) UpdateView(c echo.Context) error {
+	id := c.Param("id")
+	var input models.ViewInput
+	if err := c.Bind(&input); err != nil {
+		log.Error().Err(err).Msg("Failed to bind ViewInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Invalid input",
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(&input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ViewInput")
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation error",
+			"message": err.Error(),
+		})
+	}
+
+	view, err := h.service.UpdateView(currentUserID(c), id, input)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update view")
+		return viewError(c, "Failed to update view", err)
+	}
+	return c.JSON(http.StatusOK, view)
+}
+
+func (h *ViewHandler) DeleteView(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.service.DeleteView(currentUserID(c), id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete view")
+		return viewError(c, "Failed to delete view", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetViewTasks returns the tasks matching a view, like GET /tasks, with
+// days such as today in the tz parameter or the user's time zone.
+func (h *ViewHandler) GetViewTasks(c echo.Context) error {
+	id := c.Param("id")
+	timeZone := c.QueryParam("tz")
+	if timeZone == "" {
+		timeZone = userTimeZone(c)
+	}
+	tasks, err := h.service.GetViewTasks(currentUserID(c), id, timeZone)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch view tasks")
+		return viewError(c, "Failed to fetch tasks", err)
+	}
+	return c.JSON(http.StatusOK, tasks)
+}
+
+func viewError(c echo.Context, message string, err error) error {
+	status := statusFor(err)
+	if errors.Is(err, service.ErrViewForbidden) {
+		status = http.StatusForbidden
+	}
+	return errorJSON(c, status, message, err)
+}
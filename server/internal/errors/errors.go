@@ -28,6 +28,12 @@ func HandleError(c echo.Context, err error) error {
 			"error":   e.Message,
 			"details": e.Details,
 		})
+	case *echo.HTTPError:
+		// Errors of middleware, such as a failed basic auth, keep their status
+		return c.JSON(e.Code, map[string]interface{}{
+			"error":   http.StatusText(e.Code),
+			"message": e.Message,
+		})
 	default:
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "Internal server error",
@@ -0,0 +1,125 @@
+// Package events is an in-process publish/subscribe bus for changes to
+// tasks and their comments. Services publish an event after a change has
+// been committed, and features such as search follow those events without
+// the services knowing about them.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	TaskCreated    = "task.created"
+	TaskUpdated    = "task.updated"
+	TaskDeleted    = "task.deleted"
+	TaskRestored   = "task.restored"
+	TaskPurged     = "task.purged"
+	CommentCreated = "comment.created"
+	CommentUpdated = "comment.updated"
+	CommentDeleted = "comment.deleted"
+)
+
+// Event describes a committed change to a task or one of its comments.
+// ActorID is the user who made the change, when known. ProjectID is the
+// project of the task when a task event is published, which consumers can
+// no longer look up once the task is deleted or purged.
+type Event struct {
+	Type      string
+	TaskID    string
+	CommentID string
+	ProjectID string
+	ActorID   string
+	At        time.Time
+}
+
+// Handler is called for every published event.
+type Handler func(Event)
+
+// Publisher publishes events.
+type Publisher interface {
+	Publish(event Event)
+}
+
+// watchBuffer is how many events a watcher can fall behind by before
+// further events to it are dropped.
+const watchBuffer = 16
+
+// Bus delivers published events to its subscribers and watchers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+	watchers map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for all events published from now on.
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Watch returns the events published from now on, for consumers that come
+// and go such as streams to clients, and a function that stops the watch
+// and must be called once the consumer is gone. Publishing does not wait
+// on watchers, so a watcher that falls behind misses events.
+func (b *Bus) Watch() (<-chan Event, func()) {
+	ch := make(chan Event, watchBuffer)
+	b.mu.Lock()
+	if b.watchers == nil {
+		b.watchers = make(map[chan Event]struct{})
+	}
+	b.watchers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.watchers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Publish calls every subscriber in turn, in the caller's goroutine. A
+// subscriber that panics is logged and affects neither the others nor the
+// publisher, since the change has already been made.
+func (b *Bus) Publish(event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(handler, event)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.watchers {
+		select {
+		case ch <- event:
+		default:
+			log.Warn().Str("type", event.Type).Str("task_id", event.TaskID).Msg("Dropped event for slow watcher")
+		}
+	}
+}
+
+func deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("type", event.Type).Str("task_id", event.TaskID).Msg("Event handler panicked")
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,74 @@
+// Package filter parses the task filter language used by saved views and
+// the task list, for example
+//
+//	status:open AND due<7d AND (tag:bug OR priority>=high) AND NOT assignee:none
+//
+// Terms are field:value comparisons or bare words; they combine with AND,
+// OR, NOT and parentheses, and terms next to each other are ANDed. The
+// parser only checks syntax; the meaning of fields and values is left to
+// the code compiling the expression.
+package filter
+
+import "fmt"
+
+// Comparison operators
+const (
+	OpEq  = ":"
+	OpNe  = "!="
+	OpLt  = "<"
+	OpLte = "<="
+	OpGt  = ">"
+	OpGte = ">="
+)
+
+// Expr is a node of a parsed filter.
+type Expr interface {
+	// Column is the 1-based position of the node in the source text.
+	Column() int
+}
+
+// And matches tasks matching both sides.
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches tasks matching either side.
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches tasks not matching Expr.
+type Not struct {
+	Expr Expr
+	Col  int
+}
+
+// Term compares a field with a value. Bare words have an empty Field and
+// search the task text. "=" is read as ":".
+type Term struct {
+	Field    string
+	Op       string
+	Value    string
+	Col      int
+	ValueCol int
+}
+
+func (e *And) Column() int  { return e.Left.Column() }
+func (e *Or) Column() int   { return e.Left.Column() }
+func (e *Not) Column() int  { return e.Col }
+func (e *Term) Column() int { return e.Col }
+
+// Error reports a problem with a filter at a column of its source text.
+type Error struct {
+	Col     int
+	Message string
+}
+
+// Errorf builds an Error at the given column.
+func Errorf(column int, format string, args ...interface{}) *Error {
+	return &Error{Col: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Col, e.Message)
+}
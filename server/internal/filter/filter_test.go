@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+)
+
+// show writes an expression as nested calls, with the column of each
+// node after @.
+func show(expr Expr) string {
+	switch e := expr.(type) {
+	case nil:
+		return "all"
+	case *And:
+		return fmt.Sprintf("and(%s, %s)", show(e.Left), show(e.Right))
+	case *Or:
+		return fmt.Sprintf("or(%s, %s)", show(e.Left), show(e.Right))
+	case *Not:
+		return fmt.Sprintf("not@%d(%s)", e.Col, show(e.Expr))
+	case *Term:
+		return fmt.Sprintf("%s%s%q@%d,%d", e.Field, e.Op, e.Value, e.Col, e.ValueCol)
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"", "all"},
+		{"   ", "all"},
+		{"bug", `:"bug"@1,1`},
+		{"status:open", `status:"open"@1,8`},
+		{"Status = open", `status:"open"@1,10`},
+		{"due<7d", `due<"7d"@1,5`},
+		{"due <= today", `due<="today"@1,8`},
+		{"priority>=high", `priority>="high"@1,11`},
+		{"priority>low", `priority>"low"@1,10`},
+		{"tag!=bug", `tag!="bug"@1,6`},
+		{`title:"release notes"`, `title:"release notes"@1,7`},
+		{`title:"say \"hi\""`, `title:"say \"hi\""@1,7`},
+		{`"two words" "AND"`, `and(:"two words"@1,1, :"AND"@13,13)`},
+		{"café tag:été", `and(:"café"@1,1, tag:"été"@6,10)`},
+		{"a b c", `and(and(:"a"@1,1, :"b"@3,3), :"c"@5,5)`},
+		{"a AND b", `and(:"a"@1,1, :"b"@7,7)`},
+		{"a or b and c", `or(:"a"@1,1, and(:"b"@6,6, :"c"@12,12))`},
+		{"(a OR b) c", `and(or(:"a"@2,2, :"b"@7,7), :"c"@10,10)`},
+		{"NOT a b", `and(not@1(:"a"@5,5), :"b"@7,7)`},
+		{"not not a", `not@1(not@5(:"a"@9,9))`},
+		{"NOT (a OR b)", `not@1(or(:"a"@6,6, :"b"@11,11))`},
+		{
+			"status:open AND due<7d AND (tag:bug OR priority>=high) AND NOT assignee:none",
+			`and(and(and(status:"open"@1,8, due<"7d"@17,21), or(tag:"bug"@29,33, priority>="high"@40,50)), not@60(assignee:"none"@64,73))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := show(expr); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"AND a", `column 1: expected a term before AND`},
+		{"a OR", `column 5: expected a term, found end of filter`},
+		{"a OR OR b", `column 6: expected a term before OR`},
+		{"NOT", `column 4: expected a term, found end of filter`},
+		{"status:", `column 8: expected a value after status:, found end of filter`},
+		{"due< (a)", `column 6: expected a value after due<, found "("`},
+		{"tag:bug)", `column 8: unexpected ")"`},
+		{"(a OR b", `column 8: expected ) to close ( at column 1, found end of filter`},
+		{"()", `column 2: expected a term, found ")"`},
+		{"tag!bug", `column 4: expected "!=" but found "!"`},
+		{`title:"open`, `column 7: unterminated string`},
+		{"é:", `column 3: expected a value after é:, found end of filter`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %s, want an error", tt.src, show(expr))
+			}
+			if _, ok := err.(*Error); !ok || err.Error() != tt.want {
+				t.Errorf("Parse(%q) error %v, want %s", tt.src, err, tt.want)
+			}
+		})
+	}
+}
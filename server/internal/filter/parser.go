@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+// Parse parses a filter. An empty or blank filter yields a nil Expr, which
+// matches every task.
+func Parse(src string) (Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, Errorf(tok.col, "unexpected %s", describe(tok))
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given keyword, in any case.
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.keyword("AND") {
+			p.next()
+		} else if tok := p.peek(); tok.kind == tokenEOF || tok.kind == tokenRParen || p.keyword("OR") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.keyword("NOT") {
+		tok := p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr, Col: tok.col}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, Errorf(closing.col, "expected ) to close ( at column %d, found %s", tok.col, describe(closing))
+		}
+		return expr, nil
+	case tokenString:
+		return &Term{Op: OpEq, Value: tok.text, Col: tok.col, ValueCol: tok.col}, nil
+	case tokenWord:
+		if strings.EqualFold(tok.text, "AND") || strings.EqualFold(tok.text, "OR") {
+			return nil, Errorf(tok.col, "expected a term before %s", strings.ToUpper(tok.text))
+		}
+		if p.peek().kind != tokenOp {
+			return &Term{Op: OpEq, Value: tok.text, Col: tok.col, ValueCol: tok.col}, nil
+		}
+		op := p.next()
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, Errorf(value.col, "expected a value after %s%s, found %s", tok.text, op.text, describe(value))
+		}
+		return &Term{
+			Field:    strings.ToLower(tok.text),
+			Op:       op.text,
+			Value:    value.text,
+			Col:      tok.col,
+			ValueCol: value.col,
+		}, nil
+	default:
+		return nil, Errorf(tok.col, "expected a term, found %s", describe(tok))
+	}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return "string " + `"` + tok.text + `"`
+	default:
+		return `"` + tok.text + `"`
+	}
+}
+
+// lex splits a filter into tokens. Columns count runes from 1.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		col := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", col: col})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", col: col})
+			i++
+		case r == ':' || r == '=':
+			tokens = append(tokens, token{kind: tokenOp, text: OpEq, col: col})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, Errorf(col, `expected "!=" but found "!"`)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, col: col})
+			i += len(op)
+		case r == '"':
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, Errorf(col, "unterminated string")
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: text.String(), col: col})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()":=!<>`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), col: col})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, col: len(runes) + 1}), nil
+}
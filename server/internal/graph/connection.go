@@ -0,0 +1,263 @@
+package graph
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Page sizes of connections
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// cursorPrefix marks cursors, which are base64 so clients treat them as
+// opaque. taskCursorPrefix marks the cursors of tasks in creation order.
+const (
+	cursorPrefix     = "cursor:"
+	taskCursorPrefix = "task:"
+)
+
+// connection is a page of a list, as the Relay connection specification
+// describes. Cursors are positions in the list, or the creation time and
+// ID of tasks in lists in creation order.
+type connection struct {
+	Edges      []edge
+	Nodes      []interface{}
+	PageInfo   pageInfo
+	TotalCount int
+}
+
+type edge struct {
+	Cursor string
+	Node   interface{}
+}
+
+type pageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+// connectionArgs are the arguments of fields returning a connection.
+var connectionArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Number of items, by default 20 and at most 100",
+	},
+	"after": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Cursor of the item the page starts after",
+	},
+}
+
+// pageSize returns the page size for the first argument.
+func pageSize(first int) int {
+	switch {
+	case first <= 0:
+		return defaultPageSize
+	case first > maxPageSize:
+		return maxPageSize
+	default:
+		return first
+	}
+}
+
+// page is the part of a list connection arguments ask for. A page of
+// tasks in creation order starts after a task rather than at a position.
+type page struct {
+	start int
+	size  int
+	after *models.TaskCursor
+}
+
+// pageOf reads the connection arguments of a field. It is called when the
+// field is resolved, since errors of thunks lose their code.
+func pageOf(args map[string]interface{}) (page, error) {
+	first, _ := args["first"].(int)
+	if first < 0 {
+		return page{}, apperrors.NewValidationError("Invalid page", map[string]string{
+			"first": "must not be negative",
+		})
+	}
+	p := page{size: pageSize(first)}
+	if after, ok := args["after"].(string); ok && after != "" {
+		position, err := decodeCursor(after)
+		if err != nil {
+			return page{}, invalidCursor()
+		}
+		p.start = position + 1
+	}
+	return p, nil
+}
+
+// taskPageOf reads the connection arguments of a list of tasks, whose
+// cursors are tasks when the list is in creation order.
+func taskPageOf(args map[string]interface{}, createdOrder bool) (page, error) {
+	if !createdOrder {
+		return pageOf(args)
+	}
+	p, err := pageOf(map[string]interface{}{"first": args["first"]})
+	if err != nil {
+		return page{}, err
+	}
+	if after, ok := args["after"].(string); ok && after != "" {
+		cursor, err := decodeTaskCursor(after)
+		if err != nil {
+			return page{}, invalidCursor()
+		}
+		p.after = &cursor
+	}
+	return p, nil
+}
+
+func invalidCursor() error {
+	return apperrors.NewValidationError("Invalid cursor", map[string]string{
+		"after": "is not a cursor of this list",
+	})
+}
+
+// of returns the page of a list of n items, where node returns the item
+// at an index.
+func (p page) of(n int, node func(i int) interface{}) connection {
+	start := p.start
+	if start > n {
+		start = n
+	}
+	end := start + p.size
+	if end > n {
+		end = n
+	}
+
+	c := connection{
+		Edges:      make([]edge, 0, end-start),
+		Nodes:      make([]interface{}, 0, end-start),
+		TotalCount: n,
+		PageInfo: pageInfo{
+			HasNextPage:     end < n,
+			HasPreviousPage: start > 0,
+		},
+	}
+	for i := start; i < end; i++ {
+		item := node(i)
+		c.Edges = append(c.Edges, edge{Cursor: encodeCursor(i), Node: item})
+		c.Nodes = append(c.Nodes, item)
+	}
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = &c.Edges[0].Cursor
+		c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+	}
+	return c
+}
+
+// ofTasks returns the page of a list of total tasks, given the tasks from
+// the start of the page with one more when there is a next page.
+func (p page) ofTasks(tasks []models.Task, total int64, createdOrder bool) connection {
+	more := len(tasks) > p.size
+	if more {
+		tasks = tasks[:p.size]
+	}
+	c := connection{
+		Edges:      make([]edge, 0, len(tasks)),
+		Nodes:      make([]interface{}, 0, len(tasks)),
+		TotalCount: int(total),
+		PageInfo: pageInfo{
+			HasNextPage:     more,
+			HasPreviousPage: p.start > 0 || p.after != nil,
+		},
+	}
+	for i, task := range tasks {
+		cursor := encodeCursor(p.start + i)
+		if createdOrder {
+			cursor = encodeTaskCursor(models.TaskCursor{CreatedAt: task.CreatedAt, ID: task.ID})
+		}
+		c.Edges = append(c.Edges, edge{Cursor: cursor, Node: task})
+		c.Nodes = append(c.Nodes, task)
+	}
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = &c.Edges[0].Cursor
+		c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+	}
+	return c
+}
+
+func encodeCursor(position int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(position)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	position, ok := strings.CutPrefix(string(decoded), cursorPrefix)
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+	n, err := strconv.Atoi(position)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
+
+func encodeTaskCursor(cursor models.TaskCursor) string {
+	key := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ID
+	return base64.StdEncoding.EncodeToString([]byte(taskCursorPrefix + key))
+}
+
+func decodeTaskCursor(cursor string) (models.TaskCursor, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.TaskCursor{}, err
+	}
+	key, ok := strings.CutPrefix(string(decoded), taskCursorPrefix)
+	createdAt, id, found := strings.Cut(key, " ")
+	if !ok || !found || id == "" {
+		return models.TaskCursor{}, strconv.ErrSyntax
+	}
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return models.TaskCursor{}, err
+	}
+	return models.TaskCursor{CreatedAt: at, ID: id}, nil
+}
+
+// connectionType returns the connection type of a list of node, with its
+// edge type.
+func connectionType(node *graphql.Object) *graphql.Object {
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(node)},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Connection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"nodes":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(node)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+}
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"startCursor":     &graphql.Field{Type: graphql.String},
+		"endCursor":       &graphql.Field{Type: graphql.String},
+	},
+})
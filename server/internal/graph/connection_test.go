@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+func TestTaskPageOf(t *testing.T) {
+	created := time.Date(2026, time.October, 14, 9, 30, 0, 123456000, time.UTC)
+	taskCursor := encodeTaskCursor(models.TaskCursor{CreatedAt: created, ID: "task-7"})
+
+	tests := []struct {
+		name         string
+		args         map[string]interface{}
+		createdOrder bool
+		want         string // "start size after", or "error"
+	}{
+		{name: "first page", args: map[string]interface{}{}, createdOrder: true, want: "0 20 <nil>"},
+		{name: "page size capped", args: map[string]interface{}{"first": 500}, createdOrder: true, want: "0 100 <nil>"},
+		{name: "after a task", args: map[string]interface{}{"first": 5, "after": taskCursor}, createdOrder: true, want: "0 5 2026-10-14T09:30:00.123456Z task-7"},
+		{name: "after a position", args: map[string]interface{}{"first": 5, "after": encodeCursor(9)}, want: "10 5 <nil>"},
+		{name: "position in a list in creation order", args: map[string]interface{}{"after": encodeCursor(9)}, createdOrder: true, want: "error"},
+		{name: "task in a sorted list", args: map[string]interface{}{"after": taskCursor}, want: "error"},
+		{name: "negative first", args: map[string]interface{}{"first": -1}, createdOrder: true, want: "error"},
+		{name: "not base64", args: map[string]interface{}{"after": "task:?"}, createdOrder: true, want: "error"},
+		{name: "task without ID", args: map[string]interface{}{"after": encodeTaskCursor(models.TaskCursor{CreatedAt: created})}, createdOrder: true, want: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := taskPageOf(tt.args, tt.createdOrder)
+			got := "error"
+			if err == nil {
+				after := "<nil>"
+				if p.after != nil {
+					after = p.after.CreatedAt.Format(time.RFC3339Nano) + " " + p.after.ID
+				}
+				got = fmt.Sprintf("%d %d %s", p.start, p.size, after)
+			}
+			if got != tt.want {
+				t.Errorf("page %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageOfTasks(t *testing.T) {
+	created := time.Date(2026, time.October, 14, 9, 30, 0, 0, time.UTC)
+	tasks := make([]models.Task, 3)
+	for i := range tasks {
+		tasks[i] = models.Task{ID: fmt.Sprintf("task-%d", i+1), CreatedAt: created.Add(time.Duration(i) * time.Second)}
+	}
+
+	t.Run("creation order", func(t *testing.T) {
+		p := page{size: 2, after: &models.TaskCursor{CreatedAt: created.Add(-time.Second), ID: "task-0"}}
+		c := p.ofTasks(tasks, 7, true)
+		if len(c.Nodes) != 2 || c.TotalCount != 7 || !c.PageInfo.HasNextPage || !c.PageInfo.HasPreviousPage {
+			t.Fatalf("connection %+v, want 2 of 7 tasks with pages before and after", c)
+		}
+		cursor, err := decodeTaskCursor(*c.PageInfo.EndCursor)
+		if err != nil || cursor.ID != "task-2" || !cursor.CreatedAt.Equal(tasks[1].CreatedAt) {
+			t.Errorf("end cursor %+v, %v, want the key of task-2", cursor, err)
+		}
+	})
+	t.Run("sorted", func(t *testing.T) {
+		c := page{start: 4, size: 3}.ofTasks(tasks, 7, false)
+		if len(c.Nodes) != 3 || c.PageInfo.HasNextPage || !c.PageInfo.HasPreviousPage {
+			t.Fatalf("connection %+v, want the last 3 of 7 tasks", c)
+		}
+		if position, err := decodeCursor(*c.PageInfo.EndCursor); err != nil || position != 6 {
+			t.Errorf("end cursor at %d, %v, want 6", position, err)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		c := page{size: 2}.ofTasks(nil, 0, true)
+		if len(c.Edges) != 0 || c.PageInfo.StartCursor != nil || c.PageInfo.HasNextPage || c.PageInfo.HasPreviousPage {
+			t.Errorf("connection %+v, want an empty page", c)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"errors"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/filter"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/service"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Error codes, in the extensions of errors
+const (
+	CodeNotFound     = "NOT_FOUND"
+	CodeBadUserInput = "BAD_USER_INPUT"
+	CodeInternal     = "INTERNAL_SERVER_ERROR"
+)
+
+// codedError gives an error of the services a code, as the REST handlers
+// give it an HTTP status.
+type codedError struct {
+	err  error
+	code string
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// Extensions adds the code, and the details of validation errors and the
+// column of filter errors as the REST error bodies do.
+func (e *codedError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	var validationErr *apperrors.ValidationError
+	if errors.As(e.err, &validationErr) && len(validationErr.Details) > 0 {
+		extensions["details"] = validationErr.Details
+	}
+	var filterErr *filter.Error
+	if errors.As(e.err, &filterErr) {
+		extensions["column"] = filterErr.Col
+	}
+	return extensions
+}
+
+// coded returns err with its code.
+func coded(err error) error {
+	var validationErrs validator.ValidationErrors
+	var validationErr *apperrors.ValidationError
+	var filterErr *filter.Error
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return &codedError{err: err, code: CodeNotFound}
+	case errors.As(err, &validationErrs), errors.As(err, &validationErr), errors.As(err, &filterErr),
+		errors.Is(err, service.ErrTaskCycle), errors.Is(err, service.ErrInvalidMove):
+		return &codedError{err: err, code: CodeBadUserInput}
+	default:
+		return &codedError{err: err, code: CodeInternal}
+	}
+}
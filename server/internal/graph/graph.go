@@ -0,0 +1,172 @@
+// Package graph serves the task domain over GraphQL: tasks, projects,
+// tags, comments and users, with connections for lists, mutations through
+// the task service and subscriptions to the events of the bus. The objects
+// a field refers to are loaded in batches per operation, so a page of
+// tasks costs one query for their projects rather than one per task, and
+// operations beyond the depth and complexity limits are refused before
+// they run.
+package graph
+
+import (
+	"context"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/service"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Services are what the resolvers call.
+type Services struct {
+	Tasks    service.TaskService
+	Projects service.ProjectService
+	Comments service.CommentService
+	Users    service.UserService
+}
+
+// Request is a GraphQL request, as sent in the body of a POST or the query
+// string of a GET.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Viewer is who makes a request. UserID is empty when nobody is signed in,
+// and TimeZone is the time zone of the user, "" for UTC. Validate checks
+// mutation inputs as the REST handlers do.
+type Viewer struct {
+	UserID   string
+	TimeZone string
+	Validate func(i interface{}) error
+}
+
+// Operation is a parsed and validated request.
+type Operation struct {
+	doc       *ast.Document
+	name      string
+	variables map[string]interface{}
+	kind      string
+}
+
+// Subscription reports whether the operation is a subscription, whose
+// results are streamed.
+func (o *Operation) Subscription() bool {
+	return o.kind == ast.OperationTypeSubscription
+}
+
+// Mutation reports whether the operation is a mutation.
+func (o *Operation) Mutation() bool {
+	return o.kind == ast.OperationTypeMutation
+}
+
+// root is the root value of an operation and carries what its resolvers
+// share. Each event of a subscription runs with a root of its own, with
+// fresh loaders and the event.
+type root struct {
+	viewer  Viewer
+	loaders *loaders
+	event   *events.Event
+}
+
+// rootOf returns the root of the operation a field is resolved for.
+func rootOf(p graphql.ResolveParams) *root {
+	return p.Info.RootValue.(*root)
+}
+
+// Server runs GraphQL operations.
+type Server struct {
+	schema   graphql.Schema
+	services Services
+	limits   Limits
+	bus      *events.Bus
+}
+
+// NewServer builds the schema over services. Subscriptions follow the
+// events published on bus.
+func NewServer(services Services, limits Limits, bus *events.Bus) (*Server, error) {
+	s := &Server{services: services, limits: limits, bus: bus}
+	schema, err := s.buildSchema()
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Prepare parses and validates a request and checks it against the
+// limits. It returns the errors of an invalid request as a result.
+func (s *Server) Prepare(req Request) (*Operation, *graphql.Result) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return nil, &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if validation := graphql.ValidateDocument(&s.schema, doc, nil); !validation.IsValid {
+		return nil, &graphql.Result{Errors: validation.Errors}
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		if definition, ok := definition.(*ast.OperationDefinition); ok {
+			if req.OperationName == "" || definition.Name != nil && definition.Name.Value == req.OperationName {
+				operation = definition
+				break
+			}
+		}
+	}
+	if operation == nil {
+		return nil, &graphql.Result{Errors: gqlerrors.FormatErrors(gqlerrors.NewFormattedError("Unknown operation named \"" + req.OperationName + "\""))}
+	}
+	if err := s.limits.check(&s.schema, doc, operation, req.Variables); err != nil {
+		return nil, &graphql.Result{Errors: []gqlerrors.FormattedError{{
+			Message:    err.Error(),
+			Extensions: err.(*LimitError).Extensions(),
+		}}}
+	}
+	return &Operation{doc: doc, name: req.OperationName, variables: req.Variables, kind: operation.Operation}, nil
+}
+
+// Execute runs a query or mutation.
+func (s *Server) Execute(ctx context.Context, viewer Viewer, op *Operation) *graphql.Result {
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		Root:          &root{viewer: viewer, loaders: newLoaders(s.services)},
+		AST:           op.doc,
+		OperationName: op.name,
+		Args:          op.variables,
+		Context:       ctx,
+	})
+}
+
+// Subscribe runs a subscription until ctx is done, sending the result of
+// each event on the returned channel, which is closed at the end.
+func (s *Server) Subscribe(ctx context.Context, viewer Viewer, op *Operation) <-chan *graphql.Result {
+	results := graphql.ExecuteSubscription(graphql.ExecuteParams{
+		Schema:        s.schema,
+		Root:          &root{viewer: viewer, loaders: newLoaders(s.services)},
+		AST:           op.doc,
+		OperationName: op.name,
+		Args:          op.variables,
+		Context:       ctx,
+	})
+
+	// The executor blocks on sending results, so they are drained after
+	// the subscriber is gone until the executor notices
+	out := make(chan *graphql.Result)
+	go func() {
+		defer close(out)
+		for result := range results {
+			select {
+			case out <- result:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the operations the server runs. Depth counts the levels of
+// nested fields. Complexity counts one per field, with the fields under a
+// connection counted once per item of the page asked for. Introspection
+// fields are free.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// DefaultLimits allow the nesting and page sizes of typical screens.
+var DefaultLimits = Limits{MaxDepth: 10, MaxComplexity: 10000}
+
+// LimitError reports an operation beyond the limits.
+type LimitError struct {
+	Message string
+	Code    string
+	Limit   int
+	Actual  int
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+func (e *LimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code, "limit": e.Limit, "actual": e.Actual}
+}
+
+// measure walks an operation the way the executor will, following
+// fragments, to find its depth and complexity.
+type measure struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// check returns a LimitError when operation goes beyond limits.
+func (l Limits) check(schema *graphql.Schema, doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) error {
+	depth, complexity := measureOperation(schema, doc, operation, variables)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitError{
+			Message: fmt.Sprintf("Query is %d levels deep, more than the limit of %d", depth, l.MaxDepth),
+			Code:    "QUERY_TOO_DEEP",
+			Limit:   l.MaxDepth,
+			Actual:  depth,
+		}
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return &LimitError{
+			Message: fmt.Sprintf("Query has a complexity of %d, more than the limit of %d", complexity, l.MaxComplexity),
+			Code:    "QUERY_TOO_COMPLEX",
+			Limit:   l.MaxComplexity,
+			Actual:  complexity,
+		}
+	}
+	return nil
+}
+
+// measureOperation returns the depth and complexity of an operation of doc
+// with variables.
+func measureOperation(schema *graphql.Schema, doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) (int, int) {
+	m := &measure{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: make(map[string]interface{}, len(variables)),
+	}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue != nil {
+			m.variables[definition.Variable.Name.Value] = definition.DefaultValue
+		}
+	}
+	for name, value := range variables {
+		m.variables[name] = value
+	}
+
+	var parent graphql.Type
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		parent = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		parent = schema.SubscriptionType()
+	default:
+		parent = schema.QueryType()
+	}
+	return m.selections(operation.SelectionSet, parent)
+}
+
+// selections returns the depth and complexity of the fields of a selection
+// set on parent.
+func (m *measure) selections(set *ast.SelectionSet, parent graphql.Type) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = m.field(selection, parent)
+		case *ast.InlineFragment:
+			d, c = m.selections(selection.SelectionSet, m.condition(selection.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[selection.Name.Value]; ok {
+				d, c = m.selections(fragment.SelectionSet, m.condition(fragment.TypeCondition, parent))
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (m *measure) field(field *ast.Field, parent graphql.Type) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	var fields graphql.FieldDefinitionMap
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		fields = parent.Fields()
+	case *graphql.Interface:
+		fields = parent.Fields()
+	}
+	definition, ok := fields[field.Name.Value]
+	if !ok {
+		return 1, 1
+	}
+
+	child, _ := graphql.GetNamed(definition.Type).(graphql.Type)
+	depth, complexity := m.selections(field.SelectionSet, child)
+	if isConnection(definition) {
+		complexity *= pageSize(m.argument(field, "first"))
+	}
+	return depth + 1, complexity + 1
+}
+
+// condition returns the type a fragment applies to.
+func (m *measure) condition(condition *ast.Named, parent graphql.Type) graphql.Type {
+	if condition == nil {
+		return parent
+	}
+	if t := m.schema.Type(condition.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+// argument returns the integer value of an argument of a field, 0 when it
+// is missing or not an integer.
+func (m *measure) argument(field *ast.Field, name string) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != name {
+			continue
+		}
+		value := interface{}(argument.Value)
+		if variable, ok := value.(*ast.Variable); ok {
+			value = m.variables[variable.Name.Value]
+		}
+		switch value := value.(type) {
+		case *ast.IntValue:
+			n, _ := strconv.Atoi(value.Value)
+			return n
+		case float64:
+			return int(value)
+		case int:
+			return value
+		}
+	}
+	return 0
+}
+
+func isConnection(definition *graphql.FieldDefinition) bool {
+	for _, argument := range definition.Args {
+		if argument.Name() == "first" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"taskmanager/internal/events"
+)
+
+func newTestServer(t *testing.T, limits Limits) *Server {
+	t.Helper()
+	s, err := NewServer(Services{}, limits, events.NewBus())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return s
+}
+
+func TestMeasureOperation(t *testing.T) {
+	s := newTestServer(t, DefaultLimits)
+
+	tests := []struct {
+		name           string
+		query          string
+		variables      map[string]interface{}
+		wantDepth      int
+		wantComplexity int
+	}{
+		{name: "one field", query: `{ viewer { id } }`, wantDepth: 2, wantComplexity: 2},
+		{name: "default page", query: `{ tasks { nodes { id title } } }`, wantDepth: 3, wantComplexity: 61},
+		{name: "nested pages", query: `{ tasks(first: 5) { totalCount nodes { comments(first: 2) { nodes { body } } } } }`, wantDepth: 5, wantComplexity: 36},
+		{name: "page from a variable", query: `query($n: Int) { tasks(first: $n) { nodes { id } } }`, variables: map[string]interface{}{"n": float64(50)}, wantDepth: 3, wantComplexity: 101},
+		{name: "page from a default", query: `query($n: Int = 3) { tasks(first: $n) { nodes { id } } }`, wantDepth: 3, wantComplexity: 7},
+		{name: "page capped", query: `{ tasks(first: 1000) { nodes { id } } }`, wantDepth: 3, wantComplexity: 201},
+		{name: "fragment spread", query: `{ viewer { ...who } } fragment who on User { id username }`, wantDepth: 2, wantComplexity: 3},
+		{name: "inline fragment", query: `{ task(id: "1") { ... on Task { id } } }`, wantDepth: 2, wantComplexity: 2},
+		{name: "introspection", query: `{ __schema { types { name fields { name } } } }`, wantDepth: 0, wantComplexity: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var operation *ast.OperationDefinition
+			for _, definition := range doc.Definitions {
+				if definition, ok := definition.(*ast.OperationDefinition); ok {
+					operation = definition
+				}
+			}
+			depth, complexity := measureOperation(&s.schema, doc, operation, tt.variables)
+			if depth != tt.wantDepth || complexity != tt.wantComplexity {
+				t.Errorf("measureOperation() = %d, %d, want %d, %d", depth, complexity, tt.wantDepth, tt.wantComplexity)
+			}
+		})
+	}
+}
+
+func TestPrepareLimits(t *testing.T) {
+	deep := `{ task(id: "1") {` + strings.Repeat(" parent {", 10) + " id" + strings.Repeat(" }", 10) + " } }"
+
+	tests := []struct {
+		name       string
+		limits     Limits
+		query      string
+		wantCode   string // "" when the query is within the limits
+		wantActual int
+	}{
+		{name: "within the limits", limits: DefaultLimits, query: `{ tasks { nodes { id } } }`},
+		{name: "too deep", limits: DefaultLimits, query: deep, wantCode: "QUERY_TOO_DEEP", wantActual: 12},
+		{name: "too complex", limits: Limits{MaxComplexity: 100}, query: `{ tasks(first: 100) { nodes { id } } }`, wantCode: "QUERY_TOO_COMPLEX", wantActual: 201},
+		{name: "no limits", limits: Limits{}, query: deep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, result := newTestServer(t, tt.limits).Prepare(Request{Query: tt.query})
+			if tt.wantCode == "" {
+				if result != nil {
+					t.Fatalf("Prepare() errors = %v, want none", result.Errors)
+				}
+				if op == nil {
+					t.Fatal("Prepare() returned no operation")
+				}
+				return
+			}
+			if result == nil || len(result.Errors) != 1 {
+				t.Fatalf("Prepare() result = %v, want one %s error", result, tt.wantCode)
+			}
+			extensions := result.Errors[0].Extensions
+			if extensions["code"] != tt.wantCode || extensions["actual"] != tt.wantActual {
+				t.Errorf("Prepare() extensions = %v, want code %s and actual %d", extensions, tt.wantCode, tt.wantActual)
+			}
+			var limit int
+			if tt.wantCode == "QUERY_TOO_DEEP" {
+				limit = tt.limits.MaxDepth
+			} else {
+				limit = tt.limits.MaxComplexity
+			}
+			if extensions["limit"] != limit {
+				t.Errorf("Prepare() limit = %v, want %d", extensions["limit"], limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"taskmanager/internal/models"
+)
+
+// loader batches the loads of one kind of object during an operation.
+// Load queues a key and returns a thunk; the executor only calls thunks
+// once every field at the current level has been resolved, so the first
+// thunk called fetches all the keys queued by then in one go. Results are
+// kept for the rest of the operation. Operations run in one goroutine, so
+// loaders need no locking.
+type loader struct {
+	fetch   func(keys []string) (map[string]interface{}, error)
+	pending []string
+	queued  map[string]bool
+	results map[string]interface{}
+	errors  map[string]error
+}
+
+func newLoader(fetch func(keys []string) (map[string]interface{}, error)) *loader {
+	return &loader{
+		fetch:   fetch,
+		queued:  make(map[string]bool),
+		results: make(map[string]interface{}),
+		errors:  make(map[string]error),
+	}
+}
+
+// Load returns a thunk of the value of key, nil when there is none.
+func (l *loader) Load(key string) func() (interface{}, error) {
+	l.queue(key)
+	return func() (interface{}, error) {
+		return l.Get(key)
+	}
+}
+
+// Get returns the value of key right away, fetching it together with the
+// keys already queued.
+func (l *loader) Get(key string) (interface{}, error) {
+	l.queue(key)
+	if len(l.pending) > 0 {
+		l.dispatch()
+	}
+	return l.results[key], l.errors[key]
+}
+
+func (l *loader) queue(key string) {
+	if l.queued[key] {
+		return
+	}
+	l.queued[key] = true
+	l.pending = append(l.pending, key)
+}
+
+func (l *loader) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	results, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errors[key] = err
+			continue
+		}
+		if value, ok := results[key]; ok {
+			l.results[key] = value
+		}
+	}
+}
+
+// loaders are the loaders of an operation, or of one event of a
+// subscription.
+type loaders struct {
+	tasks        *loader
+	projects     *loader
+	users        *loader
+	projectTasks *loader
+	taskComments *loader
+}
+
+func newLoaders(services Services) *loaders {
+	return &loaders{
+		tasks: newLoader(func(ids []string) (map[string]interface{}, error) {
+			tasks, err := services.Tasks.GetTasksByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]interface{}, len(tasks))
+			for _, task := range tasks {
+				byID[task.ID] = task
+			}
+			return byID, nil
+		}),
+		projects: newLoader(func(ids []string) (map[string]interface{}, error) {
+			projects, err := services.Projects.GetProjectsByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]interface{}, len(projects))
+			for _, project := range projects {
+				byID[project.ID] = project
+			}
+			return byID, nil
+		}),
+		users: newLoader(func(ids []string) (map[string]interface{}, error) {
+			users, err := services.Users.GetUsersByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]interface{}, len(users))
+			for _, user := range users {
+				byID[user.ID] = user
+			}
+			return byID, nil
+		}),
+		projectTasks: newLoader(func(projectIDs []string) (map[string]interface{}, error) {
+			tasks, err := services.Tasks.GetTasksByProjects(projectIDs)
+			if err != nil {
+				return nil, err
+			}
+			byProject := make(map[string]interface{}, len(projectIDs))
+			for _, id := range projectIDs {
+				byProject[id] = []models.Task{}
+			}
+			for _, task := range tasks {
+				byProject[*task.ProjectID] = append(byProject[*task.ProjectID].([]models.Task), task)
+			}
+			return byProject, nil
+		}),
+		taskComments: newLoader(func(taskIDs []string) (map[string]interface{}, error) {
+			comments, err := services.Comments.ListCommentsByTasks(taskIDs)
+			if err != nil {
+				return nil, err
+			}
+			byTask := make(map[string]interface{}, len(taskIDs))
+			for _, id := range taskIDs {
+				byTask[id] = []models.Comment{}
+			}
+			for _, comment := range comments {
+				byTask[comment.TaskID] = append(byTask[comment.TaskID].([]models.Comment), comment)
+			}
+			return byTask, nil
+		}),
+	}
+}
@@ -0,0 +1,496 @@
+package graph
+
+import (
+	"sort"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+
+	"github.com/graphql-go/graphql"
+)
+
+// buildSchema returns the schema, with resolvers calling the services of
+// the server.
+func (s *Server) buildSchema() (graphql.Schema, error) {
+	t := newTypes()
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        s.queryType(t),
+		Mutation:     s.mutationType(t),
+		Subscription: s.subscriptionType(t),
+	})
+}
+
+func (s *Server) queryType(t *types) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"viewer": &graphql.Field{
+				Type:        t.user,
+				Description: "The signed in user, null when nobody is",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, rootOf(p).viewer.UserID)
+				},
+			},
+			"task": &graphql.Field{
+				Type: t.task,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return rootOf(p).loaders.tasks.Load(p.Args["id"].(string)), nil
+				},
+			},
+			"tasks": &graphql.Field{
+				Type:        nonNull(t.taskConnection),
+				Description: "Tasks, optionally of a project, filtered and sorted as GET /api/v1/tasks does",
+				Args: graphql.FieldConfigArgument{
+					"projectId": &graphql.ArgumentConfig{Type: graphql.ID},
+					"filter":    &graphql.ArgumentConfig{Type: graphql.String, Description: "An expression in the filter language"},
+					"sort":      &graphql.ArgumentConfig{Type: graphql.String},
+					"order":     &graphql.ArgumentConfig{Type: sortOrderEnum},
+					"assignee":  &graphql.ArgumentConfig{Type: graphql.String, Description: "A user ID, \"me\" or \"none\""},
+					"timeZone":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Time zone of days such as today, by default the viewer's"},
+					"first":     connectionArgs["first"],
+					"after":     connectionArgs["after"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					query := taskQuery(p)
+					createdOrder := query.Sort == "" || query.Sort == "created_at"
+					pg, err := taskPageOf(p.Args, createdOrder)
+					if err != nil {
+						return nil, coded(err)
+					}
+					// One task more tells whether there is a next page
+					tasks, total, err := s.services.Tasks.GetTaskPage(query, pg.after, pg.start, pg.size+1)
+					if err != nil {
+						return nil, coded(err)
+					}
+					return pg.ofTasks(tasks, total, createdOrder), nil
+				},
+			},
+			"project": &graphql.Field{
+				Type: t.project,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return rootOf(p).loaders.projects.Load(p.Args["id"].(string)), nil
+				},
+			},
+			"projects": &graphql.Field{
+				Type: nonNull(t.projectConnection),
+				Args: connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pg, err := pageOf(p.Args)
+					if err != nil {
+						return nil, coded(err)
+					}
+					projects, err := s.services.Projects.GetAllProjects()
+					if err != nil {
+						return nil, coded(err)
+					}
+					return pg.of(len(projects), func(i int) interface{} { return projects[i] }), nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: t.user,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return rootOf(p).loaders.users.Load(p.Args["id"].(string)), nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: nonNull(t.userConnection),
+				Args: connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pg, err := pageOf(p.Args)
+					if err != nil {
+						return nil, coded(err)
+					}
+					users, err := s.services.Users.GetAllUsers()
+					if err != nil {
+						return nil, coded(err)
+					}
+					return pg.of(len(users), func(i int) interface{} { return users[i] }), nil
+				},
+			},
+			"tags": &graphql.Field{
+				Type:        listOf(t.tag),
+				Description: "Tags in use, optionally in a project, by name",
+				Args: graphql.FieldConfigArgument{
+					"projectId": &graphql.ArgumentConfig{Type: graphql.ID},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.tags(taskQuery(p))
+				},
+			},
+		},
+	})
+}
+
+// taskQuery returns the list query the arguments of a field describe.
+func taskQuery(p graphql.ResolveParams) models.TaskQuery {
+	viewer := rootOf(p).viewer
+	query := models.TaskQuery{
+		ProjectID: stringArg(p.Args, "projectId"),
+		Filter:    stringArg(p.Args, "filter"),
+		Sort:      stringArg(p.Args, "sort"),
+		Order:     stringArg(p.Args, "order"),
+		Assignee:  stringArg(p.Args, "assignee"),
+		TimeZone:  stringArg(p.Args, "timeZone"),
+		UserID:    viewer.UserID,
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = viewer.TimeZone
+	}
+	return query
+}
+
+// tags groups the tasks query selects by tag.
+func (s *Server) tags(query models.TaskQuery) (interface{}, error) {
+	byName := make(map[string]*tag)
+	err := s.services.Tasks.EachTask(query, func(task models.Task) error {
+		for _, name := range task.Tags {
+			if byName[name] == nil {
+				byName[name] = &tag{Name: name}
+			}
+			byName[name].Tasks = append(byName[name].Tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, coded(err)
+	}
+	tags := make([]interface{}, 0, len(byName))
+	for _, tag := range byName {
+		tags = append(tags, *tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].(tag).Name < tags[j].(tag).Name
+	})
+	return tags, nil
+}
+
+func (s *Server) mutationType(t *types) *graphql.Object {
+	createInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateTaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"dueDate":      &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "YYYY-MM-DD for all day, else an RFC 3339 or local time"},
+			"dueTimeZone":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Time zone of a local due time, by default the viewer's"},
+			"completed":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"status":       &graphql.InputObjectFieldConfig{Type: taskStatusEnum},
+			"priority":     &graphql.InputObjectFieldConfig{Type: priorityEnum},
+			"recurrence":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"estimate":     &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"sprintId":     &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"projectId":    &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"parentId":     &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"externalId":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tags":         &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"customFields": &graphql.InputObjectFieldConfig{Type: jsonScalar},
+		},
+	})
+	updateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "UpdateTaskInput",
+		Description: "Changes to a task. Missing fields keep their value, and an empty projectId, parentId or sprintId removes the link",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"dueDate":      &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "As for createTask, or \"none\" to remove it"},
+			"dueTimeZone":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"completed":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"status":       &graphql.InputObjectFieldConfig{Type: taskStatusEnum},
+			"priority":     &graphql.InputObjectFieldConfig{Type: priorityEnum},
+			"recurrence":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"estimate":     &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"sprintId":     &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"projectId":    &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"parentId":     &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"tags":         &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"customFields": &graphql.InputObjectFieldConfig{Type: jsonScalar, Description: "Values by key; null clears one and missing keys are kept"},
+		},
+	})
+	moveInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "MoveTaskInput",
+		Description: "Where a task goes on its board: right after afterId, or right before beforeId",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"status":   &graphql.InputObjectFieldConfig{Type: taskStatusEnum},
+			"afterId":  &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"beforeId": &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		},
+	})
+	id := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": &graphql.Field{
+				Type: nonNull(t.task),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer := rootOf(p).viewer
+					input := createTaskInput(p.Args["input"].(map[string]interface{}))
+					if input.DueTimeZone == "" {
+						input.DueTimeZone = viewer.TimeZone
+					}
+					if err := viewer.validate(&input); err != nil {
+						return nil, coded(err)
+					}
+					task, err := s.services.Tasks.CreateTask(input)
+					if err != nil {
+						return nil, coded(err)
+					}
+					return task, nil
+				},
+			},
+			"updateTask": &graphql.Field{
+				Type: nonNull(t.task),
+				Args: graphql.FieldConfigArgument{
+					"id":    id["id"],
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer := rootOf(p).viewer
+					taskID := p.Args["id"].(string)
+					current, err := s.services.Tasks.GetTaskByID(taskID)
+					if err != nil {
+						return nil, coded(err)
+					}
+					input := updateTaskInput(current, p.Args["input"].(map[string]interface{}))
+					if input.DueTimeZone == "" {
+						input.DueTimeZone = viewer.TimeZone
+					}
+					if err := viewer.validate(&input); err != nil {
+						return nil, coded(err)
+					}
+					task, err := s.services.Tasks.UpdateTask(taskID, input, viewer.UserID)
+					if err != nil {
+						return nil, coded(err)
+					}
+					return task, nil
+				},
+			},
+			"moveTask": &graphql.Field{
+				Type: nonNull(t.task),
+				Args: graphql.FieldConfigArgument{
+					"id":    id["id"],
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(moveInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					fields := p.Args["input"].(map[string]interface{})
+					input := models.MoveTaskInput{
+						Status:   stringArg(fields, "status"),
+						AfterID:  stringArg(fields, "afterId"),
+						BeforeID: stringArg(fields, "beforeId"),
+					}
+					if err := rootOf(p).viewer.validate(&input); err != nil {
+						return nil, coded(err)
+					}
+					task, err := s.services.Tasks.MoveTask(p.Args["id"].(string), input, rootOf(p).viewer.UserID)
+					if err != nil {
+						return nil, coded(err)
+					}
+					return task, nil
+				},
+			},
+			"deleteTask": &graphql.Field{
+				Type:        nonNull(graphql.ID),
+				Description: "Moves a task to the trash and returns its ID",
+				Args:        id,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					taskID := p.Args["id"].(string)
+					if err := s.services.Tasks.DeleteTask(taskID, rootOf(p).viewer.UserID); err != nil {
+						return nil, coded(err)
+					}
+					return taskID, nil
+				},
+			},
+			"restoreTask": &graphql.Field{
+				Type:        nonNull(t.task),
+				Description: "Brings a task back from the trash",
+				Args:        id,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					task, err := s.services.Tasks.RestoreTask(p.Args["id"].(string), rootOf(p).viewer.UserID)
+					if err != nil {
+						return nil, coded(err)
+					}
+					return task, nil
+				},
+			},
+		},
+	})
+}
+
+// validate checks a mutation input with the validator of the viewer, if any.
+func (v Viewer) validate(input interface{}) error {
+	if v.Validate == nil {
+		return nil
+	}
+	return v.Validate(input)
+}
+
+func createTaskInput(fields map[string]interface{}) models.CreateTaskInput {
+	input := models.CreateTaskInput{
+		Title:       stringArg(fields, "title"),
+		Description: stringArg(fields, "description"),
+		DueDate:     stringArg(fields, "dueDate"),
+		DueTimeZone: stringArg(fields, "dueTimeZone"),
+		Status:      stringArg(fields, "status"),
+		Priority:    stringArg(fields, "priority"),
+		Recurrence:  stringArg(fields, "recurrence"),
+		SprintID:    stringPtrArg(fields, "sprintId"),
+		ProjectID:   stringPtrArg(fields, "projectId"),
+		ParentID:    stringPtrArg(fields, "parentId"),
+		ExternalID:  stringPtrArg(fields, "externalId"),
+		Tags:        stringsArg(fields, "tags"),
+	}
+	input.Completed, _ = fields["completed"].(bool)
+	input.Estimate, _ = fields["estimate"].(float64)
+	input.CustomFields, _ = fields["customFields"].(map[string]interface{})
+	return input
+}
+
+// updateTaskInput returns the update that changes the fields given of
+// current and keeps the rest.
+func updateTaskInput(current models.Task, fields map[string]interface{}) models.UpdateTaskInput {
+	input := models.UpdateTaskInput{
+		Title:       current.Title,
+		Description: current.Description,
+		Completed:   current.Completed,
+		DueDate:     stringArg(fields, "dueDate"),
+		DueTimeZone: stringArg(fields, "dueTimeZone"),
+		Status:      stringArg(fields, "status"),
+		Priority:    stringArg(fields, "priority"),
+		Recurrence:  stringPtrArg(fields, "recurrence"),
+		SprintID:    stringPtrArg(fields, "sprintId"),
+		ProjectID:   stringPtrArg(fields, "projectId"),
+		ParentID:    stringPtrArg(fields, "parentId"),
+		Tags:        stringsArg(fields, "tags"),
+	}
+	if title, ok := fields["title"].(string); ok {
+		input.Title = title
+	}
+	if description, ok := fields["description"].(string); ok {
+		input.Description = description
+	}
+	if completed, ok := fields["completed"].(bool); ok {
+		input.Completed = completed
+	}
+	if estimate, ok := fields["estimate"].(float64); ok {
+		input.Estimate = &estimate
+	}
+	input.CustomFields, _ = fields["customFields"].(map[string]interface{})
+	return input
+}
+
+// stringArg returns an argument or input field of type String or ID, ""
+// when it is missing.
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+// stringPtrArg returns an argument or input field of type String or ID,
+// nil when it is missing.
+func stringPtrArg(args map[string]interface{}, name string) *string {
+	if s, ok := args[name].(string); ok {
+		return &s
+	}
+	return nil
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	list, ok := args[name].([]interface{})
+	if !ok {
+		return nil
+	}
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func (s *Server) subscriptionType(t *types) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"taskEvents": &graphql.Field{
+				Type:        nonNull(t.taskEvent),
+				Description: "Changes to tasks and their comments as they happen. With projectId, the deletion and purge of a task are sent when the task was last in the project",
+				Args: graphql.FieldConfigArgument{
+					"projectId": &graphql.ArgumentConfig{Type: graphql.ID},
+					"taskId":    &graphql.ArgumentConfig{Type: graphql.ID},
+				},
+				Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.taskEvents(p), nil
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return *rootOf(p).event, nil
+				},
+			},
+		},
+	})
+}
+
+// taskEvents follows the events of the bus that match the arguments of a
+// subscription until its context is done. Each event is sent as the root
+// of its own execution.
+func (s *Server) taskEvents(p graphql.ResolveParams) chan interface{} {
+	viewer := rootOf(p).viewer
+	projectID := stringArg(p.Args, "projectId")
+	taskID := stringArg(p.Args, "taskId")
+	ctx := p.Context
+
+	subscription, unsubscribe := s.bus.Watch()
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-subscription:
+				if taskID != "" && event.TaskID != taskID {
+					continue
+				}
+				r := &root{viewer: viewer, loaders: newLoaders(s.services), event: &event}
+				if projectID != "" && !inProject(r.loaders, event, projectID) {
+					continue
+				}
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// inProject reports whether the task of an event is in a project, or was
+// last in it when it no longer exists. The task is loaded with the loaders
+// of the event, so the payload reuses it.
+func inProject(l *loaders, event events.Event, projectID string) bool {
+	task, err := l.tasks.Get(event.TaskID)
+	if err != nil {
+		return false
+	}
+	if task == nil {
+		return event.ProjectID == projectID
+	}
+	id := task.(models.Task).ProjectID
+	return id != nil && *id == projectID
+}
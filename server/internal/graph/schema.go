@@ -0,0 +1,434 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// eventTypes are the types of events subscriptions deliver
+var eventTypes = []string{
+	events.TaskCreated,
+	events.TaskUpdated,
+	events.TaskDeleted,
+	events.TaskRestored,
+	events.TaskPurged,
+	events.CommentCreated,
+	events.CommentUpdated,
+	events.CommentDeleted,
+}
+
+var (
+	taskStatusEnum = enum("TaskStatus", "Board column of a task", models.Statuses)
+	priorityEnum   = enum("Priority", "Priority of a task", models.Priorities)
+	sortOrderEnum  = enum("SortOrder", "Direction of a sort", []string{"asc", "desc"})
+	eventTypeEnum  = enum("TaskEventType", "Kind of change to a task or its comments", eventTypes)
+)
+
+// enum returns an enum of values, named by the values in upper case.
+func enum(name, description string, values []string) *graphql.Enum {
+	config := make(graphql.EnumValueConfigMap, len(values))
+	for _, value := range values {
+		config[strings.ToUpper(strings.ReplaceAll(value, ".", "_"))] = &graphql.EnumValueConfig{Value: value}
+	}
+	return graphql.NewEnum(graphql.EnumConfig{Name: name, Description: description, Values: config})
+}
+
+// jsonScalar holds values of any JSON type, such as custom field values.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: literal,
+})
+
+// literal returns the JSON value of a literal. Numbers are float64, as
+// encoding/json decodes them.
+func literal(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.IntValue:
+		n, _ := strconv.ParseFloat(value.Value, 64)
+		return n
+	case *ast.FloatValue:
+		n, _ := strconv.ParseFloat(value.Value, 64)
+		return n
+	case *ast.ListValue:
+		list := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			list[i] = literal(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = literal(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+func nonNull(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(t)
+}
+
+func listOf(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// types are the object types of the schema.
+type types struct {
+	user              *graphql.Object
+	task              *graphql.Object
+	project           *graphql.Object
+	comment           *graphql.Object
+	tag               *graphql.Object
+	taskEvent         *graphql.Object
+	taskConnection    *graphql.Object
+	projectConnection *graphql.Object
+	userConnection    *graphql.Object
+	commentConnection *graphql.Object
+}
+
+// tag is a tag with the tasks that have it.
+type tag struct {
+	Name  string
+	Tasks []models.Task
+}
+
+func newTypes() *types {
+	t := &types{}
+	t.user = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: nonNull(graphql.ID)},
+			"username":    &graphql.Field{Type: nonNull(graphql.String)},
+			"displayName": &graphql.Field{Type: nonNull(graphql.String)},
+			"email":       &graphql.Field{Type: nonNull(graphql.String)},
+			"timeZone":    &graphql.Field{Type: nonNull(graphql.String)},
+			"createdAt":   &graphql.Field{Type: nonNull(graphql.DateTime)},
+			"updatedAt":   &graphql.Field{Type: nonNull(graphql.DateTime)},
+		},
+	})
+	t.task = graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Task",
+		Fields: graphql.FieldsThunk(t.taskFields),
+	})
+	t.project = graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Project",
+		Fields: graphql.FieldsThunk(t.projectFields),
+	})
+	t.comment = graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Comment",
+		Fields: graphql.FieldsThunk(t.commentFields),
+	})
+	t.taskConnection = connectionType(t.task)
+	t.projectConnection = connectionType(t.project)
+	t.userConnection = connectionType(t.user)
+	t.commentConnection = connectionType(t.comment)
+
+	t.tag = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: nonNull(graphql.String)},
+			"taskCount": &graphql.Field{
+				Type: nonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return len(p.Source.(tag).Tasks), nil
+				},
+			},
+			"tasks": &graphql.Field{
+				Type: nonNull(t.taskConnection),
+				Args: connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return taskPage(p, p.Source.(tag).Tasks)
+				},
+			},
+		},
+	})
+	t.taskEvent = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TaskEvent",
+		Description: "A change to a task or one of its comments",
+		Fields: graphql.Fields{
+			"type":   &graphql.Field{Type: nonNull(eventTypeEnum)},
+			"taskId": &graphql.Field{Type: nonNull(graphql.ID)},
+			"commentId": &graphql.Field{
+				Type: graphql.ID,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullable(p.Source.(events.Event).CommentID), nil
+				},
+			},
+			"at": &graphql.Field{Type: nonNull(graphql.DateTime)},
+			"task": &graphql.Field{
+				Type:        t.task,
+				Description: "The task as it is now, null once it is deleted",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return rootOf(p).loaders.tasks.Load(p.Source.(events.Event).TaskID), nil
+				},
+			},
+			"comment": &graphql.Field{
+				Type:        t.comment,
+				Description: "The comment as it is now, null once it is deleted",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					event := p.Source.(events.Event)
+					if event.CommentID == "" {
+						return nil, nil
+					}
+					comments := rootOf(p).loaders.taskComments.Load(event.TaskID)
+					return func() (interface{}, error) {
+						list, err := comments()
+						if err != nil {
+							return nil, err
+						}
+						for _, comment := range list.([]models.Comment) {
+							if comment.ID == event.CommentID {
+								return comment, nil
+							}
+						}
+						return nil, nil
+					}, nil
+				},
+			},
+			"actor": &graphql.Field{
+				Type:        t.user,
+				Description: "Who made the change, when known",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Source.(events.Event).ActorID)
+				},
+			},
+		},
+	})
+	return t
+}
+
+func (t *types) taskFields() graphql.Fields {
+	return graphql.Fields{
+		"id":          &graphql.Field{Type: nonNull(graphql.ID)},
+		"title":       &graphql.Field{Type: nonNull(graphql.String)},
+		"description": &graphql.Field{Type: nonNull(graphql.String)},
+		"completed":   &graphql.Field{Type: nonNull(graphql.Boolean)},
+		"status":      &graphql.Field{Type: nonNull(taskStatusEnum)},
+		"priority":    &graphql.Field{Type: nonNull(priorityEnum)},
+		"position":    &graphql.Field{Type: nonNull(graphql.String)},
+		"dueDate": &graphql.Field{
+			Type:        graphql.String,
+			Description: "YYYY-MM-DD for tasks due all day, else an RFC 3339 time",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return nullable(p.Source.(models.Task).FormatDueDate()), nil
+			},
+		},
+		"dueTimeZone": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Time zone of timed due dates",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				task := p.Source.(models.Task)
+				if !task.HasDueDate() || task.DueAllDay() {
+					return nil, nil
+				}
+				return nullable(task.DueTimeZone), nil
+			},
+		},
+		"recurrence": &graphql.Field{Type: nonNull(graphql.String)},
+		"estimate":   &graphql.Field{Type: nonNull(graphql.Float)},
+		"externalId": &graphql.Field{Type: graphql.String},
+		"sprintId":   &graphql.Field{Type: graphql.ID},
+		"tags":       &graphql.Field{Type: listOf(graphql.String)},
+		"customFields": &graphql.Field{
+			Type:        nonNull(jsonScalar),
+			Description: "Values of the custom fields of the project, by key",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if fields := p.Source.(models.Task).CustomFields; fields != nil {
+					return fields, nil
+				}
+				return map[string]interface{}{}, nil
+			},
+		},
+		"project": &graphql.Field{
+			Type: t.project,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				task := p.Source.(models.Task)
+				if task.ProjectID == nil {
+					return nil, nil
+				}
+				return rootOf(p).loaders.projects.Load(*task.ProjectID), nil
+			},
+		},
+		"parent": &graphql.Field{
+			Type: t.task,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				task := p.Source.(models.Task)
+				if task.ParentID == nil {
+					return nil, nil
+				}
+				return rootOf(p).loaders.tasks.Load(*task.ParentID), nil
+			},
+		},
+		"assignees": &graphql.Field{
+			Type: listOf(t.user),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadAll(rootOf(p).loaders.users, p.Source.(models.Task).Assignees), nil
+			},
+		},
+		"watchers": &graphql.Field{
+			Type: listOf(t.user),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadAll(rootOf(p).loaders.users, p.Source.(models.Task).Watchers), nil
+			},
+		},
+		"comments": &graphql.Field{
+			Type:        nonNull(t.commentConnection),
+			Description: "Comments and replies, oldest first",
+			Args:        connectionArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := pageOf(p.Args)
+				if err != nil {
+					return nil, coded(err)
+				}
+				comments := rootOf(p).loaders.taskComments.Load(p.Source.(models.Task).ID)
+				return func() (interface{}, error) {
+					list, err := comments()
+					if err != nil {
+						return nil, err
+					}
+					all := list.([]models.Comment)
+					return pg.of(len(all), func(i int) interface{} { return all[i] }), nil
+				}, nil
+			},
+		},
+		"createdAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+		"updatedAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+	}
+}
+
+func (t *types) projectFields() graphql.Fields {
+	return graphql.Fields{
+		"id":           &graphql.Field{Type: nonNull(graphql.ID)},
+		"name":         &graphql.Field{Type: nonNull(graphql.String)},
+		"description":  &graphql.Field{Type: nonNull(graphql.String)},
+		"estimateUnit": &graphql.Field{Type: nonNull(graphql.String)},
+		"calendarId":   &graphql.Field{Type: graphql.ID},
+		"admin": &graphql.Field{
+			Type:        t.user,
+			Description: "Who SLA breaches are escalated to",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(models.Project)
+				if project.AdminID == nil {
+					return nil, nil
+				}
+				return loadUser(p, *project.AdminID)
+			},
+		},
+		"tasks": &graphql.Field{
+			Type:        nonNull(t.taskConnection),
+			Description: "Tasks of the project, oldest first",
+			Args:        connectionArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := pageOf(p.Args)
+				if err != nil {
+					return nil, coded(err)
+				}
+				tasks := rootOf(p).loaders.projectTasks.Load(p.Source.(models.Project).ID)
+				return func() (interface{}, error) {
+					list, err := tasks()
+					if err != nil {
+						return nil, err
+					}
+					all := list.([]models.Task)
+					return pg.of(len(all), func(i int) interface{} { return all[i] }), nil
+				}, nil
+			},
+		},
+		"createdAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+		"updatedAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+	}
+}
+
+func (t *types) commentFields() graphql.Fields {
+	return graphql.Fields{
+		"id":       &graphql.Field{Type: nonNull(graphql.ID)},
+		"body":     &graphql.Field{Type: nonNull(graphql.String)},
+		"bodyHtml": &graphql.Field{Type: nonNull(graphql.String), Description: "The body rendered from Markdown"},
+		"parentId": &graphql.Field{Type: graphql.ID, Description: "The comment this one replies to"},
+		"task": &graphql.Field{
+			Type: t.task,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return rootOf(p).loaders.tasks.Load(p.Source.(models.Comment).TaskID), nil
+			},
+		},
+		"author": &graphql.Field{
+			Type: t.user,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadUser(p, p.Source.(models.Comment).AuthorID)
+			},
+		},
+		"mentions": &graphql.Field{
+			Type: listOf(t.user),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadAll(rootOf(p).loaders.users, p.Source.(models.Comment).Mentions), nil
+			},
+		},
+		"editedAt":  &graphql.Field{Type: graphql.DateTime},
+		"createdAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+	}
+}
+
+// taskPage returns the page of tasks a field asks for.
+func taskPage(p graphql.ResolveParams, tasks []models.Task) (interface{}, error) {
+	pg, err := pageOf(p.Args)
+	if err != nil {
+		return nil, coded(err)
+	}
+	return pg.of(len(tasks), func(i int) interface{} { return tasks[i] }), nil
+}
+
+// loadUser returns a thunk of a user, or null without an ID.
+func loadUser(p graphql.ResolveParams, id string) (interface{}, error) {
+	if id == "" {
+		return nil, nil
+	}
+	return rootOf(p).loaders.users.Load(id), nil
+}
+
+// loadAll returns a thunk of the values of keys that exist, in order.
+func loadAll(l *loader, keys []string) func() (interface{}, error) {
+	for _, key := range keys {
+		l.queue(key)
+	}
+	return func() (interface{}, error) {
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			value, err := l.Get(key)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	}
+}
+
+// nullable returns null for an empty string.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,199 @@
+// Package ical writes tasks as iCalendar (RFC 5545): as to-dos (VTODO)
+// with their due date, state, priority, tags and recurrence, or as
+// events (VEVENT) on their due date for calendars that do not show
+// to-dos. Output depends only on the tasks, so the same tasks always give
+// the same bytes. It also reads iCalendar files, and to-dos from them.
+package ical
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"taskmanager/internal/models"
+)
+
+// ProductID identifies this app in the calendars it writes
+const ProductID = "-//taskmanager//tasks//EN"
+
+// UIDDomain follows the task ID in the UID of its to-do or event
+const UIDDomain = "taskmanager"
+
+// maxLineOctets is the length past which lines are folded
+const maxLineOctets = 75
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+)
+
+// priorities maps task priorities to iCalendar ones, where 1 is the
+// highest and 9 the lowest. Tasks without a priority have none.
+var priorities = map[string]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityMedium: 5,
+	models.PriorityLow:    9,
+}
+
+var todoStatuses = map[string]string{
+	models.StatusTodo:       "NEEDS-ACTION",
+	models.StatusInProgress: "IN-PROCESS",
+	models.StatusDone:       "COMPLETED",
+}
+
+// Calendar is an iCalendar object being written. UIDs overrides the UID
+// of tasks by task ID, for tasks whose to-do was created elsewhere.
+type Calendar struct {
+	UIDs map[string]string
+	b    strings.Builder
+}
+
+// NewCalendar starts a calendar named name. Clients are asked to refresh
+// it every refresh.
+func NewCalendar(name string, refresh time.Duration) *Calendar {
+	c := &Calendar{}
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", ProductID)
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	c.line("X-WR-CALNAME", Escape(name))
+	if refresh > 0 {
+		c.line("REFRESH-INTERVAL;VALUE=DURATION", duration(refresh))
+		c.line("X-PUBLISHED-TTL", duration(refresh))
+	}
+	return c
+}
+
+// NewObject starts a calendar object resource, which holds a single
+// to-do and no publishing properties.
+func NewObject() *Calendar {
+	c := &Calendar{}
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", ProductID)
+	return c
+}
+
+// UID returns the UID of the to-do or event of a task.
+func UID(taskID string) string {
+	return taskID + "@" + UIDDomain
+}
+
+// AddTodo writes a task as a to-do. A done task was completed at its last
+// change, as tasks do not keep when they were completed.
+func (c *Calendar) AddTodo(task models.Task) {
+	c.line("BEGIN", "VTODO")
+	c.common(task)
+	if task.HasDueDate() {
+		c.date("DUE", task)
+	}
+	c.line("STATUS", todoStatuses[task.Status])
+	if task.Status == models.StatusDone {
+		c.line("COMPLETED", task.UpdatedAt.UTC().Format(dateTimeLayout))
+		c.line("PERCENT-COMPLETE", "100")
+	}
+	if task.Recurrence != "" {
+		c.line("RRULE", task.Recurrence)
+	}
+	c.line("END", "VTODO")
+}
+
+// AddEvent writes a task as an event on its due date, or at its due time
+// without a length. Tasks without a due date are left out.
+func (c *Calendar) AddEvent(task models.Task) {
+	if !task.HasDueDate() {
+		return
+	}
+	c.line("BEGIN", "VEVENT")
+	c.common(task)
+	c.date("DTSTART", task)
+	if task.DueAllDay() {
+		c.line("DTEND;VALUE=DATE", task.DueDate.UTC().AddDate(0, 0, 1).Format(dateLayout))
+	}
+	c.line("TRANSP", "TRANSPARENT")
+	if task.Recurrence != "" {
+		c.line("RRULE", task.Recurrence)
+	}
+	c.line("END", "VEVENT")
+}
+
+// Bytes ends the calendar and returns it.
+func (c *Calendar) Bytes() []byte {
+	c.line("END", "VCALENDAR")
+	return []byte(c.b.String())
+}
+
+// common writes the properties to-dos and events share.
+func (c *Calendar) common(task models.Task) {
+	c.line("UID", c.uid(task.ID))
+	c.line("DTSTAMP", task.UpdatedAt.UTC().Format(dateTimeLayout))
+	c.line("CREATED", task.CreatedAt.UTC().Format(dateTimeLayout))
+	c.line("LAST-MODIFIED", task.UpdatedAt.UTC().Format(dateTimeLayout))
+	c.line("SUMMARY", Escape(task.Title))
+	if task.Description != "" {
+		c.line("DESCRIPTION", Escape(task.Description))
+	}
+	if priority, ok := priorities[task.Priority]; ok {
+		c.line("PRIORITY", strconv.Itoa(priority))
+	}
+	if len(task.Tags) > 0 {
+		tags := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			tags[i] = Escape(tag)
+		}
+		c.line("CATEGORIES", strings.Join(tags, ","))
+	}
+	if task.ParentID != nil {
+		c.line("RELATED-TO", c.uid(*task.ParentID))
+	}
+}
+
+func (c *Calendar) uid(taskID string) string {
+	if uid, ok := c.UIDs[taskID]; ok {
+		return uid
+	}
+	return UID(taskID)
+}
+
+// date writes the due date of a task: a date for tasks due all day and a
+// UTC time otherwise.
+func (c *Calendar) date(name string, task models.Task) {
+	if task.DueAllDay() {
+		c.line(name+";VALUE=DATE", task.DueDate.UTC().Format(dateLayout))
+		return
+	}
+	c.line(name, task.DueDate.UTC().Format(dateTimeLayout))
+}
+
+// line writes a content line, folded after 75 octets without splitting a
+// character.
+func (c *Calendar) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.b.WriteString(line[:cut])
+		c.b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space
+		limit = maxLineOctets - 1
+	}
+	c.b.WriteString(line)
+	c.b.WriteString("\r\n")
+}
+
+// Escape writes text as a TEXT value.
+func Escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// duration writes a duration as a DURATION value in minutes.
+func duration(d time.Duration) string {
+	return "PT" + strconv.Itoa(int(d.Minutes())) + "M"
+}
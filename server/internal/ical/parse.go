@@ -0,0 +1,288 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+// Component is a parsed iCalendar component, such as a VCALENDAR or a
+// VTODO, with its properties and subcomponents. Names are upper-cased.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []Component
+}
+
+// Property is a content line of a component. Parameter names are
+// upper-cased; the value is left escaped.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Prop returns the first property of a component with a name.
+func (c Component) Prop(name string) (Property, bool) {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+	return Property{}, false
+}
+
+// Decode reads the first component of an iCalendar stream, usually its
+// VCALENDAR.
+func Decode(r io.Reader) (Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Component{}, err
+	}
+	var stack []Component
+	for number, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			return Component{}, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, Component{Name: strings.ToUpper(prop.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return Component{}, fmt.Errorf("line %d: unexpected END:%s", number+1, prop.Value)
+			}
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return done, nil
+			}
+			parent := &stack[len(stack)-1]
+			parent.Components = append(parent.Components, done)
+		default:
+			if len(stack) == 0 {
+				return Component{}, fmt.Errorf("line %d: property outside any component", number+1)
+			}
+			current := &stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+	return Component{}, errors.New("unterminated or missing component")
+}
+
+// unfold joins the lines continued by a leading space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits "NAME;PARAM=value:content" into its parts.
+func parseProperty(line string) (Property, error) {
+	head, value, ok := cutUnquoted(line, ':')
+	if !ok {
+		return Property{}, fmt.Errorf("missing ':' in %q", line)
+	}
+	prop := Property{Params: make(map[string]string), Value: value}
+	name, rest, more := cutUnquoted(head, ';')
+	prop.Name = strings.ToUpper(name)
+	for more {
+		var param string
+		param, rest, more = cutUnquoted(rest, ';')
+		paramName, paramValue, _ := strings.Cut(param, "=")
+		prop.Params[strings.ToUpper(paramName)] = strings.Trim(paramValue, `"`)
+	}
+	return prop, nil
+}
+
+// cutUnquoted cuts s around the first sep outside double quotes.
+func cutUnquoted(s string, sep byte) (string, string, bool) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				return s[:i], s[i+1:], true
+			}
+		}
+	}
+	return s, "", false
+}
+
+// Unescape decodes a TEXT value.
+func Unescape(text string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(text)
+}
+
+// splitList splits a list of TEXT values on the commas that are not
+// escaped, and unescapes them.
+func splitList(value string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, Unescape(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, Unescape(value[start:]))
+}
+
+// Todo is a to-do read from iCalendar in the terms of tasks. Due is a
+// YYYY-MM-DD date, an RFC 3339 time, or a local YYYY-MM-DDTHH:MM:SS time
+// in DueTimeZone, and "" when the to-do is not due. Status and Priority
+// are task statuses and priorities.
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Due         string
+	DueTimeZone string
+	Status      string
+	Priority    string
+	Categories  []string
+	RRule       string
+}
+
+// ParseTodo reads the to-do of a calendar object resource. Overrides of
+// single occurrences of a repeating to-do (RECURRENCE-ID) are ignored.
+func ParseTodo(r io.Reader) (Todo, error) {
+	calendar, err := Decode(r)
+	if err != nil {
+		return Todo{}, err
+	}
+	if calendar.Name != "VCALENDAR" {
+		return Todo{}, errors.New("not a VCALENDAR")
+	}
+	var todos []Component
+	for _, component := range calendar.Components {
+		if component.Name != "VTODO" {
+			continue
+		}
+		if _, ok := component.Prop("RECURRENCE-ID"); ok {
+			continue
+		}
+		todos = append(todos, component)
+	}
+	if len(todos) != 1 {
+		return Todo{}, fmt.Errorf("expected one VTODO, found %d", len(todos))
+	}
+	return readTodo(todos[0])
+}
+
+func readTodo(component Component) (Todo, error) {
+	var todo Todo
+	uid, ok := component.Prop("UID")
+	if !ok || uid.Value == "" {
+		return Todo{}, errors.New("VTODO without UID")
+	}
+	todo.UID = uid.Value
+	if summary, ok := component.Prop("SUMMARY"); ok {
+		todo.Summary = strings.TrimSpace(Unescape(summary.Value))
+	}
+	if description, ok := component.Prop("DESCRIPTION"); ok {
+		todo.Description = Unescape(description.Value)
+	}
+	if due, ok := component.Prop("DUE"); ok {
+		var err error
+		if todo.Due, todo.DueTimeZone, err = readDue(due); err != nil {
+			return Todo{}, err
+		}
+	}
+
+	todo.Status = models.StatusTodo
+	if status, ok := component.Prop("STATUS"); ok {
+		switch strings.ToUpper(status.Value) {
+		case "COMPLETED":
+			todo.Status = models.StatusDone
+		case "IN-PROCESS":
+			todo.Status = models.StatusInProgress
+		}
+	}
+	if _, ok := component.Prop("COMPLETED"); ok {
+		todo.Status = models.StatusDone
+	}
+	if percent, ok := component.Prop("PERCENT-COMPLETE"); ok && percent.Value == "100" {
+		todo.Status = models.StatusDone
+	}
+
+	todo.Priority = models.PriorityNone
+	if priority, ok := component.Prop("PRIORITY"); ok {
+		n, _ := strconv.Atoi(priority.Value)
+		switch {
+		case n == 1:
+			todo.Priority = models.PriorityUrgent
+		case n >= 2 && n <= 4:
+			todo.Priority = models.PriorityHigh
+		case n == 5:
+			todo.Priority = models.PriorityMedium
+		case n >= 6 && n <= 9:
+			todo.Priority = models.PriorityLow
+		}
+	}
+	for _, prop := range component.Properties {
+		if prop.Name != "CATEGORIES" {
+			continue
+		}
+		for _, category := range splitList(prop.Value) {
+			if category = strings.TrimSpace(category); category != "" {
+				todo.Categories = append(todo.Categories, category)
+			}
+		}
+	}
+	if rrule, ok := component.Prop("RRULE"); ok {
+		todo.RRule = rrule.Value
+	}
+	return todo, nil
+}
+
+// readDue reads a DATE or DATE-TIME: UTC times as RFC 3339, times with a
+// known TZID as local times in it, and floating times as UTC.
+func readDue(prop Property) (string, string, error) {
+	value := prop.Value
+	if strings.EqualFold(prop.Params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid date %q", value)
+		}
+		return date.Format("2006-01-02"), "", nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		instant, err := time.Parse(dateTimeLayout, value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid date-time %q", value)
+		}
+		return instant.Format(time.RFC3339), "", nil
+	}
+	local, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid date-time %q", value)
+	}
+	timeZone := prop.Params["TZID"]
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" {
+		timeZone = "UTC"
+	}
+	return local.Format("2006-01-02T15:04:05"), timeZone, nil
+}
@@ -0,0 +1,289 @@
+package ical
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"taskmanager/internal/models"
+)
+
+// crlf joins lines the way clients send them.
+func crlf(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestDecode(t *testing.T) {
+	ics := crlf(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTODO",
+		"UID:folded-1",
+		"SUMMARY:Water the plants on the",
+		"  third floor",
+		"DESCRIPTION:Split in the mid",
+		"\tdle of a word",
+		"CATEGORIES:Gr\xc3",
+		" \xbcn",
+		`DUE;TZID="America/New_York";X-NOTE="a;b:c":20261015T170000`,
+		"x-custom;param=Value:lower-case name",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VTODO",
+		"",
+		"END:VCALENDAR",
+	)
+
+	got, err := Decode(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := Component{
+		Name:       "VCALENDAR",
+		Properties: []Property{{Name: "VERSION", Params: map[string]string{}, Value: "2.0"}},
+		Components: []Component{{
+			Name: "VTODO",
+			Properties: []Property{
+				{Name: "UID", Params: map[string]string{}, Value: "folded-1"},
+				{Name: "SUMMARY", Params: map[string]string{}, Value: "Water the plants on the third floor"},
+				{Name: "DESCRIPTION", Params: map[string]string{}, Value: "Split in the middle of a word"},
+				{Name: "CATEGORIES", Params: map[string]string{}, Value: "Grün"},
+				{Name: "DUE", Params: map[string]string{"TZID": "America/New_York", "X-NOTE": "a;b:c"}, Value: "20261015T170000"},
+				{Name: "X-CUSTOM", Params: map[string]string{"PARAM": "Value"}, Value: "lower-case name"},
+			},
+			Components: []Component{{
+				Name:       "VALARM",
+				Properties: []Property{{Name: "TRIGGER", Params: map[string]string{}, Value: "-PT15M"}},
+			}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+	}{
+		{name: "empty", ics: ""},
+		{name: "missing colon", ics: crlf("BEGIN:VCALENDAR", "VERSION", "END:VCALENDAR")},
+		{name: "colon only in quotes", ics: crlf("BEGIN:VCALENDAR", `X-A;P="a:b"`, "END:VCALENDAR")},
+		{name: "unterminated", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:1", "END:VTODO")},
+		{name: "mismatched end", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "END:VCALENDAR")},
+		{name: "end without begin", ics: crlf("END:VCALENDAR")},
+		{name: "property outside", ics: crlf("VERSION:2.0", "BEGIN:VCALENDAR", "END:VCALENDAR")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decode(strings.NewReader(tt.ics)); err == nil {
+				t.Errorf("Decode() = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: `plain`, want: "plain"},
+		{text: `one\, two\; three`, want: "one, two; three"},
+		{text: `line\nbreak\Nagain`, want: "line\nbreak\nagain"},
+		{text: `back\\slash`, want: `back\slash`},
+		{text: `not\\n a break`, want: `not\n a break`},
+	}
+
+	for _, tt := range tests {
+		if got := Unescape(tt.text); got != tt.want {
+			t.Errorf("Unescape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseTodo(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+		want Todo
+	}{
+		{
+			name: "Apple Reminders",
+			ics: crlf(
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//Apple Inc.//iOS 17.0//EN",
+				"CALSCALE:GREGORIAN",
+				"BEGIN:VTIMEZONE",
+				"TZID:Europe/Berlin",
+				"BEGIN:DAYLIGHT",
+				"TZOFFSETFROM:+0100",
+				"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+				"DTSTART:19810329T020000",
+				"TZNAME:CEST",
+				"TZOFFSETTO:+0200",
+				"END:DAYLIGHT",
+				"BEGIN:STANDARD",
+				"TZOFFSETFROM:+0200",
+				"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+				"DTSTART:19961027T030000",
+				"TZNAME:CET",
+				"TZOFFSETTO:+0100",
+				"END:STANDARD",
+				"END:VTIMEZONE",
+				"BEGIN:VTODO",
+				"CREATED:20261012T081500Z",
+				"DTSTAMP:20261012T081512Z",
+				"DTSTART;TZID=Europe/Berlin:20261015T090000",
+				"DUE;TZID=Europe/Berlin:20261015T090000",
+				"LAST-MODIFIED:20261012T081512Z",
+				"PRIORITY:1",
+				"SEQUENCE:0",
+				"STATUS:NEEDS-ACTION",
+				"SUMMARY:Go",
+				"UID:6F1D2C1A-4B3E-4E8F-9C55-1A2B3C4D5E6F",
+				"X-APPLE-SORT-ORDER:681971712",
+				"BEGIN:VALARM",
+				"ACTION:DISPLAY",
+				"DESCRIPTION:Reminder",
+				"TRIGGER;VALUE=DATE-TIME:20261015T070000Z",
+				"UID:0D3F1B2E-7A6C-4C1D-8E9F-112233445566",
+				"END:VALARM",
+				"END:VTODO",
+				"END:VCALENDAR",
+			),
+			want: Todo{
+				UID: "6F1D2C1A-4B3E-4E8F-9C55-1A2B3C4D5E6F", Summary: "Go",
+				Due: "2026-10-15T09:00:00", DueTimeZone: "Europe/Berlin",
+				Status: models.StatusTodo, Priority: models.PriorityUrgent,
+			},
+		},
+		{
+			name: "Thunderbird",
+			ics: crlf(
+				"BEGIN:VCALENDAR",
+				"PRODID:-//Mozilla.org/NONSGML Mozilla Calendar V1.1//EN",
+				"VERSION:2.0",
+				"BEGIN:VTODO",
+				"CREATED:20261001T101010Z",
+				"LAST-MODIFIED:20261010T120000Z",
+				"DTSTAMP:20261010T120000Z",
+				"UID:b3a1c2d4-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+				`SUMMARY:Renew passport\, then book flights`,
+				"STATUS:COMPLETED",
+				"COMPLETED:20261010T120000Z",
+				"PERCENT-COMPLETE:100",
+				"PRIORITY:5",
+				"CATEGORIES:Travel,Errands",
+				"DUE;VALUE=DATE:20261031",
+				`DESCRIPTION:Photos from the shop on Main St.\nBring the old passport\; an`,
+				" d the form.",
+				"X-MOZ-GENERATION:2",
+				"END:VTODO",
+				"END:VCALENDAR",
+			),
+			want: Todo{
+				UID: "b3a1c2d4-5e6f-4a7b-8c9d-0e1f2a3b4c5d", Summary: "Renew passport, then book flights",
+				Description: "Photos from the shop on Main St.\nBring the old passport; and the form.",
+				Due:         "2026-10-31", Status: models.StatusDone, Priority: models.PriorityMedium,
+				Categories: []string{"Travel", "Errands"},
+			},
+		},
+		{
+			name: "DAVx5",
+			ics: crlf(
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:+//IDN bitfire.at//ical4android (org.tasks)",
+				"BEGIN:VTODO",
+				"DTSTAMP:20261012T090000Z",
+				"UID:5468472374962380442",
+				"SEQUENCE:1",
+				"CREATED:20261011T070000Z",
+				"LAST-MODIFIED:20261012T085959Z",
+				"SUMMARY:Water the plants in the office kitchen and the meeting rooms on t",
+				" he third floor",
+				"PRIORITY:9",
+				"STATUS:IN-PROCESS",
+				"DUE:20261016T160000Z",
+				"RRULE:FREQ=WEEKLY;BYDAY=FR",
+				`CATEGORIES:office,plants\, indoor`,
+				"CATEGORIES:weekly",
+				"BEGIN:VALARM",
+				"TRIGGER;RELATED=END:PT0S",
+				"ACTION:DISPLAY",
+				"DESCRIPTION:Default Tasks.org description",
+				"END:VALARM",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:5468472374962380442",
+				"RECURRENCE-ID:20261023T160000Z",
+				"SUMMARY:Skipped this week",
+				"END:VTODO",
+				"END:VCALENDAR",
+			),
+			want: Todo{
+				UID:     "5468472374962380442",
+				Summary: "Water the plants in the office kitchen and the meeting rooms on the third floor",
+				Due:     "2026-10-16T16:00:00Z", Status: models.StatusInProgress, Priority: models.PriorityLow,
+				Categories: []string{"office", "plants, indoor", "weekly"}, RRule: "FREQ=WEEKLY;BYDAY=FR",
+			},
+		},
+		{
+			name: "floating time and unknown zone",
+			ics: crlf(
+				"BEGIN:VCALENDAR",
+				"BEGIN:VTODO",
+				"UID:floating",
+				"SUMMARY:  Spaced out  ",
+				`DUE;TZID="Custom/Office":20261015T170000`,
+				"PRIORITY:3",
+				"END:VTODO",
+				"END:VCALENDAR",
+			),
+			want: Todo{
+				UID: "floating", Summary: "Spaced out", Due: "2026-10-15T17:00:00", DueTimeZone: "UTC",
+				Status: models.StatusTodo, Priority: models.PriorityHigh,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTodo(strings.NewReader(tt.ics))
+			if err != nil {
+				t.Fatalf("ParseTodo() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTodo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTodoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+	}{
+		{name: "not a calendar", ics: crlf("BEGIN:VTODO", "UID:1", "END:VTODO")},
+		{name: "no to-do", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:1", "END:VEVENT", "END:VCALENDAR")},
+		{name: "two to-dos", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:1", "END:VTODO", "BEGIN:VTODO", "UID:2", "END:VTODO", "END:VCALENDAR")},
+		{name: "no UID", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "SUMMARY:Lost", "END:VTODO", "END:VCALENDAR")},
+		{name: "invalid date", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:1", "DUE;VALUE=DATE:20261340", "END:VTODO", "END:VCALENDAR")},
+		{name: "invalid UTC time", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:1", "DUE:2026-10-16Z", "END:VTODO", "END:VCALENDAR")},
+		{name: "invalid local time", ics: crlf("BEGIN:VCALENDAR", "BEGIN:VTODO", "UID:1", "DUE:tomorrow", "END:VTODO", "END:VCALENDAR")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseTodo(strings.NewReader(tt.ics)); err == nil {
+				t.Errorf("ParseTodo() = %+v, want an error", got)
+			}
+		})
+	}
+}
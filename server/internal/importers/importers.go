@@ -0,0 +1,160 @@
+// Package importers reads the exports of other task managers into
+// projects and tasks: Todoist CSV templates and JSON backups, Trello
+// board JSON exports and todo.txt files. Each importer is a pure function
+// of the file it reads. Whatever a file holds that has no place in a task
+// here (comments, members, attachments, ...) is listed in the result
+// rather than dropped silently.
+package importers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Sources
+const (
+	SourceTodoist = "todoist"
+	SourceTrello  = "trello"
+	SourceTodoTxt = "todotxt"
+)
+
+// Importer reads the export of another tool.
+type Importer interface {
+	Parse(r io.Reader) (*Result, error)
+}
+
+// ImporterFunc adapts a function to an Importer.
+type ImporterFunc func(r io.Reader) (*Result, error)
+
+// Parse calls f(r).
+func (f ImporterFunc) Parse(r io.Reader) (*Result, error) {
+	return f(r)
+}
+
+var importers = map[string]Importer{}
+
+// Register makes an importer available under the name of its source,
+// replacing any importer already registered for it.
+func Register(source string, importer Importer) {
+	importers[source] = importer
+}
+
+// Get returns the importer of a source.
+func Get(source string) (Importer, bool) {
+	importer, ok := importers[source]
+	return importer, ok
+}
+
+// Sources lists the registered sources by name.
+func Sources() []string {
+	sources := make([]string, 0, len(importers))
+	for source := range importers {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Result is what an importer read. Tasks outside any project of the
+// source are in a project with no name. Unmapped lists what was left out,
+// one note per kind of thing and task.
+type Result struct {
+	Projects []Project
+	Unmapped []string
+}
+
+// Project is a project of the source with its top-level tasks.
+type Project struct {
+	Name  string
+	Tasks []Task
+}
+
+// Task is a task of the source. ID identifies it within the source so
+// that importing the same file again finds the tasks it created; sources
+// without IDs get one derived from the content. Status and Priority are
+// task statuses and priorities of this app, or empty. DueDate is a
+// YYYY-MM-DD date, an RFC 3339 time or a local YYYY-MM-DDTHH:MM:SS time
+// in DueTimeZone.
+type Task struct {
+	ID          string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	Completed   bool
+	DueDate     string
+	DueTimeZone string
+	Tags        []string
+	Checklist   []ChecklistItem
+	Subtasks    []Task
+}
+
+// ChecklistItem is a checklist line of a task.
+type ChecklistItem struct {
+	Text string
+	Done bool
+}
+
+// ParseError is an export an importer cannot read at all.
+type ParseError struct {
+	Source string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// project returns the project of the result with a name, adding it when
+// there is none yet.
+func (r *Result) project(name string) *Project {
+	for i := range r.Projects {
+		if r.Projects[i].Name == name {
+			return &r.Projects[i]
+		}
+	}
+	r.Projects = append(r.Projects, Project{Name: name})
+	return &r.Projects[len(r.Projects)-1]
+}
+
+// note adds a line to what was left out.
+func (r *Result) note(format string, args ...interface{}) {
+	r.Unmapped = append(r.Unmapped, fmt.Sprintf(format, args...))
+}
+
+// contentIDs derives IDs from content for sources that have none. The
+// same content gets the same ID in every import, and repeated content is
+// told apart by its occurrence.
+type contentIDs map[string]int
+
+func (ids contentIDs) next(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	id := hex.EncodeToString(sum[:8])
+	ids[id]++
+	if n := ids[id]; n > 1 {
+		return fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// addTag adds a tag to a list once.
+func addTag(tags []string, tag string) []string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return tags
+	}
+	for _, existing := range tags {
+		if existing == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
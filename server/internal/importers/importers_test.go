@@ -0,0 +1,299 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"taskmanager/internal/models"
+)
+
+func parseFile(t *testing.T, name string, parse func(r io.Reader) (*Result, error)) *Result {
+	t.Helper()
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	result, err := parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// withoutIDs checks that the tasks of sources without IDs got unique IDs
+// derived from their content, and clears them to compare the rest.
+func withoutIDs(t *testing.T, result *Result) {
+	t.Helper()
+	seen := make(map[string]bool)
+	var clear func(tasks []Task)
+	clear = func(tasks []Task) {
+		for i := range tasks {
+			if tasks[i].ID == "" || seen[tasks[i].ID] {
+				t.Errorf("task %q has ID %q, want a unique one", tasks[i].Title, tasks[i].ID)
+			}
+			seen[tasks[i].ID] = true
+			tasks[i].ID = ""
+			clear(tasks[i].Subtasks)
+		}
+	}
+	for i := range result.Projects {
+		clear(result.Projects[i].Tasks)
+	}
+}
+
+func checkResult(t *testing.T, got *Result, want Result) {
+	t.Helper()
+	if !reflect.DeepEqual(got.Projects, want.Projects) {
+		t.Errorf("projects\n%+v\nwant\n%+v", got.Projects, want.Projects)
+	}
+	if !reflect.DeepEqual(got.Unmapped, want.Unmapped) {
+		t.Errorf("unmapped\n%q\nwant\n%q", got.Unmapped, want.Unmapped)
+	}
+}
+
+// todoistProjectTasks are the tasks of testdata/todoist_project.csv.
+var todoistProjectTasks = []Task{
+	{
+		Title:       "Plan the offsite",
+		Description: "Venue and agenda",
+		Priority:    models.PriorityUrgent,
+		DueDate:     "2026-11-03",
+		Tags:        []string{"planning"},
+		Subtasks: []Task{
+			{Title: "Book the venue", Priority: models.PriorityHigh, DueDate: "2026-10-28T14:00:00", DueTimeZone: "Europe/Paris"},
+			{Title: "Send the agenda", Priority: models.PriorityNone, Tags: []string{"email"}},
+		},
+	},
+	{Title: "Ideas for next year", Priority: models.PriorityNone, Tags: []string{"Later"}},
+	{Title: "Budget review", Description: "Numbers from Q3", Priority: models.PriorityMedium, Tags: []string{"Later"}},
+}
+
+var todoistProjectUnmapped = []string{
+	`row of type "meta"`,
+	`assignee "Bob (42345678)" of "Book the venue"`,
+	`duration 60 minute of "Book the venue"`,
+	`2 comment(s) of "Book the venue"`,
+	`due date "every monday" of "Send the agenda" is not a date`,
+	`section "Later" was imported as a tag`,
+	`deadline "2026-12-31" of "Ideas for next year"`,
+}
+
+func TestParseTodoistCSV(t *testing.T) {
+	got := parseFile(t, "todoist_project.csv", ParseTodoist)
+	withoutIDs(t, got)
+	checkResult(t, got, Result{
+		Projects: []Project{{Name: "", Tasks: todoistProjectTasks}},
+		Unmapped: todoistProjectUnmapped,
+	})
+}
+
+func TestParseTodoistBackup(t *testing.T) {
+	project, err := os.ReadFile("testdata/todoist_project.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	archive := zip.NewWriter(&backup)
+	for name, content := range map[string][]byte{
+		"Offsite [2203306141].csv": project,
+		"Offsite/attachment.png":   []byte("\x89PNG"),
+	} {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write(content)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseTodoist(&backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutIDs(t, got)
+	if len(got.Projects) != 1 || got.Projects[0].Name != "Offsite" {
+		t.Fatalf("projects %+v, want Offsite alone", got.Projects)
+	}
+	if !reflect.DeepEqual(got.Projects[0].Tasks, todoistProjectTasks) {
+		t.Errorf("tasks\n%+v\nwant\n%+v", got.Projects[0].Tasks, todoistProjectTasks)
+	}
+	note := `file "Offsite/attachment.png" of the backup`
+	found := false
+	for _, unmapped := range got.Unmapped {
+		found = found || unmapped == note
+	}
+	if !found || len(got.Unmapped) != len(todoistProjectUnmapped)+1 {
+		t.Errorf("unmapped %q, want the notes of the project and %q", got.Unmapped, note)
+	}
+}
+
+func TestParseTodoistSync(t *testing.T) {
+	got := parseFile(t, "todoist_sync.json", ParseTodoist)
+	checkResult(t, got, Result{
+		Projects: []Project{
+			{Name: "Home", Tasks: []Task{
+				{
+					ID:          "2995104339",
+					Title:       "Buy milk",
+					Priority:    models.PriorityHigh,
+					DueDate:     "2026-10-30T09:00:00",
+					DueTimeZone: "Europe/Paris",
+					Tags:        []string{"dairy", "Shopping", "Groceries"},
+				},
+				{
+					ID:          "2995104340",
+					Title:       "Fix the tap",
+					Description: "Kitchen, cold side",
+					Priority:    models.PriorityUrgent,
+					DueDate:     "2026-10-24",
+					Tags:        []string{"diy"},
+					Subtasks: []Task{
+						{ID: "2995104341", Title: "Turn off the water", Priority: models.PriorityMedium},
+						{ID: "2995104342", Title: "Get washers", Status: models.StatusDone, Priority: models.PriorityNone, Completed: true},
+					},
+				},
+			}},
+			{Name: "Inbox", Tasks: []Task{
+				{ID: "2995104343", Title: "Reply to Sam", Priority: models.PriorityNone, DueDate: "2026-10-20T07:30:00Z", DueTimeZone: "UTC"},
+			}},
+			{Name: "Old stuff", Tasks: []Task{
+				{ID: "2995104344", Title: "Sell the bike", Status: models.StatusDone, Priority: models.PriorityNone, Completed: true},
+			}},
+		},
+		Unmapped: []string{
+			`project "Old stuff" is archived in Todoist`,
+			`section "Groceries" was imported as a tag`,
+			`recurrence "every friday at 9" of "Buy milk"`,
+			`assignee 2671356 of "Fix the tap"`,
+			`duration of "Fix the tap"`,
+			`2 comment(s) of "Fix the tap"`,
+		},
+	})
+}
+
+func TestParseTrello(t *testing.T) {
+	got := parseFile(t, "trello_board.json", ParseTrello)
+	checkResult(t, got, Result{
+		Projects: []Project{{Name: "Website relaunch", Tasks: []Task{
+			{
+				ID:          "6512a0c3f1d2b30d8c8e4b01",
+				Title:       "Design the new home page",
+				Description: "Hero, pricing teaser and **testimonials**.",
+				Status:      models.StatusTodo,
+				DueDate:     "2026-11-02T16:00:00.000Z",
+				Tags:        []string{"Design", "green"},
+				Checklist: []ChecklistItem{
+					{Text: "Sections: Hero", Done: true},
+					{Text: "Sections: Pricing teaser"},
+					{Text: "Sections: Testimonials", Done: true},
+					{Text: "Review: Sign-off from marketing"},
+				},
+			},
+			{ID: "6512a0c3f1d2b30d8c8e4b03", Title: "Pick a font", Status: models.StatusDone, Completed: true, DueDate: "2026-10-10T12:00:00.000Z"},
+			{ID: "6512a0c3f1d2b30d8c8e4b02", Title: "Fix the broken footer links", Status: models.StatusInProgress, Tags: []string{"Bug"}},
+			{
+				ID:        "6512a0c3f1d2b30d8c8e4b04",
+				Title:     "Set up analytics",
+				Status:    models.StatusDone,
+				Completed: true,
+				Checklist: []ChecklistItem{{Text: "Add the tracking snippet", Done: true}},
+			},
+		}}},
+		Unmapped: []string{
+			`list "Doing" became status in_progress`,
+			`list "Backlog" became status todo`,
+			`list "Done ✔" became status done`,
+			`list "Old ideas" is archived; its cards were left out`,
+			`the 2 checklists of "Design the new home page" were joined into one`,
+			`1 attachment(s) of "Design the new home page"`,
+			`1 custom field value(s) of "Design the new home page"`,
+			`1 member(s) of "Fix the broken footer links"`,
+			`2 comment(s) of "Fix the broken footer links"`,
+			`1 archived card(s) were left out`,
+		},
+	})
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	got := parseFile(t, "todo.txt", ParseTodoTxt)
+	withoutIDs(t, got)
+	checkResult(t, got, Result{
+		Projects: []Project{
+			{Name: "Home", Tasks: []Task{
+				{Title: "Call the bank about the card", Priority: models.PriorityUrgent, DueDate: "2026-10-21", Tags: []string{"phone"}},
+				{Title: "Renew passport", Status: models.StatusDone, Priority: models.PriorityHigh, Completed: true, Tags: []string{"Admin"}},
+				{Title: "Water the plants"},
+				{Title: "Water the plants"},
+			}},
+			{Name: "Work", Tasks: []Task{
+				{Title: "Write the quarterly report", Priority: models.PriorityMedium, Tags: []string{"office"}},
+			}},
+			{Name: "", Tasks: []Task{
+				{Title: "Buy milk", Tags: []string{"errands"}},
+				{Title: "Read https://example.com/article", Priority: models.PriorityLow},
+				{Title: "Take out the recycling", Status: models.StatusDone, Completed: true},
+			}},
+		},
+		Unmapped: []string{
+			`rec:1m of "Write the quarterly report"`,
+			`t:2026-11-01 of "Read https://example.com/article"`,
+			`due:someday of "Read https://example.com/article"`,
+			`creation or completion dates of 2 task(s)`,
+		},
+	})
+}
+
+// TestContentIDs checks that importing a file again finds the same tasks.
+func TestContentIDs(t *testing.T) {
+	first := parseFile(t, "todo.txt", ParseTodoTxt)
+	again := parseFile(t, "todo.txt", ParseTodoTxt)
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("second import\n%+v\ndiffers from the first\n%+v", again, first)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(r io.Reader) (*Result, error)
+		source string
+		input  string
+	}{
+		{name: "empty Todoist file", parse: ParseTodoist, source: SourceTodoist, input: ""},
+		{name: "Todoist CSV without content", parse: ParseTodoist, source: SourceTodoist, input: "TYPE,PRIORITY\ntask,1\n"},
+		{name: "Todoist JSON without items", parse: ParseTodoist, source: SourceTodoist, input: `{"projects": []}`},
+		{name: "Todoist backup without CSV", parse: ParseTodoist, source: SourceTodoist, input: emptyZip(t)},
+		{name: "Trello list export", parse: ParseTrello, source: SourceTrello, input: `{"name": "Backlog", "cards": []}`},
+		{name: "Trello broken JSON", parse: ParseTrello, source: SourceTrello, input: `{"name": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(strings.NewReader(tt.input))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Source != tt.source {
+				t.Errorf("error %v, want a %s ParseError", err, tt.source)
+			}
+		})
+	}
+}
+
+func emptyZip(t *testing.T) string {
+	var backup bytes.Buffer
+	archive := zip.NewWriter(&backup)
+	if _, err := archive.Create("README.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return backup.String()
+}
@@ -0,0 +1,420 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+func init() {
+	Register(SourceTodoist, ImporterFunc(ParseTodoist))
+}
+
+// ParseTodoist reads a Todoist export: the CSV of a project, as in
+// Todoist templates, a backup zip holding one such CSV per project, or
+// the JSON of a full sync with its projects, sections, items and notes.
+//
+// Sections become tags, @labels in CSV task names and item labels become
+// tags, and CSV indents and item parents become subtasks. Priority p1 is
+// urgent, p2 high, p3 medium and p4 none. Recurrences, assignees,
+// durations and comments are reported as unmapped.
+func ParseTodoist(r io.Reader) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		err = parseTodoistZip(data, result)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = parseTodoistSync(trimmed, result)
+	default:
+		err = parseTodoistCSV(bytes.NewReader(trimmed), "", result)
+	}
+	if err != nil {
+		return nil, &ParseError{Source: SourceTodoist, Err: err}
+	}
+	return result, nil
+}
+
+// todoistFileID is the project ID Todoist appends to the names of backup
+// files.
+var todoistFileID = regexp.MustCompile(`\s*\[\d+\]$`)
+
+func parseTodoistZip(data []byte, result *Result) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	read := 0
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Base(file.Name)
+		if !strings.EqualFold(path.Ext(name), ".csv") {
+			result.note("file %q of the backup", file.Name)
+			continue
+		}
+		project := todoistFileID.ReplaceAllString(strings.TrimSuffix(name, path.Ext(name)), "")
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = parseTodoistCSV(content, project, result)
+		content.Close()
+		if err != nil {
+			return errors.New(file.Name + ": " + err.Error())
+		}
+		read++
+	}
+	if read == 0 {
+		return errors.New("the backup holds no CSV file")
+	}
+	return nil
+}
+
+// todoistCSVPriorities maps the PRIORITY column of a CSV, where 1 is p1,
+// to priorities.
+var todoistCSVPriorities = map[string]string{
+	"1": models.PriorityUrgent,
+	"2": models.PriorityHigh,
+	"3": models.PriorityMedium,
+	"4": models.PriorityNone,
+}
+
+func parseTodoistCSV(r io.Reader, projectName string, result *Result) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return errors.New("the file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"TYPE", "CONTENT"} {
+		if _, ok := columns[name]; !ok {
+			return errors.New("missing column " + name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	project := result.project(projectName)
+	ids := contentIDs{}
+	// stack holds the last task of each indent
+	var stack []*Task
+	var last *Task
+	var section string
+	comments := 0
+	reportComments := func() {
+		if comments > 0 && last != nil {
+			result.note("%d comment(s) of %q", comments, last.Title)
+		}
+		comments = 0
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch kind := strings.ToLower(field(record, "TYPE")); kind {
+		case "":
+		case "section":
+			reportComments()
+			section = field(record, "CONTENT")
+			stack, last = nil, nil
+			if section != "" {
+				result.note("section %q was imported as a tag", section)
+			}
+		case "note":
+			if last == nil {
+				result.note("%s", strings.TrimSpace("comment of the project "+projectName))
+				continue
+			}
+			comments++
+		case "task":
+			reportComments()
+			title, tags := todoistTitle(field(record, "CONTENT"))
+			task := Task{
+				Title:       title,
+				Description: field(record, "DESCRIPTION"),
+				Priority:    todoistCSVPriorities[field(record, "PRIORITY")],
+				Tags:        addTag(tags, section),
+			}
+			task.DueDate, task.DueTimeZone = todoistCSVDue(field(record, "DATE"), field(record, "TIMEZONE"), task.Title, result)
+			if responsible := field(record, "RESPONSIBLE"); responsible != "" {
+				result.note("assignee %q of %q", responsible, task.Title)
+			}
+			if duration := field(record, "DURATION"); duration != "" {
+				result.note("duration %s %s of %q", duration, field(record, "DURATION_UNIT"), task.Title)
+			}
+			if deadline := field(record, "DEADLINE"); deadline != "" {
+				result.note("deadline %q of %q", deadline, task.Title)
+			}
+
+			indent, _ := strconv.Atoi(field(record, "INDENT"))
+			if indent < 1 {
+				indent = 1
+			}
+			if indent > len(stack)+1 {
+				indent = len(stack) + 1
+			}
+			stack = stack[:indent-1]
+			parentID := ""
+			if indent > 1 {
+				parentID = stack[indent-2].ID
+			}
+			task.ID = ids.next(projectName, section, parentID, field(record, "CONTENT"))
+			if indent == 1 {
+				project.Tasks = append(project.Tasks, task)
+				last = &project.Tasks[len(project.Tasks)-1]
+			} else {
+				parent := stack[indent-2]
+				parent.Subtasks = append(parent.Subtasks, task)
+				last = &parent.Subtasks[len(parent.Subtasks)-1]
+			}
+			stack = append(stack, last)
+		default:
+			result.note("row of type %q", kind)
+		}
+	}
+	reportComments()
+	return nil
+}
+
+// todoistLabel is a label written in the name of a task
+var todoistLabel = regexp.MustCompile(`(^|\s)@(\S+)`)
+
+// todoistTitle takes the @labels out of the name of a task, and the "* "
+// prefix of tasks that cannot be completed.
+func todoistTitle(content string) (string, []string) {
+	var tags []string
+	for _, match := range todoistLabel.FindAllStringSubmatch(content, -1) {
+		tags = addTag(tags, match[2])
+	}
+	title := todoistLabel.ReplaceAllString(content, "$1")
+	title = strings.TrimPrefix(strings.TrimSpace(title), "* ")
+	return strings.Join(strings.Fields(title), " "), tags
+}
+
+// todoistCSVDue reads the DATE column, which is either a date, a date and
+// time, or anything Todoist understands such as "every monday".
+func todoistCSVDue(date, timeZone, title string, result *Result) (string, string) {
+	if date == "" {
+		return "", ""
+	}
+	if _, err := time.Parse("2006-01-02", date); err == nil {
+		return date, ""
+	}
+	if local, err := time.Parse("2006-01-02 15:04", date); err == nil {
+		return local.Format("2006-01-02T15:04:05"), timeZone
+	}
+	result.note("due date %q of %q is not a date", date, title)
+	return "", ""
+}
+
+// todoistID is an ID of the sync API, a number in older exports and a
+// string in newer ones.
+type todoistID string
+
+func (id *todoistID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = todoistID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*id = todoistID(number.String())
+	return nil
+}
+
+type todoistSync struct {
+	Projects []struct {
+		ID         todoistID `json:"id"`
+		Name       string    `json:"name"`
+		IsArchived bool      `json:"is_archived"`
+		IsDeleted  bool      `json:"is_deleted"`
+	} `json:"projects"`
+	Sections []struct {
+		ID   todoistID `json:"id"`
+		Name string    `json:"name"`
+	} `json:"sections"`
+	Items []todoistItem `json:"items"`
+	Notes []struct {
+		ItemID    todoistID `json:"item_id"`
+		IsDeleted bool      `json:"is_deleted"`
+	} `json:"notes"`
+}
+
+type todoistItem struct {
+	ID          todoistID `json:"id"`
+	ProjectID   todoistID `json:"project_id"`
+	SectionID   todoistID `json:"section_id"`
+	ParentID    todoistID `json:"parent_id"`
+	Content     string    `json:"content"`
+	Description string    `json:"description"`
+	Priority    int       `json:"priority"`
+	Due         *struct {
+		Date        string  `json:"date"`
+		Timezone    *string `json:"timezone"`
+		IsRecurring bool    `json:"is_recurring"`
+		String      string  `json:"string"`
+	} `json:"due"`
+	Labels         []string        `json:"labels"`
+	Checked        bool            `json:"checked"`
+	IsDeleted      bool            `json:"is_deleted"`
+	ChildOrder     int             `json:"child_order"`
+	ResponsibleUID todoistID       `json:"responsible_uid"`
+	Duration       json.RawMessage `json:"duration"`
+}
+
+// todoistSyncPriorities maps the priority of an item, where 4 is p1, to
+// priorities.
+var todoistSyncPriorities = map[int]string{
+	4: models.PriorityUrgent,
+	3: models.PriorityHigh,
+	2: models.PriorityMedium,
+	1: models.PriorityNone,
+}
+
+func parseTodoistSync(data []byte, result *Result) error {
+	var sync todoistSync
+	if err := json.Unmarshal(data, &sync); err != nil {
+		return err
+	}
+	if sync.Items == nil {
+		return errors.New("the file has no items")
+	}
+
+	projects := make(map[todoistID]string, len(sync.Projects))
+	for _, project := range sync.Projects {
+		if project.IsDeleted {
+			continue
+		}
+		projects[project.ID] = project.Name
+		if project.IsArchived {
+			result.note("project %q is archived in Todoist", project.Name)
+		}
+	}
+	sections := make(map[todoistID]string, len(sync.Sections))
+	for _, section := range sync.Sections {
+		sections[section.ID] = section.Name
+		result.note("section %q was imported as a tag", section.Name)
+	}
+	comments := make(map[todoistID]int)
+	for _, note := range sync.Notes {
+		if !note.IsDeleted {
+			comments[note.ItemID]++
+		}
+	}
+
+	items := make(map[todoistID]bool, len(sync.Items))
+	for _, item := range sync.Items {
+		if !item.IsDeleted {
+			items[item.ID] = true
+		}
+	}
+	children := make(map[todoistID][]todoistItem)
+	var roots []todoistItem
+	for _, item := range sync.Items {
+		if item.IsDeleted {
+			continue
+		}
+		if item.ParentID != "" && items[item.ParentID] {
+			children[item.ParentID] = append(children[item.ParentID], item)
+		} else {
+			roots = append(roots, item)
+		}
+	}
+
+	var build func(item todoistItem) Task
+	build = func(item todoistItem) Task {
+		title, tags := todoistTitle(item.Content)
+		for _, label := range item.Labels {
+			tags = addTag(tags, label)
+		}
+		task := Task{
+			ID:          string(item.ID),
+			Title:       title,
+			Description: item.Description,
+			Priority:    todoistSyncPriorities[item.Priority],
+			Completed:   item.Checked,
+			Tags:        addTag(tags, sections[item.SectionID]),
+		}
+		if item.Checked {
+			task.Status = models.StatusDone
+		}
+		if item.Due != nil {
+			task.DueDate = item.Due.Date
+			if item.Due.Timezone != nil {
+				task.DueTimeZone = *item.Due.Timezone
+			}
+			if item.Due.IsRecurring {
+				result.note("recurrence %q of %q", item.Due.String, task.Title)
+			}
+		}
+		if item.ResponsibleUID != "" {
+			result.note("assignee %s of %q", item.ResponsibleUID, task.Title)
+		}
+		if len(item.Duration) > 0 && string(item.Duration) != "null" {
+			result.note("duration of %q", task.Title)
+		}
+		if n := comments[item.ID]; n > 0 {
+			result.note("%d comment(s) of %q", n, task.Title)
+		}
+		subtasks := children[item.ID]
+		sortTodoistItems(subtasks)
+		for _, child := range subtasks {
+			task.Subtasks = append(task.Subtasks, build(child))
+		}
+		return task
+	}
+
+	sortTodoistItems(roots)
+	for _, item := range roots {
+		name, ok := projects[item.ProjectID]
+		if !ok && item.ProjectID != "" {
+			name = "Todoist project " + string(item.ProjectID)
+		}
+		task := build(item)
+		project := result.project(name)
+		project.Tasks = append(project.Tasks, task)
+	}
+	return nil
+}
+
+func sortTodoistItems(items []todoistItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ChildOrder < items[j].ChildOrder
+	})
+}
@@ -0,0 +1,148 @@
+package importers
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+func init() {
+	Register(SourceTodoTxt, ImporterFunc(ParseTodoTxt))
+}
+
+// ParseTodoTxt reads a todo.txt file, one task per line:
+//
+//	x 2024-05-02 2024-04-30 (A) Call the bank +Home @phone due:2024-05-03
+//
+// A leading "x" marks a done task. The priority (A) is urgent, (B) high,
+// (C) medium and (D) to (Z) low; done tasks may keep theirs as pri:A. The
+// first +project is the project of the task and the others become tags,
+// as do @contexts, and due: gives the due date. Creation and completion
+// dates and other key:value pairs are reported as unmapped.
+func ParseTodoTxt(r io.Reader) (*Result, error) {
+	result := &Result{}
+	ids := contentIDs{}
+	dated := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+		project, task, hasDates := parseTodoTxtLine(line, result)
+		if hasDates {
+			dated++
+		}
+		task.ID = ids.next(project, task.Title, task.Description)
+		p := result.project(project)
+		p.Tasks = append(p.Tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &ParseError{Source: SourceTodoTxt, Err: err}
+	}
+	if dated > 0 {
+		result.note("creation or completion dates of %d task(s)", dated)
+	}
+	return result, nil
+}
+
+// parseTodoTxtLine reads a task and the name of its project from a line,
+// and whether it had creation or completion dates.
+func parseTodoTxtLine(line string, result *Result) (string, Task, bool) {
+	words := strings.Fields(line)
+	var task Task
+	hasDates := false
+	if len(words) > 0 && words[0] == "x" {
+		task.Completed = true
+		task.Status = models.StatusDone
+		words = words[1:]
+	}
+	if !task.Completed && len(words) > 0 {
+		if priority, ok := todoTxtPriority(words[0]); ok {
+			task.Priority = priority
+			words = words[1:]
+		}
+	}
+	// A done task has its completion date and then its creation date, and
+	// a task to do only its creation date.
+	for i := 0; i < 2 && len(words) > 0 && isTodoTxtDate(words[0]); i++ {
+		hasDates = true
+		words = words[1:]
+	}
+
+	var project string
+	var title, unmapped []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			if project == "" {
+				project = word[1:]
+			} else {
+				task.Tags = addTag(task.Tags, word[1:])
+			}
+		case len(word) > 1 && word[0] == '@':
+			task.Tags = addTag(task.Tags, word[1:])
+		case isTodoTxtPair(word):
+			key, value, _ := strings.Cut(word, ":")
+			switch key {
+			case "due":
+				if isTodoTxtDate(value) {
+					task.DueDate = value
+				} else {
+					unmapped = append(unmapped, word)
+				}
+			case "pri":
+				if priority, ok := todoTxtPriority("(" + value + ")"); ok {
+					task.Priority = priority
+				} else {
+					unmapped = append(unmapped, word)
+				}
+			default:
+				unmapped = append(unmapped, word)
+			}
+		default:
+			title = append(title, word)
+		}
+	}
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		task.Title = line
+	}
+	for _, word := range unmapped {
+		result.note("%s of %q", word, task.Title)
+	}
+	return project, task, hasDates
+}
+
+// todoTxtPriority reads a priority such as "(A)".
+func todoTxtPriority(word string) (string, bool) {
+	if len(word) != 3 || word[0] != '(' || word[2] != ')' || word[1] < 'A' || word[1] > 'Z' {
+		return "", false
+	}
+	switch word[1] {
+	case 'A':
+		return models.PriorityUrgent, true
+	case 'B':
+		return models.PriorityHigh, true
+	case 'C':
+		return models.PriorityMedium, true
+	default:
+		return models.PriorityLow, true
+	}
+}
+
+func isTodoTxtDate(word string) bool {
+	_, err := time.Parse("2006-01-02", word)
+	return err == nil
+}
+
+// isTodoTxtPair reports whether a word is a key:value pair. Links such as
+// https://example.com are not.
+func isTodoTxtPair(word string) bool {
+	key, value, ok := strings.Cut(word, ":")
+	return ok && key != "" && value != "" && !strings.Contains(value, ":") && !strings.HasPrefix(value, "//")
+}
@@ -0,0 +1,202 @@
+package importers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"taskmanager/internal/models"
+)
+
+func init() {
+	Register(SourceTrello, ImporterFunc(ParseTrello))
+}
+
+// trelloStatusWords pick the status of the cards of a list from its name.
+// Lists matching none of them hold tasks to do.
+var trelloStatusWords = []struct {
+	status string
+	words  []string
+}{
+	{models.StatusDone, []string{"done", "complete", "finished", "closed", "shipped", "released"}},
+	{models.StatusInProgress, []string{"doing", "progress", "wip", "review", "testing", "started", "current"}},
+}
+
+// ParseTrello reads the JSON export of a Trello board into a project
+// named after the board. The list of a card gives its status by name:
+// lists named like "Done" hold done tasks, lists named like "Doing" or
+// "In review" tasks in progress, and other lists tasks to do. Labels
+// become tags, named after their color when they have no name, and
+// checklists become the checklist of the task. Archived cards and the
+// cards of archived lists are left out. Members, comments, attachments
+// and custom fields are reported as unmapped.
+func ParseTrello(r io.Reader) (*Result, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, &ParseError{Source: SourceTrello, Err: err}
+	}
+	if board.Cards == nil || board.Lists == nil {
+		return nil, &ParseError{Source: SourceTrello, Err: errors.New("the file is not a board export")}
+	}
+
+	result := &Result{}
+	lists := make(map[string]trelloList, len(board.Lists))
+	for _, list := range board.Lists {
+		lists[list.ID] = list
+		if list.Closed {
+			result.note("list %q is archived; its cards were left out", list.Name)
+			continue
+		}
+		result.note("list %q became status %s", list.Name, trelloStatus(list.Name))
+	}
+	checklists := make(map[string][]trelloChecklist)
+	for _, checklist := range board.Checklists {
+		checklists[checklist.CardID] = append(checklists[checklist.CardID], checklist)
+	}
+
+	cards := board.Cards
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := lists[cards[i].ListID], lists[cards[j].ListID]
+		if a.Pos != b.Pos {
+			return a.Pos < b.Pos
+		}
+		return cards[i].Pos < cards[j].Pos
+	})
+	project := result.project(board.Name)
+	archived := 0
+	for _, card := range cards {
+		list, ok := lists[card.ListID]
+		if list.Closed {
+			continue
+		}
+		if card.Closed {
+			archived++
+			continue
+		}
+		task := Task{
+			ID:          card.ID,
+			Title:       strings.TrimSpace(card.Name),
+			Description: card.Desc,
+			Status:      models.StatusTodo,
+		}
+		if ok {
+			task.Status = trelloStatus(list.Name)
+		}
+		if card.Due != nil {
+			task.DueDate = *card.Due
+			if card.DueComplete {
+				task.Status = models.StatusDone
+			}
+		}
+		task.Completed = task.Status == models.StatusDone
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			task.Tags = addTag(task.Tags, name)
+		}
+		task.Checklist = trelloChecklistItems(checklists[card.ID], task.Title, result)
+
+		if n := len(card.MemberIDs); n > 0 {
+			result.note("%d member(s) of %q", n, task.Title)
+		}
+		if n := card.Badges.Comments; n > 0 {
+			result.note("%d comment(s) of %q", n, task.Title)
+		}
+		if n := card.Badges.Attachments; n > 0 {
+			result.note("%d attachment(s) of %q", n, task.Title)
+		}
+		if n := len(card.CustomFieldItems); n > 0 {
+			result.note("%d custom field value(s) of %q", n, task.Title)
+		}
+		project.Tasks = append(project.Tasks, task)
+	}
+	if archived > 0 {
+		result.note("%d archived card(s) were left out", archived)
+	}
+	return result, nil
+}
+
+// trelloStatus returns the status of the cards of a list.
+func trelloStatus(list string) string {
+	name := strings.ToLower(list)
+	for _, candidate := range trelloStatusWords {
+		for _, word := range candidate.words {
+			if strings.Contains(name, word) {
+				return candidate.status
+			}
+		}
+	}
+	return models.StatusTodo
+}
+
+// trelloChecklistItems joins the checklists of a card in order. Items are
+// prefixed with the name of their checklist when a card has several.
+func trelloChecklistItems(checklists []trelloChecklist, title string, result *Result) []ChecklistItem {
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+	if len(checklists) > 1 {
+		result.note("the %d checklists of %q were joined into one", len(checklists), title)
+	}
+	var items []ChecklistItem
+	for _, checklist := range checklists {
+		checkItems := checklist.CheckItems
+		sort.SliceStable(checkItems, func(i, j int) bool { return checkItems[i].Pos < checkItems[j].Pos })
+		for _, item := range checkItems {
+			text := strings.TrimSpace(item.Name)
+			if len(checklists) > 1 {
+				text = checklist.Name + ": " + text
+			}
+			items = append(items, ChecklistItem{Text: text, Done: item.State == "complete"})
+		}
+	}
+	return items
+}
+
+type trelloBoard struct {
+	Name       string            `json:"name"`
+	Lists      []trelloList      `json:"lists"`
+	Cards      []trelloCard      `json:"cards"`
+	Checklists []trelloChecklist `json:"checklists"`
+}
+
+type trelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type trelloCard struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Desc        string  `json:"desc"`
+	ListID      string  `json:"idList"`
+	Closed      bool    `json:"closed"`
+	Pos         float64 `json:"pos"`
+	Due         *string `json:"due"`
+	DueComplete bool    `json:"dueComplete"`
+	Labels      []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	MemberIDs []string `json:"idMembers"`
+	Badges    struct {
+		Comments    int `json:"comments"`
+		Attachments int `json:"attachments"`
+	} `json:"badges"`
+	CustomFieldItems []json.RawMessage `json:"customFieldItems"`
+}
+
+type trelloChecklist struct {
+	CardID     string  `json:"idCard"`
+	Name       string  `json:"name"`
+	Pos        float64 `json:"pos"`
+	CheckItems []struct {
+		Name  string  `json:"name"`
+		State string  `json:"state"`
+		Pos   float64 `json:"pos"`
+	} `json:"checkItems"`
+}
@@ -0,0 +1,25 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = bluemonday.UGCPolicy()
+)
+
+// Render converts user-supplied Markdown into HTML that is safe to embed.
+// Raw HTML in the source is dropped by goldmark and the output is passed
+// through a user-generated-content sanitizer as a second line of defence.
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
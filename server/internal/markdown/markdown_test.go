@@ -0,0 +1,43 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"emphasis", "**bold** and _it_", "<p><strong>bold</strong> and <em>it</em></p>\n"},
+		{"heading", "# Title", "<h1>Title</h1>\n"},
+		{"quote", "> quote", "<blockquote>\n<p>quote</p>\n</blockquote>\n"},
+		{"strikethrough", "~~gone~~", "<p><del>gone</del></p>\n"},
+		{"table", "| a | b |\n|---|---|\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"link", "[x](https://example.com)", `<p><a href="https://example.com" rel="nofollow">x</a></p>` + "\n"},
+		{"autolink", "see https://example.com", `<p>see <a href="https://example.com" rel="nofollow">https://example.com</a></p>` + "\n"},
+		{"image", "![img](https://example.com/a.png)", `<p><img src="https://example.com/a.png" alt="img"></p>` + "\n"},
+		{"code", "`<code>`", "<p><code>&lt;code&gt;</code></p>\n"},
+		{"code block", "```go\nfmt.Println(\"<hi>\")\n```", "<pre><code>fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n"},
+		{"mention", "@alice ping", "<p>@alice ping</p>\n"},
+
+		// Unsafe input
+		{"script", "<script>alert(1)</script>hi", "\n"},
+		{"inline HTML", "<b>raw</b>", "<p>raw</p>\n"},
+		{"HTML image", "<img src=x onerror=alert(1)>", "\n"},
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		{"attribute injection", `![img](x" onerror="alert(1))`, "<p>![img](x&#34; onerror=&#34;alert(1))</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is a file uploaded to a task. The content is stored once per
+// distinct SHA-256 digest and shared between attachments with equal bytes.
+type Attachment struct {
+	ID          string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TaskID      string    `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	UploaderID  string    `json:"uploader_id" gorm:"type:varchar(36);not null"`
+	Filename    string    `json:"filename" gorm:"type:varchar(255);not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(255);not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	SHA256      string    `json:"sha256" gorm:"column:sha256;type:char(64);not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BlobKey is the content-addressed key of the attachment's bytes.
+func (a Attachment) BlobKey() string {
+	return BlobKey(a.SHA256)
+}
+
+// BlobKey derives the storage key for a SHA-256 digest.
+func BlobKey(digest string) string {
+	return "sha256/" + digest[:2] + "/" + digest
+}
+
+// DownloadURL is a signed, expiring link to an attachment's content
+type DownloadURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
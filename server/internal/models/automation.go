@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// Automation triggers
+const (
+	TriggerTaskCreated   = "task.created"
+	TriggerStatusChanged = "task.status_changed"
+	TriggerTagAdded      = "task.tag_added"
+	TriggerDuePassed     = "task.due_passed"
+)
+
+// Automation actions
+const (
+	ActionSetField   = "set_field"
+	ActionAddTag     = "add_tag"
+	ActionAssign     = "assign"
+	ActionCreateTask = "create_task"
+	ActionWebhook    = "webhook"
+)
+
+// Automation run outcomes
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunSkipped   = "skipped"
+)
+
+// AutomationRule runs its actions on a task when Trigger fires for it and
+// the task matches Condition, an expression in the filter language. Rules
+// with a ProjectID only see the tasks of that project. TriggerValue narrows
+// status_changed to one new status and tag_added to one tag.
+type AutomationRule struct {
+	ID           string             `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Name         string             `json:"name" gorm:"type:varchar(100);not null"`
+	ProjectID    *string            `json:"project_id" gorm:"type:varchar(36);index"`
+	Enabled      bool               `json:"enabled" gorm:"not null"`
+	Trigger      string             `json:"trigger" gorm:"type:varchar(30);not null;index"`
+	TriggerValue string             `json:"trigger_value" gorm:"type:varchar(50);not null;default:''"`
+	Condition    string             `json:"condition" gorm:"type:text;not null;default:''"`
+	Actions      []AutomationAction `json:"actions" gorm:"serializer:json;type:text"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// AutomationAction is one step of a rule. Field and Value depend on Type:
+//
+//	set_field    Field status, priority or estimate, set to Value
+//	add_tag      Value is the tag
+//	assign       Value is the user ID
+//	create_task  Value is the title of a follow-up task in the same
+//	             project, due DueInDays days after the rule runs
+//	webhook      Value is an http(s) URL the run is POSTed to as JSON
+type AutomationAction struct {
+	Type      string `json:"type" validate:"required,oneof=set_field add_tag assign create_task webhook"`
+	Field     string `json:"field,omitempty" validate:"omitempty,oneof=status priority estimate"`
+	Value     string `json:"value,omitempty" validate:"max=2000"`
+	DueInDays *int   `json:"due_in_days,omitempty" validate:"omitempty,min=0,max=3650"`
+}
+
+// AutomationRuleInput represents the input for creating or updating a rule.
+// A nil Enabled creates an enabled rule or keeps the current state.
+type AutomationRuleInput struct {
+	Name         string             `json:"name" validate:"required,min=1,max=100"`
+	ProjectID    *string            `json:"project_id"`
+	Enabled      *bool              `json:"enabled"`
+	Trigger      string             `json:"trigger" validate:"required,oneof=task.created task.status_changed task.tag_added task.due_passed"`
+	TriggerValue string             `json:"trigger_value" validate:"max=50"`
+	Condition    string             `json:"condition" validate:"max=2000"`
+	Actions      []AutomationAction `json:"actions" validate:"required,min=1,max=10,dive"`
+}
+
+// AutomationRun logs one run of a rule on a task. Depth counts the rules
+// that ran before it in a chain of rules triggering each other.
+type AutomationRun struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	RuleID    string    `json:"rule_id" gorm:"type:varchar(36);not null;index:idx_automation_runs_rule,priority:1"`
+	TaskID    string    `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	Trigger   string    `json:"trigger" gorm:"type:varchar(30);not null"`
+	Status    string    `json:"status" gorm:"type:varchar(10);not null"`
+	Depth     int       `json:"depth" gorm:"not null;default:0"`
+	Actions   []string  `json:"actions" gorm:"serializer:json;type:text"`
+	Error     string    `json:"error" gorm:"type:text;not null;default:''"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_automation_runs_rule,priority:2"`
+}
+
+// AutomationRunPage is one page of the runs of a rule, newest first
+type AutomationRunPage struct {
+	Data     []AutomationRun `json:"data"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Total    int64           `json:"total"`
+}
+
+// DryRunInput names the task to try a rule on
+type DryRunInput struct {
+	TaskID string `json:"task_id" validate:"required"`
+}
+
+// DryRunResult tells whether a rule's condition matches a task and what
+// its actions would do, without doing it
+type DryRunResult struct {
+	RuleID  string   `json:"rule_id"`
+	TaskID  string   `json:"task_id"`
+	Matches bool     `json:"matches"`
+	Actions []string `json:"actions"`
+}
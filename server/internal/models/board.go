@@ -0,0 +1,14 @@
+package models
+
+// Board shows the tasks of a project grouped by status, each column in its
+// manual order
+type Board struct {
+	ProjectID string        `json:"project_id"`
+	Columns   []BoardColumn `json:"columns"`
+}
+
+// BoardColumn holds the tasks with one status
+type BoardColumn struct {
+	Status string `json:"status"`
+	Tasks  []Task `json:"tasks"`
+}
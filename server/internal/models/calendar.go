@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Weekdays are the working day names of a calendar, indexed like
+// time.Weekday
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Calendar is a business calendar: the days and hours people work and the
+// holidays they do not. Projects may use a calendar for due date
+// arithmetic, and the default calendar applies to everything else.
+type Calendar struct {
+	ID          string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`
+	TimeZone    string    `json:"time_zone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	WorkingDays []string  `json:"working_days" gorm:"serializer:json;type:text"`
+	WorkStart   string    `json:"work_start" gorm:"type:varchar(5);not null;default:'09:00'"`
+	WorkEnd     string    `json:"work_end" gorm:"type:varchar(5);not null;default:'17:00'"`
+	IsDefault   bool      `json:"is_default" gorm:"not null;default:false"`
+	Holidays    []Holiday `json:"holidays" gorm:"foreignKey:CalendarID"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Holiday is a day off in a calendar. UID is set for holidays imported
+// from an iCalendar event.
+type Holiday struct {
+	ID         string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	CalendarID string `json:"calendar_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_holidays_calendar_date"`
+	Date       string `json:"date" gorm:"type:varchar(10);not null;uniqueIndex:idx_holidays_calendar_date"`
+	Name       string `json:"name" gorm:"type:varchar(255)"`
+	UID        string `json:"uid,omitempty" gorm:"type:varchar(255)"`
+}
+
+// CalendarInput represents the input for creating or updating a calendar.
+// Work hours are HH:MM in TimeZone, and WorkingDays defaults to Monday to
+// Friday.
+type CalendarInput struct {
+	Name        string   `json:"name" validate:"required,min=1,max=100"`
+	TimeZone    string   `json:"time_zone" validate:"omitempty,timezone"`
+	WorkingDays []string `json:"working_days" validate:"omitempty,dive,oneof=sun mon tue wed thu fri sat"`
+	WorkStart   string   `json:"work_start" validate:"omitempty,datetime=15:04"`
+	WorkEnd     string   `json:"work_end" validate:"omitempty,datetime=15:04"`
+	IsDefault   bool     `json:"is_default"`
+}
+
+// HolidayInput represents the input for adding a holiday
+type HolidayInput struct {
+	Date string `json:"date" validate:"required,datetime=2006-01-02"`
+	Name string `json:"name" validate:"max=255"`
+}
+
+// HolidayImport reports the outcome of importing an iCalendar file.
+// Skipped counts the repeating events, which are not imported.
+type HolidayImport struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// BusinessDaysResult is the date a number of business days away
+type BusinessDaysResult struct {
+	From string `json:"from"`
+	Days int    `json:"days"`
+	Date string `json:"date"`
+}
+
+// WorkingTimeResult tells whether an instant is working time, and when
+// work next happens
+type WorkingTimeResult struct {
+	At              time.Time `json:"at"`
+	Working         bool      `json:"working"`
+	NextWorkingTime time.Time `json:"next_working_time"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// ChecklistItem is a lightweight, ordered to-do line inside a task
+type ChecklistItem struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TaskID    string    `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	Text      string    `json:"text" gorm:"type:varchar(255);not null"`
+	Done      bool      `json:"done" gorm:"not null;default:false"`
+	Position  int       `json:"position" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChecklistProgress summarises how many checklist items are done
+type ChecklistProgress struct {
+	Done    int `json:"done"`
+	Total   int `json:"total"`
+	Percent int `json:"percent"`
+}
+
+// NewChecklistProgress counts the done items in a checklist
+func NewChecklistProgress(items []ChecklistItem) ChecklistProgress {
+	progress := ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+	if progress.Total > 0 {
+		progress.Percent = progress.Done * 100 / progress.Total
+	}
+	return progress
+}
+
+// CreateChecklistItemInput represents the input for adding a checklist item
+type CreateChecklistItemInput struct {
+	Text string `json:"text" validate:"required,max=255"`
+}
+
+// UpdateChecklistItemInput represents the input for editing a checklist item
+type UpdateChecklistItemInput struct {
+	Text string `json:"text" validate:"required,max=255"`
+	Done bool   `json:"done"`
+}
+
+// ReorderChecklistInput lists every item of a checklist in its new order
+type ReorderChecklistInput struct {
+	ItemIDs []string `json:"item_ids" validate:"required"`
+}
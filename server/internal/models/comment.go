@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment represents a Markdown comment on a task. Replies share the RootID
+// of the top-level comment that started the thread.
+type Comment struct {
+	ID        string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TaskID    string         `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	ParentID  *string        `json:"parent_id" gorm:"type:varchar(36)"`
+	RootID    *string        `json:"root_id" gorm:"type:varchar(36);index"`
+	AuthorID  string         `json:"author_id" gorm:"type:varchar(36);not null"`
+	Body      string         `json:"body" gorm:"type:text;not null"`
+	BodyHTML  string         `json:"body_html" gorm:"type:text;not null"`
+	Mentions  []string       `json:"mentions" gorm:"-"`
+	Deleted   bool           `json:"deleted" gorm:"-"`
+	Replies   []Comment      `json:"replies,omitempty" gorm:"-"`
+	EditedAt  *time.Time     `json:"edited_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CommentRevision keeps the previous body of a comment each time it is edited
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID string    `json:"comment_id" gorm:"type:varchar(36);not null;index"`
+	Body      string    `json:"body" gorm:"type:text;not null"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// CommentMention links a comment to a user it @mentions
+type CommentMention struct {
+	CommentID string `gorm:"type:varchar(36);primaryKey"`
+	UserID    string `gorm:"type:varchar(36);primaryKey"`
+}
+
+// CreateCommentInput represents the input for creating a comment
+type CreateCommentInput struct {
+	Body     string  `json:"body" validate:"required,max=10000"`
+	ParentID *string `json:"parent_id"`
+}
+
+// UpdateCommentInput represents the input for editing a comment
+type UpdateCommentInput struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+// CommentPage is one page of top-level comments with their replies
+type CommentPage struct {
+	Data     []Comment `json:"data"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Total    int64     `json:"total"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Custom field types
+const (
+	FieldText         = "text"
+	FieldNumber       = "number"
+	FieldDate         = "date"
+	FieldSingleSelect = "single_select"
+	FieldMultiSelect  = "multi_select"
+	FieldUser         = "user"
+	FieldURL          = "url"
+)
+
+// CustomField defines a project-specific attribute that tasks can carry
+type CustomField struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ProjectID string    `json:"project_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_custom_fields_project_key"`
+	Key       string    `json:"key" gorm:"type:varchar(50);not null;uniqueIndex:idx_custom_fields_project_key"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	Type      string    `json:"type" gorm:"type:varchar(20);not null"`
+	Options   []string  `json:"options,omitempty" gorm:"serializer:json;type:text"`
+	Required  bool      `json:"required"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomFieldValue stores one custom field value of a task. Value holds the
+// canonical JSON; TextValue and NumberValue mirror it for filtering and
+// sorting (dates are kept as YYYY-MM-DD text, which sorts correctly).
+type CustomFieldValue struct {
+	TaskID      string      `json:"-" gorm:"type:varchar(36);primaryKey"`
+	FieldID     string      `json:"-" gorm:"type:varchar(36);primaryKey;index"`
+	Field       CustomField `json:"-" gorm:"foreignKey:FieldID"`
+	Value       string      `json:"-" gorm:"type:text;not null"`
+	TextValue   *string     `json:"-" gorm:"type:varchar(1000)"`
+	NumberValue *float64    `json:"-"`
+}
+
+// Decode returns the value as a JSON-compatible Go value
+func (v CustomFieldValue) Decode() interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(v.Value), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// CreateCustomFieldInput represents the input for defining a custom field
+type CreateCustomFieldInput struct {
+	Key      string   `json:"key" validate:"required,max=50"`
+	Name     string   `json:"name" validate:"required,max=100"`
+	Type     string   `json:"type" validate:"required,oneof=text number date single_select multi_select user url"`
+	Options  []string `json:"options" validate:"dive,required,max=100"`
+	Required bool     `json:"required"`
+}
+
+// UpdateCustomFieldInput represents the input for changing a custom field.
+// The key and type of a field cannot change once values exist.
+type UpdateCustomFieldInput struct {
+	Name     string   `json:"name" validate:"required,max=100"`
+	Options  []string `json:"options" validate:"dive,required,max=100"`
+	Required bool     `json:"required"`
+	Position int      `json:"position"`
+}
+
+// TaskCursor is the position of a task in a list in creation order. A
+// page of the list after it starts with the next task even when tasks are
+// added or removed meanwhile.
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// TaskQuery holds the raw list parameters of GET /tasks
+type TaskQuery struct {
+	ProjectID string
+	// CustomFields maps "key" or "key.op" to the requested value, where op
+	// is one of gt, gte, lt, lte.
+	CustomFields map[string]string
+	// Filter is an expression in the filter language, see package filter.
+	Filter string
+	Sort   string
+	Order  string
+	// TimeZone is the IANA time zone days such as today are taken in. It
+	// defaults to UTC.
+	TimeZone string
+	// UserID is the current user, whom "me" stands for. It is empty when
+	// nobody is signed in.
+	UserID string
+	// Assignee is a user ID, "me" or "none" for unassigned tasks.
+	Assignee string
+}
+
+// customFieldMap indexes decoded values by their field key
+func customFieldMap(values []CustomFieldValue) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for _, value := range values {
+		fields[value.Field.Key] = value.Decode()
+	}
+	return fields
+}
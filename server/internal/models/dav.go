@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// DAVInbox is the CalDAV collection of the tasks outside any project
+const DAVInbox = "inbox"
+
+// DAVResource keeps the name and UID a CalDAV client gave the to-do of a
+// task it created. Other tasks are served as "<task ID>.ics" with the UID
+// of the iCalendar feed.
+type DAVResource struct {
+	TaskID    string    `json:"task_id" gorm:"type:varchar(36);primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(255);not null;uniqueIndex"`
+	UID       string    `json:"uid" gorm:"type:varchar(255);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DAVCollection is a CalDAV calendar collection: a project, or the inbox
+// of the tasks outside any project. CTag changes whenever a task in the
+// collection does.
+type DAVCollection struct {
+	ID   string
+	Name string
+	CTag string
+}
+
+// DAVCredentials are what CalDAV clients sign in with. URL is where
+// clients discover the collections.
+type DAVCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	URL      string `json:"url"`
+}
+
+// DAVObject is a calendar object resource, the to-do of a task.
+type DAVObject struct {
+	Name   string
+	TaskID string
+	ETag   string
+	Data   []byte
+}
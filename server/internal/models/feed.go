@@ -0,0 +1,19 @@
+package models
+
+// FeedOptions picks the tasks of a calendar feed, which are the tasks with
+// a due date. Assignee is a user ID, "me" for the owner of the feed, "none"
+// for unassigned tasks or "any"; it defaults to "me". Events writes the
+// tasks as events instead of to-dos.
+type FeedOptions struct {
+	ProjectID string
+	Tag       string
+	Assignee  string
+	Events    bool
+}
+
+// FeedToken is a newly issued calendar feed token with the URL of the
+// feed. The token cannot be read again later.
+type FeedToken struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Notification is an item of a user's inbox. Notifications of the same
+// kind about the same task that arrive in a burst are grouped into one
+// item, with Count of them, the latest Message and LatestAt the time of
+// the latest one.
+type Notification struct {
+	ID        string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	UserID    string     `json:"user_id" gorm:"type:varchar(36);not null;index:idx_notifications_inbox,priority:1"`
+	Kind      string     `json:"kind" gorm:"type:varchar(20);not null"`
+	TaskID    string     `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	ActorID   string     `json:"actor_id" gorm:"type:varchar(36);not null;default:''"`
+	Message   string     `json:"message" gorm:"type:text;not null"`
+	Count     int        `json:"count" gorm:"not null;default:1"`
+	ReadAt    *time.Time `json:"read_at"`
+	LatestAt  time.Time  `json:"latest_at" gorm:"index:idx_notifications_inbox,priority:2"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NotificationPage is one page of a user's inbox, latest first
+type NotificationPage struct {
+	Data     []Notification `json:"data"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Total    int64          `json:"total"`
+	Unread   int64          `json:"unread"`
+}
+
+// UnreadCount is the number of unread notifications of a user
+type UnreadCount struct {
+	Unread int64 `json:"unread"`
+}
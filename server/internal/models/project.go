@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Project groups tasks that belong together
+type Project struct {
+	ID           string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Name         string    `json:"name" gorm:"type:varchar(100);not null"`
+	Description  string    `json:"description"`
+	CalendarID   *string   `json:"calendar_id" gorm:"type:varchar(36);index"`
+	EstimateUnit string    `json:"estimate_unit" gorm:"type:varchar(10);not null;default:points"`
+	AdminID      *string   `json:"admin_id" gorm:"type:varchar(36)"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ProjectInput represents the input for creating or updating a project
+type ProjectInput struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description"`
+	// CalendarID picks the business calendar of the project, the default
+	// calendar when it is nil.
+	CalendarID *string `json:"calendar_id"`
+	// EstimateUnit is points or hours. It defaults to points for new
+	// projects and keeps the current unit otherwise.
+	EstimateUnit string `json:"estimate_unit" validate:"omitempty,oneof=points hours"`
+	// AdminID is the user SLA breaches are escalated to, none when it is
+	// nil.
+	AdminID *string `json:"admin_id"`
+}
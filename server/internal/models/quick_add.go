@@ -0,0 +1,19 @@
+package models
+
+import "taskmanager/internal/quickadd"
+
+// QuickAddInput is a task written as one line of text. Dates are read in
+// TimeZone, an IANA name such as Europe/Paris, which defaults to the time
+// zone of the user.
+type QuickAddInput struct {
+	Text      string  `json:"text" validate:"required,max=500"`
+	TimeZone  string  `json:"time_zone" validate:"max=64"`
+	ProjectID *string `json:"project_id"`
+}
+
+// QuickAddResult is the created task along with the parts of the text that
+// were understood, so that they can be highlighted
+type QuickAddResult struct {
+	Task  Task            `json:"task"`
+	Spans []quickadd.Span `json:"spans"`
+}
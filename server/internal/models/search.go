@@ -0,0 +1,27 @@
+package models
+
+// SearchQuery represents the parameters of a full-text search. Every word
+// of Query is matched as a prefix.
+type SearchQuery struct {
+	Query     string `validate:"required,max=200"`
+	ProjectID string
+	Page      int
+	PageSize  int
+}
+
+// SearchHit is a task matching a search. Highlights maps each matching
+// field (title, tags, description or comments) to an HTML snippet with
+// the matches wrapped in <mark>.
+type SearchHit struct {
+	Task       Task              `json:"task"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchResults is one page of search hits, best first
+type SearchResults struct {
+	Data     []SearchHit `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int64       `json:"total"`
+}
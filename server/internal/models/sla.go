@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Escalation step actions
+const (
+	EscalateNotifyAssignees = "notify_assignees"
+	EscalateNotifyAdmin     = "notify_admin"
+	EscalateBumpPriority    = "bump_priority"
+)
+
+// SLA states of a task
+const (
+	SLAOnTrack  = "on_track"
+	SLAAtRisk   = "at_risk"
+	SLABreached = "breached"
+)
+
+// SLAPolicy requires the open tasks of a project matching Condition, a
+// filter expression, to be done within BusinessDays working days of their
+// creation in the project's business calendar. Once a task breaches the
+// policy, each step of Escalations runs DelayHours after the breach, in
+// order. A task that stops matching Condition, for instance once its
+// priority was bumped, is no longer held to the policy.
+type SLAPolicy struct {
+	ID           string           `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ProjectID    string           `json:"project_id" gorm:"type:varchar(36);not null;index"`
+	Name         string           `json:"name" gorm:"type:varchar(100);not null"`
+	Condition    string           `json:"condition" gorm:"type:text;not null;default:''"`
+	BusinessDays int              `json:"business_days" gorm:"not null"`
+	Enabled      bool             `json:"enabled" gorm:"not null"`
+	Escalations  []EscalationStep `json:"escalations" gorm:"serializer:json;type:text"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// EscalationStep is something done about a breached task DelayHours after
+// the breach
+type EscalationStep struct {
+	Action     string `json:"action" validate:"required,oneof=notify_assignees notify_admin bump_priority"`
+	DelayHours int    `json:"delay_hours" validate:"min=0,max=8760"`
+}
+
+// SLAPolicyInput represents the input for creating or updating an SLA
+// policy. A nil Enabled keeps the policy enabled, or enables a new one.
+type SLAPolicyInput struct {
+	Name         string           `json:"name" validate:"required,min=1,max=100"`
+	Condition    string           `json:"condition" validate:"max=2000"`
+	BusinessDays int              `json:"business_days" validate:"required,min=1,max=365"`
+	Enabled      *bool            `json:"enabled"`
+	Escalations  []EscalationStep `json:"escalations" validate:"max=10,dive"`
+}
+
+// TaskSLA is where a task stands with a policy, as of the last evaluation
+type TaskSLA struct {
+	TaskID     string     `json:"task_id" gorm:"type:varchar(36);primaryKey"`
+	PolicyID   string     `json:"policy_id" gorm:"type:varchar(36);primaryKey;index"`
+	DeadlineAt time.Time  `json:"deadline_at"`
+	BreachedAt *time.Time `json:"breached_at"`
+	// Escalated is how many escalation steps have run
+	Escalated int       `json:"escalated" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SLAStatus is where an open task stands with a policy it falls under
+type SLAStatus struct {
+	TaskID     string     `json:"task_id"`
+	Title      string     `json:"title"`
+	Priority   string     `json:"priority"`
+	Assignees  []string   `json:"assignees"`
+	PolicyID   string     `json:"policy_id"`
+	PolicyName string     `json:"policy_name"`
+	State      string     `json:"state"`
+	DeadlineAt time.Time  `json:"deadline_at"`
+	BreachedAt *time.Time `json:"breached_at"`
+	Escalated  int        `json:"escalated"`
+}
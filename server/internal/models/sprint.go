@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// Sprint statuses
+const (
+	SprintPlanned = "planned"
+	SprintActive  = "active"
+	SprintClosed  = "closed"
+)
+
+// Estimate units of a project
+const (
+	EstimatePoints = "points"
+	EstimateHours  = "hours"
+)
+
+// Sprint is an iteration of a project, from StartDate to EndDate
+// (YYYY-MM-DD, inclusive). Capacity is in the estimate unit of the
+// project.
+type Sprint struct {
+	ID        string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ProjectID string     `json:"project_id" gorm:"type:varchar(36);not null;index"`
+	Name      string     `json:"name" gorm:"type:varchar(100);not null"`
+	Goal      string     `json:"goal" gorm:"type:text"`
+	StartDate string     `json:"start_date" gorm:"type:varchar(10);not null"`
+	EndDate   string     `json:"end_date" gorm:"type:varchar(10);not null"`
+	Capacity  float64    `json:"capacity" gorm:"not null;default:0"`
+	Status    string     `json:"status" gorm:"type:varchar(10);not null;default:planned"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	// Scope and Completed add up the estimates of the sprint's tasks and
+	// of those done, as of now.
+	Scope     float64   `json:"scope" gorm:"-"`
+	Completed float64   `json:"completed" gorm:"-"`
+	TaskCount int       `json:"task_count" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SprintInput represents the input for creating or updating a sprint. An
+// empty Status keeps the current one, or plans a new sprint; sprints are
+// closed with CloseSprintInput instead.
+type SprintInput struct {
+	Name      string  `json:"name" validate:"required,min=1,max=100"`
+	Goal      string  `json:"goal" validate:"max=2000"`
+	StartDate string  `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string  `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Capacity  float64 `json:"capacity" validate:"min=0"`
+	Status    string  `json:"status" validate:"omitempty,oneof=planned active"`
+}
+
+// SprintTasksInput lists tasks to add to a sprint
+type SprintTasksInput struct {
+	TaskIDs []string `json:"task_ids" validate:"required,min=1,max=500,dive,required"`
+}
+
+// CloseSprintInput says where the unfinished tasks of a closing sprint go:
+// to the sprint CarryOverTo, or back to the backlog when it is empty.
+type CloseSprintInput struct {
+	CarryOverTo string `json:"carry_over_to"`
+}
+
+// CloseSprintResult is a closed sprint with the tasks carried over
+type CloseSprintResult struct {
+	Sprint      Sprint   `json:"sprint"`
+	CarriedOver []string `json:"carried_over"`
+}
+
+// BurndownPoint is the state of a sprint at the end of a day. Remaining
+// and Ideal are for burndown charts, Scope and Completed for burnup ones.
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Scope     float64 `json:"scope"`
+	Completed float64 `json:"completed"`
+	Remaining float64 `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+	Tasks     int     `json:"tasks"`
+	DoneTasks int     `json:"done_tasks"`
+}
+
+// Burndown is the day by day history of a sprint, up to today or its end
+type Burndown struct {
+	SprintID string          `json:"sprint_id"`
+	Unit     string          `json:"unit"`
+	Capacity float64         `json:"capacity"`
+	Points   []BurndownPoint `json:"points"`
+}
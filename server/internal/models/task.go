@@ -1,50 +1,310 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Task statuses, which are also the columns of a board
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusDone       = "done"
 )
 
+// Statuses lists the task statuses in board order
+var Statuses = []string{StatusTodo, StatusInProgress, StatusDone}
+
+// Task priorities
+const (
+	PriorityNone   = "none"
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists the task priorities from lowest to highest
+var Priorities = []string{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
 // Task represents a task in the system
 type Task struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Completed   bool      `json:"completed"`
-	DueDate     time.Time `json:"due_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                string                 `json:"id"`
+	Title             string                 `json:"title"`
+	Description       string                 `json:"description"`
+	Completed         bool                   `json:"completed"`
+	Status            string                 `json:"status" gorm:"type:varchar(20);not null;default:todo"`
+	Position          string                 `json:"position" gorm:"type:varchar(255);not null;default:''"`
+	Priority          string                 `json:"priority" gorm:"type:varchar(10);not null;default:none"`
+	DueDate           time.Time              `json:"-"`
+	DueTimeZone       string                 `json:"-" gorm:"type:varchar(64);not null;default:''"`
+	Recurrence        string                 `json:"recurrence" gorm:"type:varchar(255);not null;default:''"`
+	Estimate          float64                `json:"estimate" gorm:"not null;default:0"`
+	SprintID          *string                `json:"sprint_id" gorm:"type:varchar(36);index"`
+	ProjectID         *string                `json:"project_id" gorm:"type:varchar(36);index"`
+	ParentID          *string                `json:"parent_id" gorm:"type:varchar(36);index"`
+	ExternalID        *string                `json:"external_id" gorm:"type:varchar(255);index"`
+	Tags              []string               `json:"tags" gorm:"serializer:json;type:text"`
+	CustomFields      map[string]interface{} `json:"custom_fields" gorm:"-"`
+	CustomFieldValues []CustomFieldValue     `json:"-" gorm:"foreignKey:TaskID"`
+	Checklist         []ChecklistItem        `json:"checklist" gorm:"foreignKey:TaskID"`
+	Members           []TaskMember           `json:"-" gorm:"foreignKey:TaskID"`
+	Assignees         []string               `json:"assignees" gorm:"-"`
+	Watchers          []string               `json:"watchers" gorm:"-"`
+	ChecklistProgress ChecklistProgress      `json:"checklist_progress" gorm:"-"`
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
 }
 
-// CreateTaskInput represents the input for creating a task
+// AfterFind derives the checklist progress, custom field map and members
+// once the associations are loaded
+func (t *Task) AfterFind(tx *gorm.DB) error {
+	if t.Checklist == nil {
+		t.Checklist = []ChecklistItem{}
+	}
+	if t.Tags == nil {
+		t.Tags = []string{}
+	}
+	t.ChecklistProgress = NewChecklistProgress(t.Checklist)
+	t.CustomFields = customFieldMap(t.CustomFieldValues)
+	t.Assignees = membersByRole(t.Members, RoleAssignee)
+	t.Watchers = membersByRole(t.Members, RoleWatcher)
+	return nil
+}
+
+// CreateTaskInput represents the input for creating a task. DueDate is a
+// date (YYYY-MM-DD) for a task due all day, or a time: an RFC 3339
+// timestamp, or a local YYYY-MM-DDTHH:MM[:SS] in DueTimeZone. Timed due
+// dates keep DueTimeZone, UTC when it is empty. ExternalID is the ID of
+// the task in another system, which imports match tasks by.
 type CreateTaskInput struct {
-	Title       string `json:"title" validate:"required,min=3,max=100"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date"`  // Removed validation
-	Completed   bool   `json:"completed"`
+	Title        string                 `json:"title" validate:"required,min=3,max=100"`
+	Description  string                 `json:"description"`
+	DueDate      string                 `json:"due_date"`
+	DueTimeZone  string                 `json:"due_time_zone" validate:"omitempty,timezone"`
+	Completed    bool                   `json:"completed"`
+	Status       string                 `json:"status" validate:"omitempty,oneof=todo in_progress done"`
+	Priority     string                 `json:"priority" validate:"omitempty,oneof=none low medium high urgent"`
+	Recurrence   string                 `json:"recurrence" validate:"max=255"`
+	Estimate     float64                `json:"estimate" validate:"min=0,max=100000"`
+	SprintID     *string                `json:"sprint_id"`
+	ProjectID    *string                `json:"project_id"`
+	ParentID     *string                `json:"parent_id"`
+	ExternalID   *string                `json:"external_id" validate:"omitempty,max=255"`
+	Tags         []string               `json:"tags" validate:"dive,required,max=50"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
-// UpdateTaskInput represents the input for updating a task
+// UpdateTaskInput represents the input for updating a task. A nil ProjectID,
+// ParentID, SprintID or Recurrence keeps the current value and "" removes
+// it, and nil Tags or Estimate keep the current value; an estimate of 0
+// means the task is not estimated. An empty DueDate keeps the due date and "none"
+// removes it. Custom fields missing from the map keep their value and null
+// clears one. An empty Status is derived from Completed and an empty
+// Priority keeps the current one.
 type UpdateTaskInput struct {
-	Title       string `json:"title" validate:"required,min=3,max=100"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date"`  // Removed validation
-	Completed   bool   `json:"completed"`
+	Title        string                 `json:"title" validate:"required,min=3,max=100"`
+	Description  string                 `json:"description"`
+	DueDate      string                 `json:"due_date"`
+	DueTimeZone  string                 `json:"due_time_zone" validate:"omitempty,timezone"`
+	Completed    bool                   `json:"completed"`
+	Status       string                 `json:"status" validate:"omitempty,oneof=todo in_progress done"`
+	Priority     string                 `json:"priority" validate:"omitempty,oneof=none low medium high urgent"`
+	Recurrence   *string                `json:"recurrence" validate:"omitempty,max=255"`
+	Estimate     *float64               `json:"estimate" validate:"omitempty,min=0,max=100000"`
+	SprintID     *string                `json:"sprint_id"`
+	ProjectID    *string                `json:"project_id"`
+	ParentID     *string                `json:"parent_id"`
+	Tags         []string               `json:"tags" validate:"dive,required,max=50"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// MoveTaskInput places a task on its board. The task goes right after
+// AfterID and/or right before BeforeID, both of which must be in the target
+// Status column; with neither it goes to the end of the column. An empty
+// Status keeps the current column.
+type MoveTaskInput struct {
+	Status   string `json:"status" validate:"omitempty,oneof=todo in_progress done"`
+	AfterID  string `json:"after_id"`
+	BeforeID string `json:"before_id"`
+}
+
+// TaskTreeInput describes a task to create together with its checklist and
+// subtasks. ChecklistDone marks the checklist items that are already done,
+// by position.
+type TaskTreeInput struct {
+	Task          CreateTaskInput
+	Checklist     []string
+	ChecklistDone []bool
+	Children      []TaskTreeInput
+}
+
+// ValidateDueDate parses the DueDate string into the due date and time
+// zone of a task (Create)
+func (t *CreateTaskInput) ValidateDueDate() (time.Time, string, error) {
+	return ParseDueDate(t.DueDate, t.DueTimeZone)
+}
+
+// ValidateDueDate parses the DueDate string into the due date and time
+// zone of a task (Update)
+func (t *UpdateTaskInput) ValidateDueDate() (time.Time, string, error) {
+	if t.DueDate == "none" {
+		return time.Time{}, "", nil
+	}
+	return ParseDueDate(t.DueDate, t.DueTimeZone)
+}
+
+// Layouts of the due dates accepted without an offset
+const (
+	dateLayout          = "2006-01-02"
+	localDateTimeLayout = "2006-01-02T15:04:05"
+	localMinuteLayout   = "2006-01-02T15:04"
+)
+
+// ErrInvalidDueDate is returned for due dates in none of the accepted forms.
+var ErrInvalidDueDate = errors.New("due_date must be YYYY-MM-DD, an RFC 3339 timestamp or YYYY-MM-DDTHH:MM[:SS]")
+
+// ParseDueDate reads a due date, see CreateTaskInput. All-day due dates
+// are stored as midnight UTC of their date with an empty time zone, and
+// timed ones as the UTC instant with the time zone to show them in.
+func ParseDueDate(value, timeZone string) (time.Time, string, error) {
+	if value == "" {
+		return time.Time{}, "", nil
+	}
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, "", nil
+	}
+
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		return instant.UTC(), timeZone, nil
+	}
+	for _, layout := range []string{localDateTimeLayout, localMinuteLayout} {
+		if local, err := time.ParseInLocation(layout, value, location); err == nil {
+			return local.UTC(), timeZone, nil
+		}
+	}
+	return time.Time{}, "", ErrInvalidDueDate
+}
+
+// NoDueDate is a day before which a due date means the task has none.
+var NoDueDate = time.Date(1, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// HasDueDate reports whether the task is due at some point.
+func (t Task) HasDueDate() bool {
+	return !t.DueDate.Before(NoDueDate)
+}
+
+// DueAllDay reports whether the task is due on a date rather than at a
+// time.
+func (t Task) DueAllDay() bool {
+	return t.DueTimeZone == ""
 }
 
-// ValidateDueDate parses the DueDate string into a time.Time (Create)
-func (t *CreateTaskInput) ValidateDueDate() (time.Time, error) {
-	if t.DueDate == "" {
-		return time.Time{}, nil
+// DueLocation returns the time zone of a timed due date.
+func (t Task) DueLocation() *time.Location {
+	location, err := time.LoadLocation(t.DueTimeZone)
+	if err != nil {
+		return time.UTC
 	}
-	return time.Parse("2006-01-02", t.DueDate)
+	return location
 }
 
-// ValidateDueDate parses the DueDate string into a time.Time (Update)
-func (t *UpdateTaskInput) ValidateDueDate() (time.Time, error) {
-	if t.DueDate == "" {
-		return time.Time{}, nil
+// FormatDueDate writes the due date as YYYY-MM-DD when the task is due all
+// day and otherwise as an RFC 3339 timestamp in its time zone. It returns
+// "" when the task has no due date.
+func (t Task) FormatDueDate() string {
+	switch {
+	case !t.HasDueDate():
+		return ""
+	case t.DueAllDay():
+		return t.DueDate.UTC().Format(dateLayout)
+	default:
+		return t.DueDate.In(t.DueLocation()).Format(time.RFC3339)
 	}
-	return time.Parse("2006-01-02", t.DueDate)
 }
 
+// IsOverdue reports whether an open task is past due at now. All-day due
+// dates are over once their date has passed where the user is, which is
+// the location of now.
+func (t Task) IsOverdue(now time.Time) bool {
+	if !t.HasDueDate() || t.Status == StatusDone {
+		return false
+	}
+	if t.DueAllDay() {
+		y, m, d := now.Date()
+		return t.DueDate.UTC().Before(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+	}
+	return t.DueDate.Before(now)
+}
 
+// DueAt returns the instant the task is due. All-day due dates are due at
+// the start of their date in location.
+func (t Task) DueAt(location *time.Location) time.Time {
+	if t.DueAllDay() {
+		y, m, d := t.DueDate.UTC().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, location)
+	}
+	return t.DueDate
+}
+
+// taskJSON is Task without its methods, to marshal the due date by hand.
+type taskJSON Task
+
+// taskWire is the JSON form of a task. due_date and due_time_zone are null
+// when the task has no due date, and due_time_zone is null for all-day due
+// dates.
+type taskWire struct {
+	taskJSON
+	DueDate     *string `json:"due_date"`
+	DueTimeZone *string `json:"due_time_zone"`
+}
+
+func (t Task) MarshalJSON() ([]byte, error) {
+	wire := taskWire{taskJSON: taskJSON(t)}
+	if due := t.FormatDueDate(); due != "" {
+		wire.DueDate = &due
+		if !t.DueAllDay() {
+			wire.DueTimeZone = &t.DueTimeZone
+		}
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON reads tasks written by MarshalJSON, and snapshots written
+// before due dates had time zones, whose due_date is midnight UTC of an
+// all-day date.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	var wire taskWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*t = Task(wire.taskJSON)
+	if wire.DueDate == nil {
+		return nil
+	}
+	if wire.DueTimeZone == nil {
+		if date, err := time.Parse(time.RFC3339, *wire.DueDate); err == nil {
+			if !date.Before(NoDueDate) {
+				t.DueDate = date.UTC()
+			}
+			return nil
+		}
+	}
+	timeZone := ""
+	if wire.DueTimeZone != nil {
+		timeZone = *wire.DueTimeZone
+	}
+	var err error
+	t.DueDate, t.DueTimeZone, err = ParseDueDate(*wire.DueDate, timeZone)
+	return err
+}
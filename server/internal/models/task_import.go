@@ -0,0 +1,47 @@
+package models
+
+// Import modes
+const (
+	// ImportAtomic saves every row of an import or none of them
+	ImportAtomic = "atomic"
+	// ImportPartial saves the valid rows of an import
+	ImportPartial = "partial"
+)
+
+// ImportOptions says how to read an import. Mapping maps source columns
+// or keys to task fields ("title", "cf.<key>", ...); columns named after a
+// task field need no mapping.
+type ImportOptions struct {
+	Format  string
+	Mapping map[string]string
+	Mode    string
+	DryRun  bool
+}
+
+// ImportResult reports what an import did row by row. Nothing is saved
+// for a dry run, nor for an atomic import with failed rows; the outcomes
+// of the other rows then tell what would have happened.
+type ImportResult struct {
+	Format  string `json:"format"`
+	Mode    string `json:"mode"`
+	DryRun  bool   `json:"dry_run"`
+	Saved   bool   `json:"saved"`
+	Created int    `json:"created"`
+	Updated int    `json:"updated"`
+	Failed  int    `json:"failed"`
+	// Ignored lists the source columns no task field is read from
+	Ignored []string          `json:"ignored"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult is the outcome of one row of an import: created,
+// updated or failed. Row is the line of the row, or its position in a JSON
+// array.
+type ImportRowResult struct {
+	Row        int               `json:"row"`
+	Outcome    string            `json:"outcome"`
+	TaskID     string            `json:"task_id,omitempty"`
+	ExternalID string            `json:"external_id,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Task member roles
+const (
+	RoleAssignee = "assignee"
+	RoleWatcher  = "watcher"
+)
+
+// TaskMember links a user to a task as one of its assignees or watchers. A
+// user can be both.
+type TaskMember struct {
+	TaskID    string    `json:"task_id" gorm:"type:varchar(36);primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(36);primaryKey;index"`
+	Role      string    `json:"role" gorm:"type:varchar(10);primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TaskMemberInput names the user to assign to or watch a task. An empty
+// UserID stands for the current user.
+type TaskMemberInput struct {
+	UserID string `json:"user_id"`
+}
+
+// TaskMembers lists the assignees and watchers of a task
+type TaskMembers struct {
+	TaskID    string   `json:"task_id"`
+	Assignees []string `json:"assignees"`
+	Watchers  []string `json:"watchers"`
+}
+
+// membersByRole returns the IDs of the users with role, in the order given.
+func membersByRole(members []TaskMember, role string) []string {
+	ids := []string{}
+	for _, member := range members {
+		if member.Role == role {
+			ids = append(ids, member.UserID)
+		}
+	}
+	return ids
+}
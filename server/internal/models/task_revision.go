@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Revision operations recorded in the task history
+const (
+	RevisionCreated  = "created"
+	RevisionUpdated  = "updated"
+	RevisionDeleted  = "deleted"
+	RevisionRestored = "restored"
+)
+
+// TaskRevision is an immutable snapshot of a task taken every time it
+// changes. SprintID copies the sprint of the snapshot, to find the history
+// of a sprint without reading every snapshot.
+type TaskRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TaskID    string    `json:"task_id" gorm:"type:varchar(36);not null;index:idx_task_revisions_task_revised"`
+	Operation string    `json:"operation" gorm:"type:varchar(16);not null"`
+	Snapshot  string    `json:"-" gorm:"type:text;not null"`
+	SprintID  *string   `json:"-" gorm:"type:varchar(36);index"`
+	RevisedAt time.Time `json:"revised_at" gorm:"not null;index:idx_task_revisions_task_revised;index"`
+}
+
+// NewTaskRevision snapshots task for the given operation
+func NewTaskRevision(task Task, operation string, revisedAt time.Time) (TaskRevision, error) {
+	snapshot, err := json.Marshal(task)
+	if err != nil {
+		return TaskRevision{}, err
+	}
+	return TaskRevision{
+		TaskID:    task.ID,
+		Operation: operation,
+		Snapshot:  string(snapshot),
+		SprintID:  task.SprintID,
+		RevisedAt: revisedAt,
+	}, nil
+}
+
+// Task decodes the snapshot back into the task as it was at RevisedAt
+func (r TaskRevision) Task() (Task, error) {
+	var task Task
+	err := json.Unmarshal([]byte(r.Snapshot), &task)
+	return task, err
+}
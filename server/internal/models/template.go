@@ -0,0 +1,103 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// templateVariable matches {{name}} placeholders, allowing inner spaces
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// TaskTemplate is a saved task tree that can be instantiated repeatedly
+type TaskTemplate struct {
+	ID          string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Name        string         `json:"name" gorm:"type:varchar(100);not null"`
+	Description string         `json:"description"`
+	Tasks       []TemplateTask `json:"tasks" gorm:"serializer:json;type:text"`
+	Variables   []string       `json:"variables" gorm:"-"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TemplateTask is one task of a template. Titles, descriptions and checklist
+// items may contain {{variable}} placeholders, and the due date is given in
+// days relative to the anchor date chosen when instantiating. With
+// BusinessDays the offset counts the working days of the business calendar
+// of the target project.
+type TemplateTask struct {
+	Title         string         `json:"title" validate:"required,max=200"`
+	Description   string         `json:"description"`
+	DueOffsetDays *int           `json:"due_offset_days,omitempty"`
+	BusinessDays  bool           `json:"business_days,omitempty"`
+	Tags          []string       `json:"tags,omitempty" validate:"dive,required,max=50"`
+	Checklist     []string       `json:"checklist,omitempty" validate:"dive,required,max=255"`
+	Children      []TemplateTask `json:"children,omitempty" validate:"dive"`
+}
+
+// AfterFind lists the variables used by the template
+func (t *TaskTemplate) AfterFind(tx *gorm.DB) error {
+	t.Variables = TemplateVariables(t.Tasks)
+	return nil
+}
+
+// TemplateVariables returns the distinct placeholder names used in a task
+// tree, in order of first appearance
+func TemplateVariables(tasks []TemplateTask) []string {
+	variables := []string{}
+	seen := make(map[string]bool)
+	var collect func(text string)
+	collect = func(text string) {
+		for _, match := range templateVariable.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				variables = append(variables, match[1])
+			}
+		}
+	}
+	var walk func(tasks []TemplateTask)
+	walk = func(tasks []TemplateTask) {
+		for _, task := range tasks {
+			collect(task.Title)
+			collect(task.Description)
+			for _, item := range task.Checklist {
+				collect(item)
+			}
+			walk(task.Children)
+		}
+	}
+	walk(tasks)
+	return variables
+}
+
+// ExpandTemplate replaces {{name}} placeholders with their values. Names
+// without a value are left untouched and reported as missing.
+func ExpandTemplate(text string, values map[string]string) (string, []string) {
+	var missing []string
+	expanded := templateVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templateVariable.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		return value
+	})
+	return expanded, missing
+}
+
+// TemplateInput represents the input for creating or updating a template
+type TemplateInput struct {
+	Name        string         `json:"name" validate:"required,min=1,max=100"`
+	Description string         `json:"description"`
+	Tasks       []TemplateTask `json:"tasks" validate:"required,min=1,dive"`
+}
+
+// InstantiateTemplateInput represents the input for creating tasks from a
+// template. Due dates are computed from AnchorDate (YYYY-MM-DD).
+type InstantiateTemplateInput struct {
+	AnchorDate string            `json:"anchor_date" validate:"required"`
+	ProjectID  *string           `json:"project_id"`
+	Variables  map[string]string `json:"variables"`
+}
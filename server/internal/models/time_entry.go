@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+)
+
+// TimeEntry is time a user spent on a task, either tracked with a timer or
+// entered by hand. A running timer has no EndedAt, and each user has at
+// most one.
+type TimeEntry struct {
+	ID        string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TaskID    string     `json:"task_id" gorm:"type:varchar(36);not null;index"`
+	UserID    string     `json:"user_id" gorm:"type:varchar(36);not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL"`
+	StartedAt time.Time  `json:"started_at" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"ended_at"`
+	// Seconds is the length of a stopped entry. It is filled in with the
+	// time elapsed so far when running entries are read.
+	Seconds   int64     `json:"seconds" gorm:"not null;default:0"`
+	Note      string    `json:"note" gorm:"type:text"`
+	Manual    bool      `json:"manual" gorm:"not null;default:false"`
+	Running   bool      `json:"running" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Elapse fills in Running and, for running entries, Seconds as of now
+func (e *TimeEntry) Elapse(now time.Time) {
+	e.Running = e.EndedAt == nil
+	if e.Running {
+		e.Seconds = int64(now.Sub(e.StartedAt) / time.Second)
+		if e.Seconds < 0 {
+			e.Seconds = 0
+		}
+	}
+}
+
+// TimerInput represents the input for starting a timer
+type TimerInput struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+// TimeEntryInput represents a time entry made by hand
+type TimeEntryInput struct {
+	StartedAt time.Time `json:"started_at" validate:"required"`
+	Minutes   int       `json:"minutes" validate:"required,min=1,max=1440"`
+	Note      string    `json:"note" validate:"max=1000"`
+}
+
+// UpdateTimeEntryInput represents the input for correcting a time entry.
+// Nil fields are left unchanged, and running timers have no Minutes yet.
+type UpdateTimeEntryInput struct {
+	StartedAt *time.Time `json:"started_at"`
+	Minutes   *int       `json:"minutes" validate:"omitempty,min=1,max=1440"`
+	Note      *string    `json:"note" validate:"omitempty,max=1000"`
+}
+
+// TaskTime is the time spent on a task, by itself and with its subtasks
+type TaskTime struct {
+	TaskID       string      `json:"task_id"`
+	Seconds      int64       `json:"seconds"`
+	TotalSeconds int64       `json:"total_seconds"`
+	Entries      []TimeEntry `json:"entries"`
+}
+
+// ProjectTime is the time spent on the tasks of a project
+type ProjectTime struct {
+	ProjectID    string `json:"project_id"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+// Timesheet groupings
+const (
+	GroupByDay     = "day"
+	GroupByWeek    = "week"
+	GroupByUser    = "user"
+	GroupByProject = "project"
+	GroupByTask    = "task"
+)
+
+// TimesheetQuery selects the entries started from From to To, both
+// YYYY-MM-DD and inclusive, in TimeZone, and groups them by any of day,
+// week, user, project and task.
+type TimesheetQuery struct {
+	From      string   `validate:"required,datetime=2006-01-02"`
+	To        string   `validate:"required,datetime=2006-01-02"`
+	GroupBy   []string `validate:"dive,oneof=day week user project task"`
+	UserID    string
+	ProjectID string
+	TimeZone  string `validate:"omitempty,timezone"`
+}
+
+// TimesheetEntry is a time entry with the names used to label it
+type TimesheetEntry struct {
+	TimeEntry
+	Username    string
+	ProjectID   *string
+	ProjectName string
+	TaskTitle   string
+}
+
+// TimesheetRow is the time of one group. Only the fields of the grouping
+// are set.
+type TimesheetRow struct {
+	Day         string `json:"day,omitempty"`
+	Week        string `json:"week,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	Username    string `json:"username,omitempty"`
+	ProjectID   string `json:"project_id,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	TaskID      string `json:"task_id,omitempty"`
+	TaskTitle   string `json:"task_title,omitempty"`
+	Seconds     int64  `json:"seconds"`
+	Entries     int    `json:"entries"`
+}
+
+// Timesheet is the time spent in a date range, grouped as requested
+type Timesheet struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	TimeZone     string         `json:"time_zone"`
+	GroupBy      []string       `json:"group_by"`
+	Rows         []TimesheetRow `json:"rows"`
+	TotalSeconds int64          `json:"total_seconds"`
+}
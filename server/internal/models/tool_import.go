@@ -0,0 +1,35 @@
+package models
+
+// ToolImportOptions says where the tasks of an import from another tool
+// go. With a ProjectID they all go to that project. Otherwise the tasks of
+// each project of the source go to the project with its name, created
+// when there is none, and tasks outside any project go to the project
+// named DefaultProject, or to no project when it is empty.
+type ToolImportOptions struct {
+	ProjectID      *string
+	DefaultProject string
+	DryRun         bool
+}
+
+// ToolImportReport tells what an import from another tool did, or would do
+// for a dry run. Tasks imported before are found by their ID in the source
+// and skipped with their subtasks. Unmapped lists what the source holds
+// that has no place in a task, and what had to be changed or left out.
+type ToolImportReport struct {
+	Source   string              `json:"source"`
+	DryRun   bool                `json:"dry_run"`
+	Created  int                 `json:"created"`
+	Skipped  int                 `json:"skipped"`
+	Projects []ToolImportProject `json:"projects"`
+	Unmapped []string            `json:"unmapped"`
+}
+
+// ToolImportProject is a project an import from another tool put tasks
+// in. ID is empty for a project a dry run would create, and Tasks counts
+// subtasks too.
+type ToolImportProject struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Created bool   `json:"created"`
+	Tasks   int    `json:"tasks"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// User represents a member of the workspace
+type User struct {
+	ID          string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Username    string `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	TimeZone    string `json:"time_zone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	// FeedTokenHash is the SHA-256 of the token of the calendar feed of
+	// the user, nil when the user has none.
+	FeedTokenHash *string `json:"-" gorm:"type:char(64);uniqueIndex"`
+	// DAVPasswordHash is the SHA-256 of the password CalDAV clients of the
+	// user sign in with, nil when the user has none.
+	DAVPasswordHash *string   `json:"-" gorm:"type:char(64)"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CreateUserInput represents the input for creating a user
+type CreateUserInput struct {
+	Username    string `json:"username" validate:"required,min=2,max=50,alphanum"`
+	DisplayName string `json:"display_name" validate:"max=100"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	// TimeZone is the IANA time zone used when the user gives times
+	// without one. It defaults to UTC.
+	TimeZone string `json:"time_zone" validate:"omitempty,timezone"`
+}
+
+// UpdateUserInput represents the input for updating a user. Empty fields
+// are left unchanged.
+type UpdateUserInput struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	Email       *string `json:"email" validate:"omitempty,email"`
+	TimeZone    string  `json:"time_zone" validate:"omitempty,timezone"`
+}
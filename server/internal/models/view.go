@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// View is a saved, named task list defined by a filter query. Views belong
+// to the user who created them and can be shared with everyone.
+type View struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	Query     string    `json:"query" gorm:"type:text;not null"`
+	ProjectID *string   `json:"project_id" gorm:"type:varchar(36)"`
+	Sort      string    `json:"sort" gorm:"type:varchar(100)"`
+	Order     string    `json:"order" gorm:"type:varchar(4)"`
+	OwnerID   string    `json:"owner_id" gorm:"type:varchar(36);not null;index"`
+	Shared    bool      `json:"shared" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskQuery returns the task list query the view stands for
+func (v View) TaskQuery() TaskQuery {
+	query := TaskQuery{Filter: v.Query, Sort: v.Sort, Order: v.Order}
+	if v.ProjectID != nil {
+		query.ProjectID = *v.ProjectID
+	}
+	return query
+}
+
+// ViewInput represents the input for creating or updating a view. Sort and
+// Order take the same values as the task list parameters.
+type ViewInput struct {
+	Name      string  `json:"name" validate:"required,min=1,max=100"`
+	Query     string  `json:"query" validate:"max=2000"`
+	ProjectID *string `json:"project_id"`
+	Sort      string  `json:"sort" validate:"max=100"`
+	Order     string  `json:"order" validate:"omitempty,oneof=asc desc"`
+	Shared    bool    `json:"shared"`
+}
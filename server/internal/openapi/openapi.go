@@ -0,0 +1,364 @@
+// Package openapi describes the HTTP API as an OpenAPI 3.1 document. The
+// paths come from the routes registered with Echo and the schemas from the
+// Go types of request and response bodies, following encoding/json and
+// the validate tags input is checked with. What cannot be read from the
+// code, such as query parameters, is given per handler as an Endpoint.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.1.0"
+
+// UserSecurity names the security scheme of the X-User-ID header
+const UserSecurity = "userId"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by lower-case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Upload tells how an endpoint takes a file.
+type Upload int
+
+const (
+	// NoUpload endpoints take no file
+	NoUpload Upload = iota
+	// MultipartUpload endpoints take the "file" part of a multipart request
+	MultipartUpload
+	// FileUpload endpoints take the "file" part of a multipart request or
+	// the request body itself
+	FileUpload
+)
+
+// Endpoint describes what the handler of a route takes and returns.
+type Endpoint struct {
+	Summary     string
+	Description string
+	// Query lists the query parameters, which are also read from the form
+	// of uploads
+	Query []Param
+	// Body is a value of the type of the JSON request body
+	Body   interface{}
+	Upload Upload
+	// Response is a value of the type of the JSON response body, or nil
+	// for responses without one
+	Response interface{}
+	// Status is the status of success, by default 200 with a Response and
+	// 204 without
+	Status int
+	// Produces lists the media types of responses that are not JSON
+	Produces []string
+	// User marks endpoints that need the X-User-ID header
+	User bool
+}
+
+// Param is a query parameter. Type is a JSON Schema type, by default
+// "string".
+type Param struct {
+	Name        string
+	Type        string
+	Format      string
+	Enum        []string
+	Required    bool
+	Description string
+}
+
+// Error is the body of error responses. Details holds the message of each
+// invalid field and Column the position of an error in a filter.
+type Error struct {
+	Error   string            `json:"error"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	Column  int               `json:"column,omitempty"`
+}
+
+// methods are the methods documents describe
+var methods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// handlerSuffix follows the names Go gives method values
+const handlerSuffix = "-fm"
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+// Spec builds documents.
+type Spec struct {
+	info    Info
+	schemas *schemas
+}
+
+func New(info Info) *Spec {
+	return &Spec{info: info, schemas: newSchemas()}
+}
+
+// Extend adds properties to the schema of the type of v, for types that
+// marshal fields by hand.
+func (s *Spec) Extend(v interface{}, properties map[string]*Schema) {
+	s.schemas.extensions[reflect.TypeOf(v)] = properties
+}
+
+// Build describes the routes whose path starts with prefix, by the
+// endpoints of their handlers. Endpoints are keyed by the handler type
+// and method, such as "TaskHandler.GetAllTasks". A route without an
+// endpoint, or an endpoint without a route, is an error, so the document
+// cannot drift from the handlers.
+func (s *Spec) Build(routes []*echo.Route, prefix string, endpoints map[string]Endpoint) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: s.schemas.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				UserSecurity: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "X-User-ID",
+					Description: "ID of the user making the request",
+				},
+			},
+		},
+	}
+
+	var problems []string
+	routed := make(map[string]bool)
+	operationIDs := make(map[string]string)
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		if !methods[route.Method] || !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		name := handlerName(route.Name)
+		endpoint, ok := endpoints[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s: no endpoint describes %s", route.Method, route.Path, name))
+			continue
+		}
+		routed[name] = true
+
+		operation := s.operation(route, name, endpoint)
+		if other, ok := operationIDs[operation.OperationID]; ok && other != name {
+			problems = append(problems, fmt.Sprintf("%s and %s have the same operation ID %q", other, name, operation.OperationID))
+		}
+		operationIDs[operation.OperationID] = name
+		for _, tag := range operation.Tags {
+			tags[tag] = true
+		}
+
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+	for name := range endpoints {
+		if !routed[name] {
+			problems = append(problems, fmt.Sprintf("endpoint %s has no route", name))
+		}
+	}
+	if len(s.schemas.problems) > 0 {
+		problems = append(problems, s.schemas.problems...)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("API description does not match the routes:\n%s", strings.Join(problems, "\n"))
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc, nil
+}
+
+func (s *Spec) operation(route *echo.Route, name string, endpoint Endpoint) *Operation {
+	handler, method, _ := strings.Cut(name, ".")
+	operation := &Operation{
+		OperationID: lowerFirst(method),
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		Tags:        []string{strings.TrimSuffix(handler, "Handler")},
+		Responses:   make(map[string]Response),
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range endpoint.Query {
+		operation.Parameters = append(operation.Parameters, param.parameter())
+	}
+	if endpoint.User {
+		operation.Security = []map[string][]string{{UserSecurity: {}}}
+	}
+
+	switch {
+	case endpoint.Body != nil:
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				echo.MIMEApplicationJSON: {Schema: s.schemas.of(reflect.TypeOf(endpoint.Body))},
+			},
+		}
+	case endpoint.Upload != NoUpload:
+		file := &Schema{Type: "string", Format: "binary"}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				echo.MIMEMultipartForm: {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"file": file},
+					Required:   []string{"file"},
+				}},
+			},
+		}
+		if endpoint.Upload == FileUpload {
+			operation.RequestBody.Content[echo.MIMEOctetStream] = MediaType{Schema: file}
+		}
+	}
+
+	status := endpoint.Status
+	if status == 0 {
+		status = http.StatusOK
+		if endpoint.Response == nil && len(endpoint.Produces) == 0 {
+			status = http.StatusNoContent
+		}
+	}
+	success := Response{Description: http.StatusText(status)}
+	if endpoint.Response != nil || len(endpoint.Produces) > 0 {
+		success.Content = make(map[string]MediaType)
+	}
+	if endpoint.Response != nil {
+		success.Content[echo.MIMEApplicationJSON] = MediaType{Schema: s.schemas.of(reflect.TypeOf(endpoint.Response))}
+	}
+	for _, mediaType := range endpoint.Produces {
+		success.Content[mediaType] = MediaType{Schema: &Schema{Type: "string"}}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+	operation.Responses["default"] = Response{
+		Description: "Error",
+		Content: map[string]MediaType{
+			echo.MIMEApplicationJSON: {Schema: s.schemas.of(reflect.TypeOf(Error{}))},
+		},
+	}
+	return operation
+}
+
+func (p Param) parameter() Parameter {
+	schema := &Schema{Type: p.Type, Format: p.Format, Enum: p.Enum}
+	if p.Type == "" {
+		schema.Type = "string"
+	}
+	return Parameter{
+		Name:        p.Name,
+		In:          "query",
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      schema,
+	}
+}
+
+// handlerName shortens the name Echo gives the handler of a route, such
+// as "taskmanager/internal/controllers.(*TaskHandler).GetAllTasks-fm", to
+// "TaskHandler.GetAllTasks".
+func handlerName(name string) string {
+	name = strings.TrimSuffix(name, handlerSuffix)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema. Type is a type name, or a list of them for
+// values that may also be null.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// Nullable returns a schema of the values of s or null.
+func Nullable(s *Schema) *Schema {
+	if name, ok := s.Type.(string); ok {
+		nullable := *s
+		nullable.Type = []string{name, "null"}
+		return &nullable
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemas generates the schemas of Go types, keeping the schemas of named
+// structs as components.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	extensions map[reflect.Type]map[string]*Schema
+	problems   []string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+		extensions: make(map[reflect.Type]map[string]*Schema),
+	}
+}
+
+// of returns the schema of a type, a reference for named structs.
+func (s *schemas) of(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		return Nullable(s.of(t.Elem()))
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	}
+	s.problems = append(s.problems, fmt.Sprintf("type %s has no schema", t))
+	return &Schema{}
+}
+
+// component names the schema of a named struct, adding it to the
+// components the first time. Types of the same name in other packages are
+// told apart by their package.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	s.names[t] = name
+	// Reserved before the fields are read, for types that contain themselves
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+// object returns the schema of the JSON object of a struct.
+func (s *schemas) object(t reflect.Type) *Schema {
+	extra, extended := s.extensions[t]
+	if t.Implements(marshalerType) && !extended {
+		s.problems = append(s.problems, fmt.Sprintf("type %s marshals itself and needs its schema extended", t))
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(t, schema)
+	for name, property := range extra {
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// fields adds the fields of a struct to the schema of its object,
+// including those of embedded structs as encoding/json does.
+func (s *schemas) fields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, schema)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.of(field.Type)
+		if strings.Contains(options, "string") {
+			property = &Schema{Type: "string"}
+		}
+		if validate(property, field.Type, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// validate adds the constraints of a validate tag to the schema of a
+// field, and reports whether the field is required. Rules after "dive"
+// apply to the items of a slice or map.
+func validate(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	required := false
+	target, kind := schema, t.Kind()
+	if kind == reflect.Pointer {
+		kind = t.Elem().Kind()
+		t = t.Elem()
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if target.Items == nil && target.AdditionalProperties == nil || kind != reflect.Slice && kind != reflect.Map {
+				return required
+			}
+			if target.Items != nil {
+				target = target.Items
+			} else {
+				target = target.AdditionalProperties
+			}
+			t = t.Elem()
+			kind = t.Kind()
+			if target.Ref != "" {
+				return required
+			}
+		case "required":
+			if target == schema {
+				required = true
+			} else if kind == reflect.String {
+				target.MinLength = intPointer(1)
+			}
+		case "min", "max", "gte", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte"
+			switch kind {
+			case reflect.String:
+				if lower {
+					target.MinLength = intPointer(int(n))
+				} else {
+					target.MaxLength = intPointer(int(n))
+				}
+			case reflect.Slice, reflect.Array, reflect.Map:
+				if lower {
+					target.MinItems = intPointer(int(n))
+				} else {
+					target.MaxItems = intPointer(int(n))
+				}
+			default:
+				if lower {
+					target.Minimum = &n
+				} else {
+					target.Maximum = &n
+				}
+			}
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "email":
+			target.Format = "email"
+		case "uuid", "uuid4":
+			target.Format = "uuid"
+		case "url":
+			target.Format = "uri"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		case "timezone":
+			target.Description = "IANA time zone, such as Europe/Berlin"
+		case "datetime":
+			switch param {
+			case "2006-01-02":
+				target.Format = "date"
+			case "15:04":
+				target.Pattern = `^\d{2}:\d{2}$`
+			}
+		}
+	}
+	return required
+}
+
+func intPointer(n int) *int {
+	return &n
+}
@@ -0,0 +1,559 @@
+// Package quickadd reads a task from one line of text, such as
+//
+//	Renew TLS cert next friday 9am #ops !high every month
+//
+// picking out a due date and time, #tags, a !priority and a recurrence,
+// and leaving the rest as the title. Parsing is a pure function of the
+// text and the current time, whose location is the user's time zone.
+//
+// Dates are today, tomorrow, weekdays (the next one after today), next
+// <weekday> (that day of next week), next week, next month, next year,
+// in N days/weeks/months/years/hours/minutes, YYYY-MM-DD, month-day forms
+// like "jan 5", "5 january" or "march 3rd 2027", and "the 1st",
+// optionally after on, by or due. Times are 9am, 9:30pm, 9 pm, 21:00, noon and midnight,
+// optionally after at. Recurrences are daily, weekly, monthly, yearly,
+// weekdays, and every [N|other] day/week/month/year, every weekday or
+// every <weekday>.
+package quickadd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"taskmanager/internal/recurrence"
+)
+
+// Span kinds
+const (
+	KindDate       = "date"
+	KindTime       = "time"
+	KindTag        = "tag"
+	KindPriority   = "priority"
+	KindRecurrence = "recurrence"
+)
+
+// Span is a part of the text that was understood. Start and End count
+// characters from 0, End excluded. Value is the normalized meaning: the
+// date as YYYY-MM-DD, the time as HH:MM, the tag, the priority or the
+// recurrence rule.
+type Span struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+// Result is a parsed task. Due is nil when no date or time was given, and
+// DueHasTime reports whether it has a time of day.
+type Result struct {
+	Title      string
+	Due        *time.Time
+	DueHasTime bool
+	Tags       []string
+	Priority   string
+	Recurrence string
+	Spans      []Span
+}
+
+// Priorities that can follow "!"
+var priorities = map[string]bool{"none": true, "low": true, "medium": true, "high": true, "urgent": true}
+
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"sunday": time.Sunday,
+}
+
+// shortWeekdays are only read after on, by, due, this, next or every, as
+// words like "sun" and "sat" are common in titles.
+var shortWeekdays = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wed": time.Wednesday, "thu": time.Thursday, "thur": time.Thursday,
+	"thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// units maps the words for lengths of time to a frequency.
+var units = map[string]string{
+	"day": recurrence.Daily, "days": recurrence.Daily,
+	"week": recurrence.Weekly, "weeks": recurrence.Weekly,
+	"month": recurrence.Monthly, "months": recurrence.Monthly,
+	"year": recurrence.Yearly, "years": recurrence.Yearly,
+}
+
+var clockUnits = map[string]time.Duration{
+	"hour": time.Hour, "hours": time.Hour, "hr": time.Hour, "hrs": time.Hour,
+	"minute": time.Minute, "minutes": time.Minute, "min": time.Minute, "mins": time.Minute,
+}
+
+type word struct {
+	raw   string
+	text  string // lower case without trailing punctuation
+	start int
+}
+
+type parser struct {
+	words  []word
+	now    time.Time
+	result Result
+
+	date    *time.Time
+	clock   *time.Duration
+	instant *time.Time // "in 2 hours" sets both date and time
+}
+
+// Parse reads a task from text. Only the first date, time, priority and
+// recurrence are used; later ones stay in the title.
+func Parse(text string, now time.Time) Result {
+	p := &parser{words: split(text), now: now}
+	var title []string
+	for i := 0; i < len(p.words); {
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		title = append(title, p.words[i].raw)
+		i++
+	}
+	p.result.Title = strings.TrimRightFunc(strings.Join(title, " "), isTrailing)
+	p.resolveDue()
+	return p.result
+}
+
+// match tries to understand the words from i on and returns how many it
+// used.
+func (p *parser) match(i int) int {
+	w := p.words[i]
+	switch {
+	case strings.HasPrefix(w.text, "#") && len(w.text) > 1:
+		tag := strings.TrimPrefix(strings.TrimRightFunc(w.raw, isTrailing), "#")
+		p.span(i, 1, KindTag, tag)
+		p.result.Tags = append(p.result.Tags, tag)
+		return 1
+	case strings.HasPrefix(w.text, "!") && priorities[w.text[1:]] && p.result.Priority == "":
+		p.result.Priority = w.text[1:]
+		p.span(i, 1, KindPriority, p.result.Priority)
+		return 1
+	}
+	if p.result.Recurrence == "" {
+		if n, rule := p.recurrence(i); n > 0 {
+			p.result.Recurrence = rule.String()
+			p.span(i, n, KindRecurrence, p.result.Recurrence)
+			return n
+		}
+	}
+	if p.date == nil && p.instant == nil {
+		skip := 0
+		if w.text == "on" || w.text == "by" || w.text == "due" {
+			skip = 1
+		}
+		if n := p.dateAt(i+skip, skip > 0); n > 0 {
+			value := p.date
+			if p.instant != nil {
+				value = p.instant
+			}
+			p.span(i, skip+n, KindDate, value.Format("2006-01-02"))
+			return skip + n
+		}
+	}
+	if p.clock == nil && p.instant == nil {
+		skip := 0
+		if w.text == "at" {
+			skip = 1
+		}
+		if n, clock := p.timeAt(i + skip); n > 0 {
+			p.clock = &clock
+			p.span(i, skip+n, KindTime, formatClock(clock))
+			return skip + n
+		}
+	}
+	return 0
+}
+
+func (p *parser) recurrence(i int) (int, recurrence.Rule) {
+	rule := recurrence.Rule{Interval: 1}
+	switch p.text(i) {
+	case "daily":
+		rule.Freq = recurrence.Daily
+		return 1, rule
+	case "weekly":
+		rule.Freq = recurrence.Weekly
+		return 1, rule
+	case "monthly":
+		rule.Freq = recurrence.Monthly
+		return 1, rule
+	case "yearly", "annually":
+		rule.Freq = recurrence.Yearly
+		return 1, rule
+	case "weekdays":
+		return 1, workWeek()
+	case "every":
+	default:
+		return 0, rule
+	}
+
+	next := p.text(i + 1)
+	if next == "weekday" {
+		return 2, workWeek()
+	}
+	if day, ok := weekday(next, true); ok {
+		rule.Freq = recurrence.Weekly
+		rule.ByDay = []time.Weekday{day}
+		return 2, rule
+	}
+	if freq, ok := units[next]; ok && !strings.HasSuffix(next, "s") {
+		rule.Freq = freq
+		return 2, rule
+	}
+	interval, ok := 0, false
+	if next == "other" {
+		interval, ok = 2, true
+	} else if n, err := strconv.Atoi(next); err == nil && n > 0 && n < 1000 {
+		interval, ok = n, true
+	}
+	if freq, isUnit := units[p.text(i+2)]; ok && isUnit {
+		rule.Freq = freq
+		rule.Interval = interval
+		return 3, rule
+	}
+	return 0, rule
+}
+
+func workWeek() recurrence.Rule {
+	return recurrence.Rule{
+		Freq:     recurrence.Weekly,
+		Interval: 1,
+		ByDay:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+}
+
+// dateAt reads a date at word i and returns how many words it used.
+// Prefixed tells whether the date follows on, by or due.
+func (p *parser) dateAt(i int, prefixed bool) int {
+	text := p.text(i)
+	switch text {
+	case "":
+		return 0
+	case "today":
+		return p.setDate(p.day(0), 1)
+	case "tomorrow", "tmrw", "tmr":
+		return p.setDate(p.day(1), 1)
+	case "this":
+		if day, ok := weekday(p.text(i+1), true); ok {
+			return p.setDate(p.weekday(day), 2)
+		}
+		return 0
+	case "next":
+		next := p.text(i + 1)
+		if day, ok := weekday(next, true); ok {
+			monday := p.day(7 - weekOffset(p.now.Weekday()))
+			return p.setDate(monday.AddDate(0, 0, weekOffset(day)), 2)
+		}
+		switch next {
+		case "week":
+			return p.setDate(p.day(7-weekOffset(p.now.Weekday())), 2)
+		case "month":
+			return p.setDate(time.Date(p.now.Year(), p.now.Month()+1, 1, 0, 0, 0, 0, p.now.Location()), 2)
+		case "year":
+			return p.setDate(time.Date(p.now.Year()+1, time.January, 1, 0, 0, 0, 0, p.now.Location()), 2)
+		}
+		return 0
+	case "in":
+		return p.offset(i)
+	case "the":
+		return p.dayOfNextMonth(i)
+	}
+	if day, ok := weekday(text, prefixed); ok {
+		return p.setDate(p.weekday(day), 1)
+	}
+	if date, err := time.ParseInLocation("2006-01-02", text, p.now.Location()); err == nil {
+		return p.setDate(date, 1)
+	}
+	return p.monthDay(i)
+}
+
+// offset reads "in N units" at word i.
+func (p *parser) offset(i int) int {
+	n, err := strconv.Atoi(p.text(i + 1))
+	if p.text(i+1) == "a" || p.text(i+1) == "an" {
+		n, err = 1, nil
+	}
+	if err != nil || n < 1 || n > 1000 {
+		return 0
+	}
+	unit := p.text(i + 2)
+	if duration, ok := clockUnits[unit]; ok {
+		instant := p.now.Add(time.Duration(n) * duration).Truncate(time.Minute)
+		p.instant = &instant
+		return 3
+	}
+	switch units[unit] {
+	case recurrence.Daily:
+		return p.setDate(p.day(n), 3)
+	case recurrence.Weekly:
+		return p.setDate(p.day(7*n), 3)
+	case recurrence.Monthly:
+		return p.setDate(p.day(0).AddDate(0, n, 0), 3)
+	case recurrence.Yearly:
+		return p.setDate(p.day(0).AddDate(n, 0, 0), 3)
+	}
+	return 0
+}
+
+// monthDay reads "jan 5", "january 5th 2027", "5 jan" or "5th of january"
+// at word i. Without a year the date is the next one from today.
+func (p *parser) monthDay(i int) int {
+	var month time.Month
+	var day, n int
+	if m, ok := months[p.text(i)]; ok {
+		d, ok := dayOfMonth(p.text(i + 1))
+		if !ok {
+			return 0
+		}
+		month, day, n = m, d, 2
+	} else if d, ok := dayOfMonth(p.text(i)); ok {
+		n = 1
+		if p.text(i+1) == "of" {
+			n = 2
+		}
+		m, ok := months[p.text(i+n)]
+		if !ok {
+			return 0
+		}
+		month, day, n = m, d, n+1
+	} else {
+		return 0
+	}
+
+	year := p.now.Year()
+	explicitYear := false
+	if y, err := strconv.Atoi(p.text(i + n)); err == nil && len(p.text(i+n)) == 4 {
+		year, explicitYear = y, true
+		n++
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+	if date.Day() != day {
+		return 0
+	}
+	if !explicitYear && date.Before(p.day(0)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return p.setDate(date, n)
+}
+
+// dayOfNextMonth reads "the 1st" at word i, the next such day of a month
+// from today.
+func (p *parser) dayOfNextMonth(i int) int {
+	text := p.text(i + 1)
+	day, ok := dayOfMonth(text)
+	if !ok || len(text) < 3 {
+		return 0
+	}
+	for month := 0; ; month++ {
+		date := time.Date(p.now.Year(), p.now.Month()+time.Month(month), day, 0, 0, 0, 0, p.now.Location())
+		if date.Day() == day && !date.Before(p.day(0)) {
+			return p.setDate(date, 2)
+		}
+	}
+}
+
+func dayOfMonth(text string) (int, bool) {
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		text = strings.TrimSuffix(text, suffix)
+	}
+	day, err := strconv.Atoi(text)
+	return day, err == nil && day >= 1 && day <= 31 && len(text) <= 2
+}
+
+// timeAt reads a time of day at word i, returning how many words it used
+// and the time as a duration since midnight.
+func (p *parser) timeAt(i int) (int, time.Duration) {
+	text := p.text(i)
+	switch text {
+	case "noon":
+		return 1, 12 * time.Hour
+	case "midnight":
+		return 1, 0
+	}
+
+	n := 1
+	suffix := ""
+	for _, s := range []string{"am", "pm"} {
+		if strings.HasSuffix(text, s) {
+			suffix, text = s, strings.TrimSuffix(text, s)
+		}
+	}
+	if suffix == "" {
+		if next := p.text(i + 1); next == "am" || next == "pm" {
+			suffix, n = next, 2
+		}
+	}
+
+	hourText, minuteText, hasMinutes := strings.Cut(text, ":")
+	if !hasMinutes && suffix == "" {
+		return 0, 0
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || len(hourText) > 2 {
+		return 0, 0
+	}
+	minute := 0
+	if hasMinutes {
+		if minute, err = strconv.Atoi(minuteText); err != nil || len(minuteText) != 2 || minute > 59 {
+			return 0, 0
+		}
+	}
+	switch {
+	case suffix == "" && hour > 23:
+		return 0, 0
+	case suffix != "" && (hour < 1 || hour > 12):
+		return 0, 0
+	case suffix == "am" && hour == 12:
+		hour = 0
+	case suffix == "pm" && hour < 12:
+		hour += 12
+	}
+	return n, time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
+
+// resolveDue combines the date, time and recurrence into the due time. A
+// time without a date is the next such time, and a weekday recurrence
+// without a date starts on its next day.
+func (p *parser) resolveDue() {
+	due := p.date
+	if p.instant != nil {
+		due = p.instant
+		p.result.DueHasTime = true
+	}
+	if due == nil && p.result.Recurrence != "" {
+		if rule, err := recurrence.Parse(p.result.Recurrence); err == nil && len(rule.ByDay) > 0 {
+			next := rule.Next(p.day(0))
+			due = &next
+		}
+	}
+	if p.clock != nil {
+		if due == nil {
+			today := p.day(0)
+			due = &today
+			if !at(today, *p.clock).After(p.now) {
+				tomorrow := p.day(1)
+				due = &tomorrow
+			}
+		}
+		dueAt := at(*due, *p.clock)
+		due = &dueAt
+		p.result.DueHasTime = true
+	}
+	p.result.Due = due
+}
+
+// at returns the wall clock time clock after midnight on the day of t,
+// which differs from adding clock to midnight on daylight saving days.
+func at(t time.Time, clock time.Duration) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+func (p *parser) setDate(date time.Time, n int) int {
+	p.date = &date
+	return n
+}
+
+// day returns the start of the day offset days from today.
+func (p *parser) day(offset int) time.Time {
+	y, m, d := p.now.Date()
+	return time.Date(y, m, d+offset, 0, 0, 0, 0, p.now.Location())
+}
+
+// weekday returns the next given weekday after today.
+func (p *parser) weekday(day time.Weekday) time.Time {
+	ahead := (int(day) - int(p.now.Weekday()) + 7) % 7
+	if ahead == 0 {
+		ahead = 7
+	}
+	return p.day(ahead)
+}
+
+func (p *parser) text(i int) string {
+	if i >= len(p.words) {
+		return ""
+	}
+	return p.words[i].text
+}
+
+func (p *parser) span(i, n int, kind, value string) {
+	var raw []string
+	for _, w := range p.words[i : i+n] {
+		raw = append(raw, w.raw)
+	}
+	last := p.words[i+n-1]
+	p.result.Spans = append(p.result.Spans, Span{
+		Start: p.words[i].start,
+		End:   last.start + len([]rune(strings.TrimRightFunc(last.raw, isTrailing))),
+		Kind:  kind,
+		Text:  strings.TrimRightFunc(strings.Join(raw, " "), isTrailing),
+		Value: value,
+	})
+}
+
+func weekday(text string, short bool) (time.Weekday, bool) {
+	if day, ok := weekdays[text]; ok {
+		return day, true
+	}
+	day, ok := shortWeekdays[text]
+	return day, ok && short
+}
+
+func formatClock(clock time.Duration) string {
+	return time.Time{}.Add(clock).Format("15:04")
+}
+
+func weekOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// split cuts text into words at whitespace, counting positions in runes.
+func split(text string) []word {
+	var words []word
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		raw := string(runes[start:i])
+		words = append(words, word{
+			raw:   raw,
+			text:  strings.ToLower(strings.TrimRightFunc(raw, isTrailing)),
+			start: start,
+		})
+	}
+	return words
+}
+
+// isTrailing reports punctuation ignored at the end of a word.
+func isTrailing(r rune) bool {
+	return r == ',' || r == '.' || r == ';'
+}
@@ -0,0 +1,107 @@
+package quickadd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday
+	now := time.Date(2026, time.October, 14, 10, 0, 0, 0, newYork)
+	// The days clocks move forward and back in New York
+	springForward := time.Date(2027, time.March, 14, 0, 30, 0, 0, newYork)
+	fallBack := time.Date(2026, time.November, 1, 0, 30, 0, 0, newYork)
+
+	tests := []struct {
+		text       string
+		now        time.Time
+		title      string
+		due        string // "2006-01-02 15:04 MST", the day alone without a time, or "" for none
+		tags       []string
+		priority   string
+		recurrence string
+	}{
+		{text: "Renew TLS cert next friday 9am #ops !high every month", title: "Renew TLS cert", due: "2026-10-23 09:00 EDT", tags: []string{"ops"}, priority: "high", recurrence: "FREQ=MONTHLY"},
+		{text: "water plants tomorrow", title: "water plants", due: "2026-10-15"},
+		{text: "water plants today", title: "water plants", due: "2026-10-14"},
+		{text: "standup 9:30am", title: "standup", due: "2026-10-15 09:30 EDT"},
+		{text: "lunch at noon", title: "lunch", due: "2026-10-14 12:00 EDT"},
+		{text: "deploy at 21:00", title: "deploy", due: "2026-10-14 21:00 EDT"},
+		{text: "report in 2 hours", title: "report", due: "2026-10-14 12:00 EDT"},
+		{text: "review in 3 weeks", title: "review", due: "2026-11-04"},
+		{text: "dentist jan 5", title: "dentist", due: "2027-01-05"},
+		{text: "party 5th of december", title: "party", due: "2026-12-05"},
+		{text: "launch march 3rd 2028", title: "launch", due: "2028-03-03"},
+		{text: "invoice the 1st", title: "invoice", due: "2026-11-01"},
+		{text: "meet on sun", title: "meet", due: "2026-10-18"},
+		{text: "sun tan lotion", title: "sun tan lotion"},
+		{text: "retro friday", title: "retro", due: "2026-10-16"},
+		{text: "plan next week", title: "plan", due: "2026-10-19"},
+		{text: "budget next month", title: "budget", due: "2026-11-01"},
+		{text: "gym every other week", title: "gym", recurrence: "FREQ=WEEKLY;INTERVAL=2"},
+		{text: "standup weekdays at 9:00", title: "standup", due: "2026-10-15 09:00 EDT", recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{text: "bins every tuesday", title: "bins", due: "2026-10-20", recurrence: "FREQ=WEEKLY;BYDAY=TU"},
+		{text: "fix #bug, !urgent", title: "fix", tags: []string{"bug"}, priority: "urgent"},
+		{text: "ship 2026-02-30", title: "ship 2026-02-30"},
+		{text: "read !high !low", title: "read !low", priority: "high"},
+
+		// Times on daylight saving days keep their wall clock time
+		{text: "call mom 2027-03-14 9am", title: "call mom", due: "2027-03-14 09:00 EDT"},
+		{text: "pay rent 2026-11-01 9am", title: "pay rent", due: "2026-11-01 09:00 EST"},
+		{text: "backup 3am", now: springForward, title: "backup", due: "2027-03-14 03:00 EDT"},
+		{text: "backup 1:30am", now: springForward, title: "backup", due: "2027-03-14 01:30 EST"},
+		{text: "backup 3am", now: fallBack, title: "backup", due: "2026-11-01 03:00 EST"},
+		{text: "backup in 2 hours", now: fallBack, title: "backup", due: "2026-11-01 01:30 EST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			at := now
+			if !tt.now.IsZero() {
+				at = tt.now
+			}
+			got := Parse(tt.text, at)
+
+			due := ""
+			if got.Due != nil && got.DueHasTime {
+				due = got.Due.Format("2006-01-02 15:04 MST")
+			} else if got.Due != nil {
+				due = got.Due.Format("2006-01-02")
+			}
+			if got.Title != tt.title {
+				t.Errorf("title %q, want %q", got.Title, tt.title)
+			}
+			if due != tt.due {
+				t.Errorf("due %q, want %q", due, tt.due)
+			}
+			if !reflect.DeepEqual(got.Tags, tt.tags) {
+				t.Errorf("tags %q, want %q", got.Tags, tt.tags)
+			}
+			if got.Priority != tt.priority {
+				t.Errorf("priority %q, want %q", got.Priority, tt.priority)
+			}
+			if got.Recurrence != tt.recurrence {
+				t.Errorf("recurrence %q, want %q", got.Recurrence, tt.recurrence)
+			}
+		})
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC)
+	got := Parse("Café on fri at 9:30pm #ops, !low", now).Spans
+	want := []Span{
+		{Start: 5, End: 11, Kind: KindDate, Text: "on fri", Value: "2026-10-16"},
+		{Start: 12, End: 21, Kind: KindTime, Text: "at 9:30pm", Value: "21:30"},
+		{Start: 22, End: 26, Kind: KindTag, Text: "#ops", Value: "ops"},
+		{Start: 28, End: 32, Kind: KindPriority, Text: "!low", Value: "low"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spans\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+// Package rank implements fractional indexing: string keys that sort
+// lexicographically and between which a new key can always be generated,
+// so moving an item in an ordered list only rewrites that item's key.
+//
+// Keys use the digits 0-9 and a-z, which sort the same way under byte-wise
+// and the usual locale-aware collations, and never end in "0" so that a key
+// before any other key always exists.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxLength is the key length above which a list should be rebalanced.
+const MaxLength = 24
+
+// stepWidth is how many leading digits appending and prepending step by
+// one, rather than halving the gap, so that lists growing at either end
+// keep short keys.
+const stepWidth = 2
+
+// ErrInvalidRange is returned when the lower key does not sort before the
+// upper key, or a key contains characters outside the alphabet.
+var ErrInvalidRange = errors.New("rank keys are not in ascending order")
+
+// Between returns a key that sorts strictly between lower and upper. An
+// empty lower means the start of the list and an empty upper its end.
+func Between(lower, upper string) (string, error) {
+	if !valid(lower) || !valid(upper) || (upper != "" && lower >= upper) {
+		return "", ErrInvalidRange
+	}
+	switch {
+	case lower == "" && upper == "":
+		return midpoint(lower, upper), nil
+	case upper == "":
+		return after(lower), nil
+	case lower == "":
+		return before(upper), nil
+	}
+	return midpoint(lower, upper), nil
+}
+
+// Spread returns n ascending keys spaced evenly over the key space, used to
+// rebalance a list whose keys have grown long.
+func Spread(n int) []string {
+	width, space := 1, len(digits)
+	for space <= 2*n {
+		width++
+		space *= len(digits)
+	}
+	step := space / (n + 1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = encode(step*(i+1), width)
+	}
+	return keys
+}
+
+// after returns a key following lower by one step in its leading digits.
+func after(lower string) string {
+	if next := head(lower) + 1; next < pow(stepWidth) {
+		return encode(next, stepWidth)
+	}
+	return lower[:stepWidth] + after(lower[stepWidth:])
+}
+
+// before returns a key preceding upper by one step in its leading digits.
+// Below the first step it steps down from the top of the digits after
+// them, as after does up from the bottom.
+func before(upper string) string {
+	switch previous := head(upper) - 1; {
+	case previous > 0:
+		return encode(previous, stepWidth)
+	case previous == 0:
+		return strings.Repeat("0", stepWidth) + encode(pow(stepWidth)-1, stepWidth)
+	default:
+		return upper[:stepWidth] + before(upper[stepWidth:])
+	}
+}
+
+// head returns the value of the leading stepWidth digits of key.
+func head(key string) int {
+	value := 0
+	for i := 0; i < stepWidth; i++ {
+		value = value*len(digits) + digitAt(key, i)
+	}
+	return value
+}
+
+// encode writes value as a key of width digits, dropping trailing zeros.
+func encode(value, width int) string {
+	key := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		key[i] = digits[value%len(digits)]
+		value /= len(digits)
+	}
+	return strings.TrimRight(string(key), "0")
+}
+
+func pow(width int) int {
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= len(digits)
+	}
+	return space
+}
+
+// midpoint finds a key between lower and upper, where an empty upper is
+// the end of the key space. It assumes lower < upper.
+func midpoint(lower, upper string) string {
+	if upper != "" {
+		// Keep the common prefix and find the midpoint of what follows
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == strings.IndexByte(digits, upper[n]) {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(lower) {
+				rest = lower[n:]
+			}
+			return upper[:n] + midpoint(rest, upper[n:])
+		}
+	}
+
+	low := digitAt(lower, 0)
+	high := len(digits)
+	if upper != "" {
+		high = strings.IndexByte(digits, upper[0])
+	}
+	if high-low > 1 {
+		return string(digits[(low+high+1)/2])
+	}
+	// The first digits are adjacent
+	if upper != "" && len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if len(lower) > 1 {
+		rest = lower[1:]
+	}
+	return string(digits[low]) + midpoint(rest, "")
+}
+
+// digitAt returns the value of the digit at position i, treating missing
+// trailing digits as zero.
+func digitAt(key string, i int) int {
+	if i >= len(key) {
+		return 0
+	}
+	return strings.IndexByte(digits, key[i])
+}
+
+func valid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(key, "0")
+}
@@ -0,0 +1,154 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		lower, upper string
+		want         string
+	}{
+		{"", "", "i"},
+		// Appending and prepending step through the leading digits
+		{"i", "", "i1"},
+		{"01", "", "02"},
+		{"zy", "", "zz"},
+		{"zz", "", "zz01"},
+		{"zzzz", "", "zzzz01"},
+		{"", "i", "hz"},
+		{"", "1", "0z"},
+		{"", "01", "00zz"},
+		{"", "00zz", "00zy"},
+		{"", "0001", "0000zz"},
+		// Inserting halves the gap
+		{"a", "b", "ai"},
+		{"i", "j", "ii"},
+		{"a", "a1", "a0i"},
+		{"a1", "a2", "a1i"},
+		{"az", "b", "azi"},
+		{"0z", "1", "0zi"},
+		{"1", "1001", "1000i"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lower+"_"+tt.upper, func(t *testing.T) {
+			got, err := Between(tt.lower, tt.upper)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Between(%q, %q) = %q, want %q", tt.lower, tt.upper, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		lower, upper string
+	}{
+		{"descending", "b", "a"},
+		{"equal", "a", "a"},
+		{"outside the alphabet", "A", ""},
+		{"trailing zero", "a0", ""},
+		{"upper outside the alphabet", "", "a-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Between(tt.lower, tt.upper); !errors.Is(err, ErrInvalidRange) {
+				t.Errorf("Between(%q, %q) = %q, %v, want ErrInvalidRange", tt.lower, tt.upper, got, err)
+			}
+		})
+	}
+}
+
+// TestBetweenOrder inserts keys at random places, at the ends and in the
+// middle, and checks the list stays ordered with short keys.
+func TestBetweenOrder(t *testing.T) {
+	appending := func(r *rand.Rand, n int) int { return n }
+	prepending := func(r *rand.Rand, n int) int { return 0 }
+	tests := []struct {
+		name    string
+		inserts int
+		place   func(r *rand.Rand, n int) int
+		maxLen  int // 0 for no bound
+	}{
+		{"appending", 1000, appending, 4},
+		{"appending past the first digits", 3000, appending, 6},
+		{"prepending", 1000, prepending, 4},
+		{"prepending past the first digits", 3000, prepending, 6},
+		{"random", 1000, func(r *rand.Rand, n int) int { return r.Intn(n + 1) }, 8},
+		{"middle", 1000, func(r *rand.Rand, n int) int { return n / 2 }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			var keys []string
+			for i := 0; i < tt.inserts; i++ {
+				at := tt.place(r, len(keys))
+				lower, upper := "", ""
+				if at > 0 {
+					lower = keys[at-1]
+				}
+				if at < len(keys) {
+					upper = keys[at]
+				}
+				key, err := Between(lower, upper)
+				if err != nil {
+					t.Fatalf("Between(%q, %q): %v", lower, upper, err)
+				}
+				if key <= lower || (upper != "" && key >= upper) || strings.HasSuffix(key, "0") {
+					t.Fatalf("Between(%q, %q) = %q", lower, upper, key)
+				}
+				if tt.maxLen > 0 && len(key) > tt.maxLen {
+					t.Fatalf("key %q after %d inserts is longer than %d", key, i, tt.maxLen)
+				}
+				keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		n     int
+		first []string
+		width int
+	}{
+		{0, nil, 0},
+		{1, []string{"i"}, 1},
+		{2, []string{"c", "o"}, 1},
+		{3, []string{"9", "i", "r"}, 1},
+		{17, []string{"2", "4", "6", "8"}, 1},
+		{18, []string{"1w", "3s", "5o", "7k"}, 2},
+		{5000, nil, 3},
+	}
+	for _, tt := range tests {
+		keys := Spread(tt.n)
+		if len(keys) != tt.n || !sort.StringsAreSorted(keys) {
+			t.Errorf("Spread(%d) = %q, want %d ascending keys", tt.n, keys, tt.n)
+			continue
+		}
+		for i, key := range tt.first {
+			if keys[i] != key {
+				t.Errorf("Spread(%d)[%d] = %q, want %q", tt.n, i, keys[i], key)
+			}
+		}
+		for i, key := range keys {
+			if len(key) > tt.width || strings.HasSuffix(key, "0") || (i > 0 && key == keys[i-1]) {
+				t.Errorf("Spread(%d)[%d] = %q, want a distinct key of at most %d digits", tt.n, i, key, tt.width)
+			}
+		}
+		if tt.n > 0 {
+			// The keys leave room before the first and after the last
+			if _, err := Between("", keys[0]); err != nil {
+				t.Errorf("no key before %q: %v", keys[0], err)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package realtime delivers messages to the users connected to this
+// server, for example over a server-sent event stream. Messages for users
+// who are not connected are dropped; callers keep anything durable
+// themselves.
+package realtime
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// buffer is how many messages a slow connection can fall behind by before
+// further messages to it are dropped.
+const buffer = 16
+
+// Message is sent to a user's connections as an event named Event with
+// Data encoded as JSON.
+type Message struct {
+	Event string
+	Data  interface{}
+}
+
+// Hub keeps the open connections of each user.
+type Hub struct {
+	mu          sync.RWMutex
+	connections map[string]map[chan Message]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{connections: make(map[string]map[chan Message]struct{})}
+}
+
+// Subscribe opens a connection for userID. The returned function closes
+// it and must be called once the connection is over.
+func (h *Hub) Subscribe(userID string) (<-chan Message, func()) {
+	ch := make(chan Message, buffer)
+	h.mu.Lock()
+	if h.connections[userID] == nil {
+		h.connections[userID] = make(map[chan Message]struct{})
+	}
+	h.connections[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.connections[userID], ch)
+			if len(h.connections[userID]) == 0 {
+				delete(h.connections, userID)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Online reports whether userID has an open connection.
+func (h *Hub) Online(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.connections[userID]) > 0
+}
+
+// Send delivers a message to every connection of userID without waiting
+// on any of them.
+func (h *Hub) Send(userID string, message Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.connections[userID] {
+		select {
+		case ch <- message:
+		default:
+			log.Warn().Str("user_id", userID).Str("event", message.Event).Msg("Dropped realtime message for slow connection")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package recurrence handles task repeat rules, written as the subset of
+// iCalendar RRULEs (RFC 5545) with FREQ, INTERVAL and, for weekly rules,
+// BYDAY, for example "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// Rule repeats every Interval days, weeks, months or years. A weekly rule
+// with ByDay repeats on those weekdays of every Interval-th week.
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+}
+
+var dayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse reads a rule. Parts may come in any order and case.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:"), ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("invalid recurrence part %q", part)
+		}
+		switch name {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = value
+			default:
+				return Rule{}, fmt.Errorf("unsupported recurrence frequency %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 999 {
+				return Rule{}, fmt.Errorf("invalid recurrence interval %q", value)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekday(code)
+				if !ok {
+					return Rule{}, fmt.Errorf("invalid recurrence day %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		default:
+			return Rule{}, fmt.Errorf("unsupported recurrence part %q", name)
+		}
+	}
+	if rule.Freq == "" {
+		return Rule{}, errors.New("recurrence needs a FREQ")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return Rule{}, errors.New("BYDAY is only supported for weekly recurrences")
+	}
+	sort.Slice(rule.ByDay, func(i, j int) bool { return weekOffset(rule.ByDay[i]) < weekOffset(rule.ByDay[j]) })
+	return rule, nil
+}
+
+// String writes the rule in its canonical form.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = dayCodes[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence following one at t, keeping its time of day.
+// Months that are too short for the day of t end the month instead.
+func (r Rule) Next(t time.Time) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	switch r.Freq {
+	case Daily:
+		return t.AddDate(0, 0, interval)
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*interval)
+		}
+		// Later in the same week, or the first day of the next week due
+		for _, day := range r.ByDay {
+			if weekOffset(day) > weekOffset(t.Weekday()) {
+				return t.AddDate(0, 0, weekOffset(day)-weekOffset(t.Weekday()))
+			}
+		}
+		monday := t.AddDate(0, 0, -weekOffset(t.Weekday()))
+		return monday.AddDate(0, 0, 7*interval+weekOffset(r.ByDay[0]))
+	case Monthly:
+		return addMonths(t, interval)
+	default:
+		return addMonths(t, 12*interval)
+	}
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// weekOffset numbers weekdays from Monday, as weeks start on Monday.
+func weekOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func weekday(code string) (time.Weekday, bool) {
+	for day, c := range dayCodes {
+		if c == code {
+			return time.Weekday(day), true
+		}
+	}
+	return 0, false
+}
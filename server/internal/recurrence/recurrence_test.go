@@ -0,0 +1,105 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string // the canonical form
+	}{
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "FREQ=DAILY;INTERVAL=1", want: "FREQ=DAILY"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{rule: "rrule:byday=th,mo;freq=weekly", want: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{rule: "FREQ=WEEKLY;BYDAY=SU,MO", want: "FREQ=WEEKLY;BYDAY=MO,SU"},
+		{rule: " FREQ=MONTHLY;INTERVAL=3 ", want: "FREQ=MONTHLY;INTERVAL=3"},
+		{rule: "FREQ=YEARLY;INTERVAL=999", want: "FREQ=YEARLY;INTERVAL=999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("Parse().String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"FREQ",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=1000",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=MO,",
+		"FREQ=WEEKLY;COUNT=3",
+	} {
+		if got, err := Parse(rule); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", rule, got)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday
+	wednesday := time.Date(2026, time.October, 14, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule string
+		t    time.Time
+		want string // "2006-01-02 15:04 MST"
+	}{
+		{name: "daily", rule: "FREQ=DAILY", t: wednesday, want: "2026-10-15 09:00 UTC"},
+		{name: "every third day", rule: "FREQ=DAILY;INTERVAL=3", t: wednesday, want: "2026-10-17 09:00 UTC"},
+		{name: "weekly", rule: "FREQ=WEEKLY", t: wednesday, want: "2026-10-21 09:00 UTC"},
+		{name: "every other week", rule: "FREQ=WEEKLY;INTERVAL=2", t: wednesday, want: "2026-10-28 09:00 UTC"},
+		{name: "later in the week", rule: "FREQ=WEEKLY;BYDAY=MO,TH", t: wednesday, want: "2026-10-15 09:00 UTC"},
+		{name: "next week", rule: "FREQ=WEEKLY;BYDAY=MO,TH", t: wednesday.AddDate(0, 0, 1), want: "2026-10-19 09:00 UTC"},
+		{name: "week after next", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", t: wednesday.AddDate(0, 0, 1), want: "2026-10-26 09:00 UTC"},
+		{name: "off day", rule: "FREQ=WEEKLY;BYDAY=MO,TH", t: wednesday.AddDate(0, 0, 3), want: "2026-10-19 09:00 UTC"},
+		{name: "Sunday ends the week", rule: "FREQ=WEEKLY;BYDAY=SU", t: wednesday.AddDate(0, 0, 3), want: "2026-10-18 09:00 UTC"},
+		{name: "Sunday to Monday", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", t: wednesday.AddDate(0, 0, 4), want: "2026-10-26 09:00 UTC"},
+		{name: "monthly", rule: "FREQ=MONTHLY", t: wednesday, want: "2026-11-14 09:00 UTC"},
+		{name: "into the next year", rule: "FREQ=MONTHLY;INTERVAL=3", t: wednesday, want: "2027-01-14 09:00 UTC"},
+		{name: "short month", rule: "FREQ=MONTHLY", t: time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC), want: "2026-02-28 09:00 UTC"},
+		{name: "leap month", rule: "FREQ=MONTHLY", t: time.Date(2028, time.January, 31, 9, 0, 0, 0, time.UTC), want: "2028-02-29 09:00 UTC"},
+		{name: "after a short month", rule: "FREQ=MONTHLY;INTERVAL=3", t: time.Date(2026, time.November, 30, 9, 0, 0, 0, time.UTC), want: "2027-02-28 09:00 UTC"},
+		{name: "yearly", rule: "FREQ=YEARLY", t: wednesday, want: "2027-10-14 09:00 UTC"},
+		{name: "leap day", rule: "FREQ=YEARLY", t: time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC), want: "2029-02-28 09:00 UTC"},
+
+		// The time of day stays the same across daylight saving changes
+		{name: "daily into summer time", rule: "FREQ=DAILY", t: time.Date(2027, time.March, 13, 9, 0, 0, 0, newYork), want: "2027-03-14 09:00 EDT"},
+		{name: "weekly into winter time", rule: "FREQ=WEEKLY;BYDAY=MO", t: time.Date(2026, time.October, 30, 9, 0, 0, 0, newYork), want: "2026-11-02 09:00 EST"},
+		{name: "monthly into winter time", rule: "FREQ=MONTHLY", t: time.Date(2026, time.October, 31, 9, 0, 0, 0, newYork), want: "2026-11-30 09:00 EST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := rule.Next(tt.t).Format("2006-01-02 15:04 MST"); got != tt.want {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
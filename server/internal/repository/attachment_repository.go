@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	FindByTask(taskID string) ([]models.Attachment, error)
+	FindByID(id string) (models.Attachment, error)
+	CountByDigest(digest string) (int64, error)
+	Create(attachment models.Attachment) (models.Attachment, error)
+	Delete(id string) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) FindByTask(taskID string) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.db.Where("task_id = ?", taskID).Order("created_at").Find(&attachments).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find attachments")
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) FindByID(id string) (models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.First(&attachment, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find attachment")
+		return attachment, err
+	}
+	return attachment, nil
+}
+
+func (r *attachmentRepository) CountByDigest(digest string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Attachment{}).Where("sha256 = ?", digest).Count(&count).Error; err != nil {
+		log.Error().Err(err).Str("sha256", digest).Msg("Failed to count attachments by digest")
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *attachmentRepository) Create(attachment models.Attachment) (models.Attachment, error) {
+	if err := r.db.Create(&attachment).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create attachment")
+		return models.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+func (r *attachmentRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.Attachment{}, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete attachment")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type AutomationRepository interface {
+	FindAll() ([]models.AutomationRule, error)
+	FindByID(id string) (models.AutomationRule, error)
+	// FindEnabled returns the enabled rules with the trigger.
+	FindEnabled(trigger string) ([]models.AutomationRule, error)
+	Create(rule models.AutomationRule) (models.AutomationRule, error)
+	Update(rule models.AutomationRule) (models.AutomationRule, error)
+	// Delete deletes a rule with its runs.
+	Delete(id string) error
+	CreateRun(run models.AutomationRun) error
+	FindRuns(ruleID string, offset, limit int) ([]models.AutomationRun, int64, error)
+	// RunExists reports whether a rule ran on a task at or after since,
+	// whatever the outcome.
+	RunExists(ruleID, taskID string, since time.Time) (bool, error)
+}
+
+type automationRepository struct {
+	db *gorm.DB
+}
+
+func NewAutomationRepository(db *gorm.DB) AutomationRepository {
+	return &automationRepository{db: db}
+}
+
+func (r *automationRepository) FindAll() ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	if err := r.db.Order("created_at").Find(&rules).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find automation rules")
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *automationRepository) FindByID(id string) (models.AutomationRule, error) {
+	var rule models.AutomationRule
+	if err := r.db.First(&rule, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find automation rule")
+		return rule, err
+	}
+	return rule, nil
+}
+
+func (r *automationRepository) FindEnabled(trigger string) ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	if err := r.db.Where(&models.AutomationRule{Trigger: trigger}).Where("enabled = ?", true).Order("created_at").Find(&rules).Error; err != nil {
+		log.Error().Err(err).Str("trigger", trigger).Msg("Failed to find enabled automation rules")
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *automationRepository) Create(rule models.AutomationRule) (models.AutomationRule, error) {
+	if err := r.db.Create(&rule).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create automation rule")
+		return models.AutomationRule{}, err
+	}
+	return rule, nil
+}
+
+func (r *automationRepository) Update(rule models.AutomationRule) (models.AutomationRule, error) {
+	if err := r.db.Save(&rule).Error; err != nil {
+		log.Error().Err(err).Str("id", rule.ID).Msg("Failed to update automation rule")
+		return models.AutomationRule{}, err
+	}
+	return rule, nil
+}
+
+func (r *automationRepository) Delete(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", id).Delete(&models.AutomationRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.AutomationRule{}).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete automation rule")
+		return err
+	}
+	return nil
+}
+
+func (r *automationRepository) CreateRun(run models.AutomationRun) error {
+	if err := r.db.Create(&run).Error; err != nil {
+		log.Error().Err(err).Str("rule_id", run.RuleID).Msg("Failed to record automation run")
+		return err
+	}
+	return nil
+}
+
+func (r *automationRepository) FindRuns(ruleID string, offset, limit int) ([]models.AutomationRun, int64, error) {
+	query := r.db.Model(&models.AutomationRun{}).Where("rule_id = ?", ruleID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error().Err(err).Str("rule_id", ruleID).Msg("Failed to count automation runs")
+		return nil, 0, err
+	}
+	var runs []models.AutomationRun
+	if err := query.Order("created_at DESC, id").Offset(offset).Limit(limit).Find(&runs).Error; err != nil {
+		log.Error().Err(err).Str("rule_id", ruleID).Msg("Failed to find automation runs")
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+func (r *automationRepository) RunExists(ruleID, taskID string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AutomationRun{}).
+		Where("rule_id = ? AND task_id = ? AND created_at >= ?", ruleID, taskID, since).
+		Count(&count).Error
+	if err != nil {
+		log.Error().Err(err).Str("rule_id", ruleID).Str("task_id", taskID).Msg("Failed to look up automation run")
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalendarRepository interface {
+	FindAll() ([]models.Calendar, error)
+	FindByID(id string) (models.Calendar, error)
+	// FindDefault returns the default calendar, or ErrNotFound when none
+	// is marked as default.
+	FindDefault() (models.Calendar, error)
+	Create(calendar models.Calendar) (models.Calendar, error)
+	Update(calendar models.Calendar) (models.Calendar, error)
+	Delete(id string) error
+	// SaveHolidays adds holidays to a calendar, renaming those on dates it
+	// already has.
+	SaveHolidays(holidays []models.Holiday) error
+	DeleteHoliday(calendarID, id string) error
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+// withHolidays loads the holidays of calendars in date order
+func withHolidays(db *gorm.DB) *gorm.DB {
+	return db.Preload("Holidays", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date")
+	})
+}
+
+func (r *calendarRepository) FindAll() ([]models.Calendar, error) {
+	var calendars []models.Calendar
+	if err := withHolidays(r.db).Order("name").Find(&calendars).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find all calendars")
+		return nil, err
+	}
+	return calendars, nil
+}
+
+func (r *calendarRepository) FindByID(id string) (models.Calendar, error) {
+	var calendar models.Calendar
+	if err := withHolidays(r.db).First(&calendar, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find calendar")
+		return calendar, err
+	}
+	return calendar, nil
+}
+
+func (r *calendarRepository) FindDefault() (models.Calendar, error) {
+	var calendar models.Calendar
+	if err := withHolidays(r.db).First(&calendar, "is_default").Error; err != nil {
+		return calendar, err
+	}
+	return calendar, nil
+}
+
+func (r *calendarRepository) Create(calendar models.Calendar) (models.Calendar, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, calendar); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&calendar).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create calendar")
+		return models.Calendar{}, err
+	}
+	return calendar, nil
+}
+
+func (r *calendarRepository) Update(calendar models.Calendar) (models.Calendar, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, calendar); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(&calendar).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", calendar.ID).Msg("Failed to update calendar")
+		return models.Calendar{}, err
+	}
+	return calendar, nil
+}
+
+// clearDefault unmarks the other calendars when calendar becomes the
+// default, as there is only one.
+func clearDefault(tx *gorm.DB, calendar models.Calendar) error {
+	if !calendar.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.Calendar{}).Where("is_default AND id <> ?", calendar.ID).Update("is_default", false).Error
+}
+
+// Delete removes a calendar and its holidays. Projects using it fall back
+// to the default calendar.
+func (r *calendarRepository) Delete(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Project{}).Where("calendar_id = ?", id).Update("calendar_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Holiday{}, "calendar_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Calendar{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete calendar")
+		return err
+	}
+	return nil
+}
+
+func (r *calendarRepository) SaveHolidays(holidays []models.Holiday) error {
+	if len(holidays) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "calendar_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "uid"}),
+	}).Create(&holidays).Error
+	if err != nil {
+		log.Error().Err(err).Int("count", len(holidays)).Msg("Failed to save holidays")
+		return err
+	}
+	return nil
+}
+
+func (r *calendarRepository) DeleteHoliday(calendarID, id string) error {
+	result := r.db.Delete(&models.Holiday{}, "calendar_id = ? AND id = ?", calendarID, id)
+	if result.Error != nil {
+		log.Error().Err(result.Error).Str("id", id).Msg("Failed to delete holiday")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ChecklistRepository interface {
+	FindByTask(taskID string) ([]models.ChecklistItem, error)
+	FindByID(id string) (models.ChecklistItem, error)
+	Create(item models.ChecklistItem) (models.ChecklistItem, error)
+	Update(item models.ChecklistItem) (models.ChecklistItem, error)
+	Delete(id string) error
+	Reorder(taskID string, itemIDs []string) error
+}
+
+type checklistRepository struct {
+	db *gorm.DB
+}
+
+func NewChecklistRepository(db *gorm.DB) ChecklistRepository {
+	return &checklistRepository{db: db}
+}
+
+func (r *checklistRepository) FindByTask(taskID string) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	if err := r.db.Where("task_id = ?", taskID).Order("position").Find(&items).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find checklist items")
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *checklistRepository) FindByID(id string) (models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	if err := r.db.First(&item, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find checklist item")
+		return item, err
+	}
+	return item, nil
+}
+
+// Create appends the item to the end of its task's checklist.
+func (r *checklistRepository) Create(item models.ChecklistItem) (models.ChecklistItem, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position *int }
+		if err := tx.Model(&models.ChecklistItem{}).
+			Select("MAX(position) AS position").
+			Where("task_id = ?", item.TaskID).
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		if last.Position != nil {
+			item.Position = *last.Position + 1
+		}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create checklist item")
+		return models.ChecklistItem{}, err
+	}
+	return item, nil
+}
+
+func (r *checklistRepository) Update(item models.ChecklistItem) (models.ChecklistItem, error) {
+	if err := r.db.Save(&item).Error; err != nil {
+		log.Error().Err(err).Str("id", item.ID).Msg("Failed to update checklist item")
+		return models.ChecklistItem{}, err
+	}
+	return item, nil
+}
+
+func (r *checklistRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.ChecklistItem{}, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete checklist item")
+		return err
+	}
+	return nil
+}
+
+// Reorder assigns positions following the order of itemIDs.
+func (r *checklistRepository) Reorder(taskID string, itemIDs []string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for position, id := range itemIDs {
+			err := tx.Model(&models.ChecklistItem{}).
+				Where("id = ? AND task_id = ?", id, taskID).
+				Updates(map[string]interface{}{"position": position, "updated_at": now}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to reorder checklist")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	FindPage(taskID string, offset, limit int) ([]models.Comment, int64, error)
+	FindReplies(rootIDs []string) ([]models.Comment, error)
+	FindByID(id string) (models.Comment, error)
+	FindByTasks(taskIDs []string) ([]models.Comment, error)
+	FindRevisions(commentID string) ([]models.CommentRevision, error)
+	FindMentions(commentIDs []string) (map[string][]string, error)
+	Create(comment models.Comment, mentionIDs []string) (models.Comment, error)
+	Update(comment models.Comment, previousBody string, mentionIDs []string) (models.Comment, error)
+	Delete(id string) error
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// FindPage returns top-level comments of a task, oldest first. Deleted
+// comments are kept only while they still have live replies so that the
+// thread can be shown with a placeholder.
+func (r *commentRepository) FindPage(taskID string, offset, limit int) ([]models.Comment, int64, error) {
+	query := r.db.Unscoped().Model(&models.Comment{}).
+		Where("task_id = ? AND parent_id IS NULL", taskID).
+		Where("deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments AS replies " +
+			"WHERE replies.root_id = comments.id AND replies.deleted_at IS NULL)")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to count comments")
+		return nil, 0, err
+	}
+
+	var comments []models.Comment
+	if err := query.Order("created_at, id").Offset(offset).Limit(limit).Find(&comments).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find comments")
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *commentRepository) FindReplies(rootIDs []string) ([]models.Comment, error) {
+	var replies []models.Comment
+	if len(rootIDs) == 0 {
+		return replies, nil
+	}
+	if err := r.db.Unscoped().Where("root_id IN ?", rootIDs).Order("created_at, id").Find(&replies).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find comment replies")
+		return nil, err
+	}
+	return replies, nil
+}
+
+// FindByTasks returns the live comments of the given tasks, oldest first.
+func (r *commentRepository) FindByTasks(taskIDs []string) ([]models.Comment, error) {
+	var comments []models.Comment
+	if len(taskIDs) == 0 {
+		return comments, nil
+	}
+	if err := r.db.Where("task_id IN ?", taskIDs).Order("created_at, id").Find(&comments).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find comments of tasks")
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) FindByID(id string) (models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.First(&comment, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find comment")
+		return comment, err
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) FindRevisions(commentID string) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+	if err := r.db.Where("comment_id = ?", commentID).Order("id").Find(&revisions).Error; err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Failed to find comment revisions")
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *commentRepository) FindMentions(commentIDs []string) (map[string][]string, error) {
+	mentions := make(map[string][]string)
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	var rows []models.CommentMention
+	if err := r.db.Where("comment_id IN ?", commentIDs).Find(&rows).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find comment mentions")
+		return nil, err
+	}
+	for _, row := range rows {
+		mentions[row.CommentID] = append(mentions[row.CommentID], row.UserID)
+	}
+	return mentions, nil
+}
+
+func (r *commentRepository) Create(comment models.Comment, mentionIDs []string) (models.Comment, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return replaceMentions(tx, comment.ID, mentionIDs)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create comment")
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// Update saves the edited comment and archives previousBody in its history.
+func (r *commentRepository) Update(comment models.Comment, previousBody string, mentionIDs []string) (models.Comment, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		revision := models.CommentRevision{
+			CommentID: comment.ID,
+			Body:      previousBody,
+			EditedAt:  time.Now(),
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+		return replaceMentions(tx, comment.ID, mentionIDs)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", comment.ID).Msg("Failed to update comment")
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.Comment{}, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete comment")
+		return err
+	}
+	return nil
+}
+
+func replaceMentions(tx *gorm.DB, commentID string, userIDs []string) error {
+	if err := tx.Where("comment_id = ?", commentID).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	mentions := make([]models.CommentMention, 0, len(userIDs))
+	for _, userID := range userIDs {
+		mentions = append(mentions, models.CommentMention{CommentID: commentID, UserID: userID})
+	}
+	return tx.Create(&mentions).Error
+}
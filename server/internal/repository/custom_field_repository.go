@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type CustomFieldRepository interface {
+	FindByProject(projectID string) ([]models.CustomField, error)
+	FindByID(id string) (models.CustomField, error)
+	Create(field models.CustomField) (models.CustomField, error)
+	Update(field models.CustomField) (models.CustomField, error)
+	Delete(id string) error
+}
+
+type customFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomFieldRepository(db *gorm.DB) CustomFieldRepository {
+	return &customFieldRepository{db: db}
+}
+
+func (r *customFieldRepository) FindByProject(projectID string) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	if err := r.db.Where("project_id = ?", projectID).Order("position, created_at").Find(&fields).Error; err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find custom fields")
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (r *customFieldRepository) FindByID(id string) (models.CustomField, error) {
+	var field models.CustomField
+	if err := r.db.First(&field, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find custom field")
+		return field, err
+	}
+	return field, nil
+}
+
+func (r *customFieldRepository) Create(field models.CustomField) (models.CustomField, error) {
+	if err := r.db.Create(&field).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create custom field")
+		return models.CustomField{}, err
+	}
+	return field, nil
+}
+
+func (r *customFieldRepository) Update(field models.CustomField) (models.CustomField, error) {
+	if err := r.db.Save(&field).Error; err != nil {
+		log.Error().Err(err).Str("id", field.ID).Msg("Failed to update custom field")
+		return models.CustomField{}, err
+	}
+	return field, nil
+}
+
+// Delete removes a field definition together with every value stored for it.
+func (r *customFieldRepository) Delete(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", id).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.CustomField{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete custom field")
+		return err
+	}
+	return nil
+}
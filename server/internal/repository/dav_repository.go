@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type DAVRepository interface {
+	// FindByTaskIDs returns the resources of the tasks that have one by
+	// task ID.
+	FindByTaskIDs(taskIDs []string) (map[string]models.DAVResource, error)
+	FindByName(name string) (models.DAVResource, error)
+	FindByUID(uid string) (models.DAVResource, error)
+	Save(resource models.DAVResource) error
+	Delete(taskID string) error
+}
+
+type davRepository struct {
+	db *gorm.DB
+}
+
+func NewDAVRepository(db *gorm.DB) DAVRepository {
+	return &davRepository{db: db}
+}
+
+func (r *davRepository) FindByTaskIDs(taskIDs []string) (map[string]models.DAVResource, error) {
+	byTaskID := make(map[string]models.DAVResource, len(taskIDs))
+	for start := 0; start < len(taskIDs); start += externalIDBatch {
+		end := start + externalIDBatch
+		if end > len(taskIDs) {
+			end = len(taskIDs)
+		}
+		var resources []models.DAVResource
+		if err := r.db.Where("task_id IN ?", taskIDs[start:end]).Find(&resources).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to find DAV resources by task")
+			return nil, err
+		}
+		for _, resource := range resources {
+			byTaskID[resource.TaskID] = resource
+		}
+	}
+	return byTaskID, nil
+}
+
+func (r *davRepository) FindByName(name string) (models.DAVResource, error) {
+	var resource models.DAVResource
+	if err := r.db.First(&resource, "name = ?", name).Error; err != nil {
+		return resource, err
+	}
+	return resource, nil
+}
+
+func (r *davRepository) FindByUID(uid string) (models.DAVResource, error) {
+	var resource models.DAVResource
+	if err := r.db.First(&resource, "uid = ?", uid).Error; err != nil {
+		return resource, err
+	}
+	return resource, nil
+}
+
+func (r *davRepository) Save(resource models.DAVResource) error {
+	if err := r.db.Save(&resource).Error; err != nil {
+		log.Error().Err(err).Str("task_id", resource.TaskID).Msg("Failed to save DAV resource")
+		return err
+	}
+	return nil
+}
+
+func (r *davRepository) Delete(taskID string) error {
+	if err := r.db.Where("task_id = ?", taskID).Delete(&models.DAVResource{}).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to delete DAV resource")
+		return err
+	}
+	return nil
+}
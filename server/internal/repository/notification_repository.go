@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	// FindPage returns a page of a user's notifications, latest first, and
+	// how many there are in all.
+	FindPage(userID string, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error)
+	FindByID(id string) (models.Notification, error)
+	// FindGroup returns the latest unread notification of a user of kind
+	// about taskID whose latest notification came at or after since.
+	FindGroup(userID, kind, taskID string, since time.Time) (models.Notification, error)
+	// Exists reports whether a user got a notification of kind about
+	// taskID at or after since, read or not, counting the latest of a
+	// group.
+	Exists(userID, kind, taskID string, since time.Time) (bool, error)
+	CountUnread(userID string) (int64, error)
+	Create(notification models.Notification) (models.Notification, error)
+	Update(notification models.Notification) (models.Notification, error)
+	// MarkAllRead marks every unread notification of a user read at at and
+	// returns how many there were.
+	MarkAllRead(userID string, at time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) FindPage(userID string, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count notifications")
+		return nil, 0, err
+	}
+	var notifications []models.Notification
+	if err := query.Order("latest_at DESC, id").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to find notifications")
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) FindByID(id string) (models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.First(&notification, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find notification")
+		return notification, err
+	}
+	return notification, nil
+}
+
+func (r *notificationRepository) FindGroup(userID, kind, taskID string, since time.Time) (models.Notification, error) {
+	var notification models.Notification
+	err := r.db.
+		Where("user_id = ? AND kind = ? AND task_id = ? AND read_at IS NULL AND latest_at >= ?", userID, kind, taskID, since).
+		Order("latest_at DESC").
+		First(&notification).Error
+	return notification, err
+}
+
+func (r *notificationRepository) Exists(userID, kind, taskID string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND kind = ? AND task_id = ? AND latest_at >= ?", userID, kind, taskID, since).
+		Count(&count).Error
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Str("task_id", taskID).Msg("Failed to look up notification")
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *notificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count unread notifications")
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *notificationRepository) Create(notification models.Notification) (models.Notification, error) {
+	if err := r.db.Create(&notification).Error; err != nil {
+		log.Error().Err(err).Str("user_id", notification.UserID).Msg("Failed to create notification")
+		return models.Notification{}, err
+	}
+	return notification, nil
+}
+
+func (r *notificationRepository) Update(notification models.Notification) (models.Notification, error) {
+	if err := r.db.Save(&notification).Error; err != nil {
+		log.Error().Err(err).Str("id", notification.ID).Msg("Failed to update notification")
+		return models.Notification{}, err
+	}
+	return notification, nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	if result.Error != nil {
+		log.Error().Err(result.Error).Str("user_id", userID).Msg("Failed to mark notifications read")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
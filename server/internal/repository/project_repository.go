@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ProjectRepository interface {
+	FindAll() ([]models.Project, error)
+	FindByID(id string) (models.Project, error)
+	FindByIDs(ids []string) ([]models.Project, error)
+	Create(project models.Project) (models.Project, error)
+	Update(project models.Project) (models.Project, error)
+	Delete(id string) error
+}
+
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+func (r *projectRepository) FindAll() ([]models.Project, error) {
+	var projects []models.Project
+	if err := r.db.Order("name").Find(&projects).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find all projects")
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) FindByID(id string) (models.Project, error) {
+	var project models.Project
+	if err := r.db.First(&project, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find project")
+		return project, err
+	}
+	return project, nil
+}
+
+// FindByIDs returns the projects with the given IDs that exist, in no
+// particular order.
+func (r *projectRepository) FindByIDs(ids []string) ([]models.Project, error) {
+	var projects []models.Project
+	if len(ids) == 0 {
+		return projects, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&projects).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find projects by ID")
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) Create(project models.Project) (models.Project, error) {
+	if err := r.db.Create(&project).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create project")
+		return models.Project{}, err
+	}
+	return project, nil
+}
+
+func (r *projectRepository) Update(project models.Project) (models.Project, error) {
+	if err := r.db.Save(&project).Error; err != nil {
+		log.Error().Err(err).Str("id", project.ID).Msg("Failed to update project")
+		return models.Project{}, err
+	}
+	return project, nil
+}
+
+// Delete removes a project and its custom fields. Its tasks are kept and
+// simply no longer belong to a project.
+func (r *projectRepository) Delete(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		fields := tx.Model(&models.CustomField{}).Select("id").Where("project_id = ?", id)
+		if err := tx.Where("field_id IN (?)", fields).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.CustomField{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("project_id = ?", id).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Project{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete project")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type SLARepository interface {
+	FindPolicies(projectID string) ([]models.SLAPolicy, error)
+	FindEnabledPolicies() ([]models.SLAPolicy, error)
+	FindPolicyByID(id string) (models.SLAPolicy, error)
+	CreatePolicy(policy models.SLAPolicy) (models.SLAPolicy, error)
+	UpdatePolicy(policy models.SLAPolicy) (models.SLAPolicy, error)
+	// DeletePolicy deletes a policy with the state of its tasks.
+	DeletePolicy(id string) error
+	// FindStates returns the state of the tasks under a policy by task ID.
+	FindStates(policyID string) (map[string]models.TaskSLA, error)
+	SaveState(state models.TaskSLA) error
+}
+
+type slaRepository struct {
+	db *gorm.DB
+}
+
+func NewSLARepository(db *gorm.DB) SLARepository {
+	return &slaRepository{db: db}
+}
+
+func (r *slaRepository) FindPolicies(projectID string) ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	if err := r.db.Where("project_id = ?", projectID).Order("created_at").Find(&policies).Error; err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find SLA policies")
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *slaRepository) FindEnabledPolicies() ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	if err := r.db.Where("enabled = ?", true).Order("created_at").Find(&policies).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find enabled SLA policies")
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *slaRepository) FindPolicyByID(id string) (models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := r.db.First(&policy, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find SLA policy")
+		return policy, err
+	}
+	return policy, nil
+}
+
+func (r *slaRepository) CreatePolicy(policy models.SLAPolicy) (models.SLAPolicy, error) {
+	if err := r.db.Create(&policy).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create SLA policy")
+		return policy, err
+	}
+	return policy, nil
+}
+
+func (r *slaRepository) UpdatePolicy(policy models.SLAPolicy) (models.SLAPolicy, error) {
+	if err := r.db.Save(&policy).Error; err != nil {
+		log.Error().Err(err).Str("id", policy.ID).Msg("Failed to update SLA policy")
+		return policy, err
+	}
+	return policy, nil
+}
+
+func (r *slaRepository) DeletePolicy(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", id).Delete(&models.TaskSLA{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.SLAPolicy{}).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete SLA policy")
+		return err
+	}
+	return nil
+}
+
+func (r *slaRepository) FindStates(policyID string) (map[string]models.TaskSLA, error) {
+	var states []models.TaskSLA
+	if err := r.db.Where("policy_id = ?", policyID).Find(&states).Error; err != nil {
+		log.Error().Err(err).Str("policy_id", policyID).Msg("Failed to find task SLA states")
+		return nil, err
+	}
+	byTask := make(map[string]models.TaskSLA, len(states))
+	for _, state := range states {
+		byTask[state.TaskID] = state
+	}
+	return byTask, nil
+}
+
+func (r *slaRepository) SaveState(state models.TaskSLA) error {
+	if err := r.db.Save(&state).Error; err != nil {
+		log.Error().Err(err).Str("task_id", state.TaskID).Str("policy_id", state.PolicyID).Msg("Failed to save task SLA state")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type SprintRepository interface {
+	FindByProject(projectID string) ([]models.Sprint, error)
+	FindByID(id string) (models.Sprint, error)
+	Create(sprint models.Sprint) (models.Sprint, error)
+	Update(sprint models.Sprint) (models.Sprint, error)
+	// Delete removes a sprint, moving its tasks back to the backlog.
+	Delete(id string) error
+	// Close saves a closed sprint along with the tasks it carries over, in
+	// a single transaction.
+	Close(sprint models.Sprint, carried []models.Task) (models.Sprint, error)
+	// FindHistory returns, oldest first, the revisions up to until of every
+	// task that was in the sprint at some point.
+	FindHistory(sprintID string, until time.Time) ([]models.TaskRevision, error)
+}
+
+type sprintRepository struct {
+	db *gorm.DB
+}
+
+func NewSprintRepository(db *gorm.DB) SprintRepository {
+	return &sprintRepository{db: db}
+}
+
+func (r *sprintRepository) FindByProject(projectID string) ([]models.Sprint, error) {
+	var sprints []models.Sprint
+	if err := r.db.Where("project_id = ?", projectID).Order("start_date, name").Find(&sprints).Error; err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find sprints")
+		return nil, err
+	}
+	return sprints, nil
+}
+
+func (r *sprintRepository) FindByID(id string) (models.Sprint, error) {
+	var sprint models.Sprint
+	if err := r.db.First(&sprint, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find sprint")
+		return sprint, err
+	}
+	return sprint, nil
+}
+
+func (r *sprintRepository) Create(sprint models.Sprint) (models.Sprint, error) {
+	if err := r.db.Create(&sprint).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create sprint")
+		return models.Sprint{}, err
+	}
+	return sprint, nil
+}
+
+func (r *sprintRepository) Update(sprint models.Sprint) (models.Sprint, error) {
+	if err := r.db.Save(&sprint).Error; err != nil {
+		log.Error().Err(err).Str("id", sprint.ID).Msg("Failed to update sprint")
+		return models.Sprint{}, err
+	}
+	return sprint, nil
+}
+
+func (r *sprintRepository) Delete(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("sprint_id = ?", id).Update("sprint_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Sprint{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete sprint")
+		return err
+	}
+	return nil
+}
+
+func (r *sprintRepository) Close(sprint models.Sprint, carried []models.Task) (models.Sprint, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, task := range carried {
+			if _, err := updateTask(tx, task); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&sprint).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", sprint.ID).Int("carried", len(carried)).Msg("Failed to close sprint")
+		return models.Sprint{}, err
+	}
+	return sprint, nil
+}
+
+// FindHistory finds the tasks through the sprint ID their revisions record.
+func (r *sprintRepository) FindHistory(sprintID string, until time.Time) ([]models.TaskRevision, error) {
+	members := r.db.Model(&models.TaskRevision{}).
+		Select("DISTINCT task_id").
+		Where("sprint_id = ?", sprintID)
+
+	var revisions []models.TaskRevision
+	err := r.db.Where("task_id IN (?)", members).
+		Where("revised_at <= ?", until).
+		Order("revised_at, id").
+		Find(&revisions).Error
+	if err != nil {
+		log.Error().Err(err).Str("sprint_id", sprintID).Msg("Failed to find sprint history")
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"taskmanager/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Comparison operators for custom field conditions
+const (
+	OpEq       = "eq"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpContains = "contains"
+)
+
+// TaskFilter narrows and orders the task list. Custom field conditions and
+// sorting refer to field definitions already resolved by the service layer,
+// and Where is a compiled filter expression. Assignee is a user ID, or
+// AssigneeNone for tasks nobody is assigned to. ProjectIDs selects the
+// tasks of any of several projects.
+type TaskFilter struct {
+	TaskID       string
+	ProjectID    string
+	ProjectIDs   []string
+	SprintID     string
+	Assignee     string
+	Where        *Condition
+	CustomFields []CustomFieldCondition
+	SortColumn   string
+	SortField    *models.CustomField
+	Descending   bool
+}
+
+// CustomFieldCondition compares one custom field against a value. Value is
+// a float64 for number fields and a string otherwise.
+type CustomFieldCondition struct {
+	Field models.CustomField
+	Op    string
+	Value interface{}
+}
+
+// SortableColumns are the task columns the list can be ordered by.
+var SortableColumns = map[string]bool{
+	"title":      true,
+	"due_date":   true,
+	"created_at": true,
+	"updated_at": true,
+	"position":   true,
+	"estimate":   true,
+}
+
+// AssigneeNone as TaskFilter.Assignee selects unassigned tasks.
+const AssigneeNone = "none"
+
+var sqlOperators = map[string]string{
+	OpEq:  "=",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+func (f TaskFilter) apply(db *gorm.DB) *gorm.DB {
+	return f.order(f.where(db))
+}
+
+// InCreatedOrder reports whether the filter lists tasks by creation time,
+// the order a TaskCursor is a position in.
+func (f TaskFilter) InCreatedOrder() bool {
+	return f.SortField == nil && (f.SortColumn == "" || f.SortColumn == "created_at")
+}
+
+// where selects the tasks of the filter, in no particular order.
+func (f TaskFilter) where(db *gorm.DB) *gorm.DB {
+	if f.TaskID != "" {
+		db = db.Where("tasks.id = ?", f.TaskID)
+	}
+	if f.ProjectID != "" {
+		db = db.Where("tasks.project_id = ?", f.ProjectID)
+	}
+	if len(f.ProjectIDs) > 0 {
+		db = db.Where("tasks.project_id IN ?", f.ProjectIDs)
+	}
+	if f.SprintID != "" {
+		db = db.Where("tasks.sprint_id = ?", f.SprintID)
+	}
+	switch f.Assignee {
+	case "":
+	case AssigneeNone:
+		db = db.Where("NOT EXISTS (?)", memberQuery(db, models.RoleAssignee))
+	default:
+		db = db.Where("EXISTS (?)", memberQuery(db, models.RoleAssignee).Where("task_members.user_id = ?", f.Assignee))
+	}
+	if f.Where != nil {
+		db = db.Where(f.Where.SQL, f.Where.Args...)
+	}
+
+	for i, condition := range f.CustomFields {
+		alias := fmt.Sprintf("cf%d", i)
+		db = db.Joins(fmt.Sprintf(
+			"JOIN custom_field_values %[1]s ON %[1]s.task_id = tasks.id AND %[1]s.field_id = ?", alias,
+		), condition.Field.ID)
+		db = condition.apply(db, alias)
+	}
+	return db
+}
+
+// order sorts the tasks of the filter, with the task ID breaking ties.
+func (f TaskFilter) order(db *gorm.DB) *gorm.DB {
+	direction := ""
+	if f.Descending {
+		direction = " DESC"
+	}
+	switch {
+	case f.SortField != nil:
+		column := "sortcf." + valueColumn(*f.SortField)
+		db = db.Joins("LEFT JOIN custom_field_values sortcf ON sortcf.task_id = tasks.id AND sortcf.field_id = ?", f.SortField.ID).
+			Order(column + " IS NULL").
+			Order(column + direction)
+	case SortableColumns[f.SortColumn]:
+		db = db.Order("tasks." + f.SortColumn + direction)
+	default:
+		db = db.Order("tasks.created_at" + direction)
+	}
+	return db.Order("tasks.id")
+}
+
+// after selects the tasks after a cursor in creation order. Ties on the
+// creation time are ordered by ascending ID in both directions.
+func (f TaskFilter) after(db *gorm.DB, cursor models.TaskCursor) *gorm.DB {
+	operator := ">"
+	if f.Descending {
+		operator = "<"
+	}
+	return db.Where("tasks.created_at "+operator+" ? OR (tasks.created_at = ? AND tasks.id > ?)",
+		cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+}
+
+func (c CustomFieldCondition) apply(db *gorm.DB, alias string) *gorm.DB {
+	if c.Op == OpContains {
+		encoded, _ := json.Marshal(c.Value)
+		return db.Where(alias+".value LIKE ? ESCAPE '\\'", "%"+escapeLike(string(encoded))+"%")
+	}
+	return db.Where(alias+"."+valueColumn(c.Field)+" "+sqlOperators[c.Op]+" ?", c.Value)
+}
+
+// memberQuery selects the members with role of the task in the outer query.
+func memberQuery(db *gorm.DB, role string) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Table("task_members").
+		Select("1").
+		Where("task_members.task_id = tasks.id AND task_members.role = ?", role)
+}
+
+func valueColumn(field models.CustomField) string {
+	if field.Type == models.FieldNumber {
+		return "number_value"
+	}
+	return "text_value"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
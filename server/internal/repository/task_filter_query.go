@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/filter"
+	"taskmanager/internal/models"
+)
+
+// Condition is a compiled SQL condition on the tasks table.
+type Condition struct {
+	SQL  string
+	Args []interface{}
+}
+
+// CompileFilter turns a parsed filter into a condition on tasks. Relative
+// dates such as 7d or today are resolved against now, and "me" is userID,
+// which may be empty when nobody is signed in. A nil expression compiles to
+// a nil condition. Unknown fields and values are reported as *filter.Error
+// at their column.
+func CompileFilter(expr filter.Expr, now time.Time, userID string) (*Condition, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	c := &filterCompiler{now: now, userID: userID}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Condition{SQL: sql, Args: c.args}, nil
+}
+
+type termCompiler func(c *filterCompiler, term *filter.Term) (string, error)
+
+// filterFields maps each filter field to the function compiling its terms.
+// The empty field is a bare word searching the task text.
+var filterFields = map[string]termCompiler{
+	"":         (*filterCompiler).text,
+	"title":    (*filterCompiler).title,
+	"status":   (*filterCompiler).status,
+	"priority": (*filterCompiler).priority,
+	"tag":      (*filterCompiler).tag,
+	"due":      (*filterCompiler).due,
+	"created":  dateField("tasks.created_at"),
+	"updated":  dateField("tasks.updated_at"),
+	"project":  reference("tasks.project_id"),
+	"parent":   reference("tasks.parent_id"),
+	"sprint":   reference("tasks.sprint_id"),
+	"assignee": member(models.RoleAssignee),
+	"watcher":  member(models.RoleWatcher),
+}
+
+type filterCompiler struct {
+	now    time.Time
+	userID string
+	args   []interface{}
+}
+
+func (c *filterCompiler) compile(expr filter.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *filter.And:
+		return c.binary(e.Left, "AND", e.Right)
+	case *filter.Or:
+		return c.binary(e.Left, "OR", e.Right)
+	case *filter.Not:
+		sql, err := c.compile(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + sql + ")", nil
+	case *filter.Term:
+		compile, ok := filterFields[e.Field]
+		if !ok {
+			return "", filter.Errorf(e.Col, "unknown field %q, expected one of %s", e.Field, strings.Join(fieldNames(), ", "))
+		}
+		return compile(c, e)
+	default:
+		return "", filter.Errorf(expr.Column(), "unsupported expression")
+	}
+}
+
+func (c *filterCompiler) binary(left filter.Expr, op string, right filter.Expr) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// arg records a query argument and returns its placeholder.
+func (c *filterCompiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	return "?"
+}
+
+func (c *filterCompiler) text(term *filter.Term) (string, error) {
+	pattern := c.arg(containsPattern(term.Value))
+	return "(LOWER(tasks.title) LIKE " + pattern + " ESCAPE '\\' OR LOWER(tasks.description) LIKE " +
+		c.arg(containsPattern(term.Value)) + " ESCAPE '\\')", nil
+}
+
+func (c *filterCompiler) title(term *filter.Term) (string, error) {
+	if err := equalityOnly(term); err != nil {
+		return "", err
+	}
+	return negate(term, "LOWER(tasks.title) LIKE "+c.arg(containsPattern(term.Value))+" ESCAPE '\\'"), nil
+}
+
+func (c *filterCompiler) status(term *filter.Term) (string, error) {
+	if err := equalityOnly(term); err != nil {
+		return "", err
+	}
+	var sql string
+	switch strings.ToLower(term.Value) {
+	case "open":
+		sql = "tasks.status <> " + c.arg(models.StatusDone)
+	case "closed", models.StatusDone:
+		sql = "tasks.status = " + c.arg(models.StatusDone)
+	case models.StatusTodo, models.StatusInProgress:
+		sql = "tasks.status = " + c.arg(strings.ToLower(term.Value))
+	default:
+		return "", filter.Errorf(term.ValueCol, "unknown status %q, expected one of open, closed, %s", term.Value, strings.Join(models.Statuses, ", "))
+	}
+	return negate(term, sql), nil
+}
+
+func (c *filterCompiler) priority(term *filter.Term) (string, error) {
+	index := -1
+	for i, priority := range models.Priorities {
+		if strings.EqualFold(term.Value, priority) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", filter.Errorf(term.ValueCol, "unknown priority %q, expected one of %s", term.Value, strings.Join(models.Priorities, ", "))
+	}
+
+	var matching []string
+	switch term.Op {
+	case filter.OpEq, filter.OpNe:
+		return negate(term, "tasks.priority = "+c.arg(models.Priorities[index])), nil
+	case filter.OpLt:
+		matching = models.Priorities[:index]
+	case filter.OpLte:
+		matching = models.Priorities[:index+1]
+	case filter.OpGt:
+		matching = models.Priorities[index+1:]
+	case filter.OpGte:
+		matching = models.Priorities[index:]
+	}
+	if len(matching) == 0 {
+		return "1 = 0", nil
+	}
+	return "tasks.priority IN " + c.arg(matching), nil
+}
+
+func (c *filterCompiler) tag(term *filter.Term) (string, error) {
+	if err := equalityOnly(term); err != nil {
+		return "", err
+	}
+	if strings.EqualFold(term.Value, "none") {
+		return negate(term, "(tasks.tags IS NULL OR tasks.tags IN ('null', '[]'))"), nil
+	}
+	encoded, _ := json.Marshal(strings.ToLower(term.Value))
+	return negate(term, "LOWER(tasks.tags) LIKE "+c.arg("%"+escapeLike(string(encoded))+"%")+" ESCAPE '\\'"), nil
+}
+
+// due compares due dates like other dates, leaving out tasks without one,
+// and also accepts due:none and due:overdue.
+func (c *filterCompiler) due(term *filter.Term) (string, error) {
+	noDue := "(tasks.due_date IS NULL OR tasks.due_date < " + c.arg(models.NoDueDate) + ")"
+	switch strings.ToLower(term.Value) {
+	case "none":
+		if err := equalityOnly(term); err != nil {
+			return "", err
+		}
+		return negate(term, noDue), nil
+	case "overdue":
+		if err := equalityOnly(term); err != nil {
+			return "", err
+		}
+		allDay := "(tasks.due_time_zone = '' AND tasks.due_date < " + c.arg(civil(c.day(0))) + ")"
+		timed := "(tasks.due_time_zone <> '' AND tasks.due_date < " + c.arg(c.now.UTC()) + ")"
+		return negate(term, "(NOT "+noDue+" AND ("+allDay+" OR "+timed+") AND tasks.status <> "+c.arg(models.StatusDone)+")"), nil
+	}
+	start, err := c.parseDay(term)
+	if err != nil {
+		return "", err
+	}
+	// All-day due dates are stored as midnight UTC of their date, so they
+	// compare with the date of the day, and timed ones with its instants.
+	allDay := "(tasks.due_time_zone = '' AND " + c.compareDay("tasks.due_date", civil(start), term) + ")"
+	timed := "(tasks.due_time_zone <> '' AND " + c.compareDay("tasks.due_date", start, term) + ")"
+	return "(NOT " + noDue + " AND (" + allDay + " OR " + timed + "))", nil
+}
+
+// dateField compiles comparisons of a timestamp column with a day. Days are
+// YYYY-MM-DD, today, tomorrow, yesterday, or an offset from today such as
+// 7d, -2w or 1m. ":" matches the whole day.
+func dateField(column string) termCompiler {
+	return func(c *filterCompiler, term *filter.Term) (string, error) {
+		start, err := c.parseDay(term)
+		if err != nil {
+			return "", err
+		}
+		return c.compareDay(column, start, term), nil
+	}
+}
+
+// compareDay compiles the comparison of term between column and the day
+// starting at start.
+func (c *filterCompiler) compareDay(column string, start time.Time, term *filter.Term) string {
+	end := start.AddDate(0, 0, 1).UTC()
+	start = start.UTC()
+	switch term.Op {
+	case filter.OpLt:
+		return column + " < " + c.arg(start)
+	case filter.OpLte:
+		return column + " < " + c.arg(end)
+	case filter.OpGt:
+		return column + " >= " + c.arg(end)
+	case filter.OpGte:
+		return column + " >= " + c.arg(start)
+	default:
+		return negate(term, "("+column+" >= "+c.arg(start)+" AND "+column+" < "+c.arg(end)+")")
+	}
+}
+
+// reference compiles equality with the ID in a nullable column, where none
+// matches tasks without one.
+func reference(column string) termCompiler {
+	return func(c *filterCompiler, term *filter.Term) (string, error) {
+		if err := equalityOnly(term); err != nil {
+			return "", err
+		}
+		if strings.EqualFold(term.Value, "none") {
+			return negate(term, column+" IS NULL"), nil
+		}
+		return negate(term, column+" = "+c.arg(term.Value)), nil
+	}
+}
+
+// member matches tasks by their users with role: "me", a user ID or
+// username, or none for tasks without any.
+func member(role string) termCompiler {
+	return func(c *filterCompiler, term *filter.Term) (string, error) {
+		if err := equalityOnly(term); err != nil {
+			return "", err
+		}
+		members := "SELECT 1 FROM task_members WHERE task_members.task_id = tasks.id AND task_members.role = " + c.arg(role)
+		switch value := strings.ToLower(term.Value); value {
+		case "none":
+			return negate(term, "NOT EXISTS ("+members+")"), nil
+		case "me":
+			if c.userID == "" {
+				return "", filter.Errorf(term.ValueCol, "%s:me needs a signed-in user", term.Field)
+			}
+			return negate(term, "EXISTS ("+members+" AND task_members.user_id = "+c.arg(c.userID)+")"), nil
+		default:
+			users := "SELECT id FROM users WHERE id = " + c.arg(term.Value) + " OR LOWER(username) = " + c.arg(value)
+			return negate(term, "EXISTS ("+members+" AND task_members.user_id IN ("+users+"))"), nil
+		}
+	}
+}
+
+func (c *filterCompiler) parseDay(term *filter.Term) (time.Time, error) {
+	value := strings.ToLower(term.Value)
+	switch value {
+	case "today":
+		return c.day(0), nil
+	case "tomorrow":
+		return c.day(1), nil
+	case "yesterday":
+		return c.day(-1), nil
+	}
+	if day, err := time.ParseInLocation("2006-01-02", value, c.now.Location()); err == nil {
+		return day, nil
+	}
+	if len(value) > 1 {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil {
+			switch value[len(value)-1] {
+			case 'd':
+				return c.day(n), nil
+			case 'w':
+				return c.day(7 * n), nil
+			case 'm':
+				return c.day(0).AddDate(0, n, 0), nil
+			}
+		}
+	}
+	return time.Time{}, filter.Errorf(term.ValueCol, "invalid date %q, expected YYYY-MM-DD, today, tomorrow, yesterday or an offset like 7d, 2w or -1m", term.Value)
+}
+
+// day returns the start of the day offset days from today.
+func (c *filterCompiler) day(offset int) time.Time {
+	y, m, d := c.now.Date()
+	return time.Date(y, m, d+offset, 0, 0, 0, 0, c.now.Location())
+}
+
+// civil returns midnight UTC of the date of t.
+func civil(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func equalityOnly(term *filter.Term) error {
+	if term.Op != filter.OpEq && term.Op != filter.OpNe {
+		return filter.Errorf(term.Col, "%s only supports : and !=", term.Field)
+	}
+	return nil
+}
+
+func negate(term *filter.Term, sql string) string {
+	if term.Op == filter.OpNe {
+		return "NOT (" + sql + ")"
+	}
+	return sql
+}
+
+func containsPattern(value string) string {
+	return "%" + escapeLike(strings.ToLower(value)) + "%"
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
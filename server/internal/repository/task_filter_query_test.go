@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"taskmanager/internal/filter"
+)
+
+// showArgs writes the arguments of a condition, times in RFC 3339.
+func showArgs(args []interface{}) string {
+	shown := make([]string, len(args))
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			shown[i] = t.Format(time.RFC3339)
+		} else {
+			shown[i] = fmt.Sprint(arg)
+		}
+	}
+	return strings.Join(shown, " ")
+}
+
+func TestCompileFilter(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Late evening, when the day in New York is not the day in UTC
+	now := time.Date(2026, time.October, 14, 22, 30, 0, 0, newYork)
+	const (
+		members = "SELECT 1 FROM task_members WHERE task_members.task_id = tasks.id AND task_members.role = ?"
+		hasDue  = "NOT (tasks.due_date IS NULL OR tasks.due_date < ?)"
+		noDue   = "0001-01-02T00:00:00Z"
+	)
+
+	tests := []struct {
+		src  string
+		sql  string
+		args string
+	}{
+		{"", "", ""},
+		{"Bug", `(LOWER(tasks.title) LIKE ? ESCAPE '\' OR LOWER(tasks.description) LIKE ? ESCAPE '\')`, "%bug% %bug%"},
+		{"title:50%_off", `LOWER(tasks.title) LIKE ? ESCAPE '\'`, `%50\%\_off%`},
+		{"title!=draft", `NOT (LOWER(tasks.title) LIKE ? ESCAPE '\')`, "%draft%"},
+		{"status:open", "tasks.status <> ?", "done"},
+		{"status:closed", "tasks.status = ?", "done"},
+		{"status:In_Progress", "tasks.status = ?", "in_progress"},
+		{"priority:high", "tasks.priority = ?", "high"},
+		{"priority!=none", "NOT (tasks.priority = ?)", "none"},
+		{"priority>=high", "tasks.priority IN ?", "[high urgent]"},
+		{"priority<medium", "tasks.priority IN ?", "[none low]"},
+		{"priority>urgent", "1 = 0", ""},
+		{"tag:Bug", `LOWER(tasks.tags) LIKE ? ESCAPE '\'`, `%"bug"%`},
+		{"tag:none", "(tasks.tags IS NULL OR tasks.tags IN ('null', '[]'))", ""},
+		{"due:none", "(tasks.due_date IS NULL OR tasks.due_date < ?)", noDue},
+		{"due!=none", "NOT ((tasks.due_date IS NULL OR tasks.due_date < ?))", noDue},
+		{
+			"due:overdue",
+			"(" + hasDue + " AND ((tasks.due_time_zone = '' AND tasks.due_date < ?) OR (tasks.due_time_zone <> '' AND tasks.due_date < ?)) AND tasks.status <> ?)",
+			noDue + " 2026-10-14T00:00:00Z 2026-10-15T02:30:00Z done",
+		},
+		{
+			"due<today",
+			"(" + hasDue + " AND ((tasks.due_time_zone = '' AND tasks.due_date < ?) OR (tasks.due_time_zone <> '' AND tasks.due_date < ?)))",
+			noDue + " 2026-10-14T00:00:00Z 2026-10-14T04:00:00Z",
+		},
+		{
+			"due:2026-10-20",
+			"(" + hasDue + " AND ((tasks.due_time_zone = '' AND (tasks.due_date >= ? AND tasks.due_date < ?)) OR (tasks.due_time_zone <> '' AND (tasks.due_date >= ? AND tasks.due_date < ?))))",
+			noDue + " 2026-10-20T00:00:00Z 2026-10-21T00:00:00Z 2026-10-20T04:00:00Z 2026-10-21T04:00:00Z",
+		},
+		{
+			"due>=7d",
+			"(" + hasDue + " AND ((tasks.due_time_zone = '' AND tasks.due_date >= ?) OR (tasks.due_time_zone <> '' AND tasks.due_date >= ?)))",
+			noDue + " 2026-10-21T00:00:00Z 2026-10-21T04:00:00Z",
+		},
+		{"created>-2w", "tasks.created_at >= ?", "2026-10-01T04:00:00Z"},
+		{"created:yesterday", "(tasks.created_at >= ? AND tasks.created_at < ?)", "2026-10-13T04:00:00Z 2026-10-14T04:00:00Z"},
+		// A month ahead is after the clocks go back
+		{"updated<=1m", "tasks.updated_at < ?", "2026-11-15T05:00:00Z"},
+		{"project:none", "tasks.project_id IS NULL", ""},
+		{"project:p1", "tasks.project_id = ?", "p1"},
+		{"parent!=none", "NOT (tasks.parent_id IS NULL)", ""},
+		{"sprint:s1", "tasks.sprint_id = ?", "s1"},
+		{"assignee:me", "EXISTS (" + members + " AND task_members.user_id = ?)", "assignee u1"},
+		{"assignee:none", "NOT EXISTS (" + members + ")", "assignee"},
+		{
+			"watcher:Bob",
+			"EXISTS (" + members + " AND task_members.user_id IN (SELECT id FROM users WHERE id = ? OR LOWER(username) = ?))",
+			"watcher Bob bob",
+		},
+		{"status:open OR NOT tag:bug", `(tasks.status <> ? OR NOT (LOWER(tasks.tags) LIKE ? ESCAPE '\'))`, `done %"bug"%`},
+		{"sprint:s1 project:p1", "(tasks.sprint_id = ? AND tasks.project_id = ?)", "s1 p1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := filter.Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition, err := CompileFilter(expr, now, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql, args := "", ""
+			if condition != nil {
+				sql, args = condition.SQL, showArgs(condition.Args)
+			}
+			if sql != tt.sql {
+				t.Errorf("SQL\n%s\nwant\n%s", sql, tt.sql)
+			}
+			if args != tt.args {
+				t.Errorf("args %s, want %s", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		userID string
+		want   string
+	}{
+		{"owner:me", "u1", `column 1: unknown field "owner", expected one of assignee, created, due, parent, priority, project, sprint, status, tag, title, updated, watcher`},
+		{"status:blocked", "u1", `column 8: unknown status "blocked", expected one of open, closed, todo, in_progress, done`},
+		{"priority:huge", "u1", `column 10: unknown priority "huge", expected one of none, low, medium, high, urgent`},
+		{"tag>bug", "u1", "column 1: tag only supports : and !="},
+		{"bug assignee>bob", "u1", "column 5: assignee only supports : and !="},
+		{"due:soon", "u1", `column 5: invalid date "soon", expected YYYY-MM-DD, today, tomorrow, yesterday or an offset like 7d, 2w or -1m`},
+		{"created<2026-02-30", "u1", `column 9: invalid date "2026-02-30", expected YYYY-MM-DD, today, tomorrow, yesterday or an offset like 7d, 2w or -1m`},
+		{"assignee:me", "", "column 10: assignee:me needs a signed-in user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := filter.Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = CompileFilter(expr, time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC), tt.userID)
+			if _, ok := err.(*filter.Error); !ok || err.Error() != tt.want {
+				t.Errorf("error %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskMemberRepository interface {
+	FindByTask(taskID string) ([]models.TaskMember, error)
+	// Add makes a user a member of a task, doing nothing if they already
+	// have the role.
+	Add(member models.TaskMember) error
+	Remove(taskID, userID, role string) error
+}
+
+type taskMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskMemberRepository(db *gorm.DB) TaskMemberRepository {
+	return &taskMemberRepository{db: db}
+}
+
+func (r *taskMemberRepository) FindByTask(taskID string) ([]models.TaskMember, error) {
+	var members []models.TaskMember
+	if err := r.db.Where("task_id = ?", taskID).Order("created_at, user_id").Find(&members).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find task members")
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *taskMemberRepository) Add(member models.TaskMember) error {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		log.Error().Err(err).Str("task_id", member.TaskID).Str("user_id", member.UserID).Msg("Failed to add task member")
+		return err
+	}
+	return nil
+}
+
+func (r *taskMemberRepository) Remove(taskID, userID, role string) error {
+	err := r.db.Where("task_id = ? AND user_id = ? AND role = ?", taskID, userID, role).Delete(&models.TaskMember{}).Error
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Str("user_id", userID).Msg("Failed to remove task member")
+		return err
+	}
+	return nil
+}
@@ -1,20 +1,64 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"taskmanager/internal/models"
+	"taskmanager/internal/rank"
 
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaskRepository interface {
-	FindAll() ([]models.Task, error)
+	FindAll(filter TaskFilter) ([]models.Task, error)
+	// FindPage returns at most limit of the tasks the filter selects and
+	// how many it selects in all. The page starts after the task of a
+	// cursor, which needs a filter in creation order, or else skips
+	// offset tasks.
+	FindPage(filter TaskFilter, after *models.TaskCursor, offset, limit int) ([]models.Task, int64, error)
 	FindByID(id string) (models.Task, error)
+	FindByIDs(ids []string) ([]models.Task, error)
+	// FindByExternalIDs returns the live tasks with the external IDs by
+	// external ID.
+	FindByExternalIDs(externalIDs []string) (map[string]models.Task, error)
+	// Each calls fn with each task the filter selects, in order, loading
+	// batchSize tasks at a time. It stops at the first error of fn.
+	Each(filter TaskFilter, batchSize int, fn func(models.Task) error) error
+	// FindChildIDs returns the IDs of the direct subtasks of parents.
+	FindChildIDs(parentIDs []string) ([]string, error)
 	Create(task models.Task) (models.Task, error)
+	CreateAll(tasks []models.Task) ([]models.Task, error)
 	Update(task models.Task) (models.Task, error)
+	// SaveAll creates and updates tasks in a single transaction.
+	SaveAll(creates, updates []models.Task) ([]models.Task, []models.Task, error)
 	Delete(id string) error
+	Restore(id string) (models.Task, error)
+	Purge(id string) error
+	FindColumn(projectID *string, status string) ([]models.Task, error)
+	Rebalance(projectID *string, status string) error
+	FindAllAsOf(asOf time.Time) ([]models.Task, error)
+	FindByIDAsOf(id string, asOf time.Time) (models.Task, error)
+	// FindDueBetween returns the open tasks whose stored due date is
+	// between from and to.
+	FindDueBetween(from, to time.Time) ([]models.Task, error)
+	// FindRecentRevisions returns the last revisions of a task made at or
+	// before until, newest first.
+	FindRecentRevisions(id string, until time.Time, limit int) ([]models.TaskRevision, error)
 }
 
+var (
+	// ErrNotFound is returned when a record does not exist
+	ErrNotFound = gorm.ErrRecordNotFound
+	// ErrTaskNotDeleted is returned when restoring a task that still exists
+	ErrTaskNotDeleted = errors.New("task is not deleted")
+)
+
+// externalIDBatch is how many external IDs are looked up per query
+const externalIDBatch = 500
+
 type taskRepository struct {
 	db *gorm.DB
 }
@@ -23,44 +67,499 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
-func (r *taskRepository) FindAll() ([]models.Task, error) {
+func (r *taskRepository) FindAll(filter TaskFilter) ([]models.Task, error) {
 	var tasks []models.Task
-	if err := r.db.Find(&tasks).Error; err != nil {
+	if err := filter.apply(preloadAssociations(r.db)).Find(&tasks).Error; err != nil {
 		log.Error().Err(err).Msg("Failed to find all tasks")
 		return nil, err
 	}
 	return tasks, nil
 }
 
+func (r *taskRepository) FindPage(filter TaskFilter, after *models.TaskCursor, offset, limit int) ([]models.Task, int64, error) {
+	var total int64
+	if err := filter.where(r.db.Model(&models.Task{})).Count(&total).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to count tasks")
+		return nil, 0, err
+	}
+
+	query := filter.apply(preloadAssociations(r.db))
+	if after != nil {
+		query = filter.after(query, *after)
+	}
+	var tasks []models.Task
+	if err := query.Offset(offset).Limit(limit).Find(&tasks).Error; err != nil {
+		log.Error().Err(err).Int("offset", offset).Msg("Failed to find page of tasks")
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
 func (r *taskRepository) FindByID(id string) (models.Task, error) {
 	var task models.Task
-	if err := r.db.First(&task, "id = ?", id).Error; err != nil {
+	if err := preloadAssociations(r.db).First(&task, "id = ?", id).Error; err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to find task")
 		return task, err
 	}
 	return task, nil
 }
 
+// FindByIDs returns the tasks with the given IDs that exist, in no
+// particular order.
+func (r *taskRepository) FindByIDs(ids []string) ([]models.Task, error) {
+	var tasks []models.Task
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+	if err := preloadAssociations(r.db).Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find tasks by ID")
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) FindByExternalIDs(externalIDs []string) (map[string]models.Task, error) {
+	byExternalID := make(map[string]models.Task, len(externalIDs))
+	for start := 0; start < len(externalIDs); start += externalIDBatch {
+		end := start + externalIDBatch
+		if end > len(externalIDs) {
+			end = len(externalIDs)
+		}
+		var tasks []models.Task
+		if err := preloadAssociations(r.db).Where("external_id IN ?", externalIDs[start:end]).Find(&tasks).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to find tasks by external ID")
+			return nil, err
+		}
+		for _, task := range tasks {
+			byExternalID[*task.ExternalID] = task
+		}
+	}
+	return byExternalID, nil
+}
+
+func (r *taskRepository) Each(filter TaskFilter, batchSize int, fn func(models.Task) error) error {
+	for offset := 0; ; offset += batchSize {
+		var tasks []models.Task
+		if err := filter.apply(preloadAssociations(r.db)).Offset(offset).Limit(batchSize).Find(&tasks).Error; err != nil {
+			log.Error().Err(err).Int("offset", offset).Msg("Failed to find batch of tasks")
+			return err
+		}
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		if len(tasks) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (r *taskRepository) FindChildIDs(parentIDs []string) ([]string, error) {
+	var ids []string
+	if len(parentIDs) == 0 {
+		return ids, nil
+	}
+	if err := r.db.Model(&models.Task{}).Where("parent_id IN ?", parentIDs).Pluck("id", &ids).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find subtasks")
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *taskRepository) Create(task models.Task) (models.Task, error) {
-	if err := r.db.Create(&task).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = createTask(tx, task)
+		return err
+	})
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to create task")
 		return models.Task{}, err
 	}
 	return task, nil
 }
 
+// CreateAll creates several tasks in a single transaction, in order, so
+// that parents can be listed before their subtasks.
+func (r *taskRepository) CreateAll(tasks []models.Task) ([]models.Task, error) {
+	created := make([]models.Task, 0, len(tasks))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			saved, err := createTask(tx, task)
+			if err != nil {
+				return err
+			}
+			created = append(created, saved)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Int("count", len(tasks)).Msg("Failed to create tasks")
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update saves a task. A task with an empty position is placed at the end
+// of its board column.
 func (r *taskRepository) Update(task models.Task) (models.Task, error) {
-	if err := r.db.Save(&task).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = updateTask(tx, task)
+		return err
+	})
+	if err != nil {
 		log.Error().Err(err).Str("id", task.ID).Msg("Failed to update task")
 		return models.Task{}, err
 	}
 	return task, nil
 }
 
+func (r *taskRepository) SaveAll(creates, updates []models.Task) ([]models.Task, []models.Task, error) {
+	created := make([]models.Task, 0, len(creates))
+	updated := make([]models.Task, 0, len(updates))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, task := range creates {
+			saved, err := createTask(tx, task)
+			if err != nil {
+				return err
+			}
+			created = append(created, saved)
+		}
+		for _, task := range updates {
+			saved, err := updateTask(tx, task)
+			if err != nil {
+				return err
+			}
+			updated = append(updated, saved)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Int("creates", len(creates)).Int("updates", len(updates)).Msg("Failed to save tasks")
+		return nil, nil, err
+	}
+	return created, updated, nil
+}
+
 func (r *taskRepository) Delete(id string) error {
-	if err := r.db.Delete(&models.Task{}, "id = ?", id).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := preloadAssociations(tx).First(&task, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&task).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, task, models.RevisionDeleted, time.Now())
+	})
+	if err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to delete task")
 		return err
 	}
 	return nil
 }
+
+// Restore recreates a deleted task from the snapshot taken when it was deleted.
+func (r *taskRepository) Restore(id string) (models.Task, error) {
+	var task models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var latest models.TaskRevision
+		if err := tx.Where("task_id = ?", id).Order("id DESC").First(&latest).Error; err != nil {
+			return err
+		}
+		if latest.Operation != models.RevisionDeleted {
+			return ErrTaskNotDeleted
+		}
+
+		var err error
+		if task, err = latest.Task(); err != nil {
+			return err
+		}
+		task.UpdatedAt = time.Now()
+		if err := tx.Omit(clause.Associations).Create(&task).Error; err != nil {
+			return err
+		}
+		if task, err = reload(tx, id); err != nil {
+			return err
+		}
+		return recordRevision(tx, task, models.RevisionRestored, task.UpdatedAt)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to restore task")
+		return models.Task{}, err
+	}
+	return task, nil
+}
+
+// Purge permanently removes a task, live or deleted, together with its
+// history and comments. Attachments are purged by the attachment service.
+func (r *taskRepository) Purge(id string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		comments := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("task_id = ?", id)
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("task_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", id).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", id).Delete(&models.ChecklistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", id).Delete(&models.TaskMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", id).Delete(&models.TaskRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Task{}).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to purge task")
+		return err
+	}
+	return nil
+}
+
+// FindColumn returns the tasks of one board column in board order. A nil
+// projectID selects tasks without a project.
+func (r *taskRepository) FindColumn(projectID *string, status string) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := columnOrder(inColumn(preloadAssociations(r.db), projectID, status)).Find(&tasks).Error; err != nil {
+		log.Error().Err(err).Str("status", status).Msg("Failed to find board column")
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Rebalance gives every task of a board column a new, short position while
+// keeping their order. It rewrites the whole column, so it is only used
+// once positions have grown long.
+func (r *taskRepository) Rebalance(projectID *string, status string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := columnOrder(inColumn(tx.Model(&models.Task{}), projectID, status)).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for i, position := range rank.Spread(len(ids)) {
+			if err := tx.Model(&models.Task{}).Where("id = ?", ids[i]).UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("status", status).Msg("Failed to rebalance board column")
+		return err
+	}
+	return nil
+}
+
+// FindAllAsOf returns every task that existed at asOf, in the state it had then.
+func (r *taskRepository) FindAllAsOf(asOf time.Time) ([]models.Task, error) {
+	var revisions []models.TaskRevision
+	if err := r.latestRevisions(asOf).Find(&revisions).Error; err != nil {
+		log.Error().Err(err).Time("as_of", asOf).Msg("Failed to find task revisions")
+		return nil, err
+	}
+
+	tasks := make([]models.Task, 0, len(revisions))
+	for _, revision := range revisions {
+		task, err := revision.Task()
+		if err != nil {
+			log.Error().Err(err).Uint("revision_id", revision.ID).Msg("Failed to decode task revision")
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// FindByIDAsOf returns the task as it was at asOf, or gorm.ErrRecordNotFound
+// if it had not been created yet or was deleted at that instant.
+func (r *taskRepository) FindByIDAsOf(id string, asOf time.Time) (models.Task, error) {
+	var revision models.TaskRevision
+	if err := r.latestRevisions(asOf).Where("task_id = ?", id).First(&revision).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Time("as_of", asOf).Msg("Failed to find task revision")
+		return models.Task{}, err
+	}
+	return revision.Task()
+}
+
+// latestRevisions selects, per task, the last revision at or before asOf,
+// skipping tasks whose last revision is a deletion.
+func (r *taskRepository) latestRevisions(asOf time.Time) *gorm.DB {
+	latest := r.db.Model(&models.TaskRevision{}).
+		Select("MAX(id)").
+		Where("revised_at <= ?", asOf).
+		Group("task_id")
+	return r.db.Model(&models.TaskRevision{}).
+		Where("id IN (?)", latest).
+		Where("operation <> ?", models.RevisionDeleted).
+		Order("task_id")
+}
+
+func (r *taskRepository) FindDueBetween(from, to time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := preloadAssociations(r.db).
+		Where("due_date BETWEEN ? AND ? AND status <> ?", from, to, models.StatusDone).
+		Order("due_date").
+		Find(&tasks).Error
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find tasks due soon")
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) FindRecentRevisions(id string, until time.Time, limit int) ([]models.TaskRevision, error) {
+	var revisions []models.TaskRevision
+	err := r.db.
+		Where("task_id = ? AND revised_at <= ?", id, until).
+		Order("revised_at DESC, id DESC").
+		Limit(limit).
+		Find(&revisions).Error
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find task revisions")
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func preloadAssociations(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Preload("CustomFieldValues.Field").
+		Preload("Members", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at, user_id")
+		})
+}
+
+// createTask inserts a task with its custom field values and initial
+// checklist, and records its first revision.
+func createTask(tx *gorm.DB, task models.Task) (models.Task, error) {
+	if err := assignPosition(tx, &task); err != nil {
+		return models.Task{}, err
+	}
+	if err := tx.Omit(clause.Associations).Create(&task).Error; err != nil {
+		return models.Task{}, err
+	}
+	if err := saveCustomFieldValues(tx, task); err != nil {
+		return models.Task{}, err
+	}
+	if len(task.Checklist) > 0 {
+		if err := tx.Create(&task.Checklist).Error; err != nil {
+			return models.Task{}, err
+		}
+	}
+	saved, err := reload(tx, task.ID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return saved, recordRevision(tx, saved, models.RevisionCreated, saved.UpdatedAt)
+}
+
+// updateTask saves a task with its custom field values and records a
+// revision.
+func updateTask(tx *gorm.DB, task models.Task) (models.Task, error) {
+	if err := assignPosition(tx, &task); err != nil {
+		return models.Task{}, err
+	}
+	if err := tx.Omit(clause.Associations).Save(&task).Error; err != nil {
+		return models.Task{}, err
+	}
+	if err := saveCustomFieldValues(tx, task); err != nil {
+		return models.Task{}, err
+	}
+	saved, err := reload(tx, task.ID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return saved, recordRevision(tx, saved, models.RevisionUpdated, saved.UpdatedAt)
+}
+
+// assignPosition places a task without a position after the last task of
+// its board column.
+func assignPosition(tx *gorm.DB, task *models.Task) error {
+	if task.Position != "" {
+		return nil
+	}
+	var last []string
+	err := inColumn(tx.Model(&models.Task{}), task.ProjectID, task.Status).
+		Where("id <> ?", task.ID).
+		Order("position DESC").
+		Limit(1).
+		Pluck("position", &last).Error
+	if err != nil {
+		return err
+	}
+	after := ""
+	if len(last) > 0 {
+		after = last[0]
+	}
+	position, err := rank.Between(after, "")
+	if err != nil {
+		return err
+	}
+	task.Position = position
+	return nil
+}
+
+func inColumn(db *gorm.DB, projectID *string, status string) *gorm.DB {
+	if projectID == nil {
+		db = db.Where("tasks.project_id IS NULL")
+	} else {
+		db = db.Where("tasks.project_id = ?", *projectID)
+	}
+	return db.Where("tasks.status = ?", status)
+}
+
+// columnOrder sorts a board column. Ties only occur for tasks that predate
+// positions and are broken by age.
+func columnOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("tasks.position").Order("tasks.created_at").Order("tasks.id")
+}
+
+// reload reads a task back with its associations inside a transaction.
+func reload(tx *gorm.DB, id string) (models.Task, error) {
+	var task models.Task
+	err := preloadAssociations(tx).First(&task, "id = ?", id).Error
+	return task, err
+}
+
+// saveCustomFieldValues replaces the stored custom field values of a task.
+// A nil slice means the caller did not touch custom fields.
+func saveCustomFieldValues(tx *gorm.DB, task models.Task) error {
+	if task.CustomFieldValues == nil {
+		return nil
+	}
+	if err := tx.Where("task_id = ?", task.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(task.CustomFieldValues) == 0 {
+		return nil
+	}
+	values := make([]models.CustomFieldValue, len(task.CustomFieldValues))
+	for i, value := range task.CustomFieldValues {
+		value.TaskID = task.ID
+		values[i] = value
+	}
+	return tx.Omit("Field").Create(&values).Error
+}
+
+func recordRevision(tx *gorm.DB, task models.Task, operation string, revisedAt time.Time) error {
+	revision, err := models.NewTaskRevision(task, operation, revisedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&revision).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type TemplateRepository interface {
+	FindAll() ([]models.TaskTemplate, error)
+	FindByID(id string) (models.TaskTemplate, error)
+	Create(template models.TaskTemplate) (models.TaskTemplate, error)
+	Update(template models.TaskTemplate) (models.TaskTemplate, error)
+	Delete(id string) error
+}
+
+type templateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+func (r *templateRepository) FindAll() ([]models.TaskTemplate, error) {
+	var templates []models.TaskTemplate
+	if err := r.db.Order("name").Find(&templates).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find all templates")
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *templateRepository) FindByID(id string) (models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	if err := r.db.First(&template, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find template")
+		return template, err
+	}
+	return template, nil
+}
+
+func (r *templateRepository) Create(template models.TaskTemplate) (models.TaskTemplate, error) {
+	if err := r.db.Create(&template).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create template")
+		return models.TaskTemplate{}, err
+	}
+	return template, nil
+}
+
+func (r *templateRepository) Update(template models.TaskTemplate) (models.TaskTemplate, error) {
+	if err := r.db.Save(&template).Error; err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to update template")
+		return models.TaskTemplate{}, err
+	}
+	return template, nil
+}
+
+func (r *templateRepository) Delete(id string) error {
+	result := r.db.Delete(&models.TaskTemplate{}, "id = ?", id)
+	if result.Error != nil {
+		log.Error().Err(result.Error).Str("id", id).Msg("Failed to delete template")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
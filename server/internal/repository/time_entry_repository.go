@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type TimeEntryRepository interface {
+	FindByID(id string) (models.TimeEntry, error)
+	// FindRunning returns the running timer of a user, or ErrNotFound.
+	FindRunning(userID string) (models.TimeEntry, error)
+	FindByTasks(taskIDs []string) ([]models.TimeEntry, error)
+	FindByProject(projectID string) ([]models.TimeEntry, error)
+	FindForTimesheet(filter TimesheetFilter) ([]models.TimesheetEntry, error)
+	Create(entry models.TimeEntry) (models.TimeEntry, error)
+	// Start stops the running timer of the entry's user, if any, and
+	// creates the entry in one transaction.
+	Start(entry models.TimeEntry) (models.TimeEntry, error)
+	Update(entry models.TimeEntry) (models.TimeEntry, error)
+	Delete(id string) error
+}
+
+// TimesheetFilter selects the entries started in [From, To), optionally
+// of one user or project.
+type TimesheetFilter struct {
+	From      time.Time
+	To        time.Time
+	UserID    string
+	ProjectID string
+}
+
+type timeEntryRepository struct {
+	db *gorm.DB
+}
+
+func NewTimeEntryRepository(db *gorm.DB) TimeEntryRepository {
+	return &timeEntryRepository{db: db}
+}
+
+func (r *timeEntryRepository) FindByID(id string) (models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := r.db.First(&entry, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find time entry")
+		return entry, err
+	}
+	return entry, nil
+}
+
+func (r *timeEntryRepository) FindRunning(userID string) (models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := r.db.First(&entry, "user_id = ? AND ended_at IS NULL", userID).Error
+	return entry, err
+}
+
+func (r *timeEntryRepository) FindByTasks(taskIDs []string) ([]models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	if len(taskIDs) == 0 {
+		return entries, nil
+	}
+	if err := r.db.Where("task_id IN ?", taskIDs).Order("started_at DESC").Find(&entries).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find time entries of tasks")
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *timeEntryRepository) FindByProject(projectID string) ([]models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	err := r.db.Joins("JOIN tasks ON tasks.id = time_entries.task_id").
+		Where("tasks.project_id = ?", projectID).
+		Find(&entries).Error
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find time entries of project")
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindForTimesheet labels entries with their user, project and task.
+// Entries of deleted tasks are kept without a project or title.
+func (r *timeEntryRepository) FindForTimesheet(filter TimesheetFilter) ([]models.TimesheetEntry, error) {
+	query := r.db.Table("time_entries").
+		Select("time_entries.*, users.username, tasks.project_id, projects.name AS project_name, tasks.title AS task_title").
+		Joins("LEFT JOIN users ON users.id = time_entries.user_id").
+		Joins("LEFT JOIN tasks ON tasks.id = time_entries.task_id").
+		Joins("LEFT JOIN projects ON projects.id = tasks.project_id").
+		Where("time_entries.started_at >= ? AND time_entries.started_at < ?", filter.From.UTC(), filter.To.UTC())
+	if filter.UserID != "" {
+		query = query.Where("time_entries.user_id = ?", filter.UserID)
+	}
+	if filter.ProjectID != "" {
+		query = query.Where("tasks.project_id = ?", filter.ProjectID)
+	}
+
+	var entries []models.TimesheetEntry
+	if err := query.Order("time_entries.started_at").Scan(&entries).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find time entries for timesheet")
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *timeEntryRepository) Create(entry models.TimeEntry) (models.TimeEntry, error) {
+	if err := r.db.Create(&entry).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create time entry")
+		return models.TimeEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *timeEntryRepository) Start(entry models.TimeEntry) (models.TimeEntry, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var running models.TimeEntry
+		err := tx.First(&running, "user_id = ? AND ended_at IS NULL", entry.UserID).Error
+		switch {
+		case err == nil:
+			stopped := entry.StartedAt
+			running.EndedAt = &stopped
+			running.Seconds = int64(stopped.Sub(running.StartedAt) / time.Second)
+			running.UpdatedAt = stopped
+			if err := tx.Save(&running).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, ErrNotFound):
+			return err
+		}
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", entry.UserID).Msg("Failed to start timer")
+		return models.TimeEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *timeEntryRepository) Update(entry models.TimeEntry) (models.TimeEntry, error) {
+	if err := r.db.Save(&entry).Error; err != nil {
+		log.Error().Err(err).Str("id", entry.ID).Msg("Failed to update time entry")
+		return models.TimeEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *timeEntryRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.TimeEntry{}, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete time entry")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	FindAll() ([]models.User, error)
+	FindByID(id string) (models.User, error)
+	FindByIDs(ids []string) ([]models.User, error)
+	FindByUsernames(usernames []string) ([]models.User, error)
+	FindByFeedTokenHash(hash string) (models.User, error)
+	Create(user models.User) (models.User, error)
+	Update(user models.User) (models.User, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindAll() ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Order("username").Find(&users).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find all users")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) FindByID(id string) (models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find user")
+		return user, err
+	}
+	return user, nil
+}
+
+// FindByIDs returns the users with the given IDs that exist, in no
+// particular order.
+func (r *userRepository) FindByIDs(ids []string) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find users by ID")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) FindByUsernames(usernames []string) ([]models.User, error) {
+	var users []models.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	if err := r.db.Where("LOWER(username) IN ?", usernames).Find(&users).Error; err != nil {
+		log.Error().Err(err).Strs("usernames", usernames).Msg("Failed to find users by username")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) FindByFeedTokenHash(hash string) (models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "feed_token_hash = ?", hash).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to find user by feed token")
+		return user, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) Create(user models.User) (models.User, error) {
+	if err := r.db.Create(&user).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create user")
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) Update(user models.User) (models.User, error) {
+	if err := r.db.Save(&user).Error; err != nil {
+		log.Error().Err(err).Str("id", user.ID).Msg("Failed to update user")
+		return models.User{}, err
+	}
+	return user, nil
+}
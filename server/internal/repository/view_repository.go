@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"taskmanager/internal/models"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ViewRepository interface {
+	FindVisible(userID string) ([]models.View, error)
+	FindByID(id string) (models.View, error)
+	Create(view models.View) (models.View, error)
+	Update(view models.View) (models.View, error)
+	Delete(id string) error
+}
+
+type viewRepository struct {
+	db *gorm.DB
+}
+
+func NewViewRepository(db *gorm.DB) ViewRepository {
+	return &viewRepository{db: db}
+}
+
+// FindVisible returns the views owned by a user and those shared with
+// everyone.
+func (r *viewRepository) FindVisible(userID string) ([]models.View, error) {
+	var views []models.View
+	if err := r.db.Where("owner_id = ? OR shared", userID).Order("name").Find(&views).Error; err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to find views")
+		return nil, err
+	}
+	return views, nil
+}
+
+func (r *viewRepository) FindByID(id string) (models.View, error) {
+	var view models.View
+	if err := r.db.First(&view, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find view")
+		return view, err
+	}
+	return view, nil
+}
+
+func (r *viewRepository) Create(view models.View) (models.View, error) {
+	if err := r.db.Create(&view).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to create view")
+		return models.View{}, err
+	}
+	return view, nil
+}
+
+func (r *viewRepository) Update(view models.View) (models.View, error) {
+	if err := r.db.Save(&view).Error; err != nil {
+		log.Error().Err(err).Str("id", view.ID).Msg("Failed to update view")
+		return models.View{}, err
+	}
+	return view, nil
+}
+
+func (r *viewRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.View{}, "id = ?", id).Error; err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete view")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"taskmanager/internal/controllers"
+	"taskmanager/internal/models"
+	"taskmanager/internal/openapi"
+	"taskmanager/internal/taskio"
+
+	"github.com/labstack/echo/v4"
+)
+
+// apiPrefix is the prefix of the routes the OpenAPI document describes
+const apiPrefix = "/api/v1/"
+
+// Query parameters shared by several endpoints
+var (
+	pageParams = []openapi.Param{
+		{Name: "page", Type: "integer", Description: "Page number, from 1"},
+		{Name: "page_size", Type: "integer", Description: "Items per page, at most 100"},
+	}
+	tzParam    = openapi.Param{Name: "tz", Description: "Time zone of days such as today, by default the user's"}
+	asOfParam  = openapi.Param{Name: "as_of", Format: "date-time", Description: "Return tasks as they were at this time"}
+	taskParams = []openapi.Param{
+		{Name: "project_id", Description: "Only tasks of this project"},
+		{Name: "q", Description: "Filter language expression, such as due<today tag:bug"},
+		{Name: "sort", Description: "Column to sort by, or cf.<key> for a custom field"},
+		{Name: "order", Enum: []string{"asc", "desc"}},
+		tzParam,
+		{Name: "assignee", Description: `A user ID, "me" or "none"`},
+	}
+)
+
+// taskListDescription documents the custom field filters, which have no
+// fixed names
+const taskListDescription = "Custom fields filter with cf.<key>=value or cf.<key>.<op>=value, which need project_id."
+
+// endpoints describes the handlers of the API routes by handler name.
+var endpoints = map[string]openapi.Endpoint{
+	// Tasks
+	"TaskHandler.GetAllTasks": {
+		Summary:     "List tasks",
+		Description: taskListDescription + " as_of cannot be combined with the filters.",
+		Query:       append(append([]openapi.Param{}, taskParams...), asOfParam),
+		Response:    []models.Task{},
+	},
+	"TaskHandler.GetTaskByID": {Summary: "Get a task", Query: []openapi.Param{asOfParam}, Response: models.Task{}},
+	"TaskHandler.CreateTask":  {Summary: "Create a task", Body: models.CreateTaskInput{}, Response: models.Task{}, Status: http.StatusCreated},
+	"TaskHandler.UpdateTask":  {Summary: "Update a task", Body: models.UpdateTaskInput{}, Response: models.Task{}},
+	"TaskHandler.DeleteTask": {
+		Summary: "Delete a task",
+		Query:   []openapi.Param{{Name: "permanent", Type: "boolean", Description: "Remove the task for good with its history and attachments"}},
+	},
+	"TaskHandler.RestoreTask":  {Summary: "Restore a deleted task", Response: models.Task{}},
+	"TaskHandler.MoveTask":     {Summary: "Move a task on the board", Body: models.MoveTaskInput{}, Response: models.Task{}},
+	"TaskHandler.GetBoard":     {Summary: "Get the board of a project", Response: models.Board{}},
+	"QuickAddHandler.QuickAdd": {Summary: "Create a task from a sentence", Body: models.QuickAddInput{}, Response: models.QuickAddResult{}, Status: http.StatusCreated},
+	"TaskIOHandler.ExportTasks": {
+		Summary:     "Export tasks",
+		Description: taskListDescription + " CSV and Markdown exports of a project have a cf.<key> column per custom field.",
+		Query:       append([]openapi.Param{{Name: "format", Enum: taskio.Formats}}, taskParams...),
+		Produces:    exportTypes(),
+	},
+	"TaskIOHandler.ImportTasks": {
+		Summary: "Import tasks from a file",
+		Query: []openapi.Param{
+			{Name: "format", Enum: taskio.Formats, Description: "By default taken from the content type or file name"},
+			{Name: "mode", Enum: []string{models.ImportAtomic, models.ImportPartial}},
+			{Name: "dry_run", Type: "boolean"},
+			{Name: "mapping", Description: "JSON object of source columns to task fields"},
+		},
+		Upload:   openapi.FileUpload,
+		Response: models.ImportResult{},
+	},
+
+	// Assignees and watchers
+	"TaskMemberHandler.GetMembers": {Summary: "List the assignees and watchers of a task", Response: models.TaskMembers{}},
+	"TaskMemberHandler.Assign":     {Summary: "Assign a user to a task", Body: models.TaskMemberInput{}, Response: models.TaskMembers{}, User: true},
+	"TaskMemberHandler.Unassign":   {Summary: "Unassign a user from a task", Response: models.TaskMembers{}, User: true},
+	"TaskMemberHandler.Watch":      {Summary: "Add a watcher to a task", Body: models.TaskMemberInput{}, Response: models.TaskMembers{}, User: true},
+	"TaskMemberHandler.Unwatch":    {Summary: "Remove a watcher from a task", Response: models.TaskMembers{}, User: true},
+
+	// Comments
+	"CommentHandler.ListComments":      {Summary: "List the comments of a task", Query: pageParams, Response: models.CommentPage{}},
+	"CommentHandler.CreateComment":     {Summary: "Comment on a task", Body: models.CreateCommentInput{}, Response: models.Comment{}, Status: http.StatusCreated, User: true},
+	"CommentHandler.UpdateComment":     {Summary: "Edit a comment", Body: models.UpdateCommentInput{}, Response: models.Comment{}, User: true},
+	"CommentHandler.DeleteComment":     {Summary: "Delete a comment", User: true},
+	"CommentHandler.GetCommentHistory": {Summary: "List the revisions of a comment", Response: []models.CommentRevision{}},
+
+	// Attachments
+	"AttachmentHandler.ListAttachments":  {Summary: "List the attachments of a task", Response: []models.Attachment{}},
+	"AttachmentHandler.UploadAttachment": {Summary: "Attach a file to a task", Upload: openapi.MultipartUpload, Response: models.Attachment{}, Status: http.StatusCreated, User: true},
+	"AttachmentHandler.DeleteAttachment": {Summary: "Delete an attachment", User: true},
+	"AttachmentHandler.GetDownloadURL":   {Summary: "Sign a download URL for an attachment", Response: models.DownloadURL{}},
+	"AttachmentHandler.DownloadAttachment": {
+		Summary: "Download an attachment",
+		Query: []openapi.Param{
+			{Name: "expires", Required: true},
+			{Name: "signature", Required: true},
+		},
+		Produces: []string{echo.MIMEOctetStream},
+	},
+
+	// Checklists
+	"ChecklistHandler.ListItems":    {Summary: "List the checklist of a task", Response: []models.ChecklistItem{}},
+	"ChecklistHandler.AddItem":      {Summary: "Add a checklist item", Body: models.CreateChecklistItemInput{}, Response: models.ChecklistItem{}, Status: http.StatusCreated},
+	"ChecklistHandler.ReorderItems": {Summary: "Reorder the checklist of a task", Body: models.ReorderChecklistInput{}, Response: []models.ChecklistItem{}},
+	"ChecklistHandler.UpdateItem":   {Summary: "Update a checklist item", Body: models.UpdateChecklistItemInput{}, Response: models.ChecklistItem{}},
+	"ChecklistHandler.ToggleItem":   {Summary: "Tick or untick a checklist item", Response: models.ChecklistItem{}},
+	"ChecklistHandler.ConvertItem":  {Summary: "Turn a checklist item into a subtask", Response: models.Task{}, Status: http.StatusCreated},
+	"ChecklistHandler.DeleteItem":   {Summary: "Delete a checklist item"},
+
+	// Time tracking
+	"TimeHandler.StartTimer": {Summary: "Start a timer on a task", Body: models.TimerInput{}, Response: models.TimeEntry{}, Status: http.StatusCreated, User: true},
+	"TimeHandler.StopTimer":  {Summary: "Stop the timer on a task", Response: models.TimeEntry{}, User: true},
+	"TimeHandler.TaskTime":   {Summary: "Total the time spent on a task", Response: models.TaskTime{}},
+	"TimeHandler.AddEntry":   {Summary: "Log time on a task", Body: models.TimeEntryInput{}, Response: models.TimeEntry{}, Status: http.StatusCreated, User: true},
+	"TimeHandler.UpdateEntry": {
+		Summary: "Update a time entry", Body: models.UpdateTimeEntryInput{}, Response: models.TimeEntry{}, User: true,
+	},
+	"TimeHandler.DeleteEntry": {Summary: "Delete a time entry", User: true},
+	"TimeHandler.RunningTimer": {
+		Summary:     "Get the running timer",
+		Description: "Answers 204 when no timer runs.",
+		Response:    models.TimeEntry{},
+		User:        true,
+	},
+	"TimeHandler.Timesheet": {
+		Summary: "Total time entries",
+		Query: []openapi.Param{
+			{Name: "from", Format: "date", Required: true},
+			{Name: "to", Format: "date", Required: true},
+			{Name: "group_by", Description: "Comma-separated: day, week, user, project, task"},
+			{Name: "user_id"},
+			{Name: "project_id"},
+			tzParam,
+			{Name: "format", Enum: []string{"json", "csv"}},
+		},
+		Response: models.Timesheet{},
+		Produces: []string{"text/csv"},
+	},
+	"TimeHandler.ProjectTime": {Summary: "Total the time spent on a project", Response: models.ProjectTime{}},
+
+	// Projects
+	"ProjectHandler.GetAllProjects": {Summary: "List projects", Response: []models.Project{}},
+	"ProjectHandler.GetProjectByID": {Summary: "Get a project", Response: models.Project{}},
+	"ProjectHandler.CreateProject":  {Summary: "Create a project", Body: models.ProjectInput{}, Response: models.Project{}, Status: http.StatusCreated},
+	"ProjectHandler.UpdateProject":  {Summary: "Update a project", Body: models.ProjectInput{}, Response: models.Project{}},
+	"ProjectHandler.DeleteProject":  {Summary: "Delete a project"},
+	"ProjectHandler.ListFields":     {Summary: "List the custom fields of a project", Response: []models.CustomField{}},
+	"ProjectHandler.CreateField":    {Summary: "Add a custom field", Body: models.CreateCustomFieldInput{}, Response: models.CustomField{}, Status: http.StatusCreated},
+	"ProjectHandler.UpdateField":    {Summary: "Update a custom field", Body: models.UpdateCustomFieldInput{}, Response: models.CustomField{}},
+	"ProjectHandler.DeleteField":    {Summary: "Delete a custom field"},
+
+	// Sprints
+	"SprintHandler.ListSprints":  {Summary: "List the sprints of a project", Response: []models.Sprint{}},
+	"SprintHandler.CreateSprint": {Summary: "Create a sprint", Body: models.SprintInput{}, Response: models.Sprint{}, Status: http.StatusCreated},
+	"SprintHandler.GetSprint":    {Summary: "Get a sprint", Response: models.Sprint{}},
+	"SprintHandler.UpdateSprint": {Summary: "Update a sprint", Body: models.SprintInput{}, Response: models.Sprint{}},
+	"SprintHandler.DeleteSprint": {Summary: "Delete a sprint"},
+	"SprintHandler.AddTasks":     {Summary: "Add tasks to a sprint", Body: models.SprintTasksInput{}, Response: []models.Task{}},
+	"SprintHandler.CloseSprint":  {Summary: "Close a sprint", Body: models.CloseSprintInput{}, Response: models.CloseSprintResult{}},
+	"SprintHandler.Burndown":     {Summary: "Get the burndown of a sprint", Query: []openapi.Param{tzParam}, Response: models.Burndown{}},
+
+	// Templates
+	"TemplateHandler.GetAllTemplates":     {Summary: "List task templates", Response: []models.TaskTemplate{}},
+	"TemplateHandler.GetTemplateByID":     {Summary: "Get a task template", Response: models.TaskTemplate{}},
+	"TemplateHandler.CreateTemplate":      {Summary: "Create a task template", Body: models.TemplateInput{}, Response: models.TaskTemplate{}, Status: http.StatusCreated},
+	"TemplateHandler.UpdateTemplate":      {Summary: "Update a task template", Body: models.TemplateInput{}, Response: models.TaskTemplate{}},
+	"TemplateHandler.DeleteTemplate":      {Summary: "Delete a task template"},
+	"TemplateHandler.InstantiateTemplate": {Summary: "Create the tasks of a template", Body: models.InstantiateTemplateInput{}, Response: []models.Task{}, Status: http.StatusCreated},
+
+	// Saved views
+	"ViewHandler.ListViews":    {Summary: "List saved views", Response: []models.View{}, User: true},
+	"ViewHandler.GetView":      {Summary: "Get a saved view", Response: models.View{}, User: true},
+	"ViewHandler.CreateView":   {Summary: "Save a view", Body: models.ViewInput{}, Response: models.View{}, Status: http.StatusCreated, User: true},
+	"ViewHandler.UpdateView":   {Summary: "Update a saved view", Body: models.ViewInput{}, Response: models.View{}, User: true},
+	"ViewHandler.DeleteView":   {Summary: "Delete a saved view", User: true},
+	"ViewHandler.GetViewTasks": {Summary: "List the tasks of a saved view", Query: []openapi.Param{tzParam}, Response: []models.Task{}, User: true},
+
+	// Business calendars
+	"CalendarHandler.GetAllCalendars": {Summary: "List business calendars", Response: []models.Calendar{}},
+	"CalendarHandler.GetCalendarByID": {Summary: "Get a business calendar", Response: models.Calendar{}},
+	"CalendarHandler.CreateCalendar":  {Summary: "Create a business calendar", Body: models.CalendarInput{}, Response: models.Calendar{}, Status: http.StatusCreated},
+	"CalendarHandler.UpdateCalendar":  {Summary: "Update a business calendar", Body: models.CalendarInput{}, Response: models.Calendar{}},
+	"CalendarHandler.DeleteCalendar":  {Summary: "Delete a business calendar"},
+	"CalendarHandler.AddHoliday":      {Summary: "Add a holiday", Body: models.HolidayInput{}, Response: models.Holiday{}, Status: http.StatusCreated},
+	"CalendarHandler.ImportHolidays":  {Summary: "Import holidays from an iCalendar file", Upload: openapi.FileUpload, Response: models.HolidayImport{}},
+	"CalendarHandler.DeleteHoliday":   {Summary: "Delete a holiday"},
+	"CalendarHandler.BusinessDays": {
+		Summary: "Add working days to a date",
+		Query: []openapi.Param{
+			{Name: "from", Format: "date", Required: true},
+			{Name: "days", Type: "integer", Required: true},
+		},
+		Response: models.BusinessDaysResult{},
+	},
+	"CalendarHandler.WorkingTime": {
+		Summary:  "Tell whether a time is working time",
+		Query:    []openapi.Param{{Name: "at", Format: "date-time", Description: "By default now"}},
+		Response: models.WorkingTimeResult{},
+	},
+
+	// SLA policies
+	"SLAHandler.ListPolicies": {Summary: "List the SLA policies of a project", Response: []models.SLAPolicy{}},
+	"SLAHandler.CreatePolicy": {Summary: "Create an SLA policy", Body: models.SLAPolicyInput{}, Response: models.SLAPolicy{}, Status: http.StatusCreated},
+	"SLAHandler.ListTaskStatuses": {
+		Summary:  "List the SLA status of the tasks of a project",
+		Query:    []openapi.Param{{Name: "state", Enum: []string{models.SLAAtRisk, models.SLABreached}}},
+		Response: []models.SLAStatus{},
+	},
+	"SLAHandler.GetPolicy":    {Summary: "Get an SLA policy", Response: models.SLAPolicy{}},
+	"SLAHandler.UpdatePolicy": {Summary: "Update an SLA policy", Body: models.SLAPolicyInput{}, Response: models.SLAPolicy{}},
+	"SLAHandler.DeletePolicy": {Summary: "Delete an SLA policy"},
+
+	// Calendar feeds and CalDAV
+	"FeedHandler.IssueToken":  {Summary: "Issue a calendar feed URL", Response: models.FeedToken{}, Status: http.StatusCreated, User: true},
+	"FeedHandler.RevokeToken": {Summary: "Revoke the calendar feed URL", User: true},
+	"FeedHandler.TaskFeed": {
+		Summary: "Get the iCalendar feed of a token",
+		Query: []openapi.Param{
+			{Name: "project_id"},
+			{Name: "tag"},
+			{Name: "assignee", Description: `A user ID, "me" (the default), "none" or "any"`},
+			{Name: "events", Type: "boolean", Description: "Write events instead of to-dos"},
+		},
+		Produces: []string{"text/calendar"},
+	},
+	"CalDAVHandler.IssuePassword":  {Summary: "Issue a CalDAV password", Response: models.DAVCredentials{}, Status: http.StatusCreated, User: true},
+	"CalDAVHandler.RevokePassword": {Summary: "Revoke the CalDAV password", User: true},
+
+	// Imports from other tools
+	"ToolImportHandler.ListSources": {
+		Summary: "List the tools tasks can be imported from",
+		Response: struct {
+			Sources []string `json:"sources"`
+		}{},
+	},
+	"ToolImportHandler.Import": {
+		Summary: "Import the export of another tool",
+		Query: []openapi.Param{
+			{Name: "project_id", Description: "Put every task in this project"},
+			{Name: "project_name", Description: "Project of the tasks outside any project of the source"},
+			{Name: "dry_run", Type: "boolean"},
+		},
+		Upload:   openapi.FileUpload,
+		Response: models.ToolImportReport{},
+	},
+
+	// Automation rules
+	"AutomationHandler.ListRules":  {Summary: "List automation rules", Response: []models.AutomationRule{}},
+	"AutomationHandler.GetRule":    {Summary: "Get an automation rule", Response: models.AutomationRule{}},
+	"AutomationHandler.CreateRule": {Summary: "Create an automation rule", Body: models.AutomationRuleInput{}, Response: models.AutomationRule{}, Status: http.StatusCreated},
+	"AutomationHandler.UpdateRule": {Summary: "Update an automation rule", Body: models.AutomationRuleInput{}, Response: models.AutomationRule{}},
+	"AutomationHandler.DeleteRule": {Summary: "Delete an automation rule"},
+	"AutomationHandler.DryRun":     {Summary: "Try an automation rule on a task", Body: models.DryRunInput{}, Response: models.DryRunResult{}},
+	"AutomationHandler.ListRuns": {
+		Summary:     "List the runs of an automation rule",
+		Description: "Task changes wait in a queue of 1024 for the rules to run. Changes arriving while it is full are dropped, and the rules they would have run log a failed run.",
+		Query:       pageParams,
+		Response:    models.AutomationRunPage{},
+	},
+
+	// Notifications
+	"NotificationHandler.ListNotifications": {
+		Summary:  "List notifications",
+		Query:    append([]openapi.Param{{Name: "unread", Type: "boolean", Description: "Only unread notifications"}}, pageParams...),
+		Response: models.NotificationPage{},
+		User:     true,
+	},
+	"NotificationHandler.UnreadCount": {Summary: "Count unread notifications", Response: models.UnreadCount{}, User: true},
+	"NotificationHandler.Stream": {
+		Summary:     "Stream notifications",
+		Description: "Server-sent events of new notifications and of the unread count, which is sent first.",
+		Produces:    []string{"text/event-stream"},
+		User:        true,
+	},
+	"NotificationHandler.MarkAllRead": {Summary: "Mark all notifications read", Response: models.UnreadCount{}, User: true},
+	"NotificationHandler.MarkRead":    {Summary: "Mark a notification read", Response: models.Notification{}, User: true},
+	"NotificationHandler.MarkUnread":  {Summary: "Mark a notification unread", Response: models.Notification{}, User: true},
+
+	// Search
+	"SearchHandler.Search": {
+		Summary: "Search tasks",
+		Query: append([]openapi.Param{
+			{Name: "q", Required: true, Description: "Words every task found contains"},
+			{Name: "project_id"},
+		}, pageParams...),
+		Response: models.SearchResults{},
+		User:     true,
+	},
+
+	// Users
+	"UserHandler.GetAllUsers": {Summary: "List users", Response: []models.User{}},
+	"UserHandler.GetUserByID": {Summary: "Get a user", Response: models.User{}},
+	"UserHandler.CreateUser":  {Summary: "Create a user", Body: models.CreateUserInput{}, Response: models.User{}, Status: http.StatusCreated},
+	"UserHandler.UpdateUser":  {Summary: "Update a user", Body: models.UpdateUserInput{}, Response: models.User{}},
+}
+
+// exportTypes lists the media types tasks are exported as.
+func exportTypes() []string {
+	types := make([]string, len(taskio.Formats))
+	for i, format := range taskio.Formats {
+		types[i] = taskio.ContentType(format)
+	}
+	return types
+}
+
+// OpenAPI describes the API routes registered on e.
+func OpenAPI(e *echo.Echo) (*openapi.Document, error) {
+	spec := openapi.New(openapi.Info{
+		Title:   "Task Manager API",
+		Version: "1.0.0",
+	})
+	// Tasks marshal their due date by hand
+	spec.Extend(models.Task{}, map[string]*openapi.Schema{
+		"due_date": openapi.Nullable(&openapi.Schema{
+			Type:        "string",
+			Description: "YYYY-MM-DD for tasks due all day, else an RFC 3339 time",
+		}),
+		"due_time_zone": openapi.Nullable(&openapi.Schema{
+			Type:        "string",
+			Description: "Time zone of timed due dates",
+		}),
+	})
+	return spec.Build(e.Routes(), apiPrefix, endpoints)
+}
+
+// RegisterDocs serves the OpenAPI document of the routes registered on e,
+// and Swagger UI to browse it. It fails when the document does not match
+// the routes.
+func RegisterDocs(e *echo.Echo) error {
+	doc, err := OpenAPI(e)
+	if err != nil {
+		return err
+	}
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	docs := controllers.NewDocsHandler(spec)
+	e.GET(controllers.OpenAPIPath, docs.Spec)
+	e.GET("/api/docs", docs.UI)
+	return nil
+}
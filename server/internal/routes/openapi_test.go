@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"taskmanager/internal/openapi"
+
+	"github.com/labstack/echo/v4"
+)
+
+// routedOperations lists the API routes of e as "METHOD /path", with path
+// parameters written the OpenAPI way.
+func routedOperations(e *echo.Echo) []string {
+	var operations []string
+	for _, route := range e.Routes() {
+		if route.Method == echo.RouteNotFound || !strings.HasPrefix(route.Path, apiPrefix) {
+			continue
+		}
+		path := route.Path
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") {
+				path = strings.Replace(path, segment, "{"+segment[1:]+"}", 1)
+			}
+		}
+		operations = append(operations, route.Method+" "+path)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// documentedOperations lists the operations of doc as "METHOD /path".
+func documentedOperations(doc *openapi.Document) []string {
+	var operations []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// TestOpenAPIMatchesRoutes fails on any API route the document leaves
+// out, and on any documented path with no handler.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	e := echo.New()
+	RegisterRoutes(e, Handlers{})
+	doc, err := OpenAPI(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routed := routedOperations(e)
+	documented := documentedOperations(doc)
+	inDoc := make(map[string]bool, len(documented))
+	for _, operation := range documented {
+		inDoc[operation] = true
+	}
+	inRoutes := make(map[string]bool, len(routed))
+	for _, operation := range routed {
+		inRoutes[operation] = true
+		if !inDoc[operation] {
+			t.Errorf("route %s is not documented", operation)
+		}
+	}
+	for _, operation := range documented {
+		if !inRoutes[operation] {
+			t.Errorf("documented %s has no handler", operation)
+		}
+	}
+	if len(routed) == 0 {
+		t.Error("no API routes registered")
+	}
+}
+
+// TestOpenAPIDrift checks that building the document fails when the
+// endpoints and the routes disagree.
+func TestOpenAPIDrift(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(e *echo.Echo, endpoints map[string]openapi.Endpoint)
+		problem string
+	}{
+		{
+			name: "route without endpoint",
+			change: func(e *echo.Echo, endpoints map[string]openapi.Endpoint) {
+				delete(endpoints, "SprintHandler.Burndown")
+			},
+			problem: "GET /api/v1/sprints/:id/burndown: no endpoint describes SprintHandler.Burndown",
+		},
+		{
+			name: "endpoint without route",
+			change: func(e *echo.Echo, endpoints map[string]openapi.Endpoint) {
+				endpoints["TaskHandler.ArchiveTask"] = openapi.Endpoint{Summary: "Archive a task"}
+			},
+			problem: "endpoint TaskHandler.ArchiveTask has no route",
+		},
+		{
+			name: "undocumented handler on a new route",
+			change: func(e *echo.Echo, endpoints map[string]openapi.Endpoint) {
+				e.GET(apiPrefix+"health", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+			},
+			problem: "GET /api/v1/health: no endpoint describes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			RegisterRoutes(e, Handlers{})
+			described := make(map[string]openapi.Endpoint, len(endpoints))
+			for name, endpoint := range endpoints {
+				described[name] = endpoint
+			}
+			tt.change(e, described)
+
+			_, err := openapi.New(openapi.Info{Title: "test"}).Build(e.Routes(), apiPrefix, described)
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("error %v, want one with %q", err, tt.problem)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"strings"
 	"taskmanager/internal/controllers"
 
 	"github.com/labstack/echo/v4"
@@ -9,7 +10,33 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func RegisterRoutes(e *echo.Echo, taskHandler *controllers.TaskHandler) {
+// Handlers groups the HTTP handlers served by the API.
+type Handlers struct {
+	Task         *controllers.TaskHandler
+	Comment      *controllers.CommentHandler
+	Attachment   *controllers.AttachmentHandler
+	Checklist    *controllers.ChecklistHandler
+	Project      *controllers.ProjectHandler
+	User         *controllers.UserHandler
+	Template     *controllers.TemplateHandler
+	View         *controllers.ViewHandler
+	Search       *controllers.SearchHandler
+	QuickAdd     *controllers.QuickAddHandler
+	Calendar     *controllers.CalendarHandler
+	Time         *controllers.TimeHandler
+	Sprint       *controllers.SprintHandler
+	Member       *controllers.TaskMemberHandler
+	Notification *controllers.NotificationHandler
+	Automation   *controllers.AutomationHandler
+	SLA          *controllers.SLAHandler
+	TaskIO       *controllers.TaskIOHandler
+	ToolImport   *controllers.ToolImportHandler
+	Feed         *controllers.FeedHandler
+	CalDAV       *controllers.CalDAVHandler
+	GraphQL      *controllers.GraphQLHandler
+}
+
+func RegisterRoutes(e *echo.Echo, h Handlers) {
 	// Configuring CORS middleware
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"http://localhost:5173", "http://127.0.0.1:5173"},
@@ -18,6 +45,7 @@ func RegisterRoutes(e *echo.Echo, taskHandler *controllers.TaskHandler) {
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
+			controllers.HeaderUserID,
 		},
 		AllowMethods: []string{
 			http.MethodGet,
@@ -31,18 +59,203 @@ func RegisterRoutes(e *echo.Echo, taskHandler *controllers.TaskHandler) {
 				Str("path", c.Path()).
 				Str("origin", c.Request().Header.Get("Origin")).
 				Msg("CORS middleware triggered")
-			return false
+			// CalDAV clients send OPTIONS requests that are not preflights
+			return c.Request().URL.Path == controllers.DAVPath || strings.HasPrefix(c.Request().URL.Path, controllers.DAVPath+"/")
 		},
 	}))
 
 	// Setting up API routes
-	api := e.Group("/api/v1")
+	api := e.Group("/api/v1", h.User.ResolveTimeZone)
 	tasks := api.Group("/tasks")
+	projects := api.Group("/projects")
+	users := api.Group("/users")
+	templates := api.Group("/templates")
+	views := api.Group("/views")
+	calendars := api.Group("/calendars")
+	timeEntries := api.Group("/time-entries")
+	sprints := api.Group("/sprints")
+	automations := api.Group("/automations")
+	slaPolicies := api.Group("/sla-policies")
+	imports := api.Group("/imports")
+	notifications := api.Group("/notifications", controllers.RequireUser())
+	requireUser := controllers.RequireUser()
 
 	// Task routes
-	tasks.GET("", taskHandler.GetAllTasks)
-	tasks.GET("/:id", taskHandler.GetTaskByID)
-	tasks.POST("", taskHandler.CreateTask)
-	tasks.PUT("/:id", taskHandler.UpdateTask)
-	tasks.DELETE("/:id", taskHandler.DeleteTask)
+	tasks.GET("", h.Task.GetAllTasks)
+	tasks.GET("/:id", h.Task.GetTaskByID)
+	tasks.POST("", h.Task.CreateTask)
+	tasks.POST("/quick", h.QuickAdd.QuickAdd)
+	tasks.GET("/export", h.TaskIO.ExportTasks)
+	tasks.POST("/import", h.TaskIO.ImportTasks)
+	tasks.PUT("/:id", h.Task.UpdateTask)
+	tasks.DELETE("/:id", h.Task.DeleteTask)
+	tasks.POST("/:id/restore", h.Task.RestoreTask)
+	tasks.POST("/:id/move", h.Task.MoveTask)
+
+	// Assignee and watcher routes
+	tasks.GET("/:id/members", h.Member.GetMembers)
+	tasks.POST("/:id/assignees", h.Member.Assign, requireUser)
+	tasks.DELETE("/:id/assignees/:userId", h.Member.Unassign, requireUser)
+	tasks.POST("/:id/watchers", h.Member.Watch, requireUser)
+	tasks.DELETE("/:id/watchers/:userId", h.Member.Unwatch, requireUser)
+
+	// Comment routes
+	tasks.GET("/:id/comments", h.Comment.ListComments)
+	tasks.POST("/:id/comments", h.Comment.CreateComment, requireUser)
+	tasks.PUT("/:id/comments/:commentId", h.Comment.UpdateComment, requireUser)
+	tasks.DELETE("/:id/comments/:commentId", h.Comment.DeleteComment, requireUser)
+	tasks.GET("/:id/comments/:commentId/history", h.Comment.GetCommentHistory)
+
+	// Attachment routes
+	tasks.GET("/:id/attachments", h.Attachment.ListAttachments)
+	tasks.POST("/:id/attachments", h.Attachment.UploadAttachment, requireUser)
+	tasks.DELETE("/:id/attachments/:attachmentId", h.Attachment.DeleteAttachment, requireUser)
+	tasks.GET("/:id/attachments/:attachmentId/url", h.Attachment.GetDownloadURL)
+	api.GET("/attachments/:id/download", h.Attachment.DownloadAttachment)
+
+	// Checklist routes
+	tasks.GET("/:id/checklist", h.Checklist.ListItems)
+	tasks.POST("/:id/checklist", h.Checklist.AddItem)
+	tasks.PUT("/:id/checklist/order", h.Checklist.ReorderItems)
+	tasks.PUT("/:id/checklist/:itemId", h.Checklist.UpdateItem)
+	tasks.POST("/:id/checklist/:itemId/toggle", h.Checklist.ToggleItem)
+	tasks.POST("/:id/checklist/:itemId/convert", h.Checklist.ConvertItem)
+	tasks.DELETE("/:id/checklist/:itemId", h.Checklist.DeleteItem)
+
+	// Time tracking routes
+	tasks.POST("/:id/timer/start", h.Time.StartTimer, requireUser)
+	tasks.POST("/:id/timer/stop", h.Time.StopTimer, requireUser)
+	tasks.GET("/:id/time", h.Time.TaskTime)
+	tasks.POST("/:id/time", h.Time.AddEntry, requireUser)
+	timeEntries.PUT("/:id", h.Time.UpdateEntry, requireUser)
+	timeEntries.DELETE("/:id", h.Time.DeleteEntry, requireUser)
+	api.GET("/timer", h.Time.RunningTimer, requireUser)
+	api.GET("/timesheet", h.Time.Timesheet)
+	projects.GET("/:id/time", h.Time.ProjectTime)
+
+	// Project routes
+	projects.GET("", h.Project.GetAllProjects)
+	projects.GET("/:id", h.Project.GetProjectByID)
+	projects.POST("", h.Project.CreateProject)
+	projects.PUT("/:id", h.Project.UpdateProject)
+	projects.DELETE("/:id", h.Project.DeleteProject)
+	projects.GET("/:id/board", h.Task.GetBoard)
+
+	// Sprint routes
+	projects.GET("/:id/sprints", h.Sprint.ListSprints)
+	projects.POST("/:id/sprints", h.Sprint.CreateSprint)
+	sprints.GET("/:id", h.Sprint.GetSprint)
+	sprints.PUT("/:id", h.Sprint.UpdateSprint)
+	sprints.DELETE("/:id", h.Sprint.DeleteSprint)
+	sprints.POST("/:id/tasks", h.Sprint.AddTasks)
+	sprints.POST("/:id/close", h.Sprint.CloseSprint)
+	sprints.GET("/:id/burndown", h.Sprint.Burndown)
+
+	// Custom field routes
+	projects.GET("/:id/fields", h.Project.ListFields)
+	projects.POST("/:id/fields", h.Project.CreateField)
+	projects.PUT("/:id/fields/:fieldId", h.Project.UpdateField)
+	projects.DELETE("/:id/fields/:fieldId", h.Project.DeleteField)
+
+	// Template routes
+	templates.GET("", h.Template.GetAllTemplates)
+	templates.GET("/:id", h.Template.GetTemplateByID)
+	templates.POST("", h.Template.CreateTemplate)
+	templates.PUT("/:id", h.Template.UpdateTemplate)
+	templates.DELETE("/:id", h.Template.DeleteTemplate)
+	templates.POST("/:id/instantiate", h.Template.InstantiateTemplate)
+
+	// Saved view routes
+	views.GET("", h.View.ListViews, requireUser)
+	views.GET("/:id", h.View.GetView, requireUser)
+	views.POST("", h.View.CreateView, requireUser)
+	views.PUT("/:id", h.View.UpdateView, requireUser)
+	views.DELETE("/:id", h.View.DeleteView, requireUser)
+	views.GET("/:id/tasks", h.View.GetViewTasks, requireUser)
+
+	// Business calendar routes
+	calendars.GET("", h.Calendar.GetAllCalendars)
+	calendars.GET("/:id", h.Calendar.GetCalendarByID)
+	calendars.POST("", h.Calendar.CreateCalendar)
+	calendars.PUT("/:id", h.Calendar.UpdateCalendar)
+	calendars.DELETE("/:id", h.Calendar.DeleteCalendar)
+	calendars.POST("/:id/holidays", h.Calendar.AddHoliday)
+	calendars.POST("/:id/holidays/import", h.Calendar.ImportHolidays)
+	calendars.DELETE("/:id/holidays/:holidayId", h.Calendar.DeleteHoliday)
+	calendars.GET("/:id/business-days", h.Calendar.BusinessDays)
+	calendars.GET("/:id/working-time", h.Calendar.WorkingTime)
+
+	// SLA routes
+	projects.GET("/:id/sla-policies", h.SLA.ListPolicies)
+	projects.POST("/:id/sla-policies", h.SLA.CreatePolicy)
+	projects.GET("/:id/sla", h.SLA.ListTaskStatuses)
+	slaPolicies.GET("/:id", h.SLA.GetPolicy)
+	slaPolicies.PUT("/:id", h.SLA.UpdatePolicy)
+	slaPolicies.DELETE("/:id", h.SLA.DeletePolicy)
+
+	// Calendar feed routes; the feed itself is authorized by its token
+	api.POST("/feed-token", h.Feed.IssueToken, requireUser)
+	api.DELETE("/feed-token", h.Feed.RevokeToken, requireUser)
+	api.GET("/feeds/:token/tasks.ics", h.Feed.TaskFeed)
+
+	// CalDAV password routes
+	api.POST("/dav-password", h.CalDAV.IssuePassword, requireUser)
+	api.DELETE("/dav-password", h.CalDAV.RevokePassword, requireUser)
+
+	// Routes importing from other tools
+	imports.GET("", h.ToolImport.ListSources)
+	imports.POST("/:source", h.ToolImport.Import)
+
+	// Automation rule routes
+	automations.GET("", h.Automation.ListRules)
+	automations.GET("/:id", h.Automation.GetRule)
+	automations.POST("", h.Automation.CreateRule)
+	automations.PUT("/:id", h.Automation.UpdateRule)
+	automations.DELETE("/:id", h.Automation.DeleteRule)
+	automations.GET("/:id/runs", h.Automation.ListRuns)
+	automations.POST("/:id/dry-run", h.Automation.DryRun)
+
+	// Notification inbox routes
+	notifications.GET("", h.Notification.ListNotifications)
+	notifications.GET("/unread-count", h.Notification.UnreadCount)
+	notifications.GET("/stream", h.Notification.Stream)
+	notifications.POST("/read-all", h.Notification.MarkAllRead)
+	notifications.POST("/:id/read", h.Notification.MarkRead)
+	notifications.POST("/:id/unread", h.Notification.MarkUnread)
+
+	// Search routes
+	api.GET("/search", h.Search.Search, requireUser)
+
+	// User routes
+	users.GET("", h.User.GetAllUsers)
+	users.GET("/:id", h.User.GetUserByID)
+	users.POST("", h.User.CreateUser)
+	users.PUT("/:id", h.User.UpdateUser)
+
+	// GraphQL routes
+	e.GET(controllers.GraphQLPath, h.GraphQL.Serve, h.User.ResolveTimeZone)
+	e.POST(controllers.GraphQLPath, h.GraphQL.Serve, h.User.ResolveTimeZone)
+
+	// CalDAV routes, signed in with the username and CalDAV password
+	e.Any("/.well-known/caldav", h.CalDAV.WellKnown)
+	dav := e.Group(controllers.DAVPath, middleware.BasicAuthWithConfig(middleware.BasicAuthConfig{
+		Validator: h.CalDAV.Authenticate,
+		Realm:     "taskmanager",
+	}))
+	// Collections are found with and without a trailing slash
+	davCollection := func(method, path string, handler echo.HandlerFunc) {
+		dav.Add(method, path, handler)
+		dav.Add(method, path+"/", handler)
+	}
+	dav.OPTIONS("", h.CalDAV.Options)
+	dav.OPTIONS("/*", h.CalDAV.Options)
+	davCollection(echo.PROPFIND, "", h.CalDAV.PropfindRoot)
+	davCollection(echo.PROPFIND, "/principals/:user", h.CalDAV.PropfindPrincipal)
+	davCollection(echo.PROPFIND, "/calendars", h.CalDAV.PropfindHome)
+	davCollection(echo.PROPFIND, "/calendars/:collection", h.CalDAV.PropfindCollection)
+	davCollection(echo.REPORT, "/calendars/:collection", h.CalDAV.Report)
+	dav.Add(echo.PROPFIND, "/calendars/:collection/:name", h.CalDAV.PropfindObject)
+	dav.GET("/calendars/:collection/:name", h.CalDAV.GetObject)
+	dav.PUT("/calendars/:collection/:name", h.CalDAV.PutObject)
+	dav.DELETE("/calendars/:collection/:name", h.CalDAV.DeleteObject)
 }
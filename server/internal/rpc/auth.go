@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"taskmanager/internal/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataUserID is the metadata key of auth.Header; gRPC metadata keys are
+// lower case.
+var metadataUserID = strings.ToLower(auth.Header)
+
+// identify returns ctx carrying the identity of the user its metadata names.
+func identify(ctx context.Context, users auth.Users) context.Context {
+	var userID string
+	if values := metadata.ValueFromIncomingContext(ctx, metadataUserID); len(values) > 0 {
+		userID = values[0]
+	}
+	return auth.NewContext(ctx, auth.Identify(users, userID))
+}
+
+// UnaryIdentify identifies the user of unary calls, as the ResolveTimeZone
+// middleware does for HTTP requests.
+func UnaryIdentify(users auth.Users) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(identify(ctx, users), req)
+	}
+}
+
+// StreamIdentify identifies the user of streaming calls.
+func StreamIdentify(users auth.Users) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &identifiedStream{ServerStream: stream, ctx: identify(stream.Context(), users)})
+	}
+}
+
+// identifiedStream is a stream whose context carries the identity of its
+// user.
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"strings"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	taskmanagerv1 "taskmanager/proto/taskmanager/v1"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Enum values are the model values in upper case after a prefix, with "."
+// as "_" for event types.
+const (
+	statusPrefix    = "TASK_STATUS_"
+	priorityPrefix  = "PRIORITY_"
+	eventTypePrefix = "TASK_EVENT_TYPE_"
+)
+
+func taskMessage(task models.Task) (*taskmanagerv1.Task, error) {
+	customFields, err := structpb.NewStruct(task.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	message := &taskmanagerv1.Task{
+		Id:           task.ID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Completed:    task.Completed,
+		Status:       statusEnum(task.Status),
+		Priority:     priorityEnum(task.Priority),
+		Position:     task.Position,
+		DueDate:      task.FormatDueDate(),
+		Recurrence:   task.Recurrence,
+		Estimate:     task.Estimate,
+		SprintId:     task.SprintID,
+		ProjectId:    task.ProjectID,
+		ParentId:     task.ParentID,
+		ExternalId:   task.ExternalID,
+		Tags:         task.Tags,
+		CustomFields: customFields,
+		Assignees:    task.Assignees,
+		Watchers:     task.Watchers,
+		CreatedAt:    timestamppb.New(task.CreatedAt),
+		UpdatedAt:    timestamppb.New(task.UpdatedAt),
+	}
+	if task.HasDueDate() && !task.DueAllDay() {
+		message.DueTimeZone = task.DueTimeZone
+	}
+	return message, nil
+}
+
+func statusEnum(status string) taskmanagerv1.TaskStatus {
+	return taskmanagerv1.TaskStatus(taskmanagerv1.TaskStatus_value[statusPrefix+strings.ToUpper(status)])
+}
+
+// statusString returns the model value of a status, "" when unspecified.
+func statusString(status taskmanagerv1.TaskStatus) string {
+	if status == taskmanagerv1.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(status.String(), statusPrefix))
+}
+
+func priorityEnum(priority string) taskmanagerv1.Priority {
+	return taskmanagerv1.Priority(taskmanagerv1.Priority_value[priorityPrefix+strings.ToUpper(priority)])
+}
+
+// priorityString returns the model value of a priority, "" when
+// unspecified.
+func priorityString(priority taskmanagerv1.Priority) string {
+	if priority == taskmanagerv1.Priority_PRIORITY_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(priority.String(), priorityPrefix))
+}
+
+func eventTypeEnum(eventType string) taskmanagerv1.TaskEventType {
+	name := eventTypePrefix + strings.ToUpper(strings.ReplaceAll(eventType, ".", "_"))
+	return taskmanagerv1.TaskEventType(taskmanagerv1.TaskEventType_value[name])
+}
+
+func createTaskInput(req *taskmanagerv1.CreateTaskRequest) models.CreateTaskInput {
+	return models.CreateTaskInput{
+		Title:        req.GetTitle(),
+		Description:  req.GetDescription(),
+		DueDate:      req.GetDueDate(),
+		DueTimeZone:  req.GetDueTimeZone(),
+		Completed:    req.GetCompleted(),
+		Status:       statusString(req.GetStatus()),
+		Priority:     priorityString(req.GetPriority()),
+		Recurrence:   req.GetRecurrence(),
+		Estimate:     req.GetEstimate(),
+		SprintID:     req.SprintId,
+		ProjectID:    req.ProjectId,
+		ParentID:     req.ParentId,
+		ExternalID:   req.ExternalId,
+		Tags:         req.GetTags(),
+		CustomFields: structMap(req.GetCustomFields()),
+	}
+}
+
+func updateTaskInput(req *taskmanagerv1.UpdateTaskRequest) models.UpdateTaskInput {
+	input := models.UpdateTaskInput{
+		Title:        req.GetTitle(),
+		Description:  req.GetDescription(),
+		DueDate:      req.GetDueDate(),
+		DueTimeZone:  req.GetDueTimeZone(),
+		Completed:    req.GetCompleted(),
+		Status:       statusString(req.GetStatus()),
+		Priority:     priorityString(req.GetPriority()),
+		Recurrence:   req.Recurrence,
+		Estimate:     req.Estimate,
+		SprintID:     req.SprintId,
+		ProjectID:    req.ProjectId,
+		ParentID:     req.ParentId,
+		CustomFields: structMap(req.GetCustomFields()),
+	}
+	if req.Tags != nil {
+		input.Tags = req.Tags.GetValues()
+		if input.Tags == nil {
+			input.Tags = []string{}
+		}
+	}
+	return input
+}
+
+// structMap returns the values of a struct, nil when it is unset.
+func structMap(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func eventMessage(event events.Event, task *taskmanagerv1.Task) *taskmanagerv1.TaskEvent {
+	return &taskmanagerv1.TaskEvent{
+		Type:      eventTypeEnum(event.Type),
+		TaskId:    event.TaskID,
+		CommentId: event.CommentID,
+		ActorId:   event.ActorID,
+		Time:      timestamppb.New(event.At),
+		Task:      task,
+	}
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"errors"
+	"sort"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/filter"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusOf returns the status of an error of the services, with the codes
+// matching the HTTP statuses of the REST API. Validation and filter errors
+// carry the fields at fault as BadRequest details.
+func statusOf(err error) error {
+	var validationErrs validator.ValidationErrors
+	var validationErr *apperrors.ValidationError
+	var filterErr *filter.Error
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &validationErr):
+		fields := make([]string, 0, len(validationErr.Details))
+		for field := range validationErr.Details {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+		for i, field := range fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field, Description: validationErr.Details[field]}
+		}
+		return badRequest(err, violations)
+	case errors.As(err, &filterErr):
+		return badRequest(err, []*errdetails.BadRequest_FieldViolation{{Field: "filter", Description: err.Error()}})
+	case errors.As(err, &validationErrs), errors.Is(err, service.ErrTaskCycle), errors.Is(err, service.ErrInvalidMove):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func badRequest(err error, violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	if len(violations) == 0 {
+		return st.Err()
+	}
+	if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,28 @@
+// Package rpc serves the task service over gRPC, as taskmanager.v1.TaskService
+// in proto/taskmanager/v1, for backends that want a typed client. It calls
+// the same service.TaskService as the REST API, identifies users with the
+// same auth logic and validates input with the same rules.
+package rpc
+
+import (
+	"taskmanager/internal/auth"
+	"taskmanager/internal/events"
+	"taskmanager/internal/service"
+	taskmanagerv1 "taskmanager/proto/taskmanager/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer returns a gRPC server with TaskService registered, and
+// reflection for tools such as grpcurl. Changes are watched on bus, and
+// validate checks inputs as the validator of the REST API does.
+func NewServer(tasks service.TaskService, users auth.Users, bus *events.Bus, validate func(i interface{}) error) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryIdentify(users)),
+		grpc.ChainStreamInterceptor(StreamIdentify(users)),
+	)
+	taskmanagerv1.RegisterTaskServiceServer(server, NewTaskServer(tasks, bus, validate))
+	reflection.Register(server)
+	return server
+}
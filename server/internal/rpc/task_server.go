@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"taskmanager/internal/auth"
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/service"
+	taskmanagerv1 "taskmanager/proto/taskmanager/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TaskServer implements taskmanager.v1.TaskService on top of
+// service.TaskService.
+type TaskServer struct {
+	taskmanagerv1.UnimplementedTaskServiceServer
+	service  service.TaskService
+	bus      *events.Bus
+	validate func(i interface{}) error
+}
+
+func NewTaskServer(service service.TaskService, bus *events.Bus, validate func(i interface{}) error) *TaskServer {
+	return &TaskServer{service: service, bus: bus, validate: validate}
+}
+
+func (s *TaskServer) GetTask(ctx context.Context, req *taskmanagerv1.GetTaskRequest) (*taskmanagerv1.Task, error) {
+	task, err := s.service.GetTaskByID(req.GetId())
+	if err != nil {
+		log.Error().Err(err).Str("id", req.GetId()).Msg("Failed to fetch task")
+		return nil, statusOf(err)
+	}
+	return s.message(task)
+}
+
+func (s *TaskServer) ListTasks(ctx context.Context, req *taskmanagerv1.ListTasksRequest) (*taskmanagerv1.ListTasksResponse, error) {
+	identity := auth.FromContext(ctx)
+	query := models.TaskQuery{
+		ProjectID:    req.GetProjectId(),
+		Filter:       req.GetFilter(),
+		Sort:         req.GetSort(),
+		Order:        req.GetOrder(),
+		TimeZone:     req.GetTimeZone(),
+		UserID:       identity.UserID,
+		Assignee:     req.GetAssignee(),
+		CustomFields: req.GetCustomFields(),
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = identity.TimeZone
+	}
+	tasks, err := s.service.GetAllTasks(query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all tasks")
+		return nil, statusOf(err)
+	}
+
+	res := &taskmanagerv1.ListTasksResponse{Tasks: make([]*taskmanagerv1.Task, len(tasks))}
+	for i, task := range tasks {
+		message, err := s.message(task)
+		if err != nil {
+			return nil, err
+		}
+		res.Tasks[i] = message
+	}
+	return res, nil
+}
+
+func (s *TaskServer) CreateTask(ctx context.Context, req *taskmanagerv1.CreateTaskRequest) (*taskmanagerv1.Task, error) {
+	input := createTaskInput(req)
+	if err := s.validate(&input); err != nil {
+		return nil, statusOf(err)
+	}
+	if input.DueTimeZone == "" {
+		input.DueTimeZone = auth.FromContext(ctx).TimeZone
+	}
+
+	task, err := s.service.CreateTask(input)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create task")
+		return nil, statusOf(err)
+	}
+	return s.message(task)
+}
+
+func (s *TaskServer) UpdateTask(ctx context.Context, req *taskmanagerv1.UpdateTaskRequest) (*taskmanagerv1.Task, error) {
+	input := updateTaskInput(req)
+	if err := s.validate(&input); err != nil {
+		return nil, statusOf(err)
+	}
+	if input.DueTimeZone == "" {
+		input.DueTimeZone = auth.FromContext(ctx).TimeZone
+	}
+
+	task, err := s.service.UpdateTask(req.GetId(), input, auth.FromContext(ctx).UserID)
+	if err != nil {
+		log.Error().Err(err).Str("id", req.GetId()).Msg("Failed to update task")
+		return nil, statusOf(err)
+	}
+	return s.message(task)
+}
+
+func (s *TaskServer) DeleteTask(ctx context.Context, req *taskmanagerv1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	var err error
+	if req.GetPermanent() {
+		err = s.service.PurgeTask(ctx, req.GetId())
+	} else {
+		err = s.service.DeleteTask(req.GetId(), auth.FromContext(ctx).UserID)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("id", req.GetId()).Msg("Failed to delete task")
+		return nil, statusOf(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchTasks sends the events of the bus that match the request, each with
+// the task as it is once the event is sent.
+func (s *TaskServer) WatchTasks(req *taskmanagerv1.WatchTasksRequest, stream taskmanagerv1.TaskService_WatchTasksServer) error {
+	watch, stop := s.bus.Watch()
+	defer stop()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watch:
+			if req.GetTaskId() != "" && event.TaskID != req.GetTaskId() {
+				continue
+			}
+			task, err := s.service.GetTaskByID(event.TaskID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				log.Error().Err(err).Str("id", event.TaskID).Msg("Failed to fetch watched task")
+				return statusOf(err)
+			}
+			found := err == nil
+			if req.GetProjectId() != "" && projectOf(event, task, found) != req.GetProjectId() {
+				continue
+			}
+
+			var message *taskmanagerv1.Task
+			if found {
+				if message, err = s.message(task); err != nil {
+					return err
+				}
+			}
+			if err := stream.Send(eventMessage(event, message)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// projectOf returns the project of the task of an event: the one it is in
+// when it is found, and the one it was last in when it is gone.
+func projectOf(event events.Event, task models.Task, found bool) string {
+	if !found {
+		return event.ProjectID
+	}
+	if task.ProjectID == nil {
+		return ""
+	}
+	return *task.ProjectID
+}
+
+// message converts a task for a response.
+func (s *TaskServer) message(task models.Task) (*taskmanagerv1.Task, error) {
+	message, err := taskMessage(task)
+	if err != nil {
+		log.Error().Err(err).Str("id", task.ID).Msg("Failed to convert task")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return message, nil
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// snippetWords is the number of words around the first match kept in a
+// snippet of a long field.
+const snippetWords = 24
+
+const ellipsis = "…"
+
+// highlight returns an HTML snippet of text around its first word starting
+// with one of the terms, with every such word marked. It reports false if
+// no word matches.
+func highlight(text string, terms []string) (string, bool) {
+	spans := words(text)
+	first := -1
+	matched := make([]bool, len(spans))
+	for i, span := range spans {
+		if matchesAny(strings.ToLower(text[span[0]:span[1]]), terms) {
+			matched[i] = true
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	// Show a few words of context before the first match
+	from := first - snippetWords/4
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetWords
+	if to > len(spans) {
+		to = len(spans)
+	}
+
+	var b strings.Builder
+	start, end := 0, len(text)
+	if from > 0 {
+		start = spans[from][0]
+		b.WriteString(ellipsis)
+	}
+	if to < len(spans) {
+		end = spans[to-1][1]
+	}
+	pos := start
+	for i := from; i < to; i++ {
+		if !matched[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:spans[i][0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[spans[i][0]:spans[i][1]]) + "</mark>")
+		pos = spans[i][1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString(ellipsis)
+	}
+	return b.String(), true
+}
+
+// highlightAll highlights the first of texts containing a match.
+func highlightAll(texts []string, terms []string) (string, bool) {
+	for _, text := range texts {
+		if snippet, ok := highlight(text, terms); ok {
+			return snippet, true
+		}
+	}
+	return "", false
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delimiters ts_headline is asked to put around matches. They are removed
+// from indexed text, so the snippet can be escaped before they become <mark>.
+const (
+	startSel = "\x01"
+	stopSel  = "\x02"
+)
+
+var markReplacer = strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+
+// markHeadline turns a ts_headline result into an HTML snippet. It reports
+// false if the headline has no match.
+func markHeadline(headline string) (string, bool) {
+	if !strings.Contains(headline, startSel) {
+		return "", false
+	}
+	return markReplacer.Replace(html.EscapeString(headline)), true
+}
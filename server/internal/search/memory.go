@@ -0,0 +1,204 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fieldWeights ranks matches by the field they are in, in the order title,
+// tags, description, comments.
+var fieldWeights = [...]float64{1.0, 0.6, 0.4, 0.2}
+
+// prefixWeight scales matches of a word that only starts with a query term.
+const prefixWeight = 0.5
+
+// MemoryIndex is an in-process inverted index. It is rebuilt from the
+// database on start, as nothing is persisted.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*memoryDoc
+	postings map[string]map[string]bool
+	// terms lists the keys of postings in order for prefix lookups. It is
+	// nil while stale.
+	terms []string
+}
+
+type memoryDoc struct {
+	doc   Document
+	freqs [len(fieldWeights)]map[string]int
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[string]*memoryDoc),
+		postings: make(map[string]map[string]bool),
+	}
+}
+
+func (x *MemoryIndex) Put(_ context.Context, doc Document) error {
+	entry := &memoryDoc{doc: doc}
+	texts := [...]string{doc.Title, strings.Join(doc.Tags, " "), doc.Description, strings.Join(doc.Comments, "\n")}
+	for field, text := range texts {
+		entry.freqs[field] = make(map[string]int)
+		for _, term := range Terms(text) {
+			entry.freqs[field][term]++
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(doc.TaskID)
+	x.docs[doc.TaskID] = entry
+	for _, freqs := range entry.freqs {
+		for term := range freqs {
+			if x.postings[term] == nil {
+				x.postings[term] = make(map[string]bool)
+				x.terms = nil
+			}
+			x.postings[term][doc.TaskID] = true
+		}
+	}
+	return nil
+}
+
+func (x *MemoryIndex) Delete(_ context.Context, taskID string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(taskID)
+	return nil
+}
+
+func (x *MemoryIndex) remove(taskID string) {
+	entry, ok := x.docs[taskID]
+	if !ok {
+		return
+	}
+	delete(x.docs, taskID)
+	for _, freqs := range entry.freqs {
+		for term := range freqs {
+			delete(x.postings[term], taskID)
+			if len(x.postings[term]) == 0 {
+				delete(x.postings, term)
+				x.terms = nil
+			}
+		}
+	}
+}
+
+func (x *MemoryIndex) Count(_ context.Context) (int64, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return int64(len(x.docs)), nil
+}
+
+// Search scores documents with a TF-IDF sum over the query terms, where
+// each field's term frequency is dampened and weighted by the field.
+func (x *MemoryIndex) Search(_ context.Context, query Query) ([]Hit, int64, error) {
+	terms := Terms(query.Text)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+
+	// Searching may rebuild the term list, so it takes the write lock
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.terms == nil {
+		x.terms = make([]string, 0, len(x.postings))
+		for term := range x.postings {
+			x.terms = append(x.terms, term)
+		}
+		sort.Strings(x.terms)
+	}
+
+	scores := make(map[string]float64)
+	for i, term := range terms {
+		expansions := x.expand(term)
+		matching := make(map[string]float64)
+		for _, expansion := range expansions {
+			idf := math.Log(1 + float64(len(x.docs))/float64(len(x.postings[expansion])))
+			weight := idf
+			if expansion != term {
+				weight *= prefixWeight
+			}
+			for taskID := range x.postings[expansion] {
+				entry := x.docs[taskID]
+				if query.ProjectID != "" && (entry.doc.ProjectID == nil || *entry.doc.ProjectID != query.ProjectID) {
+					continue
+				}
+				if i > 0 {
+					if _, ok := scores[taskID]; !ok {
+						continue
+					}
+				}
+				for field, freqs := range entry.freqs {
+					if tf := float64(freqs[expansion]); tf > 0 {
+						matching[taskID] += weight * fieldWeights[field] * tf / (tf + 1)
+					}
+				}
+			}
+		}
+		// Every term has to match
+		for taskID, score := range matching {
+			matching[taskID] = scores[taskID] + score
+		}
+		scores = matching
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for taskID, score := range scores {
+		hits = append(hits, Hit{TaskID: taskID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].TaskID < hits[j].TaskID
+	})
+
+	total := int64(len(hits))
+	hits = page(hits, query.Offset, query.Limit)
+	for i := range hits {
+		hits[i].Highlights = x.highlights(x.docs[hits[i].TaskID].doc, terms)
+	}
+	return hits, total, nil
+}
+
+// expand returns the indexed terms starting with prefix.
+func (x *MemoryIndex) expand(prefix string) []string {
+	var expansions []string
+	for i := sort.SearchStrings(x.terms, prefix); i < len(x.terms) && strings.HasPrefix(x.terms[i], prefix); i++ {
+		expansions = append(expansions, x.terms[i])
+	}
+	return expansions
+}
+
+func (x *MemoryIndex) highlights(doc Document, terms []string) map[string]string {
+	highlights := make(map[string]string)
+	if snippet, ok := highlight(doc.Title, terms); ok {
+		highlights[FieldTitle] = snippet
+	}
+	if snippet, ok := highlight(strings.Join(doc.Tags, " "), terms); ok {
+		highlights[FieldTags] = snippet
+	}
+	if snippet, ok := highlight(doc.Description, terms); ok {
+		highlights[FieldDescription] = snippet
+	}
+	if snippet, ok := highlightAll(doc.Comments, terms); ok {
+		highlights[FieldComments] = snippet
+	}
+	return highlights
+}
+
+func page(hits []Hit, offset, limit int) []Hit {
+	if offset >= len(hits) {
+		return []Hit{}
+	}
+	hits = hits[offset:]
+	if limit > 0 && limit < len(hits) {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// textSearchConfig is the Postgres text search configuration. The simple
+// configuration does no stemming, so prefixes match what users typed.
+const textSearchConfig = "simple"
+
+// Options passed to ts_headline for short and long fields.
+const (
+	titleHeadline = `StartSel="` + startSel + `", StopSel="` + stopSel + `", HighlightAll=true`
+	bodyHeadline  = `StartSel="` + startSel + `", StopSel="` + stopSel + `", MaxWords=24, MinWords=8, MaxFragments=1`
+)
+
+// Record is the search_documents row of a task. Document weighs the title
+// A, tags B, description C and comments D, and has a GIN index.
+type Record struct {
+	TaskID      string  `gorm:"type:varchar(36);primaryKey"`
+	ProjectID   *string `gorm:"type:varchar(36);index"`
+	Title       string  `gorm:"type:text;not null"`
+	Description string  `gorm:"type:text;not null"`
+	Tags        string  `gorm:"type:text;not null"`
+	Comments    string  `gorm:"type:text;not null"`
+	Document    string  `gorm:"type:tsvector;not null;index:idx_search_documents_document,type:gin"`
+	UpdatedAt   time.Time
+}
+
+func (Record) TableName() string {
+	return "search_documents"
+}
+
+// PostgresIndex keeps documents in the search_documents table.
+type PostgresIndex struct {
+	db *gorm.DB
+}
+
+func NewPostgresIndex(db *gorm.DB) *PostgresIndex {
+	return &PostgresIndex{db: db}
+}
+
+func (x *PostgresIndex) Put(ctx context.Context, doc Document) error {
+	record := map[string]interface{}{
+		"task_id":     doc.TaskID,
+		"project_id":  doc.ProjectID,
+		"title":       clean(doc.Title),
+		"description": clean(doc.Description),
+		"tags":        clean(strings.Join(doc.Tags, " ")),
+		"comments":    clean(strings.Join(doc.Comments, "\n")),
+		"updated_at":  time.Now(),
+	}
+	record["document"] = gorm.Expr(
+		"setweight(to_tsvector(?, ?), 'A') || setweight(to_tsvector(?, ?), 'B') || "+
+			"setweight(to_tsvector(?, ?), 'C') || setweight(to_tsvector(?, ?), 'D')",
+		textSearchConfig, record["title"], textSearchConfig, record["tags"],
+		textSearchConfig, record["description"], textSearchConfig, record["comments"],
+	)
+
+	err := x.db.WithContext(ctx).Model(&Record{}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"project_id", "title", "description", "tags", "comments", "document", "updated_at"}),
+	}).Create(record).Error
+	if err != nil {
+		log.Error().Err(err).Str("task_id", doc.TaskID).Msg("Failed to index task")
+		return err
+	}
+	return nil
+}
+
+func (x *PostgresIndex) Delete(ctx context.Context, taskID string) error {
+	if err := x.db.WithContext(ctx).Delete(&Record{}, "task_id = ?", taskID).Error; err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to remove task from index")
+		return err
+	}
+	return nil
+}
+
+func (x *PostgresIndex) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := x.db.WithContext(ctx).Model(&Record{}).Count(&count).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to count indexed tasks")
+		return 0, err
+	}
+	return count, nil
+}
+
+type postgresHit struct {
+	TaskID      string
+	Score       float64
+	Title       string
+	Tags        string
+	Description string
+	Comments    string
+	Total       int64
+}
+
+// Search ranks matches with ts_rank and builds snippets with ts_headline.
+// Each query word becomes a prefix match, so "rep bug" finds "report bugs".
+func (x *PostgresIndex) Search(ctx context.Context, query Query) ([]Hit, int64, error) {
+	terms := Terms(query.Text)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	db := x.db.WithContext(ctx).Table("search_documents, to_tsquery(?, ?) AS query", textSearchConfig, strings.Join(terms, " & ")).
+		Select("task_id, ts_rank(document, query) AS score, "+
+			"ts_headline(?, title, query, ?) AS title, ts_headline(?, tags, query, ?) AS tags, "+
+			"ts_headline(?, description, query, ?) AS description, ts_headline(?, comments, query, ?) AS comments, "+
+			"COUNT(*) OVER () AS total",
+			textSearchConfig, titleHeadline, textSearchConfig, titleHeadline,
+			textSearchConfig, bodyHeadline, textSearchConfig, bodyHeadline).
+		Where("document @@ query")
+	if query.ProjectID != "" {
+		db = db.Where("project_id = ?", query.ProjectID)
+	}
+	db = db.Order("score DESC, updated_at DESC, task_id").Offset(query.Offset)
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var rows []postgresHit
+	if err := db.Scan(&rows).Error; err != nil {
+		log.Error().Err(err).Str("query", query.Text).Msg("Failed to search tasks")
+		return nil, 0, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	var total int64
+	for _, row := range rows {
+		total = row.Total
+		hit := Hit{TaskID: row.TaskID, Score: row.Score, Highlights: make(map[string]string)}
+		for field, headline := range map[string]string{
+			FieldTitle:       row.Title,
+			FieldTags:        row.Tags,
+			FieldDescription: row.Description,
+			FieldComments:    row.Comments,
+		} {
+			if snippet, ok := markHeadline(headline); ok {
+				hit.Highlights[field] = snippet
+			}
+		}
+		hits = append(hits, hit)
+	}
+	if len(rows) == 0 && query.Offset > 0 {
+		// The page is past the end, count the matches separately
+		count := x.db.WithContext(ctx).Table("search_documents").
+			Where("document @@ to_tsquery(?, ?)", textSearchConfig, strings.Join(terms, " & "))
+		if query.ProjectID != "" {
+			count = count.Where("project_id = ?", query.ProjectID)
+		}
+		if err := count.Count(&total).Error; err != nil {
+			log.Error().Err(err).Str("query", query.Text).Msg("Failed to count search results")
+			return nil, 0, err
+		}
+	}
+	return hits, total, nil
+}
+
+// clean removes the headline delimiters from indexed text.
+func clean(text string) string {
+	return strings.NewReplacer(startSel, "", stopSel, "").Replace(text)
+}
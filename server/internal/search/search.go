@@ -0,0 +1,91 @@
+// Package search keeps a full-text index of tasks and their comments.
+//
+// Two indexes implement the same Index interface: PostgresIndex stores
+// tsvector documents next to the tasks, and MemoryIndex is an in-process
+// inverted index for databases without full-text search. Both match every
+// query word as a prefix, rank title matches above tags, description and
+// comments, in that order, and return highlighted snippets.
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Highlighted fields
+const (
+	FieldTitle       = "title"
+	FieldTags        = "tags"
+	FieldDescription = "description"
+	FieldComments    = "comments"
+)
+
+// Document is the searchable text of a task.
+type Document struct {
+	TaskID      string
+	ProjectID   *string
+	Title       string
+	Description string
+	Tags        []string
+	Comments    []string
+}
+
+// Query searches for tasks containing every word of Text, optionally within
+// one project.
+type Query struct {
+	Text      string
+	ProjectID string
+	Limit     int
+	Offset    int
+}
+
+// Hit is a matching task with its relevance and, for each field that
+// matched, an HTML snippet with the matches wrapped in <mark>.
+type Hit struct {
+	TaskID     string
+	Score      float64
+	Highlights map[string]string
+}
+
+// Index is a full-text index of tasks.
+type Index interface {
+	// Put adds a document or replaces the one of the same task.
+	Put(ctx context.Context, doc Document) error
+	// Delete removes the document of a task, if any.
+	Delete(ctx context.Context, taskID string) error
+	// Search returns a page of hits, best first, and the total number of
+	// matching tasks.
+	Search(ctx context.Context, query Query) ([]Hit, int64, error)
+	// Count returns the number of indexed documents.
+	Count(ctx context.Context) (int64, error)
+}
+
+// Terms splits text into lower-case words of letters and digits.
+func Terms(text string) []string {
+	var terms []string
+	for _, span := range words(text) {
+		terms = append(terms, strings.ToLower(text[span[0]:span[1]]))
+	}
+	return terms
+}
+
+// words returns the byte offsets of the words of text.
+func words(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
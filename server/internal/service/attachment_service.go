@@ -0,0 +1,297 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// sniffLength is how much of an upload is inspected to detect its MIME type.
+const sniffLength = 3072
+
+var (
+	// ErrAttachmentTooLarge is returned when an upload exceeds the size limit.
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the maximum allowed size")
+	// ErrEmptyAttachment is returned when an upload has no content.
+	ErrEmptyAttachment = errors.New("attachment is empty")
+	// ErrInvalidSignature is returned for tampered or expired download links.
+	ErrInvalidSignature = errors.New("download link is invalid or has expired")
+)
+
+type AttachmentService interface {
+	ListAttachments(taskID string) ([]models.Attachment, error)
+	UploadAttachment(ctx context.Context, taskID, uploaderID, filename string, r io.Reader) (models.Attachment, error)
+	DeleteAttachment(ctx context.Context, taskID, id string) error
+	SignDownloadURL(taskID, id string) (models.DownloadURL, error)
+	OpenDownload(ctx context.Context, id, expires, signature string) (models.Attachment, io.ReadCloser, error)
+	PurgeTaskAttachments(ctx context.Context, taskID string) error
+}
+
+type attachmentService struct {
+	repo       repository.AttachmentRepository
+	tasks      repository.TaskRepository
+	blobs      storage.BlobStore
+	maxBytes   int64
+	signingKey []byte
+	urlTTL     time.Duration
+	now        func() time.Time
+	digests    digestLocks
+}
+
+// digestLocks serializes the uploads and deletes of each blob, so a delete
+// that finds no attachment left cannot remove a blob an upload has just
+// stored but not yet recorded.
+type digestLocks struct {
+	mu    sync.Mutex
+	locks map[string]*digestLock
+}
+
+type digestLock struct {
+	sync.Mutex
+	holders int
+}
+
+// lock locks digest and returns the function that unlocks it.
+func (l *digestLocks) lock(digest string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*digestLock)
+	}
+	lock := l.locks[digest]
+	if lock == nil {
+		lock = &digestLock{}
+		l.locks[digest] = lock
+	}
+	lock.holders++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.mu.Lock()
+		if lock.holders--; lock.holders == 0 {
+			delete(l.locks, digest)
+		}
+		l.mu.Unlock()
+	}
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, tasks repository.TaskRepository, blobs storage.BlobStore, maxBytes int64, signingKey []byte, urlTTL time.Duration) AttachmentService {
+	return &attachmentService{
+		repo:       repo,
+		tasks:      tasks,
+		blobs:      blobs,
+		maxBytes:   maxBytes,
+		signingKey: signingKey,
+		urlTTL:     urlTTL,
+		now:        time.Now,
+	}
+}
+
+func (s *attachmentService) ListAttachments(taskID string) ([]models.Attachment, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByTask(taskID)
+}
+
+// UploadAttachment streams r to a temporary file while hashing and sniffing
+// it, then stores the bytes under their digest. The blob is written even
+// when it exists: the write is idempotent, and a delete of the last
+// attachment sharing it may remove it before this one is recorded.
+func (s *attachmentService) UploadAttachment(ctx context.Context, taskID, uploaderID, filename string, r io.Reader) (models.Attachment, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return models.Attachment{}, err
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	limited := io.LimitReader(r, s.maxBytes+1)
+	header := make([]byte, sniffLength)
+	n, err := io.ReadFull(limited, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return models.Attachment{}, err
+	}
+	header = header[:n]
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.MultiReader(bytes.NewReader(header), limited))
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if size > s.maxBytes {
+		return models.Attachment{}, ErrAttachmentTooLarge
+	}
+	if size == 0 {
+		return models.Attachment{}, ErrEmptyAttachment
+	}
+
+	attachment := models.Attachment{
+		ID:          uuid.New().String(),
+		TaskID:      taskID,
+		UploaderID:  uploaderID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: mimetype.Detect(header).String(),
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   time.Now(),
+	}
+
+	unlock := s.digests.lock(attachment.SHA256)
+	defer unlock()
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return models.Attachment{}, err
+	}
+	if err := s.blobs.Put(ctx, attachment.BlobKey(), tmp, size, attachment.ContentType); err != nil {
+		log.Error().Err(err).Str("sha256", attachment.SHA256).Msg("Failed to store attachment blob")
+		return models.Attachment{}, err
+	}
+
+	createdAttachment, err := s.repo.Create(attachment)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create attachment in repository")
+		return models.Attachment{}, err
+	}
+	return createdAttachment, nil
+}
+
+func (s *attachmentService) DeleteAttachment(ctx context.Context, taskID, id string) error {
+	attachment, err := s.findTaskAttachment(taskID, id)
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, attachment)
+}
+
+func (s *attachmentService) SignDownloadURL(taskID, id string) (models.DownloadURL, error) {
+	attachment, err := s.findTaskAttachment(taskID, id)
+	if err != nil {
+		return models.DownloadURL{}, err
+	}
+
+	expiresAt := s.now().Add(s.urlTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return models.DownloadURL{
+		URL: fmt.Sprintf("/api/v1/attachments/%s/download?expires=%s&signature=%s",
+			attachment.ID, expires, s.signature(attachment.ID, expires)),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *attachmentService) OpenDownload(ctx context.Context, id, expires, signature string) (models.Attachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > expiresAt {
+		return models.Attachment{}, nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(id, expires))) {
+		return models.Attachment{}, nil, ErrInvalidSignature
+	}
+
+	attachment, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	content, err := s.blobs.Get(ctx, attachment.BlobKey())
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to open attachment blob")
+		return models.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// PurgeTaskAttachments removes every attachment of a task that is being
+// permanently deleted, along with blobs no other attachment refers to.
+func (s *attachmentService) PurgeTaskAttachments(ctx context.Context, taskID string) error {
+	attachments, err := s.repo.FindByTask(taskID)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := s.delete(ctx, attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *attachmentService) delete(ctx context.Context, attachment models.Attachment) error {
+	if err := s.repo.Delete(attachment.ID); err != nil {
+		log.Error().Err(err).Str("id", attachment.ID).Msg("Failed to delete attachment from repository")
+		return err
+	}
+
+	unlock := s.digests.lock(attachment.SHA256)
+	defer unlock()
+	remaining, err := s.repo.CountByDigest(attachment.SHA256)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return nil
+	}
+	if err := s.blobs.Delete(ctx, attachment.BlobKey()); err != nil {
+		log.Error().Err(err).Str("sha256", attachment.SHA256).Msg("Failed to delete attachment blob")
+		return err
+	}
+	return nil
+}
+
+func (s *attachmentService) findTaskAttachment(taskID, id string) (models.Attachment, error) {
+	attachment, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if attachment.TaskID != taskID {
+		return models.Attachment{}, repository.ErrNotFound
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) signature(id, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(id + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	return name
+}
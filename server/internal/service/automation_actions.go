@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+)
+
+// checkAction reports what is wrong with the settings of an action.
+func (s *automationService) checkAction(action models.AutomationAction) error {
+	switch action.Type {
+	case models.ActionSetField:
+		if action.Field == "" {
+			return errors.New("set_field needs a field")
+		}
+		return checkFieldValue(action.Field, action.Value)
+	case models.ActionAddTag:
+		if tag := strings.TrimSpace(action.Value); tag == "" || len(tag) > 50 {
+			return errors.New("add_tag needs a tag of at most 50 characters")
+		}
+	case models.ActionAssign:
+		if _, err := s.users.FindByID(action.Value); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return errors.New("assign needs the ID of an existing user")
+			}
+			return err
+		}
+	case models.ActionCreateTask:
+		if action.Value != "" && (len(action.Value) < 3 || len(action.Value) > 100) {
+			return errors.New("create_task titles have 3 to 100 characters")
+		}
+	case models.ActionWebhook:
+		target, err := url.Parse(action.Value)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errors.New("webhook needs an http or https URL")
+		}
+	}
+	return nil
+}
+
+func checkFieldValue(field, value string) error {
+	switch field {
+	case "status":
+		if !validStatus(value) {
+			return fmt.Errorf("status must be one of %s", strings.Join(models.Statuses, ", "))
+		}
+	case "priority":
+		for _, priority := range models.Priorities {
+			if value == priority {
+				return nil
+			}
+		}
+		return fmt.Errorf("priority must be one of %s", strings.Join(models.Priorities, ", "))
+	case "estimate":
+		if estimate, err := strconv.ParseFloat(value, 64); err != nil || estimate < 0 || estimate > 100000 {
+			return errors.New("estimate must be a number between 0 and 100000")
+		}
+	}
+	return nil
+}
+
+// describeAction says in words what an action does to a task.
+func describeAction(action models.AutomationAction, task models.Task) string {
+	switch action.Type {
+	case models.ActionSetField:
+		return fmt.Sprintf("set %s to %q", action.Field, action.Value)
+	case models.ActionAddTag:
+		return fmt.Sprintf("add tag %q", strings.TrimSpace(action.Value))
+	case models.ActionAssign:
+		return fmt.Sprintf("assign user %s", action.Value)
+	case models.ActionCreateTask:
+		description := fmt.Sprintf("create task %q", followUpTitle(action, task))
+		if action.DueInDays != nil {
+			description += fmt.Sprintf(" due in %d days", *action.DueInDays)
+		}
+		return description
+	case models.ActionWebhook:
+		return fmt.Sprintf("POST to %s", action.Value)
+	default:
+		return action.Type
+	}
+}
+
+// execute performs one action of a rule on a task, in the chain of changes
+// the rule started.
+func (s *automationService) execute(rule models.AutomationRule, action models.AutomationAction, task models.Task, chain *automationChain) error {
+	switch action.Type {
+	case models.ActionSetField:
+		input := updateInput(task)
+		switch action.Field {
+		case "status":
+			input.Status = action.Value
+		case "priority":
+			input.Priority = action.Value
+		case "estimate":
+			estimate, err := strconv.ParseFloat(action.Value, 64)
+			if err != nil {
+				return err
+			}
+			input.Estimate = &estimate
+		}
+		_, err := s.tasks.UpdateTask(task.ID, input, "")
+		return err
+	case models.ActionAddTag:
+		tag := strings.TrimSpace(action.Value)
+		for _, existing := range task.Tags {
+			if strings.EqualFold(existing, tag) {
+				return nil
+			}
+		}
+		input := updateInput(task)
+		input.Tags = append(append([]string{}, task.Tags...), tag)
+		_, err := s.tasks.UpdateTask(task.ID, input, "")
+		return err
+	case models.ActionAssign:
+		_, err := s.members.Assign(task.ID, action.Value, "")
+		return err
+	case models.ActionCreateTask:
+		input := models.CreateTaskInput{
+			Title:     followUpTitle(action, task),
+			ProjectID: task.ProjectID,
+		}
+		if action.DueInDays != nil {
+			input.DueDate = s.clock.Now().UTC().AddDate(0, 0, *action.DueInDays).Format("2006-01-02")
+		}
+		created, err := s.tasks.CreateTask(input)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.spawned[created.ID] = chain
+		s.mu.Unlock()
+		return nil
+	case models.ActionWebhook:
+		return s.postWebhook(rule, action.Value, task)
+	default:
+		return fmt.Errorf("unknown action %q", action.Type)
+	}
+}
+
+// updateInput is an update that leaves a task as it is.
+func updateInput(task models.Task) models.UpdateTaskInput {
+	return models.UpdateTaskInput{
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+		Status:      task.Status,
+		Priority:    task.Priority,
+	}
+}
+
+// followUpTitle is the title of the task a create_task action creates.
+func followUpTitle(action models.AutomationAction, task models.Task) string {
+	if action.Value != "" {
+		return action.Value
+	}
+	return truncate("Follow up: "+task.Title, 100)
+}
+
+// webhookPayload is the JSON body POSTed by webhook actions
+type webhookPayload struct {
+	RuleID   string      `json:"rule_id"`
+	RuleName string      `json:"rule_name"`
+	Trigger  string      `json:"trigger"`
+	Task     models.Task `json:"task"`
+}
+
+func (s *automationService) postWebhook(rule models.AutomationRule, target string, task models.Task) error {
+	body, err := json.Marshal(webhookPayload{RuleID: rule.ID, RuleName: rule.Name, Trigger: rule.Trigger, Task: task})
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return nil
+}
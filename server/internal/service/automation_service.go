@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"taskmanager/internal/clock"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxAutomationDepth is how many rules can run one after another in a
+	// chain of rules triggering each other before the rest are skipped.
+	maxAutomationDepth = 5
+	// automationQueueSize is how many changes can wait for the rules to
+	// run before further ones are dropped, logging a failed run for each
+	// rule they would have run.
+	automationQueueSize = 1024
+	// duePassedLookback is how long after a task became overdue a
+	// due_passed rule still runs on it, for instance after downtime.
+	duePassedLookback = 7 * 24 * time.Hour
+)
+
+type AutomationService interface {
+	ListRules() ([]models.AutomationRule, error)
+	GetRule(id string) (models.AutomationRule, error)
+	CreateRule(input models.AutomationRuleInput) (models.AutomationRule, error)
+	UpdateRule(id string, input models.AutomationRuleInput) (models.AutomationRule, error)
+	DeleteRule(id string) error
+	ListRuns(ruleID string, page, pageSize int) (models.AutomationRunPage, error)
+	// DryRun evaluates a rule's condition on a task and describes what its
+	// actions would do, whatever the trigger.
+	DryRun(ruleID, taskID string) (models.DryRunResult, error)
+	// HandleEvent queues a task change for the rules to look at.
+	HandleEvent(event events.Event)
+	// CheckDueDates queues the tasks whose due date has passed for the
+	// due_passed rules that have not run on them yet.
+	CheckDueDates() error
+	// Run runs the rules on queued changes, one at a time, until ctx is
+	// done.
+	Run(ctx context.Context)
+}
+
+// automationChain follows rules triggering each other: a change made by a
+// rule's action belongs to the chain the rule ran in.
+type automationChain struct {
+	depth int
+	ran   map[string]bool
+}
+
+// then returns the chain of the changes made by rule.
+func (c *automationChain) then(ruleID string) *automationChain {
+	next := &automationChain{depth: c.depth + 1, ran: make(map[string]bool, len(c.ran)+1)}
+	for id := range c.ran {
+		next.ran[id] = true
+	}
+	next.ran[ruleID] = true
+	return next
+}
+
+// automationJob is a change of a task waiting for the rules.
+type automationJob struct {
+	event events.Event
+	// duePassed is the rule a due_passed job is for
+	duePassed *models.AutomationRule
+	chain     *automationChain
+}
+
+type automationService struct {
+	repo      repository.AutomationRepository
+	taskRepo  repository.TaskRepository
+	tasks     TaskService
+	members   TaskMemberService
+	users     repository.UserRepository
+	client    *http.Client
+	clock     clock.Clock
+	validator *validator.Validate
+	queue     chan automationJob
+
+	mu sync.Mutex
+	// acting maps the task an action is changing to the chain it runs in
+	acting map[string]*automationChain
+	// spawned maps the tasks created by actions, whose creation has yet to
+	// be processed, to the chain they were created in
+	spawned map[string]*automationChain
+}
+
+func NewAutomationService(repo repository.AutomationRepository, taskRepo repository.TaskRepository, tasks TaskService, members TaskMemberService, users repository.UserRepository, client *http.Client, clock clock.Clock) AutomationService {
+	return &automationService{
+		repo:      repo,
+		taskRepo:  taskRepo,
+		tasks:     tasks,
+		members:   members,
+		users:     users,
+		client:    client,
+		clock:     clock,
+		validator: validator.New(),
+		queue:     make(chan automationJob, automationQueueSize),
+		acting:    make(map[string]*automationChain),
+		spawned:   make(map[string]*automationChain),
+	}
+}
+
+func (s *automationService) ListRules() ([]models.AutomationRule, error) {
+	return s.repo.FindAll()
+}
+
+func (s *automationService) GetRule(id string) (models.AutomationRule, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *automationService) CreateRule(input models.AutomationRuleInput) (models.AutomationRule, error) {
+	rule := models.AutomationRule{
+		ID:        uuid.New().String(),
+		Enabled:   true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.apply(&rule, input); err != nil {
+		return models.AutomationRule{}, err
+	}
+	createdRule, err := s.repo.Create(rule)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create automation rule in repository")
+		return models.AutomationRule{}, err
+	}
+	return createdRule, nil
+}
+
+func (s *automationService) UpdateRule(id string, input models.AutomationRuleInput) (models.AutomationRule, error) {
+	rule, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.AutomationRule{}, err
+	}
+	if err := s.apply(&rule, input); err != nil {
+		return models.AutomationRule{}, err
+	}
+	updatedRule, err := s.repo.Update(rule)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update automation rule in repository")
+		return models.AutomationRule{}, err
+	}
+	return updatedRule, nil
+}
+
+// apply validates the input, including its condition and actions, and
+// copies it onto rule.
+func (s *automationService) apply(rule *models.AutomationRule, input models.AutomationRuleInput) error {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for AutomationRuleInput")
+		return err
+	}
+	if _, err := compileFilter(input.Condition, s.clock.Now(), ""); err != nil {
+		return err
+	}
+	if input.Trigger == models.TriggerStatusChanged && input.TriggerValue != "" && !validStatus(input.TriggerValue) {
+		return apperrors.NewValidationError("Invalid trigger", map[string]string{
+			"trigger_value": "must be a task status",
+		})
+	}
+	for i, action := range input.Actions {
+		if err := s.checkAction(action); err != nil {
+			return apperrors.NewValidationError("Invalid action", map[string]string{
+				fmt.Sprintf("actions[%d]", i): err.Error(),
+			})
+		}
+	}
+
+	rule.Name = input.Name
+	rule.ProjectID = input.ProjectID
+	if input.ProjectID != nil && *input.ProjectID == "" {
+		rule.ProjectID = nil
+	}
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+	rule.Trigger = input.Trigger
+	rule.TriggerValue = input.TriggerValue
+	rule.Condition = input.Condition
+	rule.Actions = input.Actions
+	rule.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *automationService) DeleteRule(id string) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *automationService) ListRuns(ruleID string, page, pageSize int) (models.AutomationRunPage, error) {
+	if _, err := s.repo.FindByID(ruleID); err != nil {
+		return models.AutomationRunPage{}, err
+	}
+	runs, total, err := s.repo.FindRuns(ruleID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return models.AutomationRunPage{}, err
+	}
+	if runs == nil {
+		runs = []models.AutomationRun{}
+	}
+	return models.AutomationRunPage{Data: runs, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+func (s *automationService) DryRun(ruleID, taskID string) (models.DryRunResult, error) {
+	rule, err := s.repo.FindByID(ruleID)
+	if err != nil {
+		return models.DryRunResult{}, err
+	}
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil {
+		return models.DryRunResult{}, err
+	}
+	matches, err := s.matches(rule, task)
+	if err != nil {
+		return models.DryRunResult{}, err
+	}
+
+	result := models.DryRunResult{RuleID: rule.ID, TaskID: task.ID, Matches: matches, Actions: []string{}}
+	if matches {
+		for _, action := range rule.Actions {
+			result.Actions = append(result.Actions, describeAction(action, task))
+		}
+	}
+	return result, nil
+}
+
+func (s *automationService) HandleEvent(event events.Event) {
+	if event.Type != events.TaskCreated && event.Type != events.TaskUpdated {
+		return
+	}
+	s.mu.Lock()
+	chain := s.acting[event.TaskID]
+	s.mu.Unlock()
+	if chain == nil {
+		chain = &automationChain{}
+	}
+	s.enqueue(automationJob{event: event, chain: chain})
+}
+
+func (s *automationService) CheckDueDates() error {
+	rules, err := s.repo.FindEnabled(models.TriggerDuePassed)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	now := s.clock.Now().UTC()
+	tasks, err := s.taskRepo.FindDueBetween(now.Add(-duePassedLookback-24*time.Hour), now)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if !task.IsOverdue(now) {
+			continue
+		}
+		for i := range rules {
+			if !inScope(rules[i], task) {
+				continue
+			}
+			ran, err := s.repo.RunExists(rules[i].ID, task.ID, task.DueAt(time.UTC))
+			if err != nil {
+				return err
+			}
+			if !ran {
+				event := events.Event{TaskID: task.ID, At: now}
+				s.enqueue(automationJob{event: event, duePassed: &rules[i], chain: &automationChain{}})
+			}
+		}
+	}
+	return nil
+}
+
+// enqueue queues a job without waiting, since the rules' own changes are
+// queued from the goroutine running them. When the queue is full the job
+// is dropped, and the rules it would have run log failed runs instead.
+func (s *automationService) enqueue(job automationJob) {
+	select {
+	case s.queue <- job:
+	default:
+		log.Error().Str("task_id", job.event.TaskID).Msg("Automation queue is full, dropping task change")
+		s.process(job, s.dropRule)
+	}
+}
+
+func (s *automationService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.process(job, s.runRule)
+		}
+	}
+}
+
+// process calls run for each rule a job triggers.
+func (s *automationService) process(job automationJob, run func(models.AutomationRule, models.Task, *automationChain)) {
+	if job.event.Type == events.TaskCreated {
+		// Created by an action, which had to create the task to know its ID
+		s.mu.Lock()
+		if chain := s.spawned[job.event.TaskID]; chain != nil {
+			job.chain = chain
+			delete(s.spawned, job.event.TaskID)
+		}
+		s.mu.Unlock()
+	}
+
+	task, err := s.taskRepo.FindByID(job.event.TaskID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Error().Err(err).Str("task_id", job.event.TaskID).Msg("Failed to load task for automation")
+		}
+		return
+	}
+
+	if job.duePassed != nil {
+		// The task may have changed since it was queued
+		if task.IsOverdue(s.clock.Now().UTC()) {
+			run(*job.duePassed, task, job.chain)
+		}
+		return
+	}
+
+	triggers, err := s.triggers(job.event, task)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", task.ID).Msg("Failed to find automation triggers")
+		return
+	}
+	for _, trigger := range triggers {
+		rules, err := s.repo.FindEnabled(trigger.name)
+		if err != nil {
+			return
+		}
+		for _, rule := range rules {
+			if rule.TriggerValue != "" && !strings.EqualFold(rule.TriggerValue, trigger.value) {
+				continue
+			}
+			if !inScope(rule, task) {
+				continue
+			}
+			// Earlier rules may have changed the task
+			if current, err := s.taskRepo.FindByID(task.ID); err == nil {
+				task = current
+			}
+			run(rule, task, job.chain)
+		}
+	}
+}
+
+// firedTrigger is a trigger that fired, with its status or tag
+type firedTrigger struct {
+	name  string
+	value string
+}
+
+// triggers compares the task with its revision before the change to find
+// which triggers the change fired.
+func (s *automationService) triggers(event events.Event, task models.Task) ([]firedTrigger, error) {
+	var triggers []firedTrigger
+	var before models.Task
+	if event.Type == events.TaskCreated {
+		triggers = append(triggers, firedTrigger{name: models.TriggerTaskCreated})
+	} else {
+		revisions, err := s.taskRepo.FindRecentRevisions(task.ID, event.At, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) < 2 {
+			return nil, nil
+		}
+		after, err := revisions[0].Task()
+		if err != nil {
+			return nil, err
+		}
+		if before, err = revisions[1].Task(); err != nil {
+			return nil, err
+		}
+		task = after
+		if task.Status != before.Status {
+			triggers = append(triggers, firedTrigger{name: models.TriggerStatusChanged, value: task.Status})
+		}
+	}
+
+	had := make(map[string]bool, len(before.Tags))
+	for _, tag := range before.Tags {
+		had[strings.ToLower(tag)] = true
+	}
+	for _, tag := range task.Tags {
+		if !had[strings.ToLower(tag)] {
+			triggers = append(triggers, firedTrigger{name: models.TriggerTagAdded, value: tag})
+		}
+	}
+	return triggers, nil
+}
+
+// runRule runs a rule whose trigger fired on a task and logs the run,
+// unless the condition does not match.
+func (s *automationService) runRule(rule models.AutomationRule, task models.Task, chain *automationChain) {
+	run := s.newRun(rule, task, chain)
+	switch {
+	case chain.ran[rule.ID]:
+		run.Status = models.RunSkipped
+		run.Error = "rule already ran earlier in this chain of rules"
+	case chain.depth >= maxAutomationDepth:
+		run.Status = models.RunSkipped
+		run.Error = fmt.Sprintf("more than %d rules ran in a chain", maxAutomationDepth)
+	default:
+		matches, err := s.matches(rule, task)
+		if err != nil {
+			run.Status = models.RunFailed
+			run.Error = err.Error()
+			break
+		}
+		if !matches {
+			return
+		}
+
+		// Changes made by the actions belong to the chain of this rule
+		next := chain.then(rule.ID)
+		s.mu.Lock()
+		s.acting[task.ID] = next
+		s.mu.Unlock()
+		run.Status = models.RunSucceeded
+		for _, action := range rule.Actions {
+			if err := s.execute(rule, action, task, next); err != nil {
+				run.Status = models.RunFailed
+				run.Error = err.Error()
+				break
+			}
+			run.Actions = append(run.Actions, describeAction(action, task))
+		}
+		s.mu.Lock()
+		delete(s.acting, task.ID)
+		s.mu.Unlock()
+	}
+
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Error().Err(err).Str("rule_id", rule.ID).Msg("Failed to log automation run")
+	}
+}
+
+// dropRule logs a failed run of a rule whose trigger fired on a task while
+// the queue was full, unless the condition does not match.
+func (s *automationService) dropRule(rule models.AutomationRule, task models.Task, chain *automationChain) {
+	if matches, err := s.matches(rule, task); err == nil && !matches {
+		return
+	}
+	run := s.newRun(rule, task, chain)
+	run.Status = models.RunFailed
+	run.Error = "the automation queue was full, so the rule did not run"
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Error().Err(err).Str("rule_id", rule.ID).Msg("Failed to log automation run")
+	}
+}
+
+func (s *automationService) newRun(rule models.AutomationRule, task models.Task, chain *automationChain) models.AutomationRun {
+	return models.AutomationRun{
+		ID:        uuid.New().String(),
+		RuleID:    rule.ID,
+		TaskID:    task.ID,
+		Trigger:   rule.Trigger,
+		Depth:     chain.depth,
+		Actions:   []string{},
+		CreatedAt: s.clock.Now(),
+	}
+}
+
+// matches reports whether a task matches the condition of a rule.
+func (s *automationService) matches(rule models.AutomationRule, task models.Task) (bool, error) {
+	if !inScope(rule, task) {
+		return false, nil
+	}
+	condition, err := compileFilter(rule.Condition, s.clock.Now(), "")
+	if err != nil {
+		return false, err
+	}
+	tasks, err := s.taskRepo.FindAll(repository.TaskFilter{TaskID: task.ID, Where: condition})
+	if err != nil {
+		return false, err
+	}
+	return len(tasks) > 0, nil
+}
+
+// inScope reports whether a task is in the project of a rule.
+func inScope(rule models.AutomationRule, task models.Task) bool {
+	return rule.ProjectID == nil || sameID(rule.ProjectID, task.ProjectID)
+}
+
+func validStatus(status string) bool {
+	for _, s := range models.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
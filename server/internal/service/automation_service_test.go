@@ -0,0 +1,229 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"taskmanager/internal/clock"
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+)
+
+// fakeAutomationRepository keeps rules and runs in memory.
+type fakeAutomationRepository struct {
+	repository.AutomationRepository
+	rules []models.AutomationRule
+	runs  []models.AutomationRun
+}
+
+func (r *fakeAutomationRepository) FindEnabled(trigger string) ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	for _, rule := range r.rules {
+		if rule.Enabled && rule.Trigger == trigger {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *fakeAutomationRepository) CreateRun(run models.AutomationRun) error {
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+// fakeTaskRepository finds the tasks of fakeTaskService.
+type fakeTaskRepository struct {
+	repository.TaskRepository
+	tasks map[string]models.Task
+}
+
+func (r *fakeTaskRepository) FindByID(id string) (models.Task, error) {
+	task, ok := r.tasks[id]
+	if !ok {
+		return models.Task{}, repository.ErrNotFound
+	}
+	return task, nil
+}
+
+// FindAll only looks at the task ID, as the rules under test have no
+// condition.
+func (r *fakeTaskRepository) FindAll(filter repository.TaskFilter) ([]models.Task, error) {
+	if task, ok := r.tasks[filter.TaskID]; ok {
+		return []models.Task{task}, nil
+	}
+	return nil, nil
+}
+
+// fakeTaskService creates tasks and publishes their creation to the
+// automation service in the caller's goroutine, as the event bus does.
+type fakeTaskService struct {
+	TaskService
+	repo      *fakeTaskRepository
+	publisher events.Publisher
+}
+
+func (s *fakeTaskService) CreateTask(input models.CreateTaskInput) (models.Task, error) {
+	task := models.Task{ID: fmt.Sprintf("task-%d", len(s.repo.tasks)+1), Title: input.Title, ProjectID: input.ProjectID}
+	s.repo.tasks[task.ID] = task
+	s.publisher.Publish(events.Event{Type: events.TaskCreated, TaskID: task.ID, At: time.Now()})
+	return task, nil
+}
+
+type automationFixture struct {
+	service *automationService
+	repo    *fakeAutomationRepository
+	tasks   *fakeTaskService
+}
+
+func newAutomationFixture(rules ...models.AutomationRule) automationFixture {
+	repo := &fakeAutomationRepository{rules: rules}
+	taskRepo := &fakeTaskRepository{tasks: make(map[string]models.Task)}
+	bus := events.NewBus()
+	tasks := &fakeTaskService{repo: taskRepo, publisher: bus}
+	service := NewAutomationService(repo, taskRepo, tasks, nil, nil, nil, clock.Fixed(time.Now())).(*automationService)
+	bus.Subscribe(service.HandleEvent)
+	return automationFixture{service: service, repo: repo, tasks: tasks}
+}
+
+// drain processes the queued jobs, failing after max of them.
+func (f automationFixture) drain(t *testing.T, max int) {
+	t.Helper()
+	for processed := 0; ; processed++ {
+		select {
+		case job := <-f.service.queue:
+			if processed == max {
+				t.Fatalf("still processing after %d jobs", max)
+			}
+			f.service.process(job, f.service.runRule)
+		default:
+			return
+		}
+	}
+}
+
+func TestAutomationCreateTaskLoop(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []models.AutomationRule
+		status []string
+	}{
+		{
+			name: "rule creating tasks on task creation",
+			rules: []models.AutomationRule{
+				{ID: "follow-up", Enabled: true, Trigger: models.TriggerTaskCreated, Actions: []models.AutomationAction{{Type: models.ActionCreateTask, Value: "Follow up"}}},
+			},
+			status: []string{models.RunSucceeded, models.RunSkipped},
+		},
+		{
+			name: "two rules creating tasks for each other",
+			rules: []models.AutomationRule{
+				{ID: "a", Enabled: true, Trigger: models.TriggerTaskCreated, Actions: []models.AutomationAction{{Type: models.ActionCreateTask, Value: "From a"}}},
+				{ID: "b", Enabled: true, Trigger: models.TriggerTaskCreated, Actions: []models.AutomationAction{{Type: models.ActionCreateTask, Value: "From b"}}},
+			},
+			// The task created by hand runs a and b. Their tasks each run
+			// the other rule, whose tasks then find both rules ran.
+			status: []string{
+				models.RunSucceeded, models.RunSucceeded,
+				models.RunSkipped, models.RunSucceeded,
+				models.RunSucceeded, models.RunSkipped,
+				models.RunSkipped, models.RunSkipped,
+				models.RunSkipped, models.RunSkipped,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAutomationFixture(tt.rules...)
+			if _, err := f.tasks.CreateTask(models.CreateTaskInput{Title: "By hand"}); err != nil {
+				t.Fatal(err)
+			}
+			f.drain(t, 50)
+
+			var status []string
+			for _, run := range f.repo.runs {
+				status = append(status, run.Status)
+			}
+			if fmt.Sprint(status) != fmt.Sprint(tt.status) {
+				t.Errorf("runs %v, want %v", status, tt.status)
+			}
+			if len(f.service.spawned) != 0 {
+				t.Errorf("%d created tasks left waiting for their chain", len(f.service.spawned))
+			}
+		})
+	}
+}
+
+func TestAutomationChainDepth(t *testing.T) {
+	// Each rule creates a task that only the next rule reacts to, so no rule
+	// runs twice and the chain ends at its depth limit.
+	var rules []models.AutomationRule
+	for i := 0; i < maxAutomationDepth+2; i++ {
+		rules = append(rules, models.AutomationRule{
+			ID:      fmt.Sprintf("rule-%d", i),
+			Enabled: true,
+			Trigger: models.TriggerTaskCreated,
+			Actions: []models.AutomationAction{{Type: models.ActionCreateTask, Value: fmt.Sprintf("Step %d", i+1)}},
+		})
+	}
+	f := newAutomationFixture(rules...)
+	f.repo.rules = rules[:1]
+	f.service.tasks = &chainedTaskService{fakeTaskService: f.tasks, repo: f.repo, rules: rules}
+	if _, err := f.tasks.CreateTask(models.CreateTaskInput{Title: "Step 0"}); err != nil {
+		t.Fatal(err)
+	}
+	f.drain(t, 50)
+
+	var depths []string
+	for _, run := range f.repo.runs {
+		depths = append(depths, fmt.Sprintf("%d:%s", run.Depth, run.Status))
+	}
+	want := "[0:succeeded 1:succeeded 2:succeeded 3:succeeded 4:succeeded 5:skipped]"
+	if fmt.Sprint(depths) != want {
+		t.Errorf("runs %v, want %s", depths, want)
+	}
+}
+
+// chainedTaskService enables the rule after the one that created a task,
+// so each created task is seen by one new rule.
+type chainedTaskService struct {
+	*fakeTaskService
+	repo  *fakeAutomationRepository
+	rules []models.AutomationRule
+}
+
+func (s *chainedTaskService) CreateTask(input models.CreateTaskInput) (models.Task, error) {
+	var step int
+	fmt.Sscanf(input.Title, "Step %d", &step)
+	if step < len(s.rules) {
+		s.repo.rules = []models.AutomationRule{s.rules[step]}
+	}
+	return s.fakeTaskService.CreateTask(input)
+}
+
+func TestAutomationQueueFull(t *testing.T) {
+	f := newAutomationFixture(models.AutomationRule{
+		ID: "follow-up", Enabled: true, Trigger: models.TriggerTaskCreated,
+		Actions: []models.AutomationAction{{Type: models.ActionCreateTask, Value: "Follow up"}},
+	})
+	f.service.queue = make(chan automationJob, 1)
+	for _, title := range []string{"Queued", "Dropped"} {
+		if _, err := f.tasks.CreateTask(models.CreateTaskInput{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.drain(t, 10)
+
+	var runs []string
+	for _, run := range f.repo.runs {
+		runs = append(runs, run.TaskID+":"+run.Status)
+	}
+	want := "[task-2:failed task-1:succeeded task-3:skipped]"
+	if fmt.Sprint(runs) != want {
+		t.Errorf("runs %v, want %s", runs, want)
+	}
+	if got := f.repo.runs[0].Error; got != "the automation queue was full, so the rule did not run" {
+		t.Errorf("error of the dropped run %q", got)
+	}
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/ical"
+	"taskmanager/internal/models"
+	"taskmanager/internal/recurrence"
+	"taskmanager/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// davInboxName is the display name of the inbox collection
+const davInboxName = "Inbox"
+
+var (
+	// ErrPreconditionFailed is returned when the If-Match or If-None-Match
+	// condition of a CalDAV request does not hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrUIDConflict is returned when a to-do is stored under a UID that
+	// another resource has, or a resource is given a new UID.
+	ErrUIDConflict = errors.New("UID is used by another resource")
+)
+
+type CalDAVService interface {
+	// IssuePassword gives a user a new password for CalDAV clients, which
+	// replaces any previous one. Only a hash of it is kept.
+	IssuePassword(userID string) (models.DAVCredentials, error)
+	// RevokePassword signs the CalDAV clients of a user out.
+	RevokePassword(userID string) error
+	// Authenticate returns the user with a username and CalDAV password,
+	// and false when they do not match.
+	Authenticate(username, password string) (models.User, bool, error)
+	// Collections lists the calendar collections: the inbox, then the
+	// projects.
+	Collections() ([]models.DAVCollection, error)
+	Collection(id string) (models.DAVCollection, error)
+	// Objects lists the to-dos of the tasks of a collection.
+	Objects(collectionID string) ([]models.DAVObject, error)
+	Object(collectionID, name string) (models.DAVObject, error)
+	// PutObject creates or replaces the task of a to-do for the user
+	// actorID, given the If-Match and If-None-Match headers of the
+	// request. It reports whether the task was created.
+	PutObject(collectionID, name string, r io.Reader, ifMatch, ifNoneMatch, actorID string) (bool, error)
+	// DeleteObject deletes the task of a to-do for the user actorID, given
+	// the If-Match header of the request.
+	DeleteObject(collectionID, name, ifMatch, actorID string) error
+}
+
+type calDAVService struct {
+	repo     repository.DAVRepository
+	users    repository.UserRepository
+	projects ProjectService
+	tasks    TaskService
+}
+
+func NewCalDAVService(repo repository.DAVRepository, users repository.UserRepository, projects ProjectService, tasks TaskService) CalDAVService {
+	return &calDAVService{repo: repo, users: users, projects: projects, tasks: tasks}
+}
+
+func (s *calDAVService) IssuePassword(userID string) (models.DAVCredentials, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return models.DAVCredentials{}, err
+	}
+	password, hash, err := newSecret()
+	if err != nil {
+		return models.DAVCredentials{}, err
+	}
+	user.DAVPasswordHash = &hash
+	user.UpdatedAt = time.Now()
+	if _, err := s.users.Update(user); err != nil {
+		return models.DAVCredentials{}, err
+	}
+	return models.DAVCredentials{Username: user.Username, Password: password}, nil
+}
+
+func (s *calDAVService) RevokePassword(userID string) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	user.DAVPasswordHash = nil
+	user.UpdatedAt = time.Now()
+	_, err = s.users.Update(user)
+	return err
+}
+
+func (s *calDAVService) Authenticate(username, password string) (models.User, bool, error) {
+	users, err := s.users.FindByUsernames([]string{username})
+	if err != nil {
+		return models.User{}, false, err
+	}
+	for _, user := range users {
+		if user.DAVPasswordHash == nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(*user.DAVPasswordHash), []byte(secretHash(password))) == 1 {
+			return user, true, nil
+		}
+	}
+	return models.User{}, false, nil
+}
+
+func (s *calDAVService) Collections() ([]models.DAVCollection, error) {
+	projects, err := s.projects.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.tasks.GetAllTasks(models.TaskQuery{})
+	if err != nil {
+		return nil, err
+	}
+	byProject := make(map[string][]models.Task)
+	for _, task := range tasks {
+		collectionID := models.DAVInbox
+		if task.ProjectID != nil {
+			collectionID = *task.ProjectID
+		}
+		byProject[collectionID] = append(byProject[collectionID], task)
+	}
+
+	collections := []models.DAVCollection{{
+		ID:   models.DAVInbox,
+		Name: davInboxName,
+		CTag: collectionTag(byProject[models.DAVInbox]),
+	}}
+	for _, project := range projects {
+		collections = append(collections, models.DAVCollection{
+			ID:   project.ID,
+			Name: project.Name,
+			CTag: collectionTag(byProject[project.ID]),
+		})
+	}
+	return collections, nil
+}
+
+func (s *calDAVService) Collection(id string) (models.DAVCollection, error) {
+	name := davInboxName
+	if id != models.DAVInbox {
+		project, err := s.projects.GetProjectByID(id)
+		if err != nil {
+			return models.DAVCollection{}, err
+		}
+		name = project.Name
+	}
+	tasks, err := s.collectionTasks(id)
+	if err != nil {
+		return models.DAVCollection{}, err
+	}
+	return models.DAVCollection{ID: id, Name: name, CTag: collectionTag(tasks)}, nil
+}
+
+func (s *calDAVService) Objects(collectionID string) ([]models.DAVObject, error) {
+	if _, err := s.Collection(collectionID); err != nil {
+		return nil, err
+	}
+	tasks, err := s.collectionTasks(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	resources, err := s.repo.FindByTaskIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]models.DAVObject, len(tasks))
+	for i, task := range tasks {
+		objects[i] = davObject(task, resources)
+	}
+	return objects, nil
+}
+
+func (s *calDAVService) Object(collectionID, name string) (models.DAVObject, error) {
+	task, resource, err := s.resolve(collectionID, name)
+	if err != nil {
+		return models.DAVObject{}, err
+	}
+	return s.object(task, resource)
+}
+
+func (s *calDAVService) PutObject(collectionID, name string, r io.Reader, ifMatch, ifNoneMatch, actorID string) (bool, error) {
+	if _, err := s.Collection(collectionID); err != nil {
+		return false, err
+	}
+	todo, err := ical.ParseTodo(r)
+	if err == nil {
+		todo, err = fitSummary(todo)
+	}
+	if err != nil {
+		return false, apperrors.NewValidationError("Invalid calendar data", map[string]string{
+			"calendar": err.Error(),
+		})
+	}
+
+	task, resource, err := s.resolve(collectionID, name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return false, err
+	}
+	if exists {
+		current, err := s.object(task, resource)
+		if err != nil {
+			return false, err
+		}
+		if !putAllowed(true, current.ETag, ifMatch, ifNoneMatch) {
+			return false, ErrPreconditionFailed
+		}
+		if todoUID(task, resource) != todo.UID {
+			return false, ErrUIDConflict
+		}
+		if _, err := s.tasks.UpdateTask(task.ID, updateInputFromTodo(todo), actorID); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if !putAllowed(false, "", ifMatch, ifNoneMatch) {
+		return false, ErrPreconditionFailed
+	}
+	if taken, err := s.uidTaken(todo.UID); err != nil || taken {
+		if err == nil {
+			err = ErrUIDConflict
+		}
+		return false, err
+	}
+	input := createInputFromTodo(todo)
+	if collectionID != models.DAVInbox {
+		input.ProjectID = &collectionID
+	}
+	created, err := s.tasks.CreateTask(input)
+	if err != nil {
+		return false, err
+	}
+	if err := s.repo.Save(models.DAVResource{TaskID: created.ID, Name: name, UID: todo.UID, CreatedAt: time.Now()}); err != nil {
+		if deleteErr := s.tasks.DeleteTask(created.ID, actorID); deleteErr != nil {
+			log.Error().Err(deleteErr).Str("id", created.ID).Msg("Failed to delete task of unsaved DAV resource")
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *calDAVService) DeleteObject(collectionID, name, ifMatch, actorID string) error {
+	task, resource, err := s.resolve(collectionID, name)
+	if err != nil {
+		return err
+	}
+	if ifMatch != "" {
+		current, err := s.object(task, resource)
+		if err != nil {
+			return err
+		}
+		if !ifMatchAllows(ifMatch, current.ETag) {
+			return ErrPreconditionFailed
+		}
+	}
+	if err := s.tasks.DeleteTask(task.ID, actorID); err != nil {
+		return err
+	}
+	if resource != nil {
+		return s.repo.Delete(task.ID)
+	}
+	return nil
+}
+
+// collectionTasks lists the tasks of a collection.
+func (s *calDAVService) collectionTasks(collectionID string) ([]models.Task, error) {
+	if collectionID == models.DAVInbox {
+		return s.tasks.GetAllTasks(models.TaskQuery{Filter: "project:none"})
+	}
+	return s.tasks.GetAllTasks(models.TaskQuery{ProjectID: collectionID})
+}
+
+// resolve finds the task a resource name stands for in a collection: the
+// name a client gave it, or else "<task ID>.ics". The resource is nil for
+// tasks no client created.
+func (s *calDAVService) resolve(collectionID, name string) (models.Task, *models.DAVResource, error) {
+	resource, err := s.repo.FindByName(name)
+	switch {
+	case err == nil:
+		task, err := s.tasks.GetTaskByID(resource.TaskID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// The task was deleted outside CalDAV, which frees its name
+			if err := s.repo.Delete(resource.TaskID); err != nil {
+				return models.Task{}, nil, err
+			}
+		}
+		if err != nil {
+			return models.Task{}, nil, err
+		}
+		if !inCollection(task, collectionID) {
+			return models.Task{}, nil, repository.ErrNotFound
+		}
+		return task, &resource, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		log.Error().Err(err).Str("name", name).Msg("Failed to find DAV resource")
+		return models.Task{}, nil, err
+	}
+
+	id, ok := strings.CutSuffix(name, ".ics")
+	if !ok {
+		return models.Task{}, nil, repository.ErrNotFound
+	}
+	task, err := s.tasks.GetTaskByID(id)
+	if err != nil {
+		return models.Task{}, nil, err
+	}
+	if !inCollection(task, collectionID) {
+		return models.Task{}, nil, repository.ErrNotFound
+	}
+	// Tasks created by a client are only served under the name it gave
+	resources, err := s.repo.FindByTaskIDs([]string{task.ID})
+	if err != nil {
+		return models.Task{}, nil, err
+	}
+	if _, ok := resources[task.ID]; ok {
+		return models.Task{}, nil, repository.ErrNotFound
+	}
+	return task, nil, nil
+}
+
+// object writes the to-do of a task, with the UID of its parent when a
+// client created the parent.
+func (s *calDAVService) object(task models.Task, resource *models.DAVResource) (models.DAVObject, error) {
+	ids := []string{task.ID}
+	if task.ParentID != nil {
+		ids = append(ids, *task.ParentID)
+	}
+	resources, err := s.repo.FindByTaskIDs(ids)
+	if err != nil {
+		return models.DAVObject{}, err
+	}
+	if resource != nil {
+		resources[task.ID] = *resource
+	}
+	return davObject(task, resources), nil
+}
+
+// uidTaken reports whether a UID is the UID of a stored to-do.
+func (s *calDAVService) uidTaken(uid string) (bool, error) {
+	if _, err := s.repo.FindByUID(uid); err == nil {
+		return true, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return false, err
+	}
+	id, ok := strings.CutSuffix(uid, "@"+ical.UIDDomain)
+	if !ok {
+		return false, nil
+	}
+	if _, err := s.tasks.GetTaskByID(id); err == nil {
+		return true, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return false, err
+	}
+	return false, nil
+}
+
+func inCollection(task models.Task, collectionID string) bool {
+	if collectionID == models.DAVInbox {
+		return task.ProjectID == nil
+	}
+	return task.ProjectID != nil && *task.ProjectID == collectionID
+}
+
+// davObject writes the to-do of a task, taking names and UIDs from the
+// resources of the tasks clients created.
+func davObject(task models.Task, resources map[string]models.DAVResource) models.DAVObject {
+	calendar := ical.NewObject()
+	calendar.UIDs = make(map[string]string, len(resources))
+	for taskID, resource := range resources {
+		calendar.UIDs[taskID] = resource.UID
+	}
+	calendar.AddTodo(task)
+	data := calendar.Bytes()
+
+	name := task.ID + ".ics"
+	if resource, ok := resources[task.ID]; ok {
+		name = resource.Name
+	}
+	sum := sha256.Sum256(data)
+	return models.DAVObject{
+		Name:   name,
+		TaskID: task.ID,
+		ETag:   `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Data:   data,
+	}
+}
+
+// todoUID returns the UID the to-do of a task is served with.
+func todoUID(task models.Task, resource *models.DAVResource) string {
+	if resource != nil {
+		return resource.UID
+	}
+	return ical.UID(task.ID)
+}
+
+// collectionTag changes whenever a task is added to, changed in or
+// removed from a collection.
+func collectionTag(tasks []models.Task) string {
+	entries := make([]string, len(tasks))
+	for i, task := range tasks {
+		entries[i] = task.ID + "@" + strconv.FormatInt(task.UpdatedAt.UnixNano(), 10)
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// putAllowed reports whether the If-Match and If-None-Match headers of a
+// PUT allow it, for a resource that exists with an ETag or not at all.
+// "If-None-Match: *" only allows creating, and any If-Match only allows
+// changing.
+func putAllowed(exists bool, etag, ifMatch, ifNoneMatch string) bool {
+	if !exists {
+		return ifMatch == ""
+	}
+	return ifNoneMatch != "*" && ifMatchAllows(ifMatch, etag)
+}
+
+// ifMatchAllows reports whether an If-Match header allows a resource with
+// an ETag to be changed. An empty header allows it.
+func ifMatchAllows(ifMatch, etag string) bool {
+	if ifMatch == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// todoRecurrence returns the repeat rule of a to-do, or "" for none or
+// for a rule tasks cannot follow.
+func todoRecurrence(todo ical.Todo) string {
+	if todo.RRule == "" {
+		return ""
+	}
+	rule, err := recurrence.Parse(todo.RRule)
+	if err != nil {
+		return ""
+	}
+	return rule.String()
+}
+
+// fitSummary fits the summary of a to-do to the length of task titles, as
+// clients allow any length. A long summary is cut and kept in full at the
+// start of the description, unless the description already starts with
+// it, and a short one is padded with ellipses.
+func fitSummary(todo ical.Todo) (ical.Todo, error) {
+	summary := strings.Join(strings.Fields(todo.Summary), " ")
+	length := utf8.RuneCountInString(summary)
+	switch {
+	case length == 0:
+		return ical.Todo{}, errors.New("VTODO without SUMMARY")
+	case length < minTitleLength:
+		summary += strings.Repeat("…", minTitleLength-length)
+	case length > maxTitleLength:
+		if !strings.HasPrefix(todo.Description, todo.Summary) {
+			todo.Description = strings.TrimSpace(todo.Summary + "\n\n" + todo.Description)
+		}
+		summary = truncate(summary, maxTitleLength)
+	}
+	todo.Summary = summary
+	return todo, nil
+}
+
+func createInputFromTodo(todo ical.Todo) models.CreateTaskInput {
+	return models.CreateTaskInput{
+		Title:       todo.Summary,
+		Description: todo.Description,
+		DueDate:     todo.Due,
+		DueTimeZone: todo.DueTimeZone,
+		Completed:   todo.Status == models.StatusDone,
+		Status:      todo.Status,
+		Priority:    todo.Priority,
+		Recurrence:  todoRecurrence(todo),
+		Tags:        todo.Categories,
+	}
+}
+
+// updateInputFromTodo replaces the fields of a task a to-do has, clearing
+// those the to-do leaves out.
+func updateInputFromTodo(todo ical.Todo) models.UpdateTaskInput {
+	due := todo.Due
+	if due == "" {
+		due = "none"
+	}
+	repeat := todoRecurrence(todo)
+	tags := todo.Categories
+	if tags == nil {
+		tags = []string{}
+	}
+	return models.UpdateTaskInput{
+		Title:       todo.Summary,
+		Description: todo.Description,
+		DueDate:     due,
+		DueTimeZone: todo.DueTimeZone,
+		Completed:   todo.Status == models.StatusDone,
+		Status:      todo.Status,
+		Priority:    todo.Priority,
+		Recurrence:  &repeat,
+		Tags:        tags,
+	}
+}
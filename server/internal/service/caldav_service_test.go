@@ -0,0 +1,110 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"taskmanager/internal/ical"
+)
+
+func TestFitSummary(t *testing.T) {
+	long := strings.Repeat("Pick up ", 15) + "groceries"
+	tests := []struct {
+		name            string
+		summary         string
+		description     string
+		wantSummary     string
+		wantDescription string
+		wantErr         bool
+	}{
+		{name: "fits", summary: "Call the dentist", description: "Before noon", wantSummary: "Call the dentist", wantDescription: "Before noon"},
+		{name: "two characters", summary: "Go", wantSummary: "Go…"},
+		{name: "one character", summary: "X", description: "Notes", wantSummary: "X……", wantDescription: "Notes"},
+		{name: "spaces collapsed", summary: "Call  the\tdentist", wantSummary: "Call the dentist"},
+		{name: "exactly the limit", summary: strings.Repeat("ü", 100), wantSummary: strings.Repeat("ü", 100)},
+		{name: "long without description", summary: long, wantSummary: long[:100], wantDescription: long},
+		{name: "long with description", summary: long, description: "From the fridge list", wantSummary: long[:100], wantDescription: long + "\n\nFrom the fridge list"},
+		{name: "long kept once", summary: long, description: long + "\n\nFrom the fridge list", wantSummary: long[:100], wantDescription: long + "\n\nFrom the fridge list"},
+		{name: "empty", summary: "", wantErr: true},
+		{name: "blank", summary: " \t ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fitSummary(ical.Todo{UID: "uid-1", Summary: tt.summary, Description: tt.description})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("fitSummary() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fitSummary() error = %v", err)
+			}
+			if got.Summary != tt.wantSummary || got.Description != tt.wantDescription {
+				t.Errorf("fitSummary() = %q, %q; want %q, %q", got.Summary, got.Description, tt.wantSummary, tt.wantDescription)
+			}
+			if got.UID != "uid-1" {
+				t.Errorf("UID = %q, want it kept", got.UID)
+			}
+		})
+	}
+}
+
+func TestIfMatchAllows(t *testing.T) {
+	const etag = `"5d41402abc4b2a76"`
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{name: "no header", ifMatch: "", want: true},
+		{name: "same", ifMatch: `"5d41402abc4b2a76"`, want: true},
+		{name: "weak", ifMatch: `W/"5d41402abc4b2a76"`, want: true},
+		{name: "any", ifMatch: "*", want: true},
+		{name: "in a list", ifMatch: `"0000", "5d41402abc4b2a76"`, want: true},
+		{name: "stale", ifMatch: `"0000"`, want: false},
+		{name: "unquoted", ifMatch: `5d41402abc4b2a76`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ifMatchAllows(tt.ifMatch, etag); got != tt.want {
+				t.Errorf("ifMatchAllows(%q) = %v, want %v", tt.ifMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPutAllowed(t *testing.T) {
+	const etag = `"5d41402abc4b2a76"`
+	tests := []struct {
+		name        string
+		exists      bool
+		ifMatch     string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "create", exists: false, want: true},
+		{name: "create only", exists: false, ifNoneMatch: "*", want: true},
+		{name: "change of a missing resource", exists: false, ifMatch: etag, want: false},
+		{name: "any of a missing resource", exists: false, ifMatch: "*", want: false},
+		{name: "overwrite", exists: true, want: true},
+		{name: "create over an existing resource", exists: true, ifNoneMatch: "*", want: false},
+		{name: "change", exists: true, ifMatch: etag, want: true},
+		{name: "change of a stale copy", exists: true, ifMatch: `"0000"`, want: false},
+		{name: "both headers", exists: true, ifMatch: etag, ifNoneMatch: "*", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current string
+			if tt.exists {
+				current = etag
+			}
+			if got := putAllowed(tt.exists, current, tt.ifMatch, tt.ifNoneMatch); got != tt.want {
+				t.Errorf("putAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
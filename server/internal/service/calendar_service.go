@@ -0,0 +1,358 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"taskmanager/internal/calendar"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultWorkingDays are the working days of calendars created without
+// any
+var defaultWorkingDays = []string{"mon", "tue", "wed", "thu", "fri"}
+
+type CalendarService interface {
+	GetAllCalendars() ([]models.Calendar, error)
+	GetCalendarByID(id string) (models.Calendar, error)
+	CreateCalendar(input models.CalendarInput) (models.Calendar, error)
+	UpdateCalendar(id string, input models.CalendarInput) (models.Calendar, error)
+	DeleteCalendar(id string) error
+	AddHoliday(calendarID string, input models.HolidayInput) (models.Holiday, error)
+	DeleteHoliday(calendarID, id string) error
+	// ImportHolidays adds the events of an iCalendar file as holidays.
+	ImportHolidays(calendarID string, r io.Reader) (models.HolidayImport, error)
+
+	// ForProject returns the business calendar of a project, the default
+	// calendar for tasks without one, or calendar.Standard when there is
+	// no default.
+	ForProject(projectID *string) (calendar.Calendar, error)
+	// AddBusinessDays returns the date days working days from a date in
+	// the project's calendar.
+	AddBusinessDays(projectID *string, from time.Time, days int) (time.Time, error)
+	// IsWorkingTime reports whether at is working time in the project's
+	// calendar.
+	IsWorkingTime(projectID *string, at time.Time) (bool, error)
+
+	// BusinessDays adds days working days to a date (YYYY-MM-DD) in a
+	// calendar.
+	BusinessDays(calendarID, from string, days int) (models.BusinessDaysResult, error)
+	// WorkingTime tells whether at is working time in a calendar.
+	WorkingTime(calendarID string, at time.Time) (models.WorkingTimeResult, error)
+}
+
+type calendarService struct {
+	repo      repository.CalendarRepository
+	projects  repository.ProjectRepository
+	validator *validator.Validate
+}
+
+func NewCalendarService(repo repository.CalendarRepository, projects repository.ProjectRepository) CalendarService {
+	return &calendarService{
+		repo:      repo,
+		projects:  projects,
+		validator: validator.New(),
+	}
+}
+
+func (s *calendarService) GetAllCalendars() ([]models.Calendar, error) {
+	calendars, err := s.repo.FindAll()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all calendars from repository")
+		return nil, err
+	}
+	return calendars, nil
+}
+
+func (s *calendarService) GetCalendarByID(id string) (models.Calendar, error) {
+	cal, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch calendar from repository")
+		return models.Calendar{}, err
+	}
+	return cal, nil
+}
+
+func (s *calendarService) CreateCalendar(input models.CalendarInput) (models.Calendar, error) {
+	cal := models.Calendar{
+		ID:        uuid.New().String(),
+		Holidays:  []models.Holiday{},
+		CreatedAt: time.Now(),
+	}
+	if err := s.apply(&cal, input); err != nil {
+		return models.Calendar{}, err
+	}
+
+	createdCalendar, err := s.repo.Create(cal)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create calendar in repository")
+		return models.Calendar{}, err
+	}
+	return createdCalendar, nil
+}
+
+func (s *calendarService) UpdateCalendar(id string, input models.CalendarInput) (models.Calendar, error) {
+	cal, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find calendar for update")
+		return models.Calendar{}, err
+	}
+	if err := s.apply(&cal, input); err != nil {
+		return models.Calendar{}, err
+	}
+
+	updatedCalendar, err := s.repo.Update(cal)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update calendar in repository")
+		return models.Calendar{}, err
+	}
+	return updatedCalendar, nil
+}
+
+// apply validates a CalendarInput and copies it onto a calendar, filling in
+// the defaults.
+func (s *calendarService) apply(cal *models.Calendar, input models.CalendarInput) error {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CalendarInput")
+		return err
+	}
+
+	cal.Name = input.Name
+	cal.TimeZone = timeZoneOrDefault(input.TimeZone)
+	cal.WorkingDays = normalizeWorkingDays(input.WorkingDays)
+	cal.WorkStart = orDefault(input.WorkStart, "09:00")
+	cal.WorkEnd = orDefault(input.WorkEnd, "17:00")
+	cal.IsDefault = input.IsDefault
+	cal.UpdatedAt = time.Now()
+	if cal.WorkEnd <= cal.WorkStart {
+		return apperrors.NewValidationError("Invalid work hours", map[string]string{
+			"work_end": "must be after work_start",
+		})
+	}
+	return nil
+}
+
+func (s *calendarService) DeleteCalendar(id string) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete calendar from repository")
+		return err
+	}
+	return nil
+}
+
+func (s *calendarService) AddHoliday(calendarID string, input models.HolidayInput) (models.Holiday, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for HolidayInput")
+		return models.Holiday{}, err
+	}
+	if _, err := s.repo.FindByID(calendarID); err != nil {
+		return models.Holiday{}, err
+	}
+
+	holiday := models.Holiday{
+		ID:         uuid.New().String(),
+		CalendarID: calendarID,
+		Date:       input.Date,
+		Name:       input.Name,
+	}
+	if err := s.repo.SaveHolidays([]models.Holiday{holiday}); err != nil {
+		return models.Holiday{}, err
+	}
+	return holiday, nil
+}
+
+func (s *calendarService) DeleteHoliday(calendarID, id string) error {
+	return s.repo.DeleteHoliday(calendarID, id)
+}
+
+func (s *calendarService) ImportHolidays(calendarID string, r io.Reader) (models.HolidayImport, error) {
+	if _, err := s.repo.FindByID(calendarID); err != nil {
+		return models.HolidayImport{}, err
+	}
+
+	parsed, skipped, err := calendar.ParseHolidays(r)
+	if err != nil {
+		return models.HolidayImport{}, apperrors.NewValidationError("Invalid iCalendar file", map[string]string{
+			"file": err.Error(),
+		})
+	}
+
+	// One holiday per date, named after every event on it
+	byDate := make(map[string]int)
+	var holidays []models.Holiday
+	for _, day := range parsed {
+		if i, ok := byDate[day.Date]; ok {
+			if day.Name != "" && !strings.Contains(holidays[i].Name, day.Name) {
+				holidays[i].Name = strings.TrimPrefix(holidays[i].Name+", "+day.Name, ", ")
+			}
+			continue
+		}
+		byDate[day.Date] = len(holidays)
+		holidays = append(holidays, models.Holiday{
+			ID:         uuid.New().String(),
+			CalendarID: calendarID,
+			Date:       day.Date,
+			Name:       truncate(day.Name, 255),
+			UID:        truncate(day.UID, 255),
+		})
+	}
+	if err := s.repo.SaveHolidays(holidays); err != nil {
+		return models.HolidayImport{}, err
+	}
+	return models.HolidayImport{Imported: len(holidays), Skipped: skipped}, nil
+}
+
+func (s *calendarService) ForProject(projectID *string) (calendar.Calendar, error) {
+	var (
+		cal models.Calendar
+		err error
+	)
+	if projectID != nil {
+		project, err := s.projects.FindByID(*projectID)
+		if err != nil {
+			return calendar.Calendar{}, err
+		}
+		if project.CalendarID != nil {
+			cal, err = s.repo.FindByID(*project.CalendarID)
+			if err != nil {
+				return calendar.Calendar{}, err
+			}
+			return toCalendar(cal), nil
+		}
+	}
+
+	cal, err = s.repo.FindDefault()
+	if errors.Is(err, repository.ErrNotFound) {
+		return calendar.Standard(), nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find default calendar")
+		return calendar.Calendar{}, err
+	}
+	return toCalendar(cal), nil
+}
+
+func (s *calendarService) AddBusinessDays(projectID *string, from time.Time, days int) (time.Time, error) {
+	cal, err := s.ForProject(projectID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cal.AddBusinessDays(from, days), nil
+}
+
+func (s *calendarService) IsWorkingTime(projectID *string, at time.Time) (bool, error) {
+	cal, err := s.ForProject(projectID)
+	if err != nil {
+		return false, err
+	}
+	return cal.IsWorkingTime(at), nil
+}
+
+func (s *calendarService) BusinessDays(calendarID, from string, days int) (models.BusinessDaysResult, error) {
+	date, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return models.BusinessDaysResult{}, apperrors.NewValidationError("Invalid date", map[string]string{
+			"from": "must be a date in YYYY-MM-DD format",
+		})
+	}
+	stored, err := s.repo.FindByID(calendarID)
+	if err != nil {
+		return models.BusinessDaysResult{}, err
+	}
+
+	cal := toCalendar(stored)
+	due := cal.AddBusinessDays(cal.Date(date.Date()), days)
+	return models.BusinessDaysResult{From: from, Days: days, Date: due.Format("2006-01-02")}, nil
+}
+
+func (s *calendarService) WorkingTime(calendarID string, at time.Time) (models.WorkingTimeResult, error) {
+	stored, err := s.repo.FindByID(calendarID)
+	if err != nil {
+		return models.WorkingTimeResult{}, err
+	}
+
+	cal := toCalendar(stored)
+	return models.WorkingTimeResult{
+		At:              at,
+		Working:         cal.IsWorkingTime(at),
+		NextWorkingTime: cal.NextWorkingTime(at),
+	}, nil
+}
+
+// toCalendar converts a stored calendar for date arithmetic.
+func toCalendar(cal models.Calendar) calendar.Calendar {
+	location, err := time.LoadLocation(cal.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	result := calendar.Calendar{
+		Location: location,
+		Start:    clockOffset(cal.WorkStart),
+		End:      clockOffset(cal.WorkEnd),
+		Holidays: make(map[string]bool, len(cal.Holidays)),
+	}
+	for day, name := range models.Weekdays {
+		for _, working := range cal.WorkingDays {
+			if working == name {
+				result.WorkingDays[day] = true
+			}
+		}
+	}
+	for _, holiday := range cal.Holidays {
+		result.Holidays[holiday.Date] = true
+	}
+	return result
+}
+
+// clockOffset converts HH:MM into the time since midnight.
+func clockOffset(clock string) time.Duration {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// normalizeWorkingDays sorts working days from Sunday and removes
+// duplicates.
+func normalizeWorkingDays(days []string) []string {
+	if len(days) == 0 {
+		return defaultWorkingDays
+	}
+	normalized := []string{}
+	for _, name := range models.Weekdays {
+		for _, day := range days {
+			if day == name {
+				normalized = append(normalized, name)
+				break
+			}
+		}
+	}
+	return normalized
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// truncate cuts text to at most max runes.
+func truncate(text string, max int) string {
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max])
+	}
+	return text
+}
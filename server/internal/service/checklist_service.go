@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrChecklistMismatch is returned when a reorder request does not list
+// exactly the items currently on the checklist.
+var ErrChecklistMismatch = errors.New("item_ids must list every checklist item exactly once")
+
+type ChecklistService interface {
+	ListItems(taskID string) ([]models.ChecklistItem, error)
+	AddItem(taskID string, input models.CreateChecklistItemInput) (models.ChecklistItem, error)
+	UpdateItem(taskID, itemID string, input models.UpdateChecklistItemInput) (models.ChecklistItem, error)
+	ToggleItem(taskID, itemID string) (models.ChecklistItem, error)
+	DeleteItem(taskID, itemID string) error
+	ReorderItems(taskID string, input models.ReorderChecklistInput) ([]models.ChecklistItem, error)
+	ConvertItem(taskID, itemID string) (models.Task, error)
+}
+
+type checklistService struct {
+	repo      repository.ChecklistRepository
+	tasks     TaskService
+	validator *validator.Validate
+}
+
+func NewChecklistService(repo repository.ChecklistRepository, tasks TaskService) ChecklistService {
+	return &checklistService{
+		repo:      repo,
+		tasks:     tasks,
+		validator: validator.New(),
+	}
+}
+
+func (s *checklistService) ListItems(taskID string) ([]models.ChecklistItem, error) {
+	if _, err := s.tasks.GetTaskByID(taskID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByTask(taskID)
+}
+
+func (s *checklistService) AddItem(taskID string, input models.CreateChecklistItemInput) (models.ChecklistItem, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateChecklistItemInput")
+		return models.ChecklistItem{}, err
+	}
+	if _, err := s.tasks.GetTaskByID(taskID); err != nil {
+		return models.ChecklistItem{}, err
+	}
+
+	item := models.ChecklistItem{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		Text:      input.Text,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	createdItem, err := s.repo.Create(item)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create checklist item in repository")
+		return models.ChecklistItem{}, err
+	}
+	return createdItem, nil
+}
+
+func (s *checklistService) UpdateItem(taskID, itemID string, input models.UpdateChecklistItemInput) (models.ChecklistItem, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateChecklistItemInput")
+		return models.ChecklistItem{}, err
+	}
+
+	item, err := s.findTaskItem(taskID, itemID)
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+	item.Text = input.Text
+	item.Done = input.Done
+	item.UpdatedAt = time.Now()
+	return s.repo.Update(item)
+}
+
+func (s *checklistService) ToggleItem(taskID, itemID string) (models.ChecklistItem, error) {
+	item, err := s.findTaskItem(taskID, itemID)
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+	item.Done = !item.Done
+	item.UpdatedAt = time.Now()
+	return s.repo.Update(item)
+}
+
+func (s *checklistService) DeleteItem(taskID, itemID string) error {
+	item, err := s.findTaskItem(taskID, itemID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(item.ID)
+}
+
+func (s *checklistService) ReorderItems(taskID string, input models.ReorderChecklistInput) ([]models.ChecklistItem, error) {
+	items, err := s.ListItems(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.ItemIDs) != len(items) {
+		return nil, ErrChecklistMismatch
+	}
+	remaining := make(map[string]bool, len(items))
+	for _, item := range items {
+		remaining[item.ID] = true
+	}
+	for _, id := range input.ItemIDs {
+		if !remaining[id] {
+			return nil, ErrChecklistMismatch
+		}
+		delete(remaining, id)
+	}
+
+	if err := s.repo.Reorder(taskID, input.ItemIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByTask(taskID)
+}
+
+// ConvertItem promotes a checklist item to a task of its own. The item is
+// only removed once the new task has been created. Text too long for a
+// title is cut, and kept in full in the description.
+func (s *checklistService) ConvertItem(taskID, itemID string) (models.Task, error) {
+	item, err := s.findTaskItem(taskID, itemID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	parent, err := s.tasks.GetTaskByID(taskID)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	title := strings.Join(strings.Fields(item.Text), " ")
+	if utf8.RuneCountInString(title) < minTitleLength {
+		return models.Task{}, apperrors.NewValidationError("Invalid checklist item", map[string]string{
+			"text": fmt.Sprintf("must have at least %d characters to become a task", minTitleLength),
+		})
+	}
+	description := fmt.Sprintf("Converted from a checklist item of %q.", parent.Title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		description = item.Text + "\n\n" + description
+		title = truncate(title, maxTitleLength)
+	}
+
+	task, err := s.tasks.CreateTask(models.CreateTaskInput{
+		Title:       title,
+		Description: description,
+		Completed:   item.Done,
+		ProjectID:   parent.ProjectID,
+	})
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	if err := s.repo.Delete(item.ID); err != nil {
+		log.Error().Err(err).Str("id", item.ID).Str("task_id", task.ID).Msg("Converted checklist item could not be removed")
+		return models.Task{}, err
+	}
+	return task, nil
+}
+
+func (s *checklistService) findTaskItem(taskID, itemID string) (models.ChecklistItem, error) {
+	item, err := s.repo.FindByID(itemID)
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+	if item.TaskID != taskID {
+		return models.ChecklistItem{}, repository.ErrNotFound
+	}
+	return item, nil
+}
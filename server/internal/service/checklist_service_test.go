@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+)
+
+// fakeChecklistRepository holds the items of a single checklist.
+type fakeChecklistRepository struct {
+	repository.ChecklistRepository
+	items map[string]models.ChecklistItem
+}
+
+func (r *fakeChecklistRepository) FindByID(id string) (models.ChecklistItem, error) {
+	item, ok := r.items[id]
+	if !ok {
+		return models.ChecklistItem{}, repository.ErrNotFound
+	}
+	return item, nil
+}
+
+func (r *fakeChecklistRepository) Delete(id string) error {
+	delete(r.items, id)
+	return nil
+}
+
+// creatingTaskService keeps the input of the tasks it creates.
+type creatingTaskService struct {
+	TaskService
+	parent  models.Task
+	created []models.CreateTaskInput
+}
+
+func (s *creatingTaskService) GetTaskByID(id string) (models.Task, error) {
+	if id != s.parent.ID {
+		return models.Task{}, repository.ErrNotFound
+	}
+	return s.parent, nil
+}
+
+func (s *creatingTaskService) CreateTask(input models.CreateTaskInput) (models.Task, error) {
+	s.created = append(s.created, input)
+	return models.Task{ID: "converted", Title: input.Title, Description: input.Description}, nil
+}
+
+func TestChecklistConvertItem(t *testing.T) {
+	long := strings.Repeat("word ", 30) + "end"
+	tests := []struct {
+		name            string
+		text            string
+		wantTitle       string
+		wantDescription string
+		wantInvalid     bool
+	}{
+		{
+			name:            "short enough",
+			text:            "Book the venue",
+			wantTitle:       "Book the venue",
+			wantDescription: `Converted from a checklist item of "Plan offsite".`,
+		},
+		{
+			name:            "spaces collapsed",
+			text:            "  Book   the\tvenue ",
+			wantTitle:       "Book the venue",
+			wantDescription: `Converted from a checklist item of "Plan offsite".`,
+		},
+		{
+			name:            "too long is cut",
+			text:            long,
+			wantTitle:       long[:100],
+			wantDescription: long + "\n\n" + `Converted from a checklist item of "Plan offsite".`,
+		},
+		{
+			name:            "exactly the limit",
+			text:            strings.Repeat("é", 100),
+			wantTitle:       strings.Repeat("é", 100),
+			wantDescription: `Converted from a checklist item of "Plan offsite".`,
+		},
+		{name: "too short", text: "Go", wantInvalid: true},
+		{name: "short after collapsing", text: "  a\t ", wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeChecklistRepository{items: map[string]models.ChecklistItem{
+				"item-1": {ID: "item-1", TaskID: "parent", Text: tt.text},
+			}}
+			tasks := &creatingTaskService{parent: models.Task{ID: "parent", Title: "Plan offsite"}}
+			service := NewChecklistService(repo, tasks)
+
+			_, err := service.ConvertItem("parent", "item-1")
+			if tt.wantInvalid {
+				var validationErr *apperrors.ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("ConvertItem() error = %v, want a validation error", err)
+				}
+				if len(tasks.created) != 0 || len(repo.items) != 1 {
+					t.Errorf("created %d tasks and kept %d items, want the item kept", len(tasks.created), len(repo.items))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertItem() error = %v", err)
+			}
+			if len(tasks.created) != 1 {
+				t.Fatalf("created %d tasks, want 1", len(tasks.created))
+			}
+			if got := tasks.created[0]; got.Title != tt.wantTitle || got.Description != tt.wantDescription {
+				t.Errorf("created %q with description %q, want %q with %q", got.Title, got.Description, tt.wantTitle, tt.wantDescription)
+			}
+			if len(repo.items) != 0 {
+				t.Error("converted item was not removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/markdown"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrCommentForbidden is returned when someone other than the author
+	// tries to edit or delete a comment.
+	ErrCommentForbidden = errors.New("only the author can modify this comment")
+	// ErrInvalidParent is returned when replying to a comment on another task.
+	ErrInvalidParent = errors.New("parent comment does not belong to this task")
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9]{2,50})\b`)
+
+type CommentService interface {
+	ListComments(taskID string, page, pageSize int) (models.CommentPage, error)
+	// ListCommentsByTasks returns the live comments of the given tasks,
+	// replies included, oldest first and without threading.
+	ListCommentsByTasks(taskIDs []string) ([]models.Comment, error)
+	CreateComment(taskID, authorID string, input models.CreateCommentInput) (models.Comment, error)
+	UpdateComment(taskID, commentID, authorID string, input models.UpdateCommentInput) (models.Comment, error)
+	DeleteComment(taskID, commentID, authorID string) error
+	GetCommentHistory(taskID, commentID string) ([]models.CommentRevision, error)
+}
+
+type commentService struct {
+	repo      repository.CommentRepository
+	tasks     repository.TaskRepository
+	users     repository.UserRepository
+	notifier  Notifier
+	publisher events.Publisher
+	validator *validator.Validate
+}
+
+func NewCommentService(repo repository.CommentRepository, tasks repository.TaskRepository, users repository.UserRepository, notifier Notifier, publisher events.Publisher) CommentService {
+	return &commentService{
+		repo:      repo,
+		tasks:     tasks,
+		users:     users,
+		notifier:  notifier,
+		publisher: publisher,
+		validator: validator.New(),
+	}
+}
+
+func (s *commentService) ListComments(taskID string, page, pageSize int) (models.CommentPage, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return models.CommentPage{}, err
+	}
+
+	roots, total, err := s.repo.FindPage(taskID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch comments from repository")
+		return models.CommentPage{}, err
+	}
+
+	rootIDs := make([]string, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+	replies, err := s.repo.FindReplies(rootIDs)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to fetch comment replies from repository")
+		return models.CommentPage{}, err
+	}
+
+	ids := make([]string, 0, len(rootIDs)+len(replies))
+	ids = append(ids, rootIDs...)
+	for _, reply := range replies {
+		ids = append(ids, reply.ID)
+	}
+	mentions, err := s.repo.FindMentions(ids)
+	if err != nil {
+		return models.CommentPage{}, err
+	}
+
+	return models.CommentPage{
+		Data:     buildThreads(roots, replies, mentions),
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *commentService) ListCommentsByTasks(taskIDs []string) ([]models.Comment, error) {
+	comments, err := s.repo.FindByTasks(taskIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch comments of tasks from repository")
+		return nil, err
+	}
+
+	ids := make([]string, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	mentions, err := s.repo.FindMentions(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		comments[i].Mentions = mentions[comments[i].ID]
+	}
+	return comments, nil
+}
+
+func (s *commentService) CreateComment(taskID, authorID string, input models.CreateCommentInput) (models.Comment, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateCommentInput")
+		return models.Comment{}, err
+	}
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return models.Comment{}, err
+	}
+	if _, err := s.users.FindByID(authorID); err != nil {
+		return models.Comment{}, err
+	}
+
+	comment := models.Comment{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		AuthorID:  authorID,
+		Body:      input.Body,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if input.ParentID != nil {
+		parent, err := s.repo.FindByID(*input.ParentID)
+		if err != nil {
+			return models.Comment{}, err
+		}
+		if parent.TaskID != taskID {
+			return models.Comment{}, ErrInvalidParent
+		}
+		comment.ParentID = &parent.ID
+		comment.RootID = parent.RootID
+		if comment.RootID == nil {
+			comment.RootID = &parent.ID
+		}
+	}
+
+	html, err := markdown.Render(comment.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render comment body")
+		return models.Comment{}, err
+	}
+	comment.BodyHTML = html
+
+	mentioned, err := s.resolveMentions(comment.Body)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	createdComment, err := s.repo.Create(comment, mentioned)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create comment in repository")
+		return models.Comment{}, err
+	}
+	createdComment.Mentions = mentioned
+	s.publish(events.CommentCreated, createdComment)
+
+	s.notifyMentions(createdComment, mentioned)
+	return createdComment, nil
+}
+
+func (s *commentService) UpdateComment(taskID, commentID, authorID string, input models.UpdateCommentInput) (models.Comment, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateCommentInput")
+		return models.Comment{}, err
+	}
+
+	comment, err := s.findTaskComment(taskID, commentID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if comment.AuthorID != authorID {
+		return models.Comment{}, ErrCommentForbidden
+	}
+	if comment.Body == input.Body {
+		return s.withMentions(comment)
+	}
+
+	previous, err := s.repo.FindMentions([]string{comment.ID})
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	html, err := markdown.Render(input.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render comment body")
+		return models.Comment{}, err
+	}
+	mentioned, err := s.resolveMentions(input.Body)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	previousBody := comment.Body
+	now := time.Now()
+	comment.Body = input.Body
+	comment.BodyHTML = html
+	comment.EditedAt = &now
+	comment.UpdatedAt = now
+
+	updatedComment, err := s.repo.Update(comment, previousBody, mentioned)
+	if err != nil {
+		log.Error().Err(err).Str("id", commentID).Msg("Failed to update comment in repository")
+		return models.Comment{}, err
+	}
+	updatedComment.Mentions = mentioned
+	s.publish(events.CommentUpdated, updatedComment)
+
+	// Only users who were not already mentioned hear about the edit.
+	s.notifyMentions(updatedComment, subtract(mentioned, previous[comment.ID]))
+	return updatedComment, nil
+}
+
+func (s *commentService) DeleteComment(taskID, commentID, authorID string) error {
+	comment, err := s.findTaskComment(taskID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != authorID {
+		return ErrCommentForbidden
+	}
+	if err := s.repo.Delete(comment.ID); err != nil {
+		log.Error().Err(err).Str("id", commentID).Msg("Failed to delete comment from repository")
+		return err
+	}
+	s.publish(events.CommentDeleted, comment)
+	return nil
+}
+
+func (s *commentService) GetCommentHistory(taskID, commentID string) ([]models.CommentRevision, error) {
+	comment, err := s.findTaskComment(taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindRevisions(comment.ID)
+}
+
+func (s *commentService) publish(eventType string, comment models.Comment) {
+	s.publisher.Publish(events.Event{Type: eventType, TaskID: comment.TaskID, CommentID: comment.ID, ActorID: comment.AuthorID})
+}
+
+func (s *commentService) findTaskComment(taskID, commentID string) (models.Comment, error) {
+	comment, err := s.repo.FindByID(commentID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if comment.TaskID != taskID {
+		return models.Comment{}, repository.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (s *commentService) withMentions(comment models.Comment) (models.Comment, error) {
+	mentions, err := s.repo.FindMentions([]string{comment.ID})
+	if err != nil {
+		return models.Comment{}, err
+	}
+	comment.Mentions = mentions[comment.ID]
+	return comment, nil
+}
+
+// resolveMentions returns the IDs of the users @mentioned in body. Unknown
+// usernames are ignored so that e-mail addresses and typos are harmless.
+func (s *commentService) resolveMentions(body string) ([]string, error) {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.ToLower(match[1])
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+
+	users, err := s.users.FindByUsernames(usernames)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+func (s *commentService) notifyMentions(comment models.Comment, userIDs []string) {
+	for _, userID := range userIDs {
+		if userID == comment.AuthorID {
+			continue
+		}
+		err := s.notifier.Notify(Notification{
+			UserID:  userID,
+			Kind:    NotificationMention,
+			TaskID:  comment.TaskID,
+			ActorID: comment.AuthorID,
+			Message: fmt.Sprintf("You were mentioned in comment %s", comment.ID),
+		})
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to send mention notification")
+		}
+	}
+}
+
+// buildThreads nests replies under their parents. Deleted comments are
+// blanked out and kept only when they still lead to a live reply.
+func buildThreads(roots, replies []models.Comment, mentions map[string][]string) []models.Comment {
+	children := make(map[string][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+
+	var attach func(comment models.Comment) (models.Comment, bool)
+	attach = func(comment models.Comment) (models.Comment, bool) {
+		for _, child := range children[comment.ID] {
+			if child, ok := attach(child); ok {
+				comment.Replies = append(comment.Replies, child)
+			}
+		}
+		comment.Mentions = mentions[comment.ID]
+		if comment.DeletedAt.Valid {
+			comment.Deleted = true
+			comment.Body = ""
+			comment.BodyHTML = ""
+			comment.Mentions = nil
+			return comment, len(comment.Replies) > 0
+		}
+		return comment, true
+	}
+
+	threads := make([]models.Comment, 0, len(roots))
+	for _, root := range roots {
+		if root, ok := attach(root); ok {
+			threads = append(threads, root)
+		}
+	}
+	return threads
+}
+
+func subtract(ids, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, id := range remove {
+		removed[id] = true
+	}
+	var result []string
+	for _, id := range ids {
+		if !removed[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const maxTextFieldLength = 1000
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ErrNoOptions is returned when a select field is defined without choices.
+var ErrNoOptions = errors.New("select fields need at least one option")
+
+type CustomFieldService interface {
+	ListFields(projectID string) ([]models.CustomField, error)
+	CreateField(projectID string, input models.CreateCustomFieldInput) (models.CustomField, error)
+	UpdateField(projectID, fieldID string, input models.UpdateCustomFieldInput) (models.CustomField, error)
+	DeleteField(projectID, fieldID string) error
+	ResolveValues(projectID *string, current []models.CustomFieldValue, input map[string]interface{}, creating bool) ([]models.CustomFieldValue, error)
+	ResolveFilter(query models.TaskQuery) (repository.TaskFilter, error)
+}
+
+type customFieldService struct {
+	repo      repository.CustomFieldRepository
+	projects  repository.ProjectRepository
+	users     repository.UserRepository
+	validator *validator.Validate
+}
+
+func NewCustomFieldService(repo repository.CustomFieldRepository, projects repository.ProjectRepository, users repository.UserRepository) CustomFieldService {
+	return &customFieldService{
+		repo:      repo,
+		projects:  projects,
+		users:     users,
+		validator: validator.New(),
+	}
+}
+
+func (s *customFieldService) ListFields(projectID string) ([]models.CustomField, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByProject(projectID)
+}
+
+func (s *customFieldService) CreateField(projectID string, input models.CreateCustomFieldInput) (models.CustomField, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateCustomFieldInput")
+		return models.CustomField{}, err
+	}
+	key := strings.ToLower(input.Key)
+	if !fieldKeyPattern.MatchString(key) {
+		return models.CustomField{}, apperrors.NewValidationError("Invalid custom field", map[string]string{
+			"key": "must start with a letter and contain only letters, digits and underscores",
+		})
+	}
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		return models.CustomField{}, err
+	}
+
+	options, err := normalizeOptions(input.Type, input.Options)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+
+	existing, err := s.repo.FindByProject(projectID)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+
+	field := models.CustomField{
+		ID:        uuid.New().String(),
+		ProjectID: projectID,
+		Key:       key,
+		Name:      input.Name,
+		Type:      input.Type,
+		Options:   options,
+		Required:  input.Required,
+		Position:  len(existing),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	createdField, err := s.repo.Create(field)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create custom field in repository")
+		return models.CustomField{}, err
+	}
+	return createdField, nil
+}
+
+func (s *customFieldService) UpdateField(projectID, fieldID string, input models.UpdateCustomFieldInput) (models.CustomField, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateCustomFieldInput")
+		return models.CustomField{}, err
+	}
+
+	field, err := s.findProjectField(projectID, fieldID)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+	options, err := normalizeOptions(field.Type, input.Options)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+
+	field.Name = input.Name
+	field.Options = options
+	field.Required = input.Required
+	field.Position = input.Position
+	field.UpdatedAt = time.Now()
+	return s.repo.Update(field)
+}
+
+func (s *customFieldService) DeleteField(projectID, fieldID string) error {
+	field, err := s.findProjectField(projectID, fieldID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(field.ID)
+}
+
+// ResolveValues validates input against the project's field definitions and
+// merges it into the task's current values. Values of fields that do not
+// belong to the project, for example after moving the task, are dropped.
+func (s *customFieldService) ResolveValues(projectID *string, current []models.CustomFieldValue, input map[string]interface{}, creating bool) ([]models.CustomFieldValue, error) {
+	if projectID == nil {
+		if len(input) > 0 {
+			return nil, apperrors.NewValidationError("Custom fields require a project", nil)
+		}
+		return []models.CustomFieldValue{}, nil
+	}
+
+	fields, err := s.repo.FindByProject(*projectID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	values := make(map[string]models.CustomFieldValue)
+	for _, value := range current {
+		if field, ok := byKey[value.Field.Key]; ok && field.ID == value.FieldID {
+			values[field.Key] = value
+		}
+	}
+
+	details := make(map[string]string)
+	for key, raw := range input {
+		field, ok := byKey[key]
+		if !ok {
+			details[key] = "unknown custom field"
+			continue
+		}
+		if raw == nil {
+			delete(values, key)
+			continue
+		}
+		value, err := s.encodeValue(field, raw)
+		if err != nil {
+			details[key] = err.Error()
+			continue
+		}
+		values[key] = value
+	}
+
+	for _, field := range fields {
+		if _, ok := values[field.Key]; field.Required && !ok {
+			if _, given := input[field.Key]; given || creating {
+				details[field.Key] = "is required"
+			}
+		}
+	}
+	if len(details) > 0 {
+		return nil, apperrors.NewValidationError("Invalid custom fields", details)
+	}
+
+	resolved := make([]models.CustomFieldValue, 0, len(values))
+	for _, field := range fields {
+		if value, ok := values[field.Key]; ok {
+			resolved = append(resolved, value)
+		}
+	}
+	return resolved, nil
+}
+
+// ResolveFilter turns list query parameters into a repository filter. Custom
+// field conditions and sorting need a project because keys are per project.
+func (s *customFieldService) ResolveFilter(query models.TaskQuery) (repository.TaskFilter, error) {
+	filter := repository.TaskFilter{
+		ProjectID:  query.ProjectID,
+		Descending: strings.EqualFold(query.Order, "desc"),
+	}
+
+	sortKey, sortsByField := strings.CutPrefix(query.Sort, "cf.")
+	if query.Sort != "" && !sortsByField {
+		if !repository.SortableColumns[query.Sort] {
+			return filter, apperrors.NewValidationError("Invalid sort", map[string]string{"sort": "unknown column " + query.Sort})
+		}
+		filter.SortColumn = query.Sort
+	}
+	if len(query.CustomFields) == 0 && !sortsByField {
+		return filter, nil
+	}
+	if query.ProjectID == "" {
+		return filter, apperrors.NewValidationError("Custom field filters require project_id", nil)
+	}
+
+	fields, err := s.repo.FindByProject(query.ProjectID)
+	if err != nil {
+		return filter, err
+	}
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	details := make(map[string]string)
+	if sortsByField {
+		if field, ok := byKey[sortKey]; ok {
+			filter.SortField = &field
+		} else {
+			details["sort"] = "unknown custom field " + sortKey
+		}
+	}
+
+	params := make([]string, 0, len(query.CustomFields))
+	for param := range query.CustomFields {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		condition, err := parseCondition(byKey, param, query.CustomFields[param])
+		if err != nil {
+			details["cf."+param] = err.Error()
+			continue
+		}
+		filter.CustomFields = append(filter.CustomFields, condition)
+	}
+	if len(details) > 0 {
+		return filter, apperrors.NewValidationError("Invalid custom field filter", details)
+	}
+	return filter, nil
+}
+
+func (s *customFieldService) findProjectField(projectID, fieldID string) (models.CustomField, error) {
+	field, err := s.repo.FindByID(fieldID)
+	if err != nil {
+		return models.CustomField{}, err
+	}
+	if field.ProjectID != projectID {
+		return models.CustomField{}, repository.ErrNotFound
+	}
+	return field, nil
+}
+
+// encodeValue validates raw against the field type and produces the stored
+// representation.
+func (s *customFieldService) encodeValue(field models.CustomField, raw interface{}) (models.CustomFieldValue, error) {
+	value := models.CustomFieldValue{FieldID: field.ID, Field: field}
+
+	var canonical interface{}
+	switch field.Type {
+	case models.FieldNumber:
+		number, ok := raw.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return value, errors.New("must be a number")
+		}
+		canonical = number
+		value.NumberValue = &number
+
+	case models.FieldMultiSelect:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return value, errors.New("must be a list of options")
+		}
+		selected := make([]string, 0, len(list))
+		seen := make(map[string]bool)
+		for _, item := range list {
+			option, ok := item.(string)
+			if !ok || !contains(field.Options, option) {
+				return value, fmt.Errorf("%v is not an option", item)
+			}
+			if !seen[option] {
+				seen[option] = true
+				selected = append(selected, option)
+			}
+		}
+		canonical = selected
+		text := strings.Join(selected, ", ")
+		value.TextValue = &text
+
+	default:
+		text, ok := raw.(string)
+		if !ok {
+			return value, errors.New("must be a string")
+		}
+		if err := s.validateText(field, text); err != nil {
+			return value, err
+		}
+		canonical = text
+		value.TextValue = &text
+	}
+
+	encoded, err := json.Marshal(canonical)
+	if err != nil {
+		return value, err
+	}
+	value.Value = string(encoded)
+	return value, nil
+}
+
+func (s *customFieldService) validateText(field models.CustomField, text string) error {
+	switch field.Type {
+	case models.FieldText:
+		if len(text) > maxTextFieldLength {
+			return fmt.Errorf("must be at most %d characters", maxTextFieldLength)
+		}
+	case models.FieldDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return errors.New("must be a date in YYYY-MM-DD format")
+		}
+	case models.FieldSingleSelect:
+		if !contains(field.Options, text) {
+			return fmt.Errorf("%q is not an option", text)
+		}
+	case models.FieldUser:
+		if _, err := s.users.FindByID(text); err != nil {
+			return errors.New("must be the ID of an existing user")
+		}
+	case models.FieldURL:
+		u, err := url.Parse(text)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("must be an absolute http or https URL")
+		}
+	}
+	return nil
+}
+
+func parseCondition(fields map[string]models.CustomField, param, raw string) (repository.CustomFieldCondition, error) {
+	key, op := param, repository.OpEq
+	if i := strings.LastIndex(param, "."); i >= 0 {
+		key, op = param[:i], param[i+1:]
+	}
+
+	field, ok := fields[key]
+	if !ok {
+		return repository.CustomFieldCondition{}, errors.New("unknown custom field")
+	}
+	condition := repository.CustomFieldCondition{Field: field, Op: op, Value: raw}
+
+	switch op {
+	case repository.OpEq:
+		if field.Type == models.FieldMultiSelect {
+			condition.Op = repository.OpContains
+		}
+	case repository.OpContains:
+		if field.Type != models.FieldMultiSelect {
+			return condition, errors.New("contains only applies to multi_select fields")
+		}
+	case repository.OpGt, repository.OpGte, repository.OpLt, repository.OpLte:
+		if field.Type != models.FieldNumber && field.Type != models.FieldDate {
+			return condition, fmt.Errorf("%s only applies to number and date fields", op)
+		}
+	default:
+		return condition, fmt.Errorf("unknown operator %q", op)
+	}
+
+	switch field.Type {
+	case models.FieldNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return condition, errors.New("must be a number")
+		}
+		condition.Value = number
+	case models.FieldDate:
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return condition, errors.New("must be a date in YYYY-MM-DD format")
+		}
+	}
+	return condition, nil
+}
+
+func normalizeOptions(fieldType string, options []string) ([]string, error) {
+	if fieldType != models.FieldSingleSelect && fieldType != models.FieldMultiSelect {
+		return nil, nil
+	}
+	unique := make([]string, 0, len(options))
+	for _, option := range options {
+		if !contains(unique, option) {
+			unique = append(unique, option)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, ErrNoOptions
+	}
+	return unique, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
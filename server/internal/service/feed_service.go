@@ -0,0 +1,130 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"taskmanager/internal/ical"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// feedRefresh is how often calendar clients are asked to fetch a feed
+const feedRefresh = 15 * time.Minute
+
+// secretBytes is the length of feed tokens and CalDAV passwords before
+// encoding
+const secretBytes = 32
+
+type FeedService interface {
+	// IssueToken gives a user a new calendar feed token, which replaces
+	// any previous one. Only a hash of the token is kept.
+	IssueToken(userID string) (string, error)
+	// RevokeToken takes the calendar feed token of a user away.
+	RevokeToken(userID string) error
+	// TaskFeed writes the iCalendar feed of the user a token was issued
+	// to. Unknown tokens give ErrNotFound.
+	TaskFeed(token string, options models.FeedOptions) ([]byte, error)
+}
+
+type feedService struct {
+	users repository.UserRepository
+	tasks TaskService
+}
+
+func NewFeedService(users repository.UserRepository, tasks TaskService) FeedService {
+	return &feedService{users: users, tasks: tasks}
+}
+
+func (s *feedService) IssueToken(userID string) (string, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+	token, hash, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	user.FeedTokenHash = &hash
+	user.UpdatedAt = time.Now()
+	if _, err := s.users.Update(user); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *feedService) RevokeToken(userID string) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	user.FeedTokenHash = nil
+	user.UpdatedAt = time.Now()
+	_, err = s.users.Update(user)
+	return err
+}
+
+func (s *feedService) TaskFeed(token string, options models.FeedOptions) ([]byte, error) {
+	user, err := s.users.FindByFeedTokenHash(secretHash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.TaskQuery{
+		ProjectID: options.ProjectID,
+		Filter:    "due!=none",
+		TimeZone:  user.TimeZone,
+		UserID:    user.ID,
+		Assignee:  options.Assignee,
+	}
+	switch options.Assignee {
+	case "":
+		query.Assignee = "me"
+	case "any":
+		query.Assignee = ""
+	}
+	if options.Tag != "" {
+		query.Filter += " tag:" + strconv.Quote(options.Tag)
+	}
+	tasks, err := s.tasks.GetAllTasks(query)
+	if err != nil {
+		return nil, err
+	}
+
+	name := user.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+	calendar := ical.NewCalendar("Tasks of "+name, feedRefresh)
+	for _, task := range tasks {
+		if options.Events {
+			calendar.AddEvent(task)
+		} else {
+			calendar.AddTodo(task)
+		}
+	}
+	return calendar.Bytes(), nil
+}
+
+// newSecret generates a random token with the hash it is stored by.
+func newSecret() (string, string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		log.Error().Err(err).Msg("Failed to generate secret")
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	return token, secretHash(token), nil
+}
+
+// secretHash is the hash a token is stored and found by.
+func secretHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
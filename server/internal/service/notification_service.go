@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanager/internal/clock"
+	"taskmanager/internal/models"
+	"taskmanager/internal/realtime"
+	"taskmanager/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// notificationBurst is how long after the latest notification of a
+	// group a new one of the same kind about the same task joins it.
+	notificationBurst = 10 * time.Minute
+	// dueSoonWindow is how long before a task is due its assignees are
+	// told about it.
+	dueSoonWindow = 24 * time.Hour
+	// maxZoneOffset bounds the offset of any time zone from UTC, by which
+	// all-day due dates can be early or late.
+	maxZoneOffset = 14 * time.Hour
+)
+
+// Realtime events sent to connected users
+const (
+	EventNotification = "notification"
+	EventUnread       = "unread"
+)
+
+// NotificationService keeps the inbox of each user. It is a Notifier: every
+// notification is stored, grouped with a burst of similar ones, pushed to
+// the user if they are connected, then handed to the next Notifier.
+type NotificationService interface {
+	Notifier
+	ListNotifications(userID string, unreadOnly bool, page, pageSize int) (models.NotificationPage, error)
+	UnreadCount(userID string) (models.UnreadCount, error)
+	// MarkRead marks a notification of the user read, or unread.
+	MarkRead(userID, id string, read bool) (models.Notification, error)
+	MarkAllRead(userID string) (models.UnreadCount, error)
+	// Subscribe opens a realtime stream of the user's notifications; the
+	// returned function closes it.
+	Subscribe(userID string) (<-chan realtime.Message, func())
+	// NotifyDueSoon tells assignees about their open tasks due within a
+	// day, once per due date.
+	NotifyDueSoon() error
+}
+
+type notificationService struct {
+	repo  repository.NotificationRepository
+	tasks repository.TaskRepository
+	users repository.UserRepository
+	hub   *realtime.Hub
+	next  Notifier
+	clock clock.Clock
+}
+
+func NewNotificationService(repo repository.NotificationRepository, tasks repository.TaskRepository, users repository.UserRepository, hub *realtime.Hub, next Notifier, clock clock.Clock) NotificationService {
+	return &notificationService{
+		repo:  repo,
+		tasks: tasks,
+		users: users,
+		hub:   hub,
+		next:  next,
+		clock: clock,
+	}
+}
+
+func (s *notificationService) Notify(n Notification) error {
+	now := s.clock.Now()
+	notification, err := s.repo.FindGroup(n.UserID, n.Kind, n.TaskID, now.Add(-notificationBurst))
+	switch {
+	case err == nil:
+		notification.Count++
+		notification.ActorID = n.ActorID
+		notification.Message = n.Message
+		notification.LatestAt = now
+		notification, err = s.repo.Update(notification)
+	case errors.Is(err, repository.ErrNotFound):
+		notification, err = s.repo.Create(models.Notification{
+			ID:        uuid.New().String(),
+			UserID:    n.UserID,
+			Kind:      n.Kind,
+			TaskID:    n.TaskID,
+			ActorID:   n.ActorID,
+			Message:   n.Message,
+			Count:     1,
+			LatestAt:  now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("user_id", n.UserID).Str("kind", n.Kind).Msg("Failed to store notification")
+		return err
+	}
+
+	if s.hub.Online(n.UserID) {
+		s.hub.Send(n.UserID, realtime.Message{Event: EventNotification, Data: notification})
+		s.pushUnread(n.UserID)
+	}
+	return s.next.Notify(n)
+}
+
+func (s *notificationService) ListNotifications(userID string, unreadOnly bool, page, pageSize int) (models.NotificationPage, error) {
+	notifications, total, err := s.repo.FindPage(userID, unreadOnly, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return models.NotificationPage{}, err
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	return models.NotificationPage{
+		Data:     notifications,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+		Unread:   unread,
+	}, nil
+}
+
+func (s *notificationService) UnreadCount(userID string) (models.UnreadCount, error) {
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return models.UnreadCount{}, err
+	}
+	return models.UnreadCount{Unread: unread}, nil
+}
+
+func (s *notificationService) MarkRead(userID, id string, read bool) (models.Notification, error) {
+	notification, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.Notification{}, err
+	}
+	// Other users' notifications are hidden rather than forbidden
+	if notification.UserID != userID {
+		return models.Notification{}, repository.ErrNotFound
+	}
+
+	if read && notification.ReadAt == nil {
+		now := s.clock.Now()
+		notification.ReadAt = &now
+	} else if !read {
+		notification.ReadAt = nil
+	}
+	updated, err := s.repo.Update(notification)
+	if err != nil {
+		return models.Notification{}, err
+	}
+	s.pushUnread(userID)
+	return updated, nil
+}
+
+func (s *notificationService) MarkAllRead(userID string) (models.UnreadCount, error) {
+	if _, err := s.repo.MarkAllRead(userID, s.clock.Now()); err != nil {
+		return models.UnreadCount{}, err
+	}
+	s.pushUnread(userID)
+	return models.UnreadCount{}, nil
+}
+
+func (s *notificationService) Subscribe(userID string) (<-chan realtime.Message, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// pushUnread sends the unread count to the user's open connections.
+func (s *notificationService) pushUnread(userID string) {
+	if !s.hub.Online(userID) {
+		return
+	}
+	count, err := s.UnreadCount(userID)
+	if err != nil {
+		return
+	}
+	s.hub.Send(userID, realtime.Message{Event: EventUnread, Data: count})
+}
+
+// NotifyDueSoon looks at the tasks due within the window wherever their
+// assignees are. All-day tasks are due at the start of their date in the
+// assignee's time zone.
+func (s *notificationService) NotifyDueSoon() error {
+	now := s.clock.Now()
+	tasks, err := s.tasks.FindDueBetween(now.Add(-maxZoneOffset), now.Add(dueSoonWindow+maxZoneOffset))
+	if err != nil {
+		return err
+	}
+
+	locations := make(map[string]*time.Location)
+	for _, task := range tasks {
+		for _, userID := range task.Assignees {
+			location, ok := locations[userID]
+			if !ok {
+				location = s.userLocation(userID)
+				locations[userID] = location
+			}
+			due := task.DueAt(location)
+			if due.Before(now) || due.After(now.Add(dueSoonWindow)) {
+				continue
+			}
+			sent, err := s.repo.Exists(userID, NotificationDueSoon, task.ID, due.Add(-dueSoonWindow))
+			if err != nil {
+				return err
+			}
+			if sent {
+				continue
+			}
+			err = s.Notify(Notification{
+				UserID:  userID,
+				Kind:    NotificationDueSoon,
+				TaskID:  task.ID,
+				Message: fmt.Sprintf("Task %q is due %s", task.Title, task.FormatDueDate()),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// userLocation returns the time zone of a user, or UTC.
+func (s *notificationService) userLocation(userID string) *time.Location {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
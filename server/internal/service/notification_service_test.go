@@ -0,0 +1,383 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/realtime"
+	"taskmanager/internal/repository"
+)
+
+// fakeNotificationRepository keeps notifications in creation order and
+// answers queries the way the database does.
+type fakeNotificationRepository struct {
+	repository.NotificationRepository
+	notifications []models.Notification
+}
+
+func (r *fakeNotificationRepository) FindByID(id string) (models.Notification, error) {
+	for _, notification := range r.notifications {
+		if notification.ID == id {
+			return notification, nil
+		}
+	}
+	return models.Notification{}, repository.ErrNotFound
+}
+
+func (r *fakeNotificationRepository) FindGroup(userID, kind, taskID string, since time.Time) (models.Notification, error) {
+	var group *models.Notification
+	for i, notification := range r.notifications {
+		if notification.UserID == userID && notification.Kind == kind && notification.TaskID == taskID &&
+			notification.ReadAt == nil && !notification.LatestAt.Before(since) &&
+			(group == nil || notification.LatestAt.After(group.LatestAt)) {
+			group = &r.notifications[i]
+		}
+	}
+	if group == nil {
+		return models.Notification{}, repository.ErrNotFound
+	}
+	return *group, nil
+}
+
+func (r *fakeNotificationRepository) Exists(userID, kind, taskID string, since time.Time) (bool, error) {
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && notification.Kind == kind && notification.TaskID == taskID &&
+			!notification.LatestAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeNotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && notification.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepository) Create(notification models.Notification) (models.Notification, error) {
+	r.notifications = append(r.notifications, notification)
+	return notification, nil
+}
+
+func (r *fakeNotificationRepository) Update(notification models.Notification) (models.Notification, error) {
+	for i := range r.notifications {
+		if r.notifications[i].ID == notification.ID {
+			r.notifications[i] = notification
+			return notification, nil
+		}
+	}
+	return models.Notification{}, repository.ErrNotFound
+}
+
+func (r *fakeNotificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	var count int64
+	for i := range r.notifications {
+		if r.notifications[i].UserID == userID && r.notifications[i].ReadAt == nil {
+			r.notifications[i].ReadAt = &at
+			count++
+		}
+	}
+	return count, nil
+}
+
+// inbox lists the notifications as "user kind task count: message".
+func (r *fakeNotificationRepository) inbox() []string {
+	var items []string
+	for _, n := range r.notifications {
+		items = append(items, fmt.Sprintf("%s %s %s %d: %s", n.UserID, n.Kind, n.TaskID, n.Count, n.Message))
+	}
+	return items
+}
+
+// dueTaskRepository finds the open tasks due in a range.
+type dueTaskRepository struct {
+	repository.TaskRepository
+	tasks []models.Task
+}
+
+func (r *dueTaskRepository) FindDueBetween(from, to time.Time) ([]models.Task, error) {
+	var due []models.Task
+	for _, task := range r.tasks {
+		if !task.Completed && !task.DueDate.Before(from) && !task.DueDate.After(to) {
+			due = append(due, task)
+		}
+	}
+	return due, nil
+}
+
+// manualClock is a clock tests move by hand.
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+type notificationFixture struct {
+	service *notificationService
+	repo    *fakeNotificationRepository
+	tasks   *dueTaskRepository
+	clock   *manualClock
+	hub     *realtime.Hub
+	next    *recordingNotifier
+}
+
+func newNotificationFixture(now time.Time, users map[string]models.User) notificationFixture {
+	f := notificationFixture{
+		repo:  &fakeNotificationRepository{},
+		tasks: &dueTaskRepository{},
+		clock: &manualClock{now: now},
+		hub:   realtime.NewHub(),
+		next:  &recordingNotifier{},
+	}
+	f.service = NewNotificationService(f.repo, f.tasks, &fakeUserRepository{users: users}, f.hub, f.next, f.clock).(*notificationService)
+	return f
+}
+
+func TestNotifyBurst(t *testing.T) {
+	start := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	type step struct {
+		after        time.Duration
+		notification Notification
+		readAll      string
+	}
+	comment := func(user, task, message string) Notification {
+		return Notification{UserID: user, Kind: NotificationMention, TaskID: task, ActorID: "bob", Message: message}
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  []string
+	}{
+		{
+			name: "burst grouped",
+			steps: []step{
+				{after: 0, notification: comment("alice", "task-1", "first")},
+				{after: 5 * time.Minute, notification: comment("alice", "task-1", "second")},
+				{after: 14 * time.Minute, notification: comment("alice", "task-1", "third")},
+			},
+			want: []string{"alice mention task-1 3: third"},
+		},
+		{
+			name: "burst measured from the latest",
+			steps: []step{
+				{after: 0, notification: comment("alice", "task-1", "first")},
+				{after: 10 * time.Minute, notification: comment("alice", "task-1", "second")},
+				{after: 20*time.Minute + time.Second, notification: comment("alice", "task-1", "third")},
+			},
+			want: []string{"alice mention task-1 2: second", "alice mention task-1 1: third"},
+		},
+		{
+			name: "separate by user, kind and task",
+			steps: []step{
+				{after: 0, notification: comment("alice", "task-1", "comment")},
+				{after: time.Minute, notification: comment("carol", "task-1", "comment")},
+				{after: time.Minute, notification: comment("alice", "task-2", "comment")},
+				{after: time.Minute, notification: Notification{UserID: "alice", Kind: NotificationAssigned, TaskID: "task-1", Message: "assigned"}},
+			},
+			want: []string{
+				"alice mention task-1 1: comment",
+				"carol mention task-1 1: comment",
+				"alice mention task-2 1: comment",
+				"alice assigned task-1 1: assigned",
+			},
+		},
+		{
+			name: "read group closed",
+			steps: []step{
+				{after: 0, notification: comment("alice", "task-1", "first")},
+				{after: time.Minute, readAll: "alice"},
+				{after: 2 * time.Minute, notification: comment("alice", "task-1", "second")},
+			},
+			want: []string{"alice mention task-1 1: first", "alice mention task-1 1: second"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newNotificationFixture(start, nil)
+			sent := 0
+			for _, step := range tt.steps {
+				f.clock.now = start.Add(step.after)
+				if step.readAll != "" {
+					if _, err := f.service.MarkAllRead(step.readAll); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err := f.service.Notify(step.notification); err != nil {
+					t.Fatalf("Notify() error = %v", err)
+				}
+				sent++
+			}
+			if got := f.repo.inbox(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inbox = %q, want %q", got, tt.want)
+			}
+			// Grouping is for the inbox; every notification is delivered
+			if len(f.next.sent) != sent {
+				t.Errorf("next notifier got %d notifications, want %d", len(f.next.sent), sent)
+			}
+		})
+	}
+}
+
+func TestNotifyBurstLatest(t *testing.T) {
+	start := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	f := newNotificationFixture(start, nil)
+	f.service.Notify(Notification{UserID: "alice", Kind: NotificationMention, TaskID: "task-1", ActorID: "bob", Message: "first"})
+	f.clock.now = start.Add(3 * time.Minute)
+	f.service.Notify(Notification{UserID: "alice", Kind: NotificationMention, TaskID: "task-1", ActorID: "carol", Message: "second"})
+
+	got := f.repo.notifications[0]
+	if got.ActorID != "carol" || !got.LatestAt.Equal(start.Add(3*time.Minute)) || !got.CreatedAt.Equal(start) {
+		t.Errorf("group = %+v, want the actor and time of the latest and the creation of the first", got)
+	}
+}
+
+func TestNotifyDueSoon(t *testing.T) {
+	// Monday 20:00 UTC is already Tuesday in Tokyo and still Monday
+	// afternoon in Los Angeles
+	now := time.Date(2026, time.October, 19, 20, 0, 0, 0, time.UTC)
+	users := map[string]models.User{
+		"alice": {ID: "alice", TimeZone: "UTC"},
+		"kenji": {ID: "kenji", TimeZone: "Asia/Tokyo"},
+		"maria": {ID: "maria", TimeZone: "America/Los_Angeles"},
+		"ghost": {ID: "ghost", TimeZone: "Not/AZone"},
+	}
+	everyone := []string{"alice", "kenji", "maria", "ghost"}
+	f := newNotificationFixture(now, users)
+	f.tasks.tasks = []models.Task{
+		{ID: "tuesday", Title: "Tuesday", DueDate: time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC), Assignees: everyone},
+		{ID: "wednesday", Title: "Wednesday", DueDate: time.Date(2026, time.October, 21, 0, 0, 0, 0, time.UTC), Assignees: everyone},
+		{ID: "timed", Title: "Timed", DueDate: time.Date(2026, time.October, 20, 18, 0, 0, 0, time.UTC), DueTimeZone: "Europe/Berlin", Assignees: []string{"alice"}},
+		{ID: "later", Title: "Later", DueDate: time.Date(2026, time.October, 20, 20, 0, 1, 0, time.UTC), DueTimeZone: "UTC", Assignees: []string{"alice"}},
+		{ID: "done", Title: "Done", DueDate: time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC), Assignees: everyone, Completed: true},
+	}
+
+	if err := f.service.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon() error = %v", err)
+	}
+	want := []string{
+		`alice due_soon tuesday 1: Task "Tuesday" is due 2026-10-20`,
+		`maria due_soon tuesday 1: Task "Tuesday" is due 2026-10-20`,
+		`ghost due_soon tuesday 1: Task "Tuesday" is due 2026-10-20`,
+		`kenji due_soon wednesday 1: Task "Wednesday" is due 2026-10-21`,
+		`alice due_soon timed 1: Task "Timed" is due 2026-10-20T20:00:00+02:00`,
+	}
+	if got := f.repo.inbox(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("inbox = %q, want %q", got, want)
+	}
+
+	// Later runs tell each assignee once per due date, whether or not the
+	// notifications were read, as the other tasks come due
+	f.service.MarkAllRead("alice")
+	for _, later := range []time.Duration{0, 15 * time.Minute, 3 * time.Hour, 12 * time.Hour} {
+		f.clock.now = now.Add(later)
+		if err := f.service.NotifyDueSoon(); err != nil {
+			t.Fatalf("NotifyDueSoon() error = %v", err)
+		}
+	}
+	want = append(want,
+		`alice due_soon later 1: Task "Later" is due 2026-10-20T20:00:01Z`,
+		`alice due_soon wednesday 1: Task "Wednesday" is due 2026-10-21`,
+		`maria due_soon wednesday 1: Task "Wednesday" is due 2026-10-21`,
+		`ghost due_soon wednesday 1: Task "Wednesday" is due 2026-10-21`,
+	)
+	if got := f.repo.inbox(); !reflect.DeepEqual(got, want) {
+		t.Errorf("inbox after later runs = %q, want %q", got, want)
+	}
+
+	// A task moved to a later date is due soon again
+	f.tasks.tasks[2].DueDate = time.Date(2026, time.October, 21, 9, 0, 0, 0, time.UTC)
+	f.clock.now = time.Date(2026, time.October, 20, 10, 0, 0, 0, time.UTC)
+	if err := f.service.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon() error = %v", err)
+	}
+	got := f.repo.inbox()
+	if last := got[len(got)-1]; last != `alice due_soon timed 1: Task "Timed" is due 2026-10-21T11:00:00+02:00` {
+		t.Errorf("last notification = %q, want the new due date", last)
+	}
+}
+
+func TestNotifyPushesUnread(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	f := newNotificationFixture(now, nil)
+	messages, closeStream := f.hub.Subscribe("alice")
+	defer closeStream()
+	received := func() []string {
+		var events []string
+		for {
+			select {
+			case message := <-messages:
+				switch data := message.Data.(type) {
+				case models.UnreadCount:
+					events = append(events, fmt.Sprintf("%s %d", message.Event, data.Unread))
+				case models.Notification:
+					events = append(events, fmt.Sprintf("%s %d: %s", message.Event, data.Count, data.Message))
+				}
+			default:
+				return events
+			}
+		}
+	}
+
+	f.service.Notify(Notification{UserID: "alice", Kind: NotificationMention, TaskID: "task-1", Message: "first"})
+	f.service.Notify(Notification{UserID: "alice", Kind: NotificationMention, TaskID: "task-1", Message: "second"})
+	f.service.Notify(Notification{UserID: "alice", Kind: NotificationAssigned, TaskID: "task-1", Message: "assigned"})
+	f.service.Notify(Notification{UserID: "bob", Kind: NotificationMention, TaskID: "task-1", Message: "offline"})
+	want := []string{"notification 1: first", "unread 1", "notification 2: second", "unread 1", "notification 1: assigned", "unread 2"}
+	if got := received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("pushed %q, want %q", got, want)
+	}
+
+	if _, err := f.service.MarkRead("alice", f.repo.notifications[0].ID, true); err != nil {
+		t.Fatalf("MarkRead() error = %v", err)
+	}
+	if _, err := f.service.MarkRead("bob", f.repo.notifications[1].ID, true); err != repository.ErrNotFound {
+		t.Errorf("MarkRead() of another user's notification error = %v, want ErrNotFound", err)
+	}
+	if _, err := f.service.MarkAllRead("alice"); err != nil {
+		t.Fatalf("MarkAllRead() error = %v", err)
+	}
+	if got, want := received(), []string{"unread 1", "unread 0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pushed %q, want %q", got, want)
+	}
+}
+
+// TestNotifyDueSoonPostponedInBurst postpones a task just after its
+// assignee was told, so that the next notification joins the same group.
+func TestNotifyDueSoonPostponedInBurst(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 20, 0, 0, 0, time.UTC)
+	f := newNotificationFixture(now, map[string]models.User{"alice": {ID: "alice", TimeZone: "UTC"}})
+	f.tasks.tasks = []models.Task{
+		{ID: "task-1", Title: "Report", DueDate: time.Date(2026, time.October, 20, 18, 0, 0, 0, time.UTC), DueTimeZone: "UTC", Assignees: []string{"alice"}},
+	}
+	if err := f.service.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon() error = %v", err)
+	}
+
+	f.tasks.tasks[0].DueDate = time.Date(2026, time.October, 20, 20, 3, 0, 0, time.UTC)
+	for _, later := range []time.Duration{5 * time.Minute, 8 * time.Minute, 12 * time.Minute, 30 * time.Minute} {
+		f.clock.now = now.Add(later)
+		if err := f.service.NotifyDueSoon(); err != nil {
+			t.Fatalf("NotifyDueSoon() error = %v", err)
+		}
+	}
+	want := []string{`alice due_soon task-1 2: Task "Report" is due 2026-10-20T20:03:00Z`}
+	if got := f.repo.inbox(); !reflect.DeepEqual(got, want) {
+		t.Errorf("inbox = %q, want %q", got, want)
+	}
+	if len(f.next.sent) != 2 {
+		t.Errorf("next notifier got %d notifications, want 2", len(f.next.sent))
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// Notification kinds
+const (
+	NotificationMention  = "mention"
+	NotificationAssigned = "assigned"
+	// NotificationActivity tells a watcher about a change to a task
+	NotificationActivity = "activity"
+	NotificationDueSoon  = "due_soon"
+	// NotificationSLA tells of a task that breached an SLA policy
+	NotificationSLA = "sla"
+)
+
+// Notification is a message addressed to a single user about a task.
+type Notification struct {
+	UserID  string
+	Kind    string
+	TaskID  string
+	ActorID string
+	Message string
+}
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a Notifier that only writes notifications to the log.
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(n Notification) error {
+	log.Info().
+		Str("user_id", n.UserID).
+		Str("kind", n.Kind).
+		Str("task_id", n.TaskID).
+		Str("actor_id", n.ActorID).
+		Msg(n.Message)
+	return nil
+}
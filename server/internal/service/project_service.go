@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type ProjectService interface {
+	GetAllProjects() ([]models.Project, error)
+	GetProjectByID(id string) (models.Project, error)
+	// GetProjectsByIDs returns the projects with the given IDs that exist,
+	// in no particular order.
+	GetProjectsByIDs(ids []string) ([]models.Project, error)
+	CreateProject(input models.ProjectInput) (models.Project, error)
+	UpdateProject(id string, input models.ProjectInput) (models.Project, error)
+	DeleteProject(id string) error
+}
+
+type projectService struct {
+	repo      repository.ProjectRepository
+	calendars repository.CalendarRepository
+	users     repository.UserRepository
+	validator *validator.Validate
+}
+
+func NewProjectService(repo repository.ProjectRepository, calendars repository.CalendarRepository, users repository.UserRepository) ProjectService {
+	return &projectService{
+		repo:      repo,
+		calendars: calendars,
+		users:     users,
+		validator: validator.New(),
+	}
+}
+
+func (s *projectService) GetAllProjects() ([]models.Project, error) {
+	projects, err := s.repo.FindAll()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all projects from repository")
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (s *projectService) GetProjectByID(id string) (models.Project, error) {
+	project, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch project from repository")
+		return models.Project{}, err
+	}
+	return project, nil
+}
+
+func (s *projectService) GetProjectsByIDs(ids []string) ([]models.Project, error) {
+	projects, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch projects by ID from repository")
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (s *projectService) CreateProject(input models.ProjectInput) (models.Project, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ProjectInput")
+		return models.Project{}, err
+	}
+	if err := s.checkCalendar(input.CalendarID); err != nil {
+		return models.Project{}, err
+	}
+	if err := s.checkAdmin(input.AdminID); err != nil {
+		return models.Project{}, err
+	}
+
+	project := models.Project{
+		ID:           uuid.New().String(),
+		Name:         input.Name,
+		Description:  input.Description,
+		CalendarID:   input.CalendarID,
+		AdminID:      input.AdminID,
+		EstimateUnit: orDefault(input.EstimateUnit, models.EstimatePoints),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	createdProject, err := s.repo.Create(project)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create project in repository")
+		return models.Project{}, err
+	}
+	return createdProject, nil
+}
+
+func (s *projectService) UpdateProject(id string, input models.ProjectInput) (models.Project, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ProjectInput")
+		return models.Project{}, err
+	}
+	if err := s.checkCalendar(input.CalendarID); err != nil {
+		return models.Project{}, err
+	}
+	if err := s.checkAdmin(input.AdminID); err != nil {
+		return models.Project{}, err
+	}
+
+	project, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find project for update")
+		return models.Project{}, err
+	}
+
+	project.Name = input.Name
+	project.Description = input.Description
+	project.CalendarID = input.CalendarID
+	project.AdminID = input.AdminID
+	if input.EstimateUnit != "" {
+		project.EstimateUnit = input.EstimateUnit
+	}
+	project.UpdatedAt = time.Now()
+
+	updatedProject, err := s.repo.Update(project)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update project in repository")
+		return models.Project{}, err
+	}
+	return updatedProject, nil
+}
+
+func (s *projectService) DeleteProject(id string) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete project from repository")
+		return err
+	}
+	return nil
+}
+
+// checkAdmin reports an admin that is not a user as invalid input.
+func (s *projectService) checkAdmin(adminID *string) error {
+	if adminID == nil {
+		return nil
+	}
+	if _, err := s.users.FindByID(*adminID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apperrors.NewValidationError("Invalid admin", map[string]string{
+				"admin_id": "user not found",
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+// checkCalendar reports a calendar ID that does not exist as invalid input.
+func (s *projectService) checkCalendar(calendarID *string) error {
+	if calendarID == nil {
+		return nil
+	}
+	if _, err := s.calendars.FindByID(*calendarID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apperrors.NewValidationError("Invalid calendar", map[string]string{
+				"calendar_id": "calendar not found",
+			})
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"time"
+
+	"taskmanager/internal/clock"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/quickadd"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type QuickAddService interface {
+	QuickAdd(input models.QuickAddInput) (models.QuickAddResult, error)
+}
+
+type quickAddService struct {
+	tasks     TaskService
+	clock     clock.Clock
+	validator *validator.Validate
+}
+
+// NewQuickAddService returns a QuickAddService reading relative dates
+// against clock.
+func NewQuickAddService(tasks TaskService, clock clock.Clock) QuickAddService {
+	return &quickAddService{
+		tasks:     tasks,
+		clock:     clock,
+		validator: validator.New(),
+	}
+}
+
+// QuickAdd parses the text into a task and creates it. Due dates with a
+// time of day are kept in the given time zone.
+func (s *quickAddService) QuickAdd(input models.QuickAddInput) (models.QuickAddResult, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for QuickAddInput")
+		return models.QuickAddResult{}, err
+	}
+
+	location, err := time.LoadLocation(input.TimeZone)
+	if err != nil {
+		return models.QuickAddResult{}, apperrors.NewValidationError("Invalid time zone", map[string]string{
+			"time_zone": "unknown time zone " + input.TimeZone,
+		})
+	}
+
+	parsed := quickadd.Parse(input.Text, s.clock.Now().In(location))
+	create := models.CreateTaskInput{
+		Title:      parsed.Title,
+		Priority:   parsed.Priority,
+		Recurrence: parsed.Recurrence,
+		ProjectID:  input.ProjectID,
+		Tags:       parsed.Tags,
+	}
+	if parsed.Due != nil {
+		if parsed.DueHasTime {
+			create.DueDate = parsed.Due.Format(time.RFC3339)
+			create.DueTimeZone = location.String()
+		} else {
+			create.DueDate = parsed.Due.Format("2006-01-02")
+		}
+	}
+
+	task, err := s.tasks.CreateTask(create)
+	if err != nil {
+		log.Error().Err(err).Str("text", input.Text).Msg("Failed to create quick-added task")
+		return models.QuickAddResult{}, err
+	}
+
+	spans := parsed.Spans
+	if spans == nil {
+		spans = []quickadd.Span{}
+	}
+	return models.QuickAddResult{Task: task, Spans: spans}, nil
+}
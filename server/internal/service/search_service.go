@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/search"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// reindexBatch is the number of tasks whose comments are loaded at once
+// while rebuilding the index.
+const reindexBatch = 500
+
+type SearchService interface {
+	Search(ctx context.Context, query models.SearchQuery) (models.SearchResults, error)
+	// Reindex rebuilds the index from every task in the database.
+	Reindex(ctx context.Context) error
+	// HandleEvent keeps the index in sync with a task change.
+	HandleEvent(event events.Event)
+}
+
+type searchService struct {
+	index     search.Index
+	tasks     repository.TaskRepository
+	comments  repository.CommentRepository
+	validator *validator.Validate
+}
+
+func NewSearchService(index search.Index, tasks repository.TaskRepository, comments repository.CommentRepository) SearchService {
+	return &searchService{
+		index:     index,
+		tasks:     tasks,
+		comments:  comments,
+		validator: validator.New(),
+	}
+}
+
+func (s *searchService) Search(ctx context.Context, query models.SearchQuery) (models.SearchResults, error) {
+	if err := s.validator.Struct(query); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SearchQuery")
+		return models.SearchResults{}, err
+	}
+
+	hits, total, err := s.index.Search(ctx, search.Query{
+		Text:      query.Query,
+		ProjectID: query.ProjectID,
+		Limit:     query.PageSize,
+		Offset:    (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search index")
+		return models.SearchResults{}, err
+	}
+
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.TaskID)
+	}
+	tasks, err := s.tasks.FindByIDs(ids)
+	if err != nil {
+		return models.SearchResults{}, err
+	}
+	byID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	results := models.SearchResults{
+		Data:     make([]models.SearchHit, 0, len(hits)),
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Total:    total,
+	}
+	for _, hit := range hits {
+		// A task deleted since it was indexed is left out
+		task, ok := byID[hit.TaskID]
+		if !ok {
+			continue
+		}
+		results.Data = append(results.Data, models.SearchHit{Task: task, Score: hit.Score, Highlights: hit.Highlights})
+	}
+	return results, nil
+}
+
+func (s *searchService) Reindex(ctx context.Context) error {
+	tasks, err := s.tasks.FindAll(repository.TaskFilter{})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch tasks to index")
+		return err
+	}
+	for start := 0; start < len(tasks); start += reindexBatch {
+		end := start + reindexBatch
+		if end > len(tasks) {
+			end = len(tasks)
+		}
+		if err := s.put(ctx, tasks[start:end]); err != nil {
+			return err
+		}
+	}
+	log.Info().Int("tasks", len(tasks)).Msg("Rebuilt search index")
+	return nil
+}
+
+func (s *searchService) HandleEvent(event events.Event) {
+	ctx := context.Background()
+	var err error
+	switch event.Type {
+	case events.TaskDeleted, events.TaskPurged:
+		err = s.index.Delete(ctx, event.TaskID)
+	default:
+		var task models.Task
+		task, err = s.tasks.FindByID(event.TaskID)
+		if errors.Is(err, repository.ErrNotFound) {
+			err = s.index.Delete(ctx, event.TaskID)
+		} else if err == nil {
+			err = s.put(ctx, []models.Task{task})
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("type", event.Type).Str("task_id", event.TaskID).Msg("Failed to update search index")
+	}
+}
+
+// put indexes tasks with their comments.
+func (s *searchService) put(ctx context.Context, tasks []models.Task) error {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	comments, err := s.comments.FindByTasks(ids)
+	if err != nil {
+		return err
+	}
+	bodies := make(map[string][]string)
+	for _, comment := range comments {
+		bodies[comment.TaskID] = append(bodies[comment.TaskID], comment.Body)
+	}
+
+	for _, task := range tasks {
+		err := s.index.Put(ctx, search.Document{
+			TaskID:      task.ID,
+			ProjectID:   task.ProjectID,
+			Title:       task.Title,
+			Description: task.Description,
+			Tags:        task.Tags,
+			Comments:    bodies[task.ID],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
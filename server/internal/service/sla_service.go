@@ -0,0 +1,352 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"taskmanager/internal/calendar"
+	"taskmanager/internal/clock"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// slaAtRiskShare is the share of the time allowed by a policy after which
+// an open task is at risk of breaching it.
+const slaAtRiskShare = 0.75
+
+type SLAService interface {
+	ListPolicies(projectID string) ([]models.SLAPolicy, error)
+	GetPolicy(id string) (models.SLAPolicy, error)
+	CreatePolicy(projectID string, input models.SLAPolicyInput) (models.SLAPolicy, error)
+	UpdatePolicy(id string, input models.SLAPolicyInput) (models.SLAPolicy, error)
+	DeletePolicy(id string) error
+	// ListTaskStatuses lists the open tasks of a project that are at risk
+	// of breaching a policy or have breached one, soonest deadline first.
+	// A state of at_risk or breached lists only those.
+	ListTaskStatuses(projectID, state string) ([]models.SLAStatus, error)
+	// Evaluate marks the tasks that breached a policy and runs the
+	// escalation steps that are due.
+	Evaluate() error
+}
+
+type slaService struct {
+	repo      repository.SLARepository
+	projects  repository.ProjectRepository
+	taskRepo  repository.TaskRepository
+	tasks     TaskService
+	calendars CalendarService
+	notifier  Notifier
+	clock     clock.Clock
+	validator *validator.Validate
+}
+
+func NewSLAService(repo repository.SLARepository, projects repository.ProjectRepository, taskRepo repository.TaskRepository, tasks TaskService, calendars CalendarService, notifier Notifier, clock clock.Clock) SLAService {
+	return &slaService{
+		repo:      repo,
+		projects:  projects,
+		taskRepo:  taskRepo,
+		tasks:     tasks,
+		calendars: calendars,
+		notifier:  notifier,
+		clock:     clock,
+		validator: validator.New(),
+	}
+}
+
+func (s *slaService) ListPolicies(projectID string) ([]models.SLAPolicy, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPolicies(projectID)
+}
+
+func (s *slaService) GetPolicy(id string) (models.SLAPolicy, error) {
+	return s.repo.FindPolicyByID(id)
+}
+
+func (s *slaService) CreatePolicy(projectID string, input models.SLAPolicyInput) (models.SLAPolicy, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		return models.SLAPolicy{}, err
+	}
+	policy := models.SLAPolicy{
+		ID:        uuid.New().String(),
+		ProjectID: projectID,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.apply(&policy, input); err != nil {
+		return models.SLAPolicy{}, err
+	}
+	createdPolicy, err := s.repo.CreatePolicy(policy)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create SLA policy in repository")
+		return models.SLAPolicy{}, err
+	}
+	return createdPolicy, nil
+}
+
+func (s *slaService) UpdatePolicy(id string, input models.SLAPolicyInput) (models.SLAPolicy, error) {
+	policy, err := s.repo.FindPolicyByID(id)
+	if err != nil {
+		return models.SLAPolicy{}, err
+	}
+	if err := s.apply(&policy, input); err != nil {
+		return models.SLAPolicy{}, err
+	}
+	updatedPolicy, err := s.repo.UpdatePolicy(policy)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update SLA policy in repository")
+		return models.SLAPolicy{}, err
+	}
+	return updatedPolicy, nil
+}
+
+// apply validates the input, including its condition, and copies it onto
+// policy.
+func (s *slaService) apply(policy *models.SLAPolicy, input models.SLAPolicyInput) error {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SLAPolicyInput")
+		return err
+	}
+	if _, err := compileFilter(input.Condition, s.clock.Now(), ""); err != nil {
+		return err
+	}
+	for i := 1; i < len(input.Escalations); i++ {
+		if input.Escalations[i].DelayHours < input.Escalations[i-1].DelayHours {
+			return apperrors.NewValidationError("Invalid escalations", map[string]string{
+				fmt.Sprintf("escalations[%d]", i): "delays must not decrease from one step to the next",
+			})
+		}
+	}
+
+	policy.Name = input.Name
+	policy.Condition = input.Condition
+	policy.BusinessDays = input.BusinessDays
+	if input.Enabled != nil {
+		policy.Enabled = *input.Enabled
+	}
+	policy.Escalations = input.Escalations
+	if policy.Escalations == nil {
+		policy.Escalations = []models.EscalationStep{}
+	}
+	policy.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *slaService) DeletePolicy(id string) error {
+	if _, err := s.repo.FindPolicyByID(id); err != nil {
+		return err
+	}
+	return s.repo.DeletePolicy(id)
+}
+
+func (s *slaService) ListTaskStatuses(projectID, state string) ([]models.SLAStatus, error) {
+	if state != "" && state != models.SLAAtRisk && state != models.SLABreached {
+		return nil, apperrors.NewValidationError("Invalid state", map[string]string{
+			"state": "must be at_risk or breached",
+		})
+	}
+	policies, err := s.ListPolicies(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	statuses := []models.SLAStatus{}
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		tasks, cal, states, err := s.load(policy)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			current := s.track(policy, task, cal, states[task.ID], now)
+			status := statusOf(policy, task, current, now)
+			if status.State == models.SLAOnTrack || (state != "" && status.State != state) {
+				continue
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	sortStatuses(statuses)
+	return statuses, nil
+}
+
+func (s *slaService) Evaluate() error {
+	policies, err := s.repo.FindEnabledPolicies()
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	for _, policy := range policies {
+		tasks, cal, states, err := s.load(policy)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			previous, tracked := states[task.ID]
+			current := s.track(policy, task, cal, previous, now)
+			if current.BreachedAt != nil {
+				current.Escalated = s.escalate(policy, task, current, now)
+			}
+			if tracked && current.DeadlineAt.Equal(previous.DeadlineAt) && current.Escalated == previous.Escalated &&
+				(current.BreachedAt == nil) == (previous.BreachedAt == nil) {
+				continue
+			}
+			current.UpdatedAt = now
+			if err := s.repo.SaveState(current); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// load returns the open tasks a policy applies to, the calendar of its
+// project and the state of its tasks.
+func (s *slaService) load(policy models.SLAPolicy) ([]models.Task, calendar.Calendar, map[string]models.TaskSLA, error) {
+	where, err := compileFilter(policy.Condition, s.clock.Now(), "")
+	if err != nil {
+		return nil, calendar.Calendar{}, nil, err
+	}
+	all, err := s.taskRepo.FindAll(repository.TaskFilter{ProjectID: policy.ProjectID, Where: where})
+	if err != nil {
+		return nil, calendar.Calendar{}, nil, err
+	}
+	var tasks []models.Task
+	for _, task := range all {
+		if !task.Completed {
+			tasks = append(tasks, task)
+		}
+	}
+	cal, err := s.calendars.ForProject(&policy.ProjectID)
+	if err != nil {
+		return nil, calendar.Calendar{}, nil, err
+	}
+	states, err := s.repo.FindStates(policy.ID)
+	if err != nil {
+		return nil, calendar.Calendar{}, nil, err
+	}
+	return tasks, cal, states, nil
+}
+
+// track returns the state of a task with a policy as of now, from its
+// previous state. A breach stays on record even if the deadline moves.
+func (s *slaService) track(policy models.SLAPolicy, task models.Task, cal calendar.Calendar, state models.TaskSLA, now time.Time) models.TaskSLA {
+	state.TaskID = task.ID
+	state.PolicyID = policy.ID
+	state.DeadlineAt = cal.AddBusinessDays(task.CreatedAt, policy.BusinessDays).UTC()
+	if state.BreachedAt == nil && now.After(state.DeadlineAt) {
+		breachedAt := now
+		state.BreachedAt = &breachedAt
+	}
+	return state
+}
+
+// escalate runs the escalation steps of a breached task that are due and
+// returns how many steps have run. A step that fails is retried on the
+// next evaluation, before the steps after it.
+func (s *slaService) escalate(policy models.SLAPolicy, task models.Task, state models.TaskSLA, now time.Time) int {
+	escalated := state.Escalated
+	for escalated < len(policy.Escalations) {
+		step := policy.Escalations[escalated]
+		if now.Before(state.BreachedAt.Add(time.Duration(step.DelayHours) * time.Hour)) {
+			break
+		}
+		if err := s.runStep(policy, task, step); err != nil {
+			log.Error().Err(err).Str("policy_id", policy.ID).Str("task_id", task.ID).Str("action", step.Action).Msg("Failed to run SLA escalation step")
+			break
+		}
+		escalated++
+	}
+	return escalated
+}
+
+func (s *slaService) runStep(policy models.SLAPolicy, task models.Task, step models.EscalationStep) error {
+	message := fmt.Sprintf("%q breached the %s SLA", task.Title, policy.Name)
+	switch step.Action {
+	case models.EscalateNotifyAssignees:
+		for _, userID := range task.Assignees {
+			if err := s.notifier.Notify(Notification{UserID: userID, Kind: NotificationSLA, TaskID: task.ID, Message: message}); err != nil {
+				return err
+			}
+		}
+	case models.EscalateNotifyAdmin:
+		project, err := s.projects.FindByID(policy.ProjectID)
+		if err != nil {
+			return err
+		}
+		if project.AdminID == nil {
+			log.Warn().Str("project_id", project.ID).Msg("Project has no admin to escalate SLA breach to")
+			return nil
+		}
+		return s.notifier.Notify(Notification{UserID: *project.AdminID, Kind: NotificationSLA, TaskID: task.ID, Message: message})
+	case models.EscalateBumpPriority:
+		current, err := s.taskRepo.FindByID(task.ID)
+		if err != nil {
+			return err
+		}
+		next := nextPriority(current.Priority)
+		if next == current.Priority {
+			return nil
+		}
+		input := updateInput(current)
+		input.Priority = next
+		_, err = s.tasks.UpdateTask(current.ID, input, "")
+		return err
+	}
+	return nil
+}
+
+// statusOf tells where a task stands with a policy.
+func statusOf(policy models.SLAPolicy, task models.Task, state models.TaskSLA, now time.Time) models.SLAStatus {
+	status := models.SLAStatus{
+		TaskID:     task.ID,
+		Title:      task.Title,
+		Priority:   task.Priority,
+		Assignees:  task.Assignees,
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		State:      models.SLAOnTrack,
+		DeadlineAt: state.DeadlineAt,
+		BreachedAt: state.BreachedAt,
+		Escalated:  state.Escalated,
+	}
+	allowed := state.DeadlineAt.Sub(task.CreatedAt)
+	switch {
+	case state.BreachedAt != nil:
+		status.State = models.SLABreached
+	case now.Sub(task.CreatedAt) >= time.Duration(float64(allowed)*slaAtRiskShare):
+		status.State = models.SLAAtRisk
+	}
+	return status
+}
+
+// sortStatuses orders statuses by deadline, then by task.
+func sortStatuses(statuses []models.SLAStatus) {
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if !statuses[i].DeadlineAt.Equal(statuses[j].DeadlineAt) {
+			return statuses[i].DeadlineAt.Before(statuses[j].DeadlineAt)
+		}
+		return statuses[i].TaskID < statuses[j].TaskID
+	})
+}
+
+// nextPriority returns the priority above priority, or priority when it is
+// the highest.
+func nextPriority(priority string) string {
+	for i, p := range models.Priorities {
+		if p == priority && i+1 < len(models.Priorities) {
+			return models.Priorities[i+1]
+		}
+	}
+	return priority
+}
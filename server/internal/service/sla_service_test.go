@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"taskmanager/internal/calendar"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+)
+
+func TestSLATrack(t *testing.T) {
+	// Friday morning, so that one business day ends on Monday
+	created := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	earlier := time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC)
+	withHoliday := calendar.Standard()
+	withHoliday.Holidays = map[string]bool{"2026-10-19": true}
+
+	tests := []struct {
+		name         string
+		businessDays int
+		cal          calendar.Calendar
+		previous     models.TaskSLA
+		now          time.Time
+		wantDeadline time.Time
+		wantBreached *time.Time
+		wantEscalate int
+	}{
+		{
+			name: "before the deadline", businessDays: 1, cal: calendar.Standard(),
+			now: monday.Add(-time.Minute), wantDeadline: monday,
+		},
+		{
+			name: "at the deadline", businessDays: 1, cal: calendar.Standard(),
+			now: monday, wantDeadline: monday,
+		},
+		{
+			name: "after the deadline", businessDays: 1, cal: calendar.Standard(),
+			now: monday.Add(time.Minute), wantDeadline: monday, wantBreached: timePtr(monday.Add(time.Minute)),
+		},
+		{
+			name: "holiday skipped", businessDays: 1, cal: withHoliday,
+			now: monday.Add(time.Hour), wantDeadline: monday.AddDate(0, 0, 1),
+		},
+		{
+			name: "breach kept", businessDays: 1, cal: calendar.Standard(),
+			previous: models.TaskSLA{DeadlineAt: monday, BreachedAt: &earlier, Escalated: 2},
+			now:      monday.Add(3 * time.Hour), wantDeadline: monday, wantBreached: &earlier, wantEscalate: 2,
+		},
+		{
+			name: "breach kept after the deadline moves", businessDays: 3, cal: calendar.Standard(),
+			previous: models.TaskSLA{DeadlineAt: monday, BreachedAt: &earlier, Escalated: 1},
+			now:      monday.Add(3 * time.Hour), wantDeadline: monday.AddDate(0, 0, 2), wantBreached: &earlier, wantEscalate: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := models.SLAPolicy{ID: "policy-1", BusinessDays: tt.businessDays}
+			task := models.Task{ID: "task-1", CreatedAt: created}
+			got := (&slaService{}).track(policy, task, tt.cal, tt.previous, tt.now)
+			if got.TaskID != "task-1" || got.PolicyID != "policy-1" {
+				t.Errorf("track() is for %s and %s, want task-1 and policy-1", got.TaskID, got.PolicyID)
+			}
+			if !got.DeadlineAt.Equal(tt.wantDeadline) {
+				t.Errorf("DeadlineAt = %v, want %v", got.DeadlineAt, tt.wantDeadline)
+			}
+			if (got.BreachedAt == nil) != (tt.wantBreached == nil) || (got.BreachedAt != nil && !got.BreachedAt.Equal(*tt.wantBreached)) {
+				t.Errorf("BreachedAt = %v, want %v", got.BreachedAt, tt.wantBreached)
+			}
+			if got.Escalated != tt.wantEscalate {
+				t.Errorf("Escalated = %d, want %d", got.Escalated, tt.wantEscalate)
+			}
+		})
+	}
+}
+
+func TestSLAStatusOf(t *testing.T) {
+	// Four business days from Monday 9:00 allow 96 hours, so a task is at
+	// risk from Thursday 9:00.
+	created := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, time.October, 23, 9, 0, 0, 0, time.UTC)
+	atRisk := time.Date(2026, time.October, 22, 9, 0, 0, 0, time.UTC)
+	breachedAt := deadline.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		state models.TaskSLA
+		now   time.Time
+		want  string
+	}{
+		{name: "new", state: models.TaskSLA{DeadlineAt: deadline}, now: created, want: models.SLAOnTrack},
+		{name: "just before the threshold", state: models.TaskSLA{DeadlineAt: deadline}, now: atRisk.Add(-time.Second), want: models.SLAOnTrack},
+		{name: "at the threshold", state: models.TaskSLA{DeadlineAt: deadline}, now: atRisk, want: models.SLAAtRisk},
+		{name: "breached", state: models.TaskSLA{DeadlineAt: deadline, BreachedAt: &breachedAt, Escalated: 1}, now: breachedAt, want: models.SLABreached},
+		{
+			name:  "breached before the deadline moved",
+			state: models.TaskSLA{DeadlineAt: deadline.AddDate(0, 0, 7), BreachedAt: &breachedAt},
+			now:   breachedAt.Add(time.Hour), want: models.SLABreached,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := models.SLAPolicy{ID: "policy-1", Name: "Support"}
+			task := models.Task{ID: "task-1", Title: "Reply", Priority: models.PriorityHigh, Assignees: []string{"alice"}, CreatedAt: created}
+			got := statusOf(policy, task, tt.state, tt.now)
+			want := models.SLAStatus{
+				TaskID: "task-1", Title: "Reply", Priority: models.PriorityHigh, Assignees: []string{"alice"},
+				PolicyID: "policy-1", PolicyName: "Support", State: tt.want,
+				DeadlineAt: tt.state.DeadlineAt, BreachedAt: tt.state.BreachedAt, Escalated: tt.state.Escalated,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("statusOf() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// escalationLog records the actions escalation steps take, in order.
+type escalationLog struct {
+	actions []string
+	// down holds the users notifications fail for.
+	down map[string]bool
+}
+
+func (l *escalationLog) Notify(notification Notification) error {
+	if l.down[notification.UserID] {
+		return errors.New("mail server unavailable")
+	}
+	l.actions = append(l.actions, "notify "+notification.UserID+": "+notification.Message)
+	return nil
+}
+
+// bumpingTaskService records the priority changes of escalations.
+type bumpingTaskService struct {
+	TaskService
+	log *escalationLog
+}
+
+func (s *bumpingTaskService) UpdateTask(id string, input models.UpdateTaskInput, actorID string) (models.Task, error) {
+	s.log.actions = append(s.log.actions, fmt.Sprintf("bump %s to %s", id, input.Priority))
+	return models.Task{ID: id, Priority: input.Priority}, nil
+}
+
+type fakeProjectRepository struct {
+	repository.ProjectRepository
+	projects map[string]models.Project
+}
+
+func (r *fakeProjectRepository) FindByID(id string) (models.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return models.Project{}, repository.ErrNotFound
+	}
+	return project, nil
+}
+
+func newEscalationService(log *escalationLog, task models.Task) *slaService {
+	admin := "carol"
+	return &slaService{
+		projects: &fakeProjectRepository{projects: map[string]models.Project{"project-1": {ID: "project-1", AdminID: &admin}}},
+		taskRepo: &fakeTaskRepository{tasks: map[string]models.Task{task.ID: task}},
+		tasks:    &bumpingTaskService{log: log},
+		notifier: log,
+	}
+}
+
+func TestSLAEscalate(t *testing.T) {
+	breachedAt := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	policy := models.SLAPolicy{ID: "policy-1", ProjectID: "project-1", Name: "Support", Escalations: []models.EscalationStep{
+		{Action: models.EscalateNotifyAssignees, DelayHours: 0},
+		{Action: models.EscalateNotifyAdmin, DelayHours: 4},
+		{Action: models.EscalateBumpPriority, DelayHours: 24},
+	}}
+	task := models.Task{ID: "task-1", Title: "Reply", Priority: models.PriorityMedium, Assignees: []string{"alice", "bob"}}
+	assignees := []string{`notify alice: "Reply" breached the Support SLA`, `notify bob: "Reply" breached the Support SLA`}
+	admin := `notify carol: "Reply" breached the Support SLA`
+	bump := "bump task-1 to high"
+
+	tests := []struct {
+		name      string
+		escalated int
+		after     time.Duration
+		down      []string
+		want      int
+		actions   []string
+	}{
+		{name: "first step at the breach", after: 0, want: 1, actions: assignees},
+		{name: "next step not due", escalated: 1, after: 4*time.Hour - time.Minute, want: 1},
+		{name: "due steps in order", after: 5 * time.Hour, want: 2, actions: append(append([]string{}, assignees...), admin)},
+		{name: "all steps late", after: 48 * time.Hour, want: 3, actions: append(append([]string{}, assignees...), admin, bump)},
+		{name: "rest of the steps", escalated: 1, after: 48 * time.Hour, want: 3, actions: []string{admin, bump}},
+		{name: "all run", escalated: 3, after: 48 * time.Hour, want: 3},
+		{name: "failed step stops later ones", after: 48 * time.Hour, down: []string{"carol"}, want: 1, actions: assignees},
+		{name: "failed step retried first", escalated: 1, after: 49 * time.Hour, want: 3, actions: []string{admin, bump}},
+		{name: "partly failed step not counted", after: 48 * time.Hour, down: []string{"bob"}, want: 0, actions: assignees[:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &escalationLog{down: make(map[string]bool)}
+			for _, user := range tt.down {
+				log.down[user] = true
+			}
+			service := newEscalationService(log, task)
+			state := models.TaskSLA{BreachedAt: &breachedAt, Escalated: tt.escalated}
+			if got := service.escalate(policy, task, state, breachedAt.Add(tt.after)); got != tt.want {
+				t.Errorf("escalate() = %d, want %d", got, tt.want)
+			}
+			if !reflect.DeepEqual(log.actions, tt.actions) {
+				t.Errorf("actions = %q, want %q", log.actions, tt.actions)
+			}
+		})
+	}
+}
+
+func TestSLAEscalateWithoutAdmin(t *testing.T) {
+	breachedAt := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	policy := models.SLAPolicy{ID: "policy-1", ProjectID: "project-1", Name: "Support", Escalations: []models.EscalationStep{
+		{Action: models.EscalateNotifyAdmin},
+		{Action: models.EscalateBumpPriority},
+	}}
+	task := models.Task{ID: "task-1", Title: "Reply", Priority: models.PriorityUrgent}
+	log := &escalationLog{}
+	service := newEscalationService(log, task)
+	service.projects = &fakeProjectRepository{projects: map[string]models.Project{"project-1": {ID: "project-1"}}}
+
+	// Neither step has anything to do, and both count as run
+	if got := service.escalate(policy, task, models.TaskSLA{BreachedAt: &breachedAt}, breachedAt); got != 2 {
+		t.Errorf("escalate() = %d, want 2", got)
+	}
+	if len(log.actions) != 0 {
+		t.Errorf("actions = %q, want none", log.actions)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
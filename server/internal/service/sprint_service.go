@@ -0,0 +1,355 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"taskmanager/internal/clock"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type SprintService interface {
+	ListSprints(projectID string) ([]models.Sprint, error)
+	GetSprint(id string) (models.Sprint, error)
+	CreateSprint(projectID string, input models.SprintInput) (models.Sprint, error)
+	UpdateSprint(id string, input models.SprintInput) (models.Sprint, error)
+	DeleteSprint(id string) error
+	// AddTasks moves tasks of the sprint's project into the sprint for the
+	// user actorID.
+	AddTasks(id string, taskIDs []string, actorID string) ([]models.Task, error)
+	// CloseSprint closes a sprint and carries its unfinished tasks over.
+	CloseSprint(id string, input models.CloseSprintInput) (models.CloseSprintResult, error)
+	// Burndown replays the task history of a sprint day by day, with days
+	// ending at midnight in timeZone.
+	Burndown(id, timeZone string) (models.Burndown, error)
+}
+
+type sprintService struct {
+	repo      repository.SprintRepository
+	projects  repository.ProjectRepository
+	taskRepo  repository.TaskRepository
+	tasks     TaskService
+	clock     clock.Clock
+	publisher events.Publisher
+	validator *validator.Validate
+}
+
+func NewSprintService(repo repository.SprintRepository, projects repository.ProjectRepository, taskRepo repository.TaskRepository, tasks TaskService, clock clock.Clock, publisher events.Publisher) SprintService {
+	return &sprintService{
+		repo:      repo,
+		projects:  projects,
+		taskRepo:  taskRepo,
+		tasks:     tasks,
+		clock:     clock,
+		publisher: publisher,
+		validator: validator.New(),
+	}
+}
+
+func (s *sprintService) ListSprints(projectID string) ([]models.Sprint, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		return nil, err
+	}
+	sprints, err := s.repo.FindByProject(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch sprints from repository")
+		return nil, err
+	}
+	return sprints, nil
+}
+
+func (s *sprintService) GetSprint(id string) (models.Sprint, error) {
+	sprint, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch sprint from repository")
+		return models.Sprint{}, err
+	}
+	tasks, err := s.taskRepo.FindAll(repository.TaskFilter{SprintID: id})
+	if err != nil {
+		return models.Sprint{}, err
+	}
+	for _, task := range tasks {
+		sprint.TaskCount++
+		sprint.Scope += task.Estimate
+		if task.Status == models.StatusDone {
+			sprint.Completed += task.Estimate
+		}
+	}
+	return sprint, nil
+}
+
+func (s *sprintService) CreateSprint(projectID string, input models.SprintInput) (models.Sprint, error) {
+	if err := s.validate(input); err != nil {
+		return models.Sprint{}, err
+	}
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find project for new sprint")
+		return models.Sprint{}, err
+	}
+
+	sprint := models.Sprint{
+		ID:        uuid.New().String(),
+		ProjectID: projectID,
+		Status:    models.SprintPlanned,
+		CreatedAt: time.Now(),
+	}
+	applySprintInput(&sprint, input)
+
+	createdSprint, err := s.repo.Create(sprint)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create sprint in repository")
+		return models.Sprint{}, err
+	}
+	return createdSprint, nil
+}
+
+func (s *sprintService) UpdateSprint(id string, input models.SprintInput) (models.Sprint, error) {
+	if err := s.validate(input); err != nil {
+		return models.Sprint{}, err
+	}
+	sprint, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find sprint for update")
+		return models.Sprint{}, err
+	}
+	if sprint.Status == models.SprintClosed && input.Status != "" {
+		return models.Sprint{}, apperrors.NewValidationError("Sprint is closed", map[string]string{
+			"status": "closed sprints cannot be reopened",
+		})
+	}
+
+	applySprintInput(&sprint, input)
+	updatedSprint, err := s.repo.Update(sprint)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update sprint in repository")
+		return models.Sprint{}, err
+	}
+	return updatedSprint, nil
+}
+
+func (s *sprintService) validate(input models.SprintInput) error {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for SprintInput")
+		return err
+	}
+	if input.EndDate < input.StartDate {
+		return apperrors.NewValidationError("Invalid sprint dates", map[string]string{
+			"end_date": "must not be before start_date",
+		})
+	}
+	return nil
+}
+
+func applySprintInput(sprint *models.Sprint, input models.SprintInput) {
+	sprint.Name = input.Name
+	sprint.Goal = input.Goal
+	sprint.StartDate = input.StartDate
+	sprint.EndDate = input.EndDate
+	sprint.Capacity = input.Capacity
+	if input.Status != "" {
+		sprint.Status = input.Status
+	}
+	sprint.UpdatedAt = time.Now()
+}
+
+func (s *sprintService) DeleteSprint(id string) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete sprint from repository")
+		return err
+	}
+	return nil
+}
+
+func (s *sprintService) AddTasks(id string, taskIDs []string, actorID string) ([]models.Task, error) {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, err
+	}
+	tasks := make([]models.Task, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		task, err := s.tasks.SetSprint(taskID, &id, actorID)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (s *sprintService) CloseSprint(id string, input models.CloseSprintInput) (models.CloseSprintResult, error) {
+	sprint, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find sprint to close")
+		return models.CloseSprintResult{}, err
+	}
+	if sprint.Status == models.SprintClosed {
+		return models.CloseSprintResult{}, apperrors.NewValidationError("Sprint is closed", map[string]string{
+			"status": "sprint is already closed",
+		})
+	}
+
+	var target *string
+	if input.CarryOverTo != "" {
+		if err := s.checkCarryOver(sprint, input.CarryOverTo); err != nil {
+			return models.CloseSprintResult{}, err
+		}
+		target = &input.CarryOverTo
+	}
+
+	// Taken before carrying tasks over, so the burndown of the sprint ends
+	// with the tasks it had when it was closed
+	now := s.clock.Now()
+	tasks, err := s.taskRepo.FindAll(repository.TaskFilter{SprintID: id})
+	if err != nil {
+		return models.CloseSprintResult{}, err
+	}
+	result := models.CloseSprintResult{CarriedOver: []string{}}
+	var carried []models.Task
+	for _, task := range tasks {
+		if task.Status == models.StatusDone {
+			continue
+		}
+		task.SprintID = target
+		task.UpdatedAt = time.Now()
+		carried = append(carried, task)
+		result.CarriedOver = append(result.CarriedOver, task.ID)
+	}
+
+	sprint.Status = models.SprintClosed
+	sprint.ClosedAt = &now
+	sprint.UpdatedAt = now
+	if result.Sprint, err = s.repo.Close(sprint, carried); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to close sprint in repository")
+		return models.CloseSprintResult{}, err
+	}
+	for _, task := range carried {
+		s.publisher.Publish(events.Event{Type: events.TaskUpdated, TaskID: task.ID, ProjectID: sprint.ProjectID})
+	}
+	return result, nil
+}
+
+// checkCarryOver makes sure the unfinished tasks of sprint may move to
+// targetID: another open sprint of the same project.
+func (s *sprintService) checkCarryOver(sprint models.Sprint, targetID string) error {
+	if targetID == sprint.ID {
+		return invalidCarryOver("must be another sprint")
+	}
+	target, err := s.repo.FindByID(targetID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return invalidCarryOver("sprint not found")
+	}
+	if err != nil {
+		return err
+	}
+	if target.ProjectID != sprint.ProjectID {
+		return invalidCarryOver("sprint belongs to another project")
+	}
+	if target.Status == models.SprintClosed {
+		return invalidCarryOver("sprint is closed")
+	}
+	return nil
+}
+
+func invalidCarryOver(reason string) error {
+	return apperrors.NewValidationError("Invalid sprint", map[string]string{
+		"carry_over_to": reason,
+	})
+}
+
+// Burndown gives one point per day from the start of the sprint to its
+// end, today, or the day it was closed, whichever comes first. The ideal
+// line goes from the scope of the first day down to zero on the last day
+// of the sprint.
+func (s *sprintService) Burndown(id, timeZone string) (models.Burndown, error) {
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return models.Burndown{}, apperrors.NewValidationError("Invalid time zone", map[string]string{
+			"tz": err.Error(),
+		})
+	}
+	sprint, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.Burndown{}, err
+	}
+	project, err := s.projects.FindByID(sprint.ProjectID)
+	if err != nil {
+		return models.Burndown{}, err
+	}
+
+	start, _ := time.ParseInLocation("2006-01-02", sprint.StartDate, location)
+	end, _ := time.ParseInLocation("2006-01-02", sprint.EndDate, location)
+	until := s.clock.Now()
+	if sprint.ClosedAt != nil && sprint.ClosedAt.Before(until) {
+		until = *sprint.ClosedAt
+	}
+	revisions, err := s.repo.FindHistory(id, until)
+	if err != nil {
+		return models.Burndown{}, err
+	}
+
+	burndown := models.Burndown{
+		SprintID: id,
+		Unit:     project.EstimateUnit,
+		Capacity: sprint.Capacity,
+		Points:   []models.BurndownPoint{},
+	}
+	days := int(end.Sub(start).Hours()/24+0.5) + 1
+	state := make(map[string]models.Task)
+	next := 0
+	for day := 0; day < days; day++ {
+		dayStart := start.AddDate(0, 0, day)
+		if dayStart.After(until) {
+			break
+		}
+
+		// Apply the changes made up to the end of the day
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		for ; next < len(revisions) && revisions[next].RevisedAt.Before(dayEnd); next++ {
+			revision := revisions[next]
+			if revision.Operation == models.RevisionDeleted {
+				delete(state, revision.TaskID)
+				continue
+			}
+			task, err := revision.Task()
+			if err != nil {
+				log.Error().Err(err).Uint("revision_id", revision.ID).Msg("Failed to decode task revision")
+				return models.Burndown{}, err
+			}
+			state[revision.TaskID] = task
+		}
+
+		point := models.BurndownPoint{Date: dayStart.Format("2006-01-02")}
+		for _, task := range state {
+			if task.SprintID == nil || *task.SprintID != id {
+				continue
+			}
+			point.Tasks++
+			point.Scope += task.Estimate
+			if task.Status == models.StatusDone {
+				point.DoneTasks++
+				point.Completed += task.Estimate
+			}
+		}
+		point.Remaining = point.Scope - point.Completed
+		burndown.Points = append(burndown.Points, point)
+	}
+
+	if len(burndown.Points) > 0 {
+		initial := burndown.Points[0].Scope
+		for i := range burndown.Points {
+			if days > 1 {
+				burndown.Points[i].Ideal = initial * float64(days-1-i) / float64(days-1)
+			}
+		}
+	}
+	return burndown, nil
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+	"taskmanager/internal/taskio"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxImportRows caps the rows of one import
+const maxImportRows = 10000
+
+// importFields are the task fields an import reads. Custom fields are
+// read from "cf.<key>".
+var importFields = map[string]bool{
+	"external_id":   true,
+	"title":         true,
+	"description":   true,
+	"status":        true,
+	"priority":      true,
+	"completed":     true,
+	"due_date":      true,
+	"due_time_zone": true,
+	"estimate":      true,
+	"recurrence":    true,
+	"tags":          true,
+	"project_id":    true,
+	"parent_id":     true,
+	"sprint_id":     true,
+	"custom_fields": true,
+}
+
+type TaskIOService interface {
+	// CheckExport reports whether an export can run, before anything is
+	// written.
+	CheckExport(query models.TaskQuery, format string) error
+	// ExportTasks writes the tasks a list query selects to w in a format,
+	// a batch of tasks at a time.
+	ExportTasks(query models.TaskQuery, format string, w io.Writer) error
+	// ImportTasks reads tasks from r and upserts them by external ID.
+	ImportTasks(r io.Reader, options models.ImportOptions) (models.ImportResult, error)
+}
+
+type taskIOService struct {
+	tasks        TaskService
+	customFields CustomFieldService
+}
+
+func NewTaskIOService(tasks TaskService, customFields CustomFieldService) TaskIOService {
+	return &taskIOService{tasks: tasks, customFields: customFields}
+}
+
+func (s *taskIOService) CheckExport(query models.TaskQuery, format string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	return s.tasks.CheckQuery(query)
+}
+
+func (s *taskIOService) ExportTasks(query models.TaskQuery, format string, w io.Writer) error {
+	var fieldKeys []string
+	if query.ProjectID != "" {
+		fields, err := s.customFields.ListFields(query.ProjectID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		for _, field := range fields {
+			fieldKeys = append(fieldKeys, field.Key)
+		}
+	}
+	writer, err := taskio.NewWriter(format, w, fieldKeys)
+	if err != nil {
+		return err
+	}
+	if err := s.tasks.EachTask(query, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *taskIOService) ImportTasks(r io.Reader, options models.ImportOptions) (models.ImportResult, error) {
+	if err := checkFormat(options.Format); err != nil {
+		return models.ImportResult{}, err
+	}
+	if options.Mode == "" {
+		options.Mode = models.ImportAtomic
+	}
+	if options.Mode != models.ImportAtomic && options.Mode != models.ImportPartial {
+		return models.ImportResult{}, apperrors.NewValidationError("Invalid mode", map[string]string{
+			"mode": "must be atomic or partial",
+		})
+	}
+	for source, target := range options.Mapping {
+		if !importFields[target] && !strings.HasPrefix(target, "cf.") {
+			return models.ImportResult{}, apperrors.NewValidationError("Invalid mapping", map[string]string{
+				source: fmt.Sprintf("unknown task field %q", target),
+			})
+		}
+	}
+
+	reader, err := taskio.NewReader(options.Format, r)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	result := models.ImportResult{
+		Format:  options.Format,
+		Mode:    options.Mode,
+		DryRun:  options.DryRun,
+		Ignored: []string{},
+		Rows:    []models.ImportRowResult{},
+	}
+	ignored := make(map[string]bool)
+	seen := make(map[string]bool)
+	fields := newFieldTypes(s.customFields)
+	var inputs []models.CreateTaskInput
+	var rows []models.ImportRowResult
+	var failedRows []models.ImportRowResult
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return models.ImportResult{}, importSyntaxError(err)
+		}
+		if len(inputs)+len(failedRows) == maxImportRows {
+			return models.ImportResult{}, apperrors.NewValidationError("Too many rows", map[string]string{
+				"rows": fmt.Sprintf("an import has at most %d rows", maxImportRows),
+			})
+		}
+
+		input, details := s.recordInput(record, options.Mapping, ignored, fields)
+		row := models.ImportRowResult{Row: record.Row, ExternalID: deref(input.ExternalID)}
+		if input.ExternalID != nil {
+			if seen[*input.ExternalID] {
+				details["external_id"] = "appears on an earlier row"
+			}
+			seen[*input.ExternalID] = true
+		}
+		if len(details) > 0 {
+			row.Outcome = UpsertFailed
+			row.Error = "Invalid values"
+			row.Details = details
+			failedRows = append(failedRows, row)
+			continue
+		}
+		inputs = append(inputs, input)
+		rows = append(rows, row)
+	}
+
+	atomic := options.Mode == models.ImportAtomic
+	dryRun := options.DryRun || (atomic && len(failedRows) > 0)
+	upserts, saved, err := s.tasks.UpsertTasks(inputs, atomic, dryRun)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	for i, upsert := range upserts {
+		rows[i].Outcome = upsert.Outcome
+		rows[i].TaskID = upsert.TaskID
+		if upsert.Err != nil {
+			rows[i].Error, rows[i].Details = rowError(upsert.Err)
+		}
+	}
+	rows = append(rows, failedRows...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Row < rows[j].Row })
+
+	for _, row := range rows {
+		switch row.Outcome {
+		case UpsertCreated:
+			result.Created++
+		case UpsertUpdated:
+			result.Updated++
+		default:
+			result.Failed++
+		}
+	}
+	for column := range ignored {
+		result.Ignored = append(result.Ignored, column)
+	}
+	sort.Strings(result.Ignored)
+	result.Rows = rows
+	result.Saved = saved
+	return result, nil
+}
+
+// recordInput maps a record onto the input of a task, with the problems
+// of the values it could not read by field.
+func (s *taskIOService) recordInput(record taskio.Record, mapping map[string]string, ignored map[string]bool, fields *fieldTypes) (models.CreateTaskInput, map[string]string) {
+	var input models.CreateTaskInput
+	details := make(map[string]string)
+	customFields := make(map[string]interface{})
+	for source, value := range record.Fields {
+		target, ok := mapping[source]
+		if !ok {
+			target = source
+		}
+		if key, ok := strings.CutPrefix(target, "cf."); ok {
+			customFields[key] = value
+			continue
+		}
+		if !importFields[target] {
+			ignored[source] = true
+			continue
+		}
+		if err := setInputField(&input, target, value, customFields); err != nil {
+			details[target] = err.Error()
+		}
+	}
+
+	if len(customFields) > 0 {
+		if input.ProjectID == nil {
+			details["custom_fields"] = "custom fields require a project_id"
+		} else {
+			input.CustomFields = fields.coerce(*input.ProjectID, customFields)
+		}
+	}
+	return input, details
+}
+
+// setInputField sets one field of a task input from an imported value.
+func setInputField(input *models.CreateTaskInput, field string, value interface{}, customFields map[string]interface{}) error {
+	switch field {
+	case "completed":
+		completed, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		input.Completed = completed
+		if completed && input.Status == "" {
+			input.Status = models.StatusDone
+		}
+		return nil
+	case "estimate":
+		estimate, err := numberValue(value)
+		input.Estimate = estimate
+		return err
+	case "tags":
+		tags, err := listValue(value)
+		input.Tags = tags
+		return err
+	case "custom_fields":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return errors.New("must be an object")
+		}
+		for key, v := range values {
+			customFields[key] = v
+		}
+		return nil
+	}
+
+	text, err := textValue(value)
+	if err != nil || text == "" {
+		return err
+	}
+	switch field {
+	case "external_id":
+		input.ExternalID = &text
+	case "title":
+		input.Title = text
+	case "description":
+		input.Description = text
+	case "status":
+		input.Status = text
+	case "priority":
+		input.Priority = text
+	case "due_date":
+		input.DueDate = text
+	case "due_time_zone":
+		input.DueTimeZone = text
+	case "recurrence":
+		input.Recurrence = text
+	case "project_id":
+		input.ProjectID = &text
+	case "parent_id":
+		input.ParentID = &text
+	case "sprint_id":
+		input.SprintID = &text
+	}
+	if field == "status" && text == models.StatusDone {
+		input.Completed = true
+	}
+	return nil
+}
+
+func textValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", errors.New("must be text")
+	}
+}
+
+func numberValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, errors.New("must be a number")
+		}
+		return number, nil
+	default:
+		return 0, errors.New("must be a number")
+	}
+}
+
+func boolValue(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, errors.New("must be true or false")
+		}
+		return b, nil
+	default:
+		return false, errors.New("must be true or false")
+	}
+}
+
+// listValue reads a JSON array of strings, or comma-separated text.
+func listValue(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, errors.New("must be a list of text")
+			}
+			items = append(items, text)
+		}
+		return items, nil
+	default:
+		return nil, errors.New("must be a list of text")
+	}
+}
+
+// fieldTypes reads custom field values given as text by the type of the
+// field, loading the fields of each project once.
+type fieldTypes struct {
+	customFields CustomFieldService
+	byProject    map[string]map[string]string
+}
+
+func newFieldTypes(customFields CustomFieldService) *fieldTypes {
+	return &fieldTypes{customFields: customFields, byProject: make(map[string]map[string]string)}
+}
+
+// coerce converts the text values of number and multi-select fields, and
+// blank text to no value. Values it cannot convert are left for
+// validation to report.
+func (f *fieldTypes) coerce(projectID string, values map[string]interface{}) map[string]interface{} {
+	types, ok := f.byProject[projectID]
+	if !ok {
+		types = make(map[string]string)
+		fields, err := f.customFields.ListFields(projectID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error().Err(err).Str("project_id", projectID).Msg("Failed to load custom fields for import")
+		}
+		for _, field := range fields {
+			types[field.Key] = field.Type
+		}
+		f.byProject[projectID] = types
+	}
+
+	for key, value := range values {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			values[key] = nil
+			continue
+		}
+		switch types[key] {
+		case models.FieldNumber:
+			if number, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+				values[key] = number
+			}
+		case models.FieldMultiSelect:
+			items, _ := listValue(text)
+			list := make([]interface{}, len(items))
+			for i, item := range items {
+				list[i] = item
+			}
+			values[key] = list
+		}
+	}
+	return values
+}
+
+func checkFormat(format string) error {
+	if !taskio.Valid(format) {
+		return apperrors.NewValidationError("Invalid format", map[string]string{
+			"format": "must be one of " + strings.Join(taskio.Formats, ", "),
+		})
+	}
+	return nil
+}
+
+// importSyntaxError reports a file that cannot be read as invalid input.
+func importSyntaxError(err error) error {
+	var syntaxErr *taskio.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return apperrors.NewValidationError("Invalid file", map[string]string{
+			fmt.Sprintf("row %d", syntaxErr.Row): syntaxErr.Err.Error(),
+		})
+	}
+	return err
+}
+
+// rowError describes why a row could not be imported.
+func rowError(err error) (string, map[string]string) {
+	var validationErr *apperrors.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Message, validationErr.Details
+	case errors.Is(err, repository.ErrNotFound):
+		return "The project, parent or sprint does not exist", nil
+	default:
+		return err.Error(), nil
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
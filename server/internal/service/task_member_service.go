@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// activityMessages describes the events watchers are told about
+var activityMessages = map[string]string{
+	events.TaskUpdated:    "Task %q was updated",
+	events.TaskDeleted:    "Task %q was deleted",
+	events.TaskRestored:   "Task %q was restored",
+	events.CommentCreated: "New comment on task %q",
+	events.CommentUpdated: "A comment on task %q was edited",
+}
+
+type TaskMemberService interface {
+	GetMembers(taskID string) (models.TaskMembers, error)
+	// Assign makes a user an assignee of a task, and a watcher too.
+	Assign(taskID, userID, actorID string) (models.TaskMembers, error)
+	// Unassign removes an assignee; they keep watching the task.
+	Unassign(taskID, userID string) (models.TaskMembers, error)
+	Watch(taskID, userID string) (models.TaskMembers, error)
+	Unwatch(taskID, userID string) (models.TaskMembers, error)
+	// HandleEvent makes commenters watch the task and notifies the
+	// watchers of a change.
+	HandleEvent(event events.Event)
+}
+
+type taskMemberService struct {
+	repo     repository.TaskMemberRepository
+	tasks    repository.TaskRepository
+	users    repository.UserRepository
+	notifier Notifier
+}
+
+func NewTaskMemberService(repo repository.TaskMemberRepository, tasks repository.TaskRepository, users repository.UserRepository, notifier Notifier) TaskMemberService {
+	return &taskMemberService{
+		repo:     repo,
+		tasks:    tasks,
+		users:    users,
+		notifier: notifier,
+	}
+}
+
+func (s *taskMemberService) GetMembers(taskID string) (models.TaskMembers, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return models.TaskMembers{}, err
+	}
+	return s.members(taskID)
+}
+
+func (s *taskMemberService) Assign(taskID, userID, actorID string) (models.TaskMembers, error) {
+	task, err := s.add(taskID, userID, models.RoleAssignee, models.RoleWatcher)
+	if err != nil {
+		return models.TaskMembers{}, err
+	}
+	if userID != actorID {
+		err := s.notifier.Notify(Notification{
+			UserID:  userID,
+			Kind:    NotificationAssigned,
+			TaskID:  taskID,
+			ActorID: actorID,
+			Message: fmt.Sprintf("You were assigned to %q", task.Title),
+		})
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to send assignment notification")
+		}
+	}
+	return s.members(taskID)
+}
+
+func (s *taskMemberService) Unassign(taskID, userID string) (models.TaskMembers, error) {
+	return s.remove(taskID, userID, models.RoleAssignee)
+}
+
+func (s *taskMemberService) Watch(taskID, userID string) (models.TaskMembers, error) {
+	if _, err := s.add(taskID, userID, models.RoleWatcher); err != nil {
+		return models.TaskMembers{}, err
+	}
+	return s.members(taskID)
+}
+
+func (s *taskMemberService) Unwatch(taskID, userID string) (models.TaskMembers, error) {
+	return s.remove(taskID, userID, models.RoleWatcher)
+}
+
+// add gives an existing user roles on an existing task, which it returns.
+func (s *taskMemberService) add(taskID, userID string, roles ...string) (models.Task, error) {
+	task, err := s.tasks.FindByID(taskID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if _, err := s.users.FindByID(userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.Task{}, apperrors.NewValidationError("Unknown user", map[string]string{
+				"user_id": "no user with this ID",
+			})
+		}
+		return models.Task{}, err
+	}
+	for _, role := range roles {
+		err := s.repo.Add(models.TaskMember{TaskID: taskID, UserID: userID, Role: role, CreatedAt: time.Now()})
+		if err != nil {
+			return models.Task{}, err
+		}
+	}
+	return task, nil
+}
+
+func (s *taskMemberService) remove(taskID, userID, role string) (models.TaskMembers, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		return models.TaskMembers{}, err
+	}
+	if err := s.repo.Remove(taskID, userID, role); err != nil {
+		return models.TaskMembers{}, err
+	}
+	return s.members(taskID)
+}
+
+func (s *taskMemberService) members(taskID string) (models.TaskMembers, error) {
+	members, err := s.repo.FindByTask(taskID)
+	if err != nil {
+		return models.TaskMembers{}, err
+	}
+	result := models.TaskMembers{TaskID: taskID, Assignees: []string{}, Watchers: []string{}}
+	for _, member := range members {
+		if member.Role == models.RoleAssignee {
+			result.Assignees = append(result.Assignees, member.UserID)
+		} else {
+			result.Watchers = append(result.Watchers, member.UserID)
+		}
+	}
+	return result, nil
+}
+
+// HandleEvent notifies every watcher but the one who made the change.
+func (s *taskMemberService) HandleEvent(event events.Event) {
+	if event.Type == events.CommentCreated && event.ActorID != "" {
+		err := s.repo.Add(models.TaskMember{TaskID: event.TaskID, UserID: event.ActorID, Role: models.RoleWatcher, CreatedAt: time.Now()})
+		if err != nil {
+			log.Error().Err(err).Str("task_id", event.TaskID).Msg("Failed to make commenter watch task")
+		}
+	}
+
+	message, ok := activityMessages[event.Type]
+	if !ok {
+		return
+	}
+	members, err := s.repo.FindByTask(event.TaskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", event.TaskID).Msg("Failed to find task watchers")
+		return
+	}
+	task, err := s.tasks.FindByID(event.TaskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		task = lastRevision(s.tasks, event.TaskID)
+	} else if err != nil {
+		log.Error().Err(err).Str("task_id", event.TaskID).Msg("Failed to find watched task")
+		return
+	}
+	for _, member := range members {
+		if member.Role != models.RoleWatcher || member.UserID == event.ActorID {
+			continue
+		}
+		err := s.notifier.Notify(Notification{
+			UserID:  member.UserID,
+			Kind:    NotificationActivity,
+			TaskID:  event.TaskID,
+			ActorID: event.ActorID,
+			Message: fmt.Sprintf(message, task.Title),
+		})
+		if err != nil {
+			log.Error().Err(err).Str("user_id", member.UserID).Msg("Failed to notify watcher")
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"taskmanager/internal/events"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+)
+
+// fakeTaskMemberRepository keeps members in memory.
+type fakeTaskMemberRepository struct {
+	members []models.TaskMember
+}
+
+func (r *fakeTaskMemberRepository) FindByTask(taskID string) ([]models.TaskMember, error) {
+	var members []models.TaskMember
+	for _, member := range r.members {
+		if member.TaskID == taskID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeTaskMemberRepository) Add(member models.TaskMember) error {
+	for _, existing := range r.members {
+		if existing.TaskID == member.TaskID && existing.UserID == member.UserID && existing.Role == member.Role {
+			return nil
+		}
+	}
+	r.members = append(r.members, member)
+	return nil
+}
+
+func (r *fakeTaskMemberRepository) Remove(taskID, userID, role string) error {
+	return nil
+}
+
+// revisionTaskRepository also finds the last revision of deleted tasks.
+type revisionTaskRepository struct {
+	*fakeTaskRepository
+	deleted map[string]models.Task
+}
+
+func (r *revisionTaskRepository) FindRecentRevisions(id string, until time.Time, limit int) ([]models.TaskRevision, error) {
+	task, ok := r.deleted[id]
+	if !ok {
+		return nil, nil
+	}
+	revision, err := models.NewTaskRevision(task, models.RevisionDeleted, until)
+	return []models.TaskRevision{revision}, err
+}
+
+// fakeUserRepository finds users by ID.
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[string]models.User
+}
+
+func (r *fakeUserRepository) FindByID(id string) (models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+// recordingNotifier keeps the notifications sent.
+type recordingNotifier struct {
+	sent []Notification
+}
+
+func (n *recordingNotifier) Notify(notification Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func (n *recordingNotifier) messages() []string {
+	var messages []string
+	for _, notification := range n.sent {
+		messages = append(messages, notification.UserID+": "+notification.Message)
+	}
+	return messages
+}
+
+func TestTaskMemberHandleEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event events.Event
+		want  []string
+	}{
+		{
+			name:  "update",
+			event: events.Event{Type: events.TaskUpdated, TaskID: "task-1", ActorID: "bob"},
+			want:  []string{`alice: Task "Ship it" was updated`},
+		},
+		{
+			name:  "update without an actor",
+			event: events.Event{Type: events.TaskUpdated, TaskID: "task-1"},
+			want:  []string{`alice: Task "Ship it" was updated`, `bob: Task "Ship it" was updated`},
+		},
+		{
+			name:  "deletion",
+			event: events.Event{Type: events.TaskDeleted, TaskID: "task-2", ActorID: "alice"},
+			want:  []string{`bob: Task "Old plan" was deleted`},
+		},
+		{
+			name:  "comment",
+			event: events.Event{Type: events.CommentCreated, TaskID: "task-1", CommentID: "comment-1", ActorID: "dave"},
+			want:  []string{`alice: New comment on task "Ship it"`, `bob: New comment on task "Ship it"`},
+		},
+		{
+			name:  "creation",
+			event: events.Event{Type: events.TaskCreated, TaskID: "task-1", ActorID: "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := &fakeTaskMemberRepository{}
+			for _, taskID := range []string{"task-1", "task-2"} {
+				members.members = append(members.members,
+					models.TaskMember{TaskID: taskID, UserID: "alice", Role: models.RoleWatcher},
+					models.TaskMember{TaskID: taskID, UserID: "bob", Role: models.RoleWatcher},
+					models.TaskMember{TaskID: taskID, UserID: "carol", Role: models.RoleAssignee},
+				)
+			}
+			tasks := &revisionTaskRepository{
+				fakeTaskRepository: &fakeTaskRepository{tasks: map[string]models.Task{"task-1": {ID: "task-1", Title: "Ship it"}}},
+				deleted:            map[string]models.Task{"task-2": {ID: "task-2", Title: "Old plan"}},
+			}
+			notifier := &recordingNotifier{}
+			NewTaskMemberService(members, tasks, nil, notifier).HandleEvent(tt.event)
+
+			if got := notifier.messages(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("notifications %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskMemberCommenterWatches(t *testing.T) {
+	members := &fakeTaskMemberRepository{}
+	tasks := &fakeTaskRepository{tasks: map[string]models.Task{"task-1": {ID: "task-1", Title: "Ship it"}}}
+	s := NewTaskMemberService(members, tasks, nil, &recordingNotifier{})
+	s.HandleEvent(events.Event{Type: events.CommentCreated, TaskID: "task-1", CommentID: "comment-1", ActorID: "dave"})
+
+	got, err := s.GetMembers("task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got.Watchers) != "[dave]" {
+		t.Errorf("watchers %v, want [dave]", got.Watchers)
+	}
+}
+
+func TestTaskMemberAssign(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		actorID string
+		want    []string
+	}{
+		{name: "by someone else", userID: "alice", actorID: "bob", want: []string{`alice: You were assigned to "Ship it"`}},
+		{name: "by themselves", userID: "alice", actorID: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := &fakeTaskRepository{tasks: map[string]models.Task{"task-1": {ID: "task-1", Title: "Ship it"}}}
+			users := &fakeUserRepository{users: map[string]models.User{"alice": {ID: "alice"}, "bob": {ID: "bob"}}}
+			notifier := &recordingNotifier{}
+			members, err := NewTaskMemberService(&fakeTaskMemberRepository{}, tasks, users, notifier).Assign("task-1", tt.userID, tt.actorID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(members.Assignees) != "[alice]" || fmt.Sprint(members.Watchers) != "[alice]" {
+				t.Errorf("members %+v, want alice assigned and watching", members)
+			}
+			if got := notifier.messages(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("notifications %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
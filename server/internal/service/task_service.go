@@ -1,9 +1,17 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
 
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/events"
+	"taskmanager/internal/filter"
 	"taskmanager/internal/models"
+	"taskmanager/internal/rank"
+	"taskmanager/internal/recurrence"
 	"taskmanager/internal/repository"
 
 	"github.com/go-playground/validator/v10"
@@ -11,28 +19,104 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+var (
+	// ErrTaskCycle is returned when a task would become its own ancestor.
+	ErrTaskCycle = errors.New("a task cannot be nested under itself or one of its subtasks")
+	// ErrInvalidMove is returned when the neighbours of a move are not tasks
+	// of the target column in board order.
+	ErrInvalidMove = errors.New("after_id and before_id must be other tasks of the target column, in board order")
+)
+
 type TaskService interface {
-	GetAllTasks() ([]models.Task, error)
+	GetAllTasks(query models.TaskQuery) ([]models.Task, error)
+	// GetTaskPage returns at most limit of the tasks GetAllTasks returns,
+	// and how many there are in all. The page starts after a task when
+	// the list is in creation order, or else skips offset tasks.
+	GetTaskPage(query models.TaskQuery, after *models.TaskCursor, offset, limit int) ([]models.Task, int64, error)
+	CheckQuery(query models.TaskQuery) error
 	GetTaskByID(id string) (models.Task, error)
+	// GetTasksByIDs returns the tasks with the given IDs that exist, in no
+	// particular order.
+	GetTasksByIDs(ids []string) ([]models.Task, error)
+	// GetTasksByProjects returns the tasks of the given projects, oldest
+	// first.
+	GetTasksByProjects(projectIDs []string) ([]models.Task, error)
 	CreateTask(input models.CreateTaskInput) (models.Task, error)
-	UpdateTask(id string, input models.UpdateTaskInput) (models.Task, error)
-	DeleteTask(id string) error
+	CreateTaskTree(inputs []models.TaskTreeInput) ([]models.Task, error)
+	// UpdateTask, DeleteTask, MoveTask, RestoreTask and SetSprint take the
+	// user making the change, or "" for changes nobody asked for, so that
+	// the events of the change say who made it.
+	UpdateTask(id string, input models.UpdateTaskInput, actorID string) (models.Task, error)
+	DeleteTask(id, actorID string) error
+	MoveTask(id string, input models.MoveTaskInput, actorID string) (models.Task, error)
+	GetBoard(projectID string) (models.Board, error)
+	RestoreTask(id, actorID string) (models.Task, error)
+	PurgeTask(ctx context.Context, id string) error
+	GetAllTasksAsOf(asOf time.Time) ([]models.Task, error)
+	GetTaskByIDAsOf(id string, asOf time.Time) (models.Task, error)
+	// SetSprint moves a task into a sprint of its project, or back to the
+	// backlog when sprintID is nil.
+	SetSprint(id string, sprintID *string, actorID string) (models.Task, error)
+	// EachTask calls fn with each task a list query selects, in order,
+	// without loading them all at once.
+	EachTask(query models.TaskQuery, fn func(models.Task) error) error
+	// UpsertTasks creates a task for each input, or updates the live task
+	// with the same external ID with the fields the input sets. No two
+	// inputs may have the same external ID. Every input is validated and
+	// has a result. With atomic, nothing is saved when an
+	// input is invalid and the rest are saved in one transaction; with
+	// dryRun, nothing is saved at all. It reports whether anything was
+	// saved.
+	UpsertTasks(inputs []models.CreateTaskInput, atomic, dryRun bool) ([]UpsertResult, bool, error)
+}
+
+// Upsert outcomes
+const (
+	UpsertCreated = "created"
+	UpsertUpdated = "updated"
+	UpsertFailed  = "failed"
+)
+
+// UpsertResult is the outcome of one input of UpsertTasks. TaskID is empty
+// for tasks that were to be created but were not saved.
+type UpsertResult struct {
+	TaskID  string
+	Outcome string
+	Err     error
 }
 
+// exportBatchSize is how many tasks EachTask loads at a time
+const exportBatchSize = 200
+
 type taskService struct {
-	repo      repository.TaskRepository
-	validator *validator.Validate
+	repo         repository.TaskRepository
+	projects     repository.ProjectRepository
+	sprints      repository.SprintRepository
+	customFields CustomFieldService
+	attachments  AttachmentService
+	publisher    events.Publisher
+	validator    *validator.Validate
 }
 
-func NewTaskService(repo repository.TaskRepository) TaskService {
+func NewTaskService(repo repository.TaskRepository, projects repository.ProjectRepository, sprints repository.SprintRepository, customFields CustomFieldService, attachments AttachmentService, publisher events.Publisher) TaskService {
 	return &taskService{
-		repo:      repo,
-		validator: validator.New(),
+		repo:         repo,
+		projects:     projects,
+		sprints:      sprints,
+		customFields: customFields,
+		attachments:  attachments,
+		publisher:    publisher,
+		validator:    validator.New(),
 	}
 }
 
-func (s *taskService) GetAllTasks() ([]models.Task, error) {
-	tasks, err := s.repo.FindAll()
+func (s *taskService) GetAllTasks(query models.TaskQuery) ([]models.Task, error) {
+	filter, err := s.resolveQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.repo.FindAll(filter)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch all tasks from repository")
 		return nil, err
@@ -40,6 +124,57 @@ func (s *taskService) GetAllTasks() ([]models.Task, error) {
 	return tasks, nil
 }
 
+func (s *taskService) GetTaskPage(query models.TaskQuery, after *models.TaskCursor, offset, limit int) ([]models.Task, int64, error) {
+	filter, err := s.resolveQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if after != nil && !filter.InCreatedOrder() {
+		return nil, 0, apperrors.NewValidationError("Invalid cursor", map[string]string{
+			"after": "only lists in creation order start after a task",
+		})
+	}
+
+	tasks, total, err := s.repo.FindPage(filter, after, offset, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch page of tasks from repository")
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// CheckQuery reports whether a list query is valid without running it.
+func (s *taskService) CheckQuery(query models.TaskQuery) error {
+	_, err := s.resolveQuery(query)
+	return err
+}
+
+func (s *taskService) resolveQuery(query models.TaskQuery) (repository.TaskFilter, error) {
+	filter, err := s.customFields.ResolveFilter(query)
+	if err != nil {
+		return filter, err
+	}
+	location, err := time.LoadLocation(query.TimeZone)
+	if err != nil {
+		return filter, apperrors.NewValidationError("Invalid time zone", map[string]string{
+			"tz": err.Error(),
+		})
+	}
+	switch query.Assignee {
+	case "me":
+		if query.UserID == "" {
+			return filter, apperrors.NewValidationError("Invalid assignee", map[string]string{
+				"assignee": "me needs a signed-in user",
+			})
+		}
+		filter.Assignee = query.UserID
+	default:
+		filter.Assignee = query.Assignee
+	}
+	filter.Where, err = compileFilter(query.Filter, time.Now().In(location), query.UserID)
+	return filter, err
+}
+
 func (s *taskService) GetTaskByID(id string) (models.Task, error) {
 	task, err := s.repo.FindByID(id)
 	if err != nil {
@@ -49,38 +184,161 @@ func (s *taskService) GetTaskByID(id string) (models.Task, error) {
 	return task, nil
 }
 
+func (s *taskService) GetTasksByIDs(ids []string) ([]models.Task, error) {
+	tasks, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch tasks by ID from repository")
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *taskService) GetTasksByProjects(projectIDs []string) ([]models.Task, error) {
+	if len(projectIDs) == 0 {
+		return nil, nil
+	}
+	tasks, err := s.repo.FindAll(repository.TaskFilter{ProjectIDs: projectIDs})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch tasks of projects from repository")
+		return nil, err
+	}
+	return tasks, nil
+}
+
 func (s *taskService) CreateTask(input models.CreateTaskInput) (models.Task, error) {
+	task, err := s.newTask(input)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	createdTask, err := s.repo.Create(task)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create task in repository")
+		return models.Task{}, err
+	}
+
+	s.publish(events.TaskCreated, createdTask, "")
+	return createdTask, nil
+}
+
+// CreateTaskTree creates tasks with their checklists and subtasks in one
+// transaction. Nothing is created if any task in the tree is invalid. The
+// created tasks are returned parents first.
+func (s *taskService) CreateTaskTree(inputs []models.TaskTreeInput) ([]models.Task, error) {
+	var tasks []models.Task
+	var add func(nodes []models.TaskTreeInput, parentID *string) error
+	add = func(nodes []models.TaskTreeInput, parentID *string) error {
+		for _, node := range nodes {
+			input := node.Task
+			input.ParentID = nil
+			task, err := s.newTask(input)
+			if err != nil {
+				return err
+			}
+			task.ParentID = parentID
+			for i, text := range node.Checklist {
+				task.Checklist = append(task.Checklist, models.ChecklistItem{
+					ID:        uuid.New().String(),
+					TaskID:    task.ID,
+					Text:      text,
+					Done:      i < len(node.ChecklistDone) && node.ChecklistDone[i],
+					Position:  i,
+					CreatedAt: task.CreatedAt,
+					UpdatedAt: task.CreatedAt,
+				})
+			}
+			tasks = append(tasks, task)
+			id := task.ID
+			if err := add(node.Children, &id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(inputs, nil); err != nil {
+		return nil, err
+	}
+
+	createdTasks, err := s.repo.CreateAll(tasks)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create task tree in repository")
+		return nil, err
+	}
+	for _, task := range createdTasks {
+		s.publish(events.TaskCreated, task, "")
+	}
+	return createdTasks, nil
+}
+
+// newTask validates a CreateTaskInput and builds the task it describes.
+func (s *taskService) newTask(input models.CreateTaskInput) (models.Task, error) {
 	if err := s.validator.Struct(input); err != nil {
 		log.Error().Err(err).Msg("Validation failed for CreateTaskInput")
 		return models.Task{}, err
 	}
 
-	dueDate, err := input.ValidateDueDate()
+	dueDate, dueTimeZone, err := input.ValidateDueDate()
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to parse due date during task creation")
+		return models.Task{}, invalidDueDate(err)
+	}
+
+	repeat, err := normalizeRecurrence(input.Recurrence)
+	if err != nil {
 		return models.Task{}, err
 	}
 
-	task := models.Task{
-		ID:          uuid.New().String(),
-		Title:       input.Title,
-		Description: input.Description,
-		DueDate:     dueDate,
-		Completed:   input.Completed,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	if input.ProjectID != nil {
+		if _, err := s.projects.FindByID(*input.ProjectID); err != nil {
+			log.Error().Err(err).Str("project_id", *input.ProjectID).Msg("Failed to find project for new task")
+			return models.Task{}, err
+		}
 	}
 
-	createdTask, err := s.repo.Create(task)
+	if input.ParentID != nil {
+		if _, err := s.repo.FindByID(*input.ParentID); err != nil {
+			log.Error().Err(err).Str("parent_id", *input.ParentID).Msg("Failed to find parent for new task")
+			return models.Task{}, err
+		}
+	}
+
+	if err := s.checkSprint(input.SprintID, input.ProjectID); err != nil {
+		return models.Task{}, err
+	}
+
+	values, err := s.customFields.ResolveValues(input.ProjectID, nil, input.CustomFields, true)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create task in repository")
 		return models.Task{}, err
 	}
 
-	return createdTask, nil
+	status := input.Status
+	if status == "" {
+		status = statusForCompleted(input.Completed)
+	}
+
+	return models.Task{
+		ID:                uuid.New().String(),
+		Title:             input.Title,
+		Description:       input.Description,
+		DueDate:           dueDate,
+		DueTimeZone:       dueTimeZone,
+		Recurrence:        repeat,
+		Estimate:          input.Estimate,
+		SprintID:          input.SprintID,
+		Completed:         status == models.StatusDone,
+		Status:            status,
+		Priority:          priorityOrDefault(input.Priority),
+		ProjectID:         input.ProjectID,
+		ParentID:          input.ParentID,
+		ExternalID:        input.ExternalID,
+		Tags:              normalizeTags(input.Tags),
+		CustomFieldValues: values,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}, nil
 }
 
-func (s *taskService) UpdateTask(id string, input models.UpdateTaskInput) (models.Task, error) {
+func (s *taskService) UpdateTask(id string, input models.UpdateTaskInput, actorID string) (models.Task, error) {
 	if err := s.validator.Struct(input); err != nil {
 		log.Error().Err(err).Msg("Validation failed for UpdateTaskInput")
 		return models.Task{}, err
@@ -92,35 +350,601 @@ func (s *taskService) UpdateTask(id string, input models.UpdateTaskInput) (model
 		return models.Task{}, err
 	}
 
+	task, err = s.applyUpdate(task, input)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	updatedTask, err := s.repo.Update(task)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update task in repository")
+		return models.Task{}, err
+	}
+
+	s.publish(events.TaskUpdated, updatedTask, actorID)
+	return updatedTask, nil
+}
+
+// applyUpdate returns task changed as a valid UpdateTaskInput describes.
+func (s *taskService) applyUpdate(task models.Task, input models.UpdateTaskInput) (models.Task, error) {
+	id := task.ID
+
 	// Parse due date if provided
 	if input.DueDate != "" {
-		dueDate, err := input.ValidateDueDate()
+		dueDate, dueTimeZone, err := input.ValidateDueDate()
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to parse due date in update")
+			return models.Task{}, invalidDueDate(err)
+		}
+		task.DueDate, task.DueTimeZone = dueDate, dueTimeZone
+	}
+
+	// Remember the board column to notice moves out of it
+	projectID, status := task.ProjectID, task.Status
+
+	// Move between projects if requested
+	if input.ProjectID != nil {
+		if *input.ProjectID == "" {
+			task.ProjectID = nil
+		} else {
+			if _, err := s.projects.FindByID(*input.ProjectID); err != nil {
+				log.Error().Err(err).Str("project_id", *input.ProjectID).Msg("Failed to find project for task update")
+				return models.Task{}, err
+			}
+			task.ProjectID = input.ProjectID
+		}
+	}
+
+	// Move under another parent if requested
+	if input.ParentID != nil {
+		if *input.ParentID == "" {
+			task.ParentID = nil
+		} else {
+			if err := s.checkParent(id, *input.ParentID); err != nil {
+				return models.Task{}, err
+			}
+			task.ParentID = input.ParentID
+		}
+	}
+
+	// Join or leave a sprint, and leave it when moving to another project
+	sprintID := task.SprintID
+	switch {
+	case input.SprintID != nil && *input.SprintID == "":
+		task.SprintID = nil
+	case input.SprintID != nil:
+		task.SprintID = input.SprintID
+	case !sameID(task.ProjectID, projectID):
+		task.SprintID = nil
+	}
+	if !sameID(task.SprintID, sprintID) || !sameID(task.ProjectID, projectID) {
+		if err := s.checkSprint(task.SprintID, task.ProjectID); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	if input.Tags != nil {
+		task.Tags = normalizeTags(input.Tags)
+	}
+
+	if input.Estimate != nil {
+		task.Estimate = *input.Estimate
+	}
+
+	if input.Recurrence != nil {
+		repeat, err := normalizeRecurrence(*input.Recurrence)
+		if err != nil {
 			return models.Task{}, err
 		}
-		task.DueDate = dueDate
+		task.Recurrence = repeat
 	}
 
+	values, err := s.customFields.ResolveValues(task.ProjectID, task.CustomFieldValues, input.CustomFields, false)
+	if err != nil {
+		return models.Task{}, err
+	}
+	task.CustomFieldValues = values
+
 	// Update fields
 	task.Title = input.Title
 	task.Description = input.Description
-	task.Completed = input.Completed
+	switch {
+	case input.Status != "":
+		task.Status = input.Status
+	case input.Completed != task.Completed:
+		task.Status = statusForCompleted(input.Completed)
+	}
+	task.Completed = task.Status == models.StatusDone
+	if input.Priority != "" {
+		task.Priority = input.Priority
+	}
 	task.UpdatedAt = time.Now()
 
-	updatedTask, err := s.repo.Update(task)
+	// A task changing column goes to the end of the new one
+	if task.Status != status || !sameID(task.ProjectID, projectID) {
+		task.Position = ""
+	}
+	return task, nil
+}
+
+func (s *taskService) EachTask(query models.TaskQuery, fn func(models.Task) error) error {
+	filter, err := s.resolveQuery(query)
 	if err != nil {
-		log.Error().Err(err).Str("id", id).Msg("Failed to update task in repository")
-		return models.Task{}, err
+		return err
 	}
+	return s.repo.Each(filter, exportBatchSize, fn)
+}
 
-	return updatedTask, nil
+func (s *taskService) UpsertTasks(inputs []models.CreateTaskInput, atomic, dryRun bool) ([]UpsertResult, bool, error) {
+	var externalIDs []string
+	for _, input := range inputs {
+		if input.ExternalID != nil && *input.ExternalID != "" {
+			externalIDs = append(externalIDs, *input.ExternalID)
+		}
+	}
+	existing, err := s.repo.FindByExternalIDs(externalIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	results := make([]UpsertResult, len(inputs))
+	tasks := make([]models.Task, len(inputs))
+	failed := false
+	for i, input := range inputs {
+		if input.ExternalID != nil && *input.ExternalID == "" {
+			input.ExternalID = nil
+		}
+		var current models.Task
+		var exists bool
+		if input.ExternalID != nil {
+			current, exists = existing[*input.ExternalID]
+		}
+		if exists {
+			tasks[i], err = s.upsertUpdate(current, input)
+			results[i] = UpsertResult{TaskID: current.ID, Outcome: UpsertUpdated}
+		} else {
+			tasks[i], err = s.newTask(input)
+			results[i] = UpsertResult{Outcome: UpsertCreated}
+		}
+		if err != nil {
+			results[i] = UpsertResult{Outcome: UpsertFailed, Err: err}
+			failed = true
+		}
+	}
+	if dryRun || (atomic && failed) {
+		return results, false, nil
+	}
+
+	if atomic {
+		var creates, updates []models.Task
+		for i, result := range results {
+			if result.Outcome == UpsertCreated {
+				creates = append(creates, tasks[i])
+			} else {
+				updates = append(updates, tasks[i])
+			}
+		}
+		created, _, err := s.repo.SaveAll(creates, updates)
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range results {
+			if results[i].Outcome == UpsertCreated {
+				results[i].TaskID = created[0].ID
+				s.publish(events.TaskCreated, created[0], "")
+				created = created[1:]
+			} else {
+				s.publish(events.TaskUpdated, tasks[i], "")
+			}
+		}
+		return results, true, nil
+	}
+
+	saved := false
+	for i, result := range results {
+		var task models.Task
+		switch result.Outcome {
+		case UpsertCreated:
+			task, err = s.repo.Create(tasks[i])
+		case UpsertUpdated:
+			task, err = s.repo.Update(tasks[i])
+		default:
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Int("row", i).Msg("Failed to save imported task")
+			results[i] = UpsertResult{Outcome: UpsertFailed, Err: err}
+			continue
+		}
+		saved = true
+		results[i].TaskID = task.ID
+		if result.Outcome == UpsertCreated {
+			s.publish(events.TaskCreated, task, "")
+		} else {
+			s.publish(events.TaskUpdated, task, "")
+		}
+	}
+	return results, saved, nil
+}
+
+// upsertUpdate validates the update an upsert input makes to the task with
+// its external ID and returns the changed task. Fields the input leaves
+// empty keep their value.
+func (s *taskService) upsertUpdate(task models.Task, input models.CreateTaskInput) (models.Task, error) {
+	update := models.UpdateTaskInput{
+		Title:        orDefault(input.Title, task.Title),
+		Description:  orDefault(input.Description, task.Description),
+		DueDate:      input.DueDate,
+		DueTimeZone:  input.DueTimeZone,
+		Completed:    task.Completed,
+		Status:       input.Status,
+		Priority:     input.Priority,
+		SprintID:     input.SprintID,
+		ProjectID:    input.ProjectID,
+		ParentID:     input.ParentID,
+		Tags:         input.Tags,
+		CustomFields: input.CustomFields,
+	}
+	if input.Completed && update.Status == "" {
+		update.Status = models.StatusDone
+	}
+	if input.Recurrence != "" {
+		update.Recurrence = &input.Recurrence
+	}
+	if input.Estimate != 0 {
+		update.Estimate = &input.Estimate
+	}
+	if err := s.validator.Struct(update); err != nil {
+		log.Error().Err(err).Msg("Validation failed for upserted UpdateTaskInput")
+		return models.Task{}, err
+	}
+	return s.applyUpdate(task, update)
 }
 
-func (s *taskService) DeleteTask(id string) error {
+func (s *taskService) DeleteTask(id, actorID string) error {
 	if err := s.repo.Delete(id); err != nil {
 		log.Error().Err(err).Str("id", id).Msg("Failed to delete task from repository")
 		return err
 	}
+	s.publish(events.TaskDeleted, lastRevision(s.repo, id), actorID)
+	return nil
+}
+
+// MoveTask changes the column and position of a task on its board. Only the
+// moved task is rewritten, unless its column has to be rebalanced because
+// positions tie or have grown too long.
+func (s *taskService) MoveTask(id string, input models.MoveTaskInput, actorID string) (models.Task, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for MoveTaskInput")
+		return models.Task{}, err
+	}
+
+	task, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find task to move")
+		return models.Task{}, err
+	}
+	status := input.Status
+	if status == "" {
+		status = task.Status
+	}
+
+	var position string
+	for attempt := 0; ; attempt++ {
+		column, err := s.repo.FindColumn(task.ProjectID, status)
+		if err != nil {
+			return models.Task{}, err
+		}
+		lower, upper, err := neighbours(column, id, input)
+		if err == nil {
+			position, err = rank.Between(lower, upper)
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, rank.ErrInvalidRange) || attempt > 0 {
+			log.Error().Err(err).Str("id", id).Msg("Failed to position task after rebalancing")
+			return models.Task{}, err
+		}
+		if err := s.repo.Rebalance(task.ProjectID, status); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	task.Status = status
+	task.Completed = status == models.StatusDone
+	task.Position = position
+	task.UpdatedAt = time.Now()
+	// Keep custom field values as they are
+	task.CustomFieldValues = nil
+
+	movedTask, err := s.repo.Update(task)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to move task in repository")
+		return models.Task{}, err
+	}
+	s.publish(events.TaskUpdated, movedTask, actorID)
+
+	if len(position) > rank.MaxLength {
+		if err := s.repo.Rebalance(task.ProjectID, status); err != nil {
+			return models.Task{}, err
+		}
+		return s.repo.FindByID(id)
+	}
+	return movedTask, nil
+}
+
+// GetBoard returns the tasks of a project grouped into one column per status.
+func (s *taskService) GetBoard(projectID string) (models.Board, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find project for board")
+		return models.Board{}, err
+	}
+
+	board := models.Board{ProjectID: projectID, Columns: make([]models.BoardColumn, 0, len(models.Statuses))}
+	for _, status := range models.Statuses {
+		tasks, err := s.repo.FindColumn(&projectID, status)
+		if err != nil {
+			log.Error().Err(err).Str("project_id", projectID).Msg("Failed to fetch board column from repository")
+			return models.Board{}, err
+		}
+		board.Columns = append(board.Columns, models.BoardColumn{Status: status, Tasks: tasks})
+	}
+	return board, nil
+}
+
+func (s *taskService) RestoreTask(id, actorID string) (models.Task, error) {
+	task, err := s.repo.Restore(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to restore task in repository")
+		return models.Task{}, err
+	}
+	s.publish(events.TaskRestored, task, actorID)
+	return task, nil
+}
+
+// PurgeTask permanently deletes a task so it can no longer be restored,
+// releasing its attachments first.
+func (s *taskService) PurgeTask(ctx context.Context, id string) error {
+	if err := s.attachments.PurgeTaskAttachments(ctx, id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to purge task attachments")
+		return err
+	}
+	task := lastRevision(s.repo, id)
+	if err := s.repo.Purge(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to purge task from repository")
+		return err
+	}
+	s.publish(events.TaskPurged, task, "")
 	return nil
 }
+
+func (s *taskService) GetAllTasksAsOf(asOf time.Time) ([]models.Task, error) {
+	tasks, err := s.repo.FindAllAsOf(asOf)
+	if err != nil {
+		log.Error().Err(err).Time("as_of", asOf).Msg("Failed to fetch task history from repository")
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *taskService) GetTaskByIDAsOf(id string, asOf time.Time) (models.Task, error) {
+	task, err := s.repo.FindByIDAsOf(id, asOf)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Time("as_of", asOf).Msg("Failed to fetch task history from repository")
+		return models.Task{}, err
+	}
+	return task, nil
+}
+
+func (s *taskService) publish(eventType string, task models.Task, actorID string) {
+	event := events.Event{Type: eventType, TaskID: task.ID, ActorID: actorID}
+	if task.ProjectID != nil {
+		event.ProjectID = *task.ProjectID
+	}
+	s.publisher.Publish(event)
+}
+
+// lastRevision returns a task as its latest revision recorded it, which
+// outlives the task's deletion, for the events of tasks that are gone.
+func lastRevision(repo repository.TaskRepository, id string) models.Task {
+	revisions, err := repo.FindRecentRevisions(id, time.Now(), 1)
+	if err == nil && len(revisions) > 0 {
+		if task, err := revisions[0].Task(); err == nil {
+			return task
+		}
+	}
+	return models.Task{ID: id}
+}
+
+func (s *taskService) SetSprint(id string, sprintID *string, actorID string) (models.Task, error) {
+	task, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find task to plan")
+		return models.Task{}, err
+	}
+	if err := s.checkSprint(sprintID, task.ProjectID); err != nil {
+		return models.Task{}, err
+	}
+
+	task.SprintID = sprintID
+	task.UpdatedAt = time.Now()
+	updatedTask, err := s.repo.Update(task)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update task sprint")
+		return models.Task{}, err
+	}
+	s.publish(events.TaskUpdated, updatedTask, actorID)
+	return updatedTask, nil
+}
+
+// checkSprint makes sure a task of the project may join sprintID: the
+// sprint exists, belongs to the project and is not closed.
+func (s *taskService) checkSprint(sprintID, projectID *string) error {
+	if sprintID == nil {
+		return nil
+	}
+	sprint, err := s.sprints.FindByID(*sprintID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return invalidSprint("sprint not found")
+	}
+	if err != nil {
+		return err
+	}
+	if projectID == nil || sprint.ProjectID != *projectID {
+		return invalidSprint("sprint belongs to another project")
+	}
+	if sprint.Status == models.SprintClosed {
+		return invalidSprint("sprint is closed")
+	}
+	return nil
+}
+
+func invalidSprint(reason string) error {
+	return apperrors.NewValidationError("Invalid sprint", map[string]string{
+		"sprint_id": reason,
+	})
+}
+
+// checkParent makes sure parentID exists and is not id or one of its
+// descendants.
+func (s *taskService) checkParent(id, parentID string) error {
+	for ancestor := &parentID; ancestor != nil; {
+		if *ancestor == id {
+			return ErrTaskCycle
+		}
+		parent, err := s.repo.FindByID(*ancestor)
+		if err != nil {
+			log.Error().Err(err).Str("parent_id", *ancestor).Msg("Failed to find parent task")
+			return err
+		}
+		ancestor = parent.ParentID
+	}
+	return nil
+}
+
+// normalizeTags trims tags and drops duplicates, keeping the first spelling.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// normalizeRecurrence checks a recurrence rule and returns it in canonical
+// form. An empty rule means the task does not repeat.
+func normalizeRecurrence(rule string) (string, error) {
+	if strings.TrimSpace(rule) == "" {
+		return "", nil
+	}
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", apperrors.NewValidationError("Invalid recurrence", map[string]string{
+			"recurrence": err.Error(),
+		})
+	}
+	return parsed.String(), nil
+}
+
+// neighbours returns the positions a moved task has to fit between. The
+// column is in board order and may include the moved task itself. Tasks
+// without a position predate manual ordering and are reported as a range
+// that cannot be split, so that the column gets rebalanced.
+func neighbours(column []models.Task, id string, input models.MoveTaskInput) (string, string, error) {
+	others := make([]models.Task, 0, len(column))
+	for _, task := range column {
+		if task.ID != id {
+			others = append(others, task)
+		}
+	}
+	index := func(neighbourID string) int {
+		for i, task := range others {
+			if task.ID == neighbourID {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// The moved task goes between others[lower] and others[upper]
+	lower, upper := len(others)-1, len(others)
+	switch {
+	case input.AfterID != "" && input.BeforeID != "":
+		lower, upper = index(input.AfterID), index(input.BeforeID)
+		if lower < 0 || upper <= lower {
+			return "", "", ErrInvalidMove
+		}
+	case input.AfterID != "":
+		if lower = index(input.AfterID); lower < 0 {
+			return "", "", ErrInvalidMove
+		}
+		upper = lower + 1
+	case input.BeforeID != "":
+		if upper = index(input.BeforeID); upper < 0 {
+			return "", "", ErrInvalidMove
+		}
+		lower = upper - 1
+	}
+
+	var after, before string
+	if lower >= 0 {
+		after = others[lower].Position
+	}
+	if upper < len(others) {
+		before = others[upper].Position
+		if before == "" {
+			return "", "", rank.ErrInvalidRange
+		}
+	}
+	if lower >= 0 && after == "" {
+		return "", "", rank.ErrInvalidRange
+	}
+	return after, before, nil
+}
+
+func statusForCompleted(completed bool) string {
+	if completed {
+		return models.StatusDone
+	}
+	return models.StatusTodo
+}
+
+func priorityOrDefault(priority string) string {
+	if priority == "" {
+		return models.PriorityNone
+	}
+	return priority
+}
+
+// sameID reports whether two optional IDs are equal.
+func sameID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// invalidDueDate reports a due date or due time zone that could not be
+// read.
+func invalidDueDate(err error) error {
+	return apperrors.NewValidationError("Invalid due date", map[string]string{
+		"due_date": err.Error(),
+	})
+}
+
+// compileFilter parses and compiles a filter language expression.
+func compileFilter(src string, now time.Time, userID string) (*repository.Condition, error) {
+	expr, err := filter.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return repository.CompileFilter(expr, now, userID)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"time"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type TemplateService interface {
+	GetAllTemplates() ([]models.TaskTemplate, error)
+	GetTemplateByID(id string) (models.TaskTemplate, error)
+	CreateTemplate(input models.TemplateInput) (models.TaskTemplate, error)
+	UpdateTemplate(id string, input models.TemplateInput) (models.TaskTemplate, error)
+	DeleteTemplate(id string) error
+	InstantiateTemplate(id string, input models.InstantiateTemplateInput) ([]models.Task, error)
+}
+
+type templateService struct {
+	repo      repository.TemplateRepository
+	tasks     TaskService
+	calendars CalendarService
+	validator *validator.Validate
+}
+
+func NewTemplateService(repo repository.TemplateRepository, tasks TaskService, calendars CalendarService) TemplateService {
+	return &templateService{
+		repo:      repo,
+		tasks:     tasks,
+		calendars: calendars,
+		validator: validator.New(),
+	}
+}
+
+func (s *templateService) GetAllTemplates() ([]models.TaskTemplate, error) {
+	templates, err := s.repo.FindAll()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all templates from repository")
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *templateService) GetTemplateByID(id string) (models.TaskTemplate, error) {
+	template, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch template from repository")
+		return models.TaskTemplate{}, err
+	}
+	return template, nil
+}
+
+func (s *templateService) CreateTemplate(input models.TemplateInput) (models.TaskTemplate, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TemplateInput")
+		return models.TaskTemplate{}, err
+	}
+
+	template := models.TaskTemplate{
+		ID:          uuid.New().String(),
+		Name:        input.Name,
+		Description: input.Description,
+		Tasks:       input.Tasks,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	createdTemplate, err := s.repo.Create(template)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create template in repository")
+		return models.TaskTemplate{}, err
+	}
+	createdTemplate.Variables = models.TemplateVariables(createdTemplate.Tasks)
+	return createdTemplate, nil
+}
+
+func (s *templateService) UpdateTemplate(id string, input models.TemplateInput) (models.TaskTemplate, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TemplateInput")
+		return models.TaskTemplate{}, err
+	}
+
+	template, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find template for update")
+		return models.TaskTemplate{}, err
+	}
+
+	template.Name = input.Name
+	template.Description = input.Description
+	template.Tasks = input.Tasks
+	template.UpdatedAt = time.Now()
+
+	updatedTemplate, err := s.repo.Update(template)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update template in repository")
+		return models.TaskTemplate{}, err
+	}
+	updatedTemplate.Variables = models.TemplateVariables(updatedTemplate.Tasks)
+	return updatedTemplate, nil
+}
+
+func (s *templateService) DeleteTemplate(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete template from repository")
+		return err
+	}
+	return nil
+}
+
+// InstantiateTemplate creates the template's task tree in one go. Every
+// placeholder must have a value, and due dates are offset from the anchor.
+func (s *templateService) InstantiateTemplate(id string, input models.InstantiateTemplateInput) ([]models.Task, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for InstantiateTemplateInput")
+		return nil, err
+	}
+	anchor, err := time.Parse("2006-01-02", input.AnchorDate)
+	if err != nil {
+		return nil, apperrors.NewValidationError("Invalid anchor date", map[string]string{
+			"anchor_date": "must be a date in YYYY-MM-DD format",
+		})
+	}
+
+	template, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find template to instantiate")
+		return nil, err
+	}
+
+	cal, err := s.calendars.ForProject(input.ProjectID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find business calendar for template")
+		return nil, err
+	}
+
+	missing := make(map[string]string)
+	expand := func(text string) string {
+		expanded, names := models.ExpandTemplate(text, input.Variables)
+		for _, name := range names {
+			missing["variables."+name] = "is required"
+		}
+		return expanded
+	}
+
+	var build func(tasks []models.TemplateTask) []models.TaskTreeInput
+	build = func(tasks []models.TemplateTask) []models.TaskTreeInput {
+		nodes := make([]models.TaskTreeInput, 0, len(tasks))
+		for _, task := range tasks {
+			node := models.TaskTreeInput{
+				Task: models.CreateTaskInput{
+					Title:       expand(task.Title),
+					Description: expand(task.Description),
+					ProjectID:   input.ProjectID,
+					Tags:        task.Tags,
+				},
+				Children: build(task.Children),
+			}
+			if task.DueOffsetDays != nil {
+				due := anchor.AddDate(0, 0, *task.DueOffsetDays)
+				if task.BusinessDays {
+					due = cal.AddBusinessDays(cal.Date(anchor.Date()), *task.DueOffsetDays)
+				}
+				node.Task.DueDate = due.Format("2006-01-02")
+			}
+			for _, item := range task.Checklist {
+				node.Checklist = append(node.Checklist, expand(item))
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	nodes := build(template.Tasks)
+
+	if len(missing) > 0 {
+		return nil, apperrors.NewValidationError("Missing template variables", missing)
+	}
+
+	tasks, err := s.tasks.CreateTaskTree(nodes)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to create tasks from template")
+		return nil, err
+	}
+	return tasks, nil
+}
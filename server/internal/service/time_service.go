@@ -0,0 +1,341 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"taskmanager/internal/clock"
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrNoRunningTimer is returned when stopping a timer that is not
+	// running
+	ErrNoRunningTimer = errors.New("no timer is running on this task")
+	// ErrTimeEntryForbidden is returned when a user changes another user's
+	// time entry
+	ErrTimeEntryForbidden = errors.New("only the owner can modify this time entry")
+)
+
+type TimeService interface {
+	// StartTimer starts a timer on a task, stopping the user's running
+	// timer first.
+	StartTimer(userID, taskID string, input models.TimerInput) (models.TimeEntry, error)
+	StopTimer(userID, taskID string) (models.TimeEntry, error)
+	// RunningTimer returns the user's running timer, or ErrNotFound.
+	RunningTimer(userID string) (models.TimeEntry, error)
+	AddEntry(userID, taskID string, input models.TimeEntryInput) (models.TimeEntry, error)
+	UpdateEntry(userID, id string, input models.UpdateTimeEntryInput) (models.TimeEntry, error)
+	DeleteEntry(userID, id string) error
+	// TaskTime returns the entries of a task and its time including that
+	// of its subtasks.
+	TaskTime(taskID string) (models.TaskTime, error)
+	ProjectTime(projectID string) (models.ProjectTime, error)
+	Timesheet(query models.TimesheetQuery) (models.Timesheet, error)
+}
+
+type timeService struct {
+	repo      repository.TimeEntryRepository
+	tasks     repository.TaskRepository
+	projects  repository.ProjectRepository
+	clock     clock.Clock
+	validator *validator.Validate
+}
+
+func NewTimeService(repo repository.TimeEntryRepository, tasks repository.TaskRepository, projects repository.ProjectRepository, clock clock.Clock) TimeService {
+	return &timeService{
+		repo:      repo,
+		tasks:     tasks,
+		projects:  projects,
+		clock:     clock,
+		validator: validator.New(),
+	}
+}
+
+func (s *timeService) StartTimer(userID, taskID string, input models.TimerInput) (models.TimeEntry, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TimerInput")
+		return models.TimeEntry{}, err
+	}
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find task for timer")
+		return models.TimeEntry{}, err
+	}
+
+	now := s.clock.Now()
+	entry, err := s.repo.Start(models.TimeEntry{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: now,
+		Note:      input.Note,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	entry.Elapse(now)
+	return entry, nil
+}
+
+func (s *timeService) StopTimer(userID, taskID string) (models.TimeEntry, error) {
+	entry, err := s.repo.FindRunning(userID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && entry.TaskID != taskID) {
+		return models.TimeEntry{}, ErrNoRunningTimer
+	}
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to find running timer")
+		return models.TimeEntry{}, err
+	}
+
+	now := s.clock.Now()
+	entry.Elapse(now)
+	entry.EndedAt = &now
+	entry.Running = false
+	entry.UpdatedAt = now
+	return s.repo.Update(entry)
+}
+
+func (s *timeService) RunningTimer(userID string) (models.TimeEntry, error) {
+	entry, err := s.repo.FindRunning(userID)
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	entry.Elapse(s.clock.Now())
+	return entry, nil
+}
+
+func (s *timeService) AddEntry(userID, taskID string, input models.TimeEntryInput) (models.TimeEntry, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TimeEntryInput")
+		return models.TimeEntry{}, err
+	}
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find task for time entry")
+		return models.TimeEntry{}, err
+	}
+
+	endedAt := input.StartedAt.Add(time.Duration(input.Minutes) * time.Minute)
+	return s.repo.Create(models.TimeEntry{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: input.StartedAt,
+		EndedAt:   &endedAt,
+		Seconds:   int64(input.Minutes) * 60,
+		Note:      input.Note,
+		Manual:    true,
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
+	})
+}
+
+func (s *timeService) UpdateEntry(userID, id string, input models.UpdateTimeEntryInput) (models.TimeEntry, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateTimeEntryInput")
+		return models.TimeEntry{}, err
+	}
+	entry, err := s.ownEntry(userID, id)
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	if entry.EndedAt == nil && input.Minutes != nil {
+		return models.TimeEntry{}, apperrors.NewValidationError("Timer is running", map[string]string{
+			"minutes": "cannot be set while the timer is running",
+		})
+	}
+
+	if input.StartedAt != nil {
+		entry.StartedAt = *input.StartedAt
+	}
+	if input.Minutes != nil {
+		entry.Seconds = int64(*input.Minutes) * 60
+	}
+	if input.Note != nil {
+		entry.Note = *input.Note
+	}
+	if entry.EndedAt != nil {
+		endedAt := entry.StartedAt.Add(time.Duration(entry.Seconds) * time.Second)
+		entry.EndedAt = &endedAt
+	}
+	entry.UpdatedAt = s.clock.Now()
+
+	updatedEntry, err := s.repo.Update(entry)
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	updatedEntry.Elapse(s.clock.Now())
+	return updatedEntry, nil
+}
+
+func (s *timeService) DeleteEntry(userID, id string) error {
+	if _, err := s.ownEntry(userID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// ownEntry loads a time entry the user may change.
+func (s *timeService) ownEntry(userID, id string) (models.TimeEntry, error) {
+	entry, err := s.repo.FindByID(id)
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	if entry.UserID != userID {
+		return models.TimeEntry{}, ErrTimeEntryForbidden
+	}
+	return entry, nil
+}
+
+func (s *timeService) TaskTime(taskID string) (models.TaskTime, error) {
+	if _, err := s.tasks.FindByID(taskID); err != nil {
+		log.Error().Err(err).Str("task_id", taskID).Msg("Failed to find task for time report")
+		return models.TaskTime{}, err
+	}
+
+	// Collect the subtree level by level, guarding against cycles
+	ids := []string{taskID}
+	seen := map[string]bool{taskID: true}
+	for level := []string{taskID}; len(level) > 0; {
+		children, err := s.tasks.FindChildIDs(level)
+		if err != nil {
+			return models.TaskTime{}, err
+		}
+		level = level[:0]
+		for _, id := range children {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+				level = append(level, id)
+			}
+		}
+	}
+
+	entries, err := s.repo.FindByTasks(ids)
+	if err != nil {
+		return models.TaskTime{}, err
+	}
+	now := s.clock.Now()
+	result := models.TaskTime{TaskID: taskID, Entries: []models.TimeEntry{}}
+	for _, entry := range entries {
+		entry.Elapse(now)
+		result.TotalSeconds += entry.Seconds
+		if entry.TaskID == taskID {
+			result.Seconds += entry.Seconds
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (s *timeService) ProjectTime(projectID string) (models.ProjectTime, error) {
+	if _, err := s.projects.FindByID(projectID); err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("Failed to find project for time report")
+		return models.ProjectTime{}, err
+	}
+	entries, err := s.repo.FindByProject(projectID)
+	if err != nil {
+		return models.ProjectTime{}, err
+	}
+	now := s.clock.Now()
+	result := models.ProjectTime{ProjectID: projectID}
+	for _, entry := range entries {
+		entry.Elapse(now)
+		result.TotalSeconds += entry.Seconds
+	}
+	return result, nil
+}
+
+// Timesheet adds up the entries in the date range by group. Entries count
+// toward the day they started on, in the query's time zone.
+func (s *timeService) Timesheet(query models.TimesheetQuery) (models.Timesheet, error) {
+	if err := s.validator.Struct(query); err != nil {
+		log.Error().Err(err).Msg("Validation failed for TimesheetQuery")
+		return models.Timesheet{}, err
+	}
+	location, err := time.LoadLocation(query.TimeZone)
+	if err != nil {
+		return models.Timesheet{}, err
+	}
+	from, _ := time.ParseInLocation("2006-01-02", query.From, location)
+	to, _ := time.ParseInLocation("2006-01-02", query.To, location)
+	if to.Before(from) {
+		return models.Timesheet{}, apperrors.NewValidationError("Invalid date range", map[string]string{
+			"to": "must not be before from",
+		})
+	}
+
+	entries, err := s.repo.FindForTimesheet(repository.TimesheetFilter{
+		From:      from,
+		To:        to.AddDate(0, 0, 1),
+		UserID:    query.UserID,
+		ProjectID: query.ProjectID,
+	})
+	if err != nil {
+		return models.Timesheet{}, err
+	}
+
+	now := s.clock.Now()
+	sheet := models.Timesheet{
+		From:     query.From,
+		To:       query.To,
+		TimeZone: location.String(),
+		GroupBy:  query.GroupBy,
+		Rows:     []models.TimesheetRow{},
+	}
+	if sheet.GroupBy == nil {
+		sheet.GroupBy = []string{}
+	}
+	rows := make(map[string]*models.TimesheetRow)
+	var keys []string
+	for _, entry := range entries {
+		entry.Elapse(now)
+		row := timesheetRow(entry, query.GroupBy, location)
+		key := fmt.Sprintf("%s|%s|%s|%s|%s", row.Day, row.Week, row.UserID, row.ProjectID, row.TaskID)
+		if rows[key] == nil {
+			rows[key] = &row
+			keys = append(keys, key)
+		}
+		rows[key].Seconds += entry.Seconds
+		rows[key].Entries++
+		sheet.TotalSeconds += entry.Seconds
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sheet.Rows = append(sheet.Rows, *rows[key])
+	}
+	return sheet, nil
+}
+
+// timesheetRow returns the empty row of the group an entry belongs to.
+func timesheetRow(entry models.TimesheetEntry, groupBy []string, location *time.Location) models.TimesheetRow {
+	var row models.TimesheetRow
+	started := entry.StartedAt.In(location)
+	for _, group := range groupBy {
+		switch group {
+		case models.GroupByDay:
+			row.Day = started.Format("2006-01-02")
+		case models.GroupByWeek:
+			year, week := started.ISOWeek()
+			row.Week = fmt.Sprintf("%d-W%02d", year, week)
+		case models.GroupByUser:
+			row.UserID, row.Username = entry.UserID, entry.Username
+		case models.GroupByProject:
+			if entry.ProjectID != nil {
+				row.ProjectID, row.ProjectName = *entry.ProjectID, entry.ProjectName
+			}
+		case models.GroupByTask:
+			row.TaskID, row.TaskTitle = entry.TaskID, entry.TaskTitle
+		}
+	}
+	return row
+}
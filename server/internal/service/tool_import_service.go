@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	apperrors "taskmanager/internal/errors"
+	"taskmanager/internal/importers"
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Limits of the task fields an import from another tool fills
+const (
+	maxTitleLength         = 100
+	minTitleLength         = 3
+	maxTagLength           = 50
+	maxChecklistTextLength = 255
+)
+
+type ToolImportService interface {
+	// Sources lists the tools tasks can be imported from.
+	Sources() []string
+	// Import reads the export of another tool from r and creates its
+	// projects and tasks.
+	Import(source string, r io.Reader, options models.ToolImportOptions) (models.ToolImportReport, error)
+}
+
+type toolImportService struct {
+	projects ProjectService
+	tasks    TaskService
+	taskRepo repository.TaskRepository
+}
+
+func NewToolImportService(projects ProjectService, tasks TaskService, taskRepo repository.TaskRepository) ToolImportService {
+	return &toolImportService{projects: projects, tasks: tasks, taskRepo: taskRepo}
+}
+
+func (s *toolImportService) Sources() []string {
+	return importers.Sources()
+}
+
+func (s *toolImportService) Import(source string, r io.Reader, options models.ToolImportOptions) (models.ToolImportReport, error) {
+	importer, ok := importers.Get(source)
+	if !ok {
+		return models.ToolImportReport{}, apperrors.NewValidationError("Unknown source", map[string]string{
+			"source": "must be one of " + strings.Join(importers.Sources(), ", "),
+		})
+	}
+	result, err := importer.Parse(r)
+	if err != nil {
+		var parseErr *importers.ParseError
+		if errors.As(err, &parseErr) {
+			return models.ToolImportReport{}, apperrors.NewValidationError("Invalid file", map[string]string{
+				"file": parseErr.Err.Error(),
+			})
+		}
+		log.Error().Err(err).Str("source", source).Msg("Failed to read import")
+		return models.ToolImportReport{}, err
+	}
+
+	report := models.ToolImportReport{
+		Source:   source,
+		DryRun:   options.DryRun,
+		Projects: []models.ToolImportProject{},
+		Unmapped: append([]string{}, result.Unmapped...),
+	}
+	existing, err := s.importedTasks(source, result)
+	if err != nil {
+		return models.ToolImportReport{}, err
+	}
+	targets, err := s.targetProjects(result, options)
+	if err != nil {
+		return models.ToolImportReport{}, err
+	}
+
+	// Nothing is created before the whole source has been read.
+	byName := make(map[string]int)
+	var nodes []models.TaskTreeInput
+	var projectNodes [][]int
+	for _, project := range result.Projects {
+		target := targets[project.Name]
+		i := -1
+		if target.Name != "" {
+			var ok bool
+			if i, ok = byName[target.Name]; !ok {
+				i = len(report.Projects)
+				byName[target.Name] = i
+				report.Projects = append(report.Projects, target)
+				projectNodes = append(projectNodes, nil)
+			}
+		}
+		for _, task := range project.Tasks {
+			node, created, ok := s.treeInput(source, task, existing, &report)
+			if !ok {
+				continue
+			}
+			if i >= 0 {
+				report.Projects[i].Tasks += created
+				projectNodes[i] = append(projectNodes[i], len(nodes))
+			}
+			report.Created += created
+			nodes = append(nodes, node)
+		}
+	}
+	// Projects whose tasks were all imported before are left alone.
+	projects := report.Projects[:0]
+	var indexes [][]int
+	for i, project := range report.Projects {
+		if project.Tasks > 0 {
+			projects = append(projects, project)
+			indexes = append(indexes, projectNodes[i])
+		}
+	}
+	report.Projects, projectNodes = projects, indexes
+	if options.DryRun || len(nodes) == 0 {
+		return report, nil
+	}
+
+	for i := range report.Projects {
+		project := &report.Projects[i]
+		if !project.Created {
+			continue
+		}
+		created, err := s.projects.CreateProject(models.ProjectInput{Name: project.Name})
+		if err != nil {
+			log.Error().Err(err).Str("name", project.Name).Msg("Failed to create imported project")
+			return models.ToolImportReport{}, err
+		}
+		project.ID = created.ID
+	}
+	for i, indexes := range projectNodes {
+		projectID := report.Projects[i].ID
+		for _, index := range indexes {
+			setProject(&nodes[index], projectID)
+		}
+	}
+	if _, err := s.tasks.CreateTaskTree(nodes); err != nil {
+		log.Error().Err(err).Str("source", source).Msg("Failed to create imported tasks")
+		return models.ToolImportReport{}, err
+	}
+	return report, nil
+}
+
+// targetProjects picks the project each project of the source goes to, by
+// name. Projects yet to be created have no ID.
+func (s *toolImportService) targetProjects(result *importers.Result, options models.ToolImportOptions) (map[string]models.ToolImportProject, error) {
+	targets := make(map[string]models.ToolImportProject, len(result.Projects))
+	if options.ProjectID != nil {
+		project, err := s.projects.GetProjectByID(*options.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range result.Projects {
+			targets[source.Name] = models.ToolImportProject{ID: project.ID, Name: project.Name}
+		}
+		return targets, nil
+	}
+
+	projects, err := s.projects.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]models.Project, len(projects))
+	for _, project := range projects {
+		if _, ok := existing[project.Name]; !ok {
+			existing[project.Name] = project
+		}
+	}
+	for _, source := range result.Projects {
+		name := strings.TrimSpace(source.Name)
+		if name == "" {
+			name = options.DefaultProject
+		}
+		name = truncate(name, maxTitleLength)
+		if name == "" {
+			targets[source.Name] = models.ToolImportProject{}
+		} else if project, ok := existing[name]; ok {
+			targets[source.Name] = models.ToolImportProject{ID: project.ID, Name: project.Name}
+		} else {
+			targets[source.Name] = models.ToolImportProject{Name: name, Created: true}
+		}
+	}
+	return targets, nil
+}
+
+// importedTasks returns the external IDs of the tasks of the source that
+// were imported before.
+func (s *toolImportService) importedTasks(source string, result *importers.Result) (map[string]bool, error) {
+	var ids []string
+	var collect func(tasks []importers.Task)
+	collect = func(tasks []importers.Task) {
+		for _, task := range tasks {
+			if task.ID != "" {
+				ids = append(ids, toolExternalID(source, task.ID))
+			}
+			collect(task.Subtasks)
+		}
+	}
+	for _, project := range result.Projects {
+		collect(project.Tasks)
+	}
+	found, err := s.taskRepo.FindByExternalIDs(ids)
+	if err != nil {
+		log.Error().Err(err).Str("source", source).Msg("Failed to find imported tasks")
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// treeInput turns a task of the source and its subtasks into the input
+// creating them, fitting its values to the limits of tasks here. It
+// returns how many tasks the input creates, and false for a task that was
+// imported before or cannot be imported.
+func (s *toolImportService) treeInput(source string, task importers.Task, existing map[string]bool, report *models.ToolImportReport) (models.TaskTreeInput, int, bool) {
+	var externalID *string
+	if task.ID != "" {
+		id := toolExternalID(source, task.ID)
+		if existing[id] {
+			report.Skipped += countTasks(task)
+			return models.TaskTreeInput{}, 0, false
+		}
+		externalID = &id
+	}
+
+	title := strings.Join(strings.Fields(task.Title), " ")
+	if utf8.RuneCountInString(title) < minTitleLength {
+		report.Unmapped = append(report.Unmapped, fmt.Sprintf("task %q has too short a title and was left out with its subtasks", title))
+		return models.TaskTreeInput{}, 0, false
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		report.Unmapped = append(report.Unmapped, fmt.Sprintf("title of %q was cut to %d characters", title, maxTitleLength))
+		title = truncate(title, maxTitleLength)
+	}
+
+	input := models.CreateTaskInput{
+		Title:       title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Completed:   task.Completed,
+		ExternalID:  externalID,
+	}
+	if _, zone, err := models.ParseDueDate(task.DueDate, task.DueTimeZone); err != nil {
+		report.Unmapped = append(report.Unmapped, fmt.Sprintf("due date %q of %q", task.DueDate, title))
+	} else {
+		input.DueDate, input.DueTimeZone = task.DueDate, zone
+	}
+	for _, tag := range task.Tags {
+		input.Tags = append(input.Tags, truncate(tag, maxTagLength))
+	}
+
+	node := models.TaskTreeInput{Task: input}
+	for _, item := range task.Checklist {
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			continue
+		}
+		node.Checklist = append(node.Checklist, truncate(text, maxChecklistTextLength))
+		node.ChecklistDone = append(node.ChecklistDone, item.Done)
+	}
+	created := 1
+	for _, subtask := range task.Subtasks {
+		child, n, ok := s.treeInput(source, subtask, existing, report)
+		if ok {
+			node.Children = append(node.Children, child)
+			created += n
+		}
+	}
+	return node, created, true
+}
+
+// toolExternalID is the external ID of a task imported from a source.
+func toolExternalID(source, id string) string {
+	return truncate(source+":"+id, 255)
+}
+
+// countTasks counts a task and its subtasks.
+func countTasks(task importers.Task) int {
+	n := 1
+	for _, subtask := range task.Subtasks {
+		n += countTasks(subtask)
+	}
+	return n
+}
+
+// setProject puts a task and its subtasks in a project.
+func setProject(node *models.TaskTreeInput, projectID string) {
+	node.Task.ProjectID = &projectID
+	for i := range node.Children {
+		setProject(&node.Children[i], projectID)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type UserService interface {
+	GetAllUsers() ([]models.User, error)
+	GetUserByID(id string) (models.User, error)
+	// GetUsersByIDs returns the users with the given IDs that exist, in no
+	// particular order.
+	GetUsersByIDs(ids []string) ([]models.User, error)
+	CreateUser(input models.CreateUserInput) (models.User, error)
+	UpdateUser(id string, input models.UpdateUserInput) (models.User, error)
+}
+
+type userService struct {
+	repo      repository.UserRepository
+	validator *validator.Validate
+}
+
+func NewUserService(repo repository.UserRepository) UserService {
+	return &userService{
+		repo:      repo,
+		validator: validator.New(),
+	}
+}
+
+func (s *userService) GetAllUsers() ([]models.User, error) {
+	users, err := s.repo.FindAll()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch all users from repository")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *userService) GetUserByID(id string) (models.User, error) {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch user from repository")
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s *userService) GetUsersByIDs(ids []string) ([]models.User, error) {
+	users, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users by ID from repository")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *userService) CreateUser(input models.CreateUserInput) (models.User, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for CreateUserInput")
+		return models.User{}, err
+	}
+
+	user := models.User{
+		ID:          uuid.New().String(),
+		Username:    input.Username,
+		DisplayName: input.DisplayName,
+		Email:       input.Email,
+		TimeZone:    timeZoneOrDefault(input.TimeZone),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	createdUser, err := s.repo.Create(user)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create user in repository")
+		return models.User{}, err
+	}
+	return createdUser, nil
+}
+
+func (s *userService) UpdateUser(id string, input models.UpdateUserInput) (models.User, error) {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for UpdateUserInput")
+		return models.User{}, err
+	}
+
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to find user for update")
+		return models.User{}, err
+	}
+
+	if input.DisplayName != nil {
+		user.DisplayName = *input.DisplayName
+	}
+	if input.Email != nil {
+		user.Email = *input.Email
+	}
+	if input.TimeZone != "" {
+		user.TimeZone = input.TimeZone
+	}
+	user.UpdatedAt = time.Now()
+
+	updatedUser, err := s.repo.Update(user)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update user in repository")
+		return models.User{}, err
+	}
+	return updatedUser, nil
+}
+
+func timeZoneOrDefault(timeZone string) string {
+	if timeZone == "" {
+		return "UTC"
+	}
+	return timeZone
+}
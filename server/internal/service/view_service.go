@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"taskmanager/internal/models"
+	"taskmanager/internal/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrViewForbidden is returned when someone other than the owner tries to
+// modify a view.
+var ErrViewForbidden = errors.New("only the owner can modify this view")
+
+type ViewService interface {
+	ListViews(userID string) ([]models.View, error)
+	GetView(userID, id string) (models.View, error)
+	CreateView(userID string, input models.ViewInput) (models.View, error)
+	UpdateView(userID, id string, input models.ViewInput) (models.View, error)
+	DeleteView(userID, id string) error
+	// GetViewTasks runs the view's query with days such as today taken
+	// in timeZone.
+	GetViewTasks(userID, id, timeZone string) ([]models.Task, error)
+}
+
+type viewService struct {
+	repo      repository.ViewRepository
+	tasks     TaskService
+	validator *validator.Validate
+}
+
+func NewViewService(repo repository.ViewRepository, tasks TaskService) ViewService {
+	return &viewService{
+		repo:      repo,
+		tasks:     tasks,
+		validator: validator.New(),
+	}
+}
+
+func (s *viewService) ListViews(userID string) ([]models.View, error) {
+	views, err := s.repo.FindVisible(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch views from repository")
+		return nil, err
+	}
+	return views, nil
+}
+
+// GetView returns a view owned by the user or shared with everyone. Other
+// users' private views are reported as not found.
+func (s *viewService) GetView(userID, id string) (models.View, error) {
+	view, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to fetch view from repository")
+		return models.View{}, err
+	}
+	if view.OwnerID != userID && !view.Shared {
+		return models.View{}, repository.ErrNotFound
+	}
+	return view, nil
+}
+
+func (s *viewService) CreateView(userID string, input models.ViewInput) (models.View, error) {
+	view := models.View{
+		ID:        uuid.New().String(),
+		OwnerID:   userID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.apply(&view, input); err != nil {
+		return models.View{}, err
+	}
+
+	createdView, err := s.repo.Create(view)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create view in repository")
+		return models.View{}, err
+	}
+	return createdView, nil
+}
+
+func (s *viewService) UpdateView(userID, id string, input models.ViewInput) (models.View, error) {
+	view, err := s.GetView(userID, id)
+	if err != nil {
+		return models.View{}, err
+	}
+	if view.OwnerID != userID {
+		return models.View{}, ErrViewForbidden
+	}
+	if err := s.apply(&view, input); err != nil {
+		return models.View{}, err
+	}
+
+	updatedView, err := s.repo.Update(view)
+	if err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to update view in repository")
+		return models.View{}, err
+	}
+	return updatedView, nil
+}
+
+func (s *viewService) DeleteView(userID, id string) error {
+	view, err := s.GetView(userID, id)
+	if err != nil {
+		return err
+	}
+	if view.OwnerID != userID {
+		return ErrViewForbidden
+	}
+	if err := s.repo.Delete(id); err != nil {
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete view from repository")
+		return err
+	}
+	return nil
+}
+
+// GetViewTasks runs the view's query like the normal task list.
+func (s *viewService) GetViewTasks(userID, id, timeZone string) ([]models.Task, error) {
+	view, err := s.GetView(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	query := view.TaskQuery()
+	query.TimeZone = timeZone
+	query.UserID = userID
+	return s.tasks.GetAllTasks(query)
+}
+
+// apply validates the input, including its query, and copies it onto view.
+func (s *viewService) apply(view *models.View, input models.ViewInput) error {
+	if err := s.validator.Struct(input); err != nil {
+		log.Error().Err(err).Msg("Validation failed for ViewInput")
+		return err
+	}
+
+	view.Name = input.Name
+	view.Query = input.Query
+	view.ProjectID = input.ProjectID
+	view.Sort = input.Sort
+	view.Order = input.Order
+	view.Shared = input.Shared
+	view.UpdatedAt = time.Now()
+	query := view.TaskQuery()
+	query.UserID = view.OwnerID
+	return s.tasks.CheckQuery(query)
+}
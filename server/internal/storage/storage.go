@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in the store.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore persists opaque binary objects under string keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
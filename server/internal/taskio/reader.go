@@ -0,0 +1,267 @@
+package taskio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineBytes bounds one line of an NDJSON or Markdown file
+const maxLineBytes = 1 << 20
+
+// Record is one task read from a file: its values by column or key, and
+// the line it is on, or its position in a JSON array.
+type Record struct {
+	Row    int
+	Fields map[string]interface{}
+}
+
+// Reader reads the records of a file one at a time. CSV and Markdown
+// values are strings, and empty cells are left out; JSON values keep their
+// type.
+type Reader interface {
+	// Read returns the next record, or io.EOF after the last one.
+	Read() (Record, error)
+}
+
+// SyntaxError is a file that cannot be read past a line, or an element of
+// a JSON array.
+type SyntaxError struct {
+	Row int
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// NewReader returns a Reader of a format.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		return &csvReader{r: reader}, nil
+	case FormatJSON:
+		return &jsonReader{dec: json.NewDecoder(r)}, nil
+	case FormatNDJSON:
+		return &ndjsonReader{lines: newLineScanner(r)}, nil
+	case FormatMarkdown:
+		return &markdownReader{lines: newLineScanner(r)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	return scanner
+}
+
+// csvReader reads a CSV file with a header row
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+	// line is where the last record read starts
+	line int
+}
+
+func (r *csvReader) Read() (Record, error) {
+	if r.header == nil {
+		header, err := r.r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return Record{}, io.EOF
+			}
+			return Record{}, &SyntaxError{Row: 1, Err: err}
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		}
+		r.header = header
+		r.line = 1
+	}
+
+	cells, err := r.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		// FieldPos panics after a failed read
+		line := r.line + 1
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line = parseErr.StartLine
+		}
+		return Record{}, &SyntaxError{Row: line, Err: err}
+	}
+	r.line, _ = r.r.FieldPos(0)
+	return Record{Row: r.line, Fields: cellFields(r.header, cells)}, nil
+}
+
+// cellFields maps the non-empty cells of a row to their column.
+func cellFields(header, cells []string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(cells))
+	for i, cell := range cells {
+		if i < len(header) && header[i] != "" && strings.TrimSpace(cell) != "" {
+			fields[header[i]] = cell
+		}
+	}
+	return fields
+}
+
+// jsonReader reads an array of objects
+type jsonReader struct {
+	dec     *json.Decoder
+	started bool
+	count   int
+}
+
+func (r *jsonReader) Read() (Record, error) {
+	if !r.started {
+		r.started = true
+		token, err := r.dec.Token()
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		if err != nil {
+			return Record{}, &SyntaxError{Row: 1, Err: err}
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return Record{}, &SyntaxError{Row: 1, Err: errors.New("expected an array of tasks")}
+		}
+	}
+	if !r.dec.More() {
+		if _, err := r.dec.Token(); err != nil {
+			return Record{}, &SyntaxError{Row: r.count + 1, Err: err}
+		}
+		return Record{}, io.EOF
+	}
+
+	r.count++
+	var fields map[string]interface{}
+	if err := r.dec.Decode(&fields); err != nil {
+		return Record{}, &SyntaxError{Row: r.count, Err: err}
+	}
+	if fields == nil {
+		return Record{}, &SyntaxError{Row: r.count, Err: errors.New("expected a task object")}
+	}
+	return Record{Row: r.count, Fields: fields}, nil
+}
+
+// ndjsonReader reads one object per line, skipping blank lines
+type ndjsonReader struct {
+	lines *bufio.Scanner
+	line  int
+}
+
+func (r *ndjsonReader) Read() (Record, error) {
+	for r.lines.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.lines.Text())
+		if text == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &fields); err != nil {
+			return Record{}, &SyntaxError{Row: r.line, Err: err}
+		}
+		if fields == nil {
+			return Record{}, &SyntaxError{Row: r.line, Err: errors.New("expected a task object")}
+		}
+		return Record{Row: r.line, Fields: fields}, nil
+	}
+	if err := r.lines.Err(); err != nil {
+		return Record{}, &SyntaxError{Row: r.line + 1, Err: err}
+	}
+	return Record{}, io.EOF
+}
+
+// markdownReader reads the first table of a Markdown file, which ends at
+// the first line that is not a table row
+type markdownReader struct {
+	lines  *bufio.Scanner
+	line   int
+	header []string
+	done   bool
+}
+
+func (r *markdownReader) Read() (Record, error) {
+	if r.done {
+		return Record{}, io.EOF
+	}
+	for r.lines.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.lines.Text())
+		if r.header == nil {
+			if strings.HasPrefix(text, "|") {
+				if err := r.readHeader(text); err != nil {
+					return Record{}, err
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(text, "|") {
+			r.done = true
+			return Record{}, io.EOF
+		}
+		return Record{Row: r.line, Fields: cellFields(r.header, splitRow(text))}, nil
+	}
+	if err := r.lines.Err(); err != nil {
+		return Record{}, &SyntaxError{Row: r.line + 1, Err: err}
+	}
+	return Record{}, io.EOF
+}
+
+// readHeader reads the header row of the table and the delimiter row
+// under it.
+func (r *markdownReader) readHeader(text string) error {
+	header := splitRow(text)
+	if !r.lines.Scan() {
+		return &SyntaxError{Row: r.line, Err: errors.New("table has no delimiter row")}
+	}
+	r.line++
+	for _, cell := range splitRow(strings.TrimSpace(r.lines.Text())) {
+		if strings.Trim(cell, ":-") != "" || !strings.Contains(cell, "-") {
+			return &SyntaxError{Row: r.line, Err: errors.New("table has no delimiter row")}
+		}
+	}
+	r.header = header
+	return nil
+}
+
+// splitRow returns the cells of a table row, unescaped.
+func splitRow(text string) []string {
+	text = strings.TrimPrefix(text, "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && (text[i+1] == '|' || text[i+1] == '\\'):
+			cell.WriteByte(text[i+1])
+			i++
+		case text[i] == '|':
+			cells = append(cells, unescapeCell(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(text[i])
+		}
+	}
+	if strings.TrimSpace(cell.String()) != "" {
+		cells = append(cells, unescapeCell(cell.String()))
+	}
+	return cells
+}
+
+func unescapeCell(cell string) string {
+	return strings.ReplaceAll(strings.TrimSpace(cell), "<br>", "\n")
+}
@@ -0,0 +1,207 @@
+package taskio
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAll reads the records of a file.
+func readAll(format, file string) ([]Record, error) {
+	reader, err := NewReader(format, strings.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		want   []Record
+	}{
+		{
+			name:   "CSV",
+			format: FormatCSV,
+			file:   "\ufefftitle, tags ,status\nWrite docs,\"docs,q4\",todo\n\"Two\nlines\",,\n Spaces ,  ,done\n",
+			want: []Record{
+				{Row: 2, Fields: map[string]interface{}{"title": "Write docs", "tags": "docs,q4", "status": "todo"}},
+				{Row: 3, Fields: map[string]interface{}{"title": "Two\nlines"}},
+				{Row: 5, Fields: map[string]interface{}{"title": " Spaces ", "status": "done"}},
+			},
+		},
+		{
+			name:   "CSV with short and long rows",
+			format: FormatCSV,
+			file:   "title,status\nShort\nLong,todo,extra\n",
+			want: []Record{
+				{Row: 2, Fields: map[string]interface{}{"title": "Short"}},
+				{Row: 3, Fields: map[string]interface{}{"title": "Long", "status": "todo"}},
+			},
+		},
+		{name: "empty CSV", format: FormatCSV, file: ""},
+		{name: "CSV header alone", format: FormatCSV, file: "title,status\n"},
+		{
+			name:   "JSON",
+			format: FormatJSON,
+			file:   `[{"title": "Write docs", "estimate": 2, "tags": ["docs"]}, {"title": "Ship", "completed": true}]`,
+			want: []Record{
+				{Row: 1, Fields: map[string]interface{}{"title": "Write docs", "estimate": 2.0, "tags": []interface{}{"docs"}}},
+				{Row: 2, Fields: map[string]interface{}{"title": "Ship", "completed": true}},
+			},
+		},
+		{name: "empty JSON array", format: FormatJSON, file: "[]"},
+		{name: "empty JSON file", format: FormatJSON, file: ""},
+		{
+			name:   "NDJSON",
+			format: FormatNDJSON,
+			file:   "{\"title\": \"Write docs\"}\n\n  \n{\"title\": \"Ship\", \"estimate\": 1.5}\n",
+			want: []Record{
+				{Row: 1, Fields: map[string]interface{}{"title": "Write docs"}},
+				{Row: 4, Fields: map[string]interface{}{"title": "Ship", "estimate": 1.5}},
+			},
+		},
+		{
+			name:   "Markdown",
+			format: FormatMarkdown,
+			file: "# Tasks\n\nExported today.\n\n" +
+				"| title | tags | description |\n" +
+				"|:---|---:|:-:|\n" +
+				"| Write docs | docs,q4 | a \\| b |\n" +
+				"| Two | | line one<br>line two |\n" +
+				"| Path |  | C:\\\\temp\\\\ |\n" +
+				"\n| title |\n| --- |\n| In a second table |\n",
+			want: []Record{
+				{Row: 7, Fields: map[string]interface{}{"title": "Write docs", "tags": "docs,q4", "description": "a | b"}},
+				{Row: 8, Fields: map[string]interface{}{"title": "Two", "description": "line one\nline two"}},
+				{Row: 9, Fields: map[string]interface{}{"title": "Path", "description": `C:\temp\`}},
+			},
+		},
+		{
+			name:   "Markdown without outer pipes at the end",
+			format: FormatMarkdown,
+			file:   "| title | status\n| --- | ---\n| Ship | done\n",
+			want: []Record{
+				{Row: 3, Fields: map[string]interface{}{"title": "Ship", "status": "done"}},
+			},
+		},
+		{name: "Markdown without a table", format: FormatMarkdown, file: "# Nothing here\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAll(tt.format, tt.file)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		file    string
+		read    int // records read before the error
+		wantRow int
+	}{
+		{name: "CSV with a bare quote in the header", format: FormatCSV, file: "ti\"tle\nShip\n", wantRow: 1},
+		{name: "CSV with an unclosed quote", format: FormatCSV, file: "title\nShip\n\"Never\nclosed\n", read: 1, wantRow: 3},
+		{name: "JSON object", format: FormatJSON, file: `{"title": "Ship"}`, wantRow: 1},
+		{name: "JSON that is not JSON", format: FormatJSON, file: `tasks`, wantRow: 1},
+		{name: "JSON array of numbers", format: FormatJSON, file: `[{"title": "Ship"}, 3]`, read: 1, wantRow: 2},
+		{name: "JSON array with null", format: FormatJSON, file: `[null]`, wantRow: 1},
+		{name: "JSON array cut short", format: FormatJSON, file: `[{"title": "Ship"}, {"title": `, read: 1, wantRow: 2},
+		{name: "NDJSON line that is not JSON", format: FormatNDJSON, file: "{\"title\": \"Ship\"}\n\nnot json\n", read: 1, wantRow: 3},
+		{name: "NDJSON array", format: FormatNDJSON, file: "[1, 2]\n", wantRow: 1},
+		{name: "NDJSON null", format: FormatNDJSON, file: "null\n", wantRow: 1},
+		{name: "Markdown without a delimiter row", format: FormatMarkdown, file: "Intro\n| title |\n| Ship |\n", wantRow: 3},
+		{name: "Markdown ending after the header", format: FormatMarkdown, file: "| title |", wantRow: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := readAll(tt.format, tt.file)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Read() error = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Row != tt.wantRow || len(records) != tt.read {
+				t.Errorf("Read() error on row %d after %d records, want row %d after %d", syntaxErr.Row, len(records), tt.wantRow, tt.read)
+			}
+		})
+	}
+}
+
+func TestNewReaderUnknownFormat(t *testing.T) {
+	if _, err := NewReader("xml", strings.NewReader("")); err == nil {
+		t.Error("NewReader() error = nil, want an error")
+	}
+}
+
+func TestSplitRow(t *testing.T) {
+	tests := []struct {
+		row  string
+		want []string
+	}{
+		{row: "| a | b |", want: []string{"a", "b"}},
+		{row: "| a | b", want: []string{"a", "b"}},
+		{row: "|  | b |", want: []string{"", "b"}},
+		{row: `| a \| b | c |`, want: []string{"a | b", "c"}},
+		{row: `| a \\| b |`, want: []string{`a \`, "b"}},
+		{row: `| \n \x |`, want: []string{`\n \x`}},
+		{row: "| one<br>two |", want: []string{"one\ntwo"}},
+		{row: "|", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.row, func(t *testing.T) {
+			if got := splitRow(tt.row); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitRow() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeCell(t *testing.T) {
+	for _, cell := range []string{
+		"plain",
+		"a | b",
+		`back\slash`,
+		`ends with \`,
+		`\|`,
+		"one\ntwo",
+		"one\r\ntwo\rthree",
+		"| leading and trailing |",
+	} {
+		t.Run(cell, func(t *testing.T) {
+			escaped := escapeCell(cell)
+			if strings.ContainsAny(escaped, "\r\n") {
+				t.Errorf("escapeCell() = %q, want one line", escaped)
+			}
+			cells := splitRow("| " + escaped + " |")
+			want := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(cell)
+			if len(cells) != 1 || cells[0] != want {
+				t.Errorf("splitRow(escapeCell()) = %q, want [%q]", cells, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package taskio writes task lists to files and reads them back, as CSV,
+// JSON, NDJSON or Markdown tables.
+package taskio
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+// Formats
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatCSV, FormatJSON, FormatNDJSON, FormatMarkdown}
+
+var contentTypes = map[string]string{
+	FormatCSV:      "text/csv; charset=utf-8",
+	FormatJSON:     "application/json",
+	FormatNDJSON:   "application/x-ndjson",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+var extensions = map[string]string{
+	FormatCSV:      "csv",
+	FormatJSON:     "json",
+	FormatNDJSON:   "ndjson",
+	FormatMarkdown: "md",
+}
+
+// Valid reports whether format is supported.
+func Valid(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
+
+// ContentType returns the media type of files in a format.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Extension returns the file name extension of a format.
+func Extension(format string) string {
+	return extensions[format]
+}
+
+// FormatOf returns the format of a media type, or "" for none of them.
+func FormatOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	for format, known := range contentTypes {
+		if known, _, _ := strings.Cut(known, ";"); known == mediaType {
+			return format
+		}
+	}
+	return ""
+}
+
+// Columns are the fields of a task in CSV and Markdown files, followed by
+// a "cf.<key>" column per custom field exported. JSON and NDJSON files
+// have the whole task.
+var Columns = []string{
+	"id", "external_id", "title", "description", "status", "priority",
+	"due_date", "due_time_zone", "estimate", "recurrence", "tags",
+	"project_id", "parent_id", "sprint_id", "assignees", "created_at", "updated_at",
+}
+
+// Header returns Columns and the columns of the custom fields with the
+// given keys.
+func Header(fieldKeys []string) []string {
+	header := append([]string{}, Columns...)
+	for _, key := range fieldKeys {
+		header = append(header, "cf."+key)
+	}
+	return header
+}
+
+// Row returns the cells of a task, by Header. Tags, assignees and the
+// options of multi-select fields are separated by commas.
+func Row(task models.Task, fieldKeys []string) []string {
+	dueTimeZone := ""
+	if task.HasDueDate() && !task.DueAllDay() {
+		dueTimeZone = task.DueTimeZone
+	}
+	estimate := ""
+	if task.Estimate != 0 {
+		estimate = strconv.FormatFloat(task.Estimate, 'f', -1, 64)
+	}
+	row := []string{
+		task.ID,
+		deref(task.ExternalID),
+		task.Title,
+		task.Description,
+		task.Status,
+		task.Priority,
+		task.FormatDueDate(),
+		dueTimeZone,
+		estimate,
+		task.Recurrence,
+		strings.Join(task.Tags, ","),
+		deref(task.ProjectID),
+		deref(task.ParentID),
+		deref(task.SprintID),
+		strings.Join(task.Assignees, ","),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, key := range fieldKeys {
+		row = append(row, fieldCell(task.CustomFields[key]))
+	}
+	return row
+}
+
+// fieldCell writes a custom field value the way imports read it back.
+func fieldCell(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fieldCell(item)
+		}
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(value, ",")
+	default:
+		return ""
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
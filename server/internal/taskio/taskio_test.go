@@ -0,0 +1,115 @@
+package taskio
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"taskmanager/internal/models"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{contentType: "text/csv", want: FormatCSV},
+		{contentType: "Text/CSV; charset=utf-8", want: FormatCSV},
+		{contentType: "application/json", want: FormatJSON},
+		{contentType: "application/x-ndjson", want: FormatNDJSON},
+		{contentType: " text/markdown ;charset=utf-8", want: FormatMarkdown},
+		{contentType: "text/plain", want: ""},
+		{contentType: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := FormatOf(tt.contentType); got != tt.want {
+				t.Errorf("FormatOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRow(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, parent := "project-1", "task-0"
+	created := time.Date(2026, time.October, 14, 9, 30, 0, 0, newYork)
+	fieldKeys := []string{"points", "labels", "note", "missing"}
+
+	tests := []struct {
+		name string
+		task models.Task
+		want map[string]string // the cells that are not empty, by column
+	}{
+		{
+			name: "all fields",
+			task: models.Task{
+				ID: "task-1", Title: "Ship, then \"celebrate\"", Description: "Line one\nLine two",
+				Status: models.StatusInProgress, Priority: "high", Estimate: 2.5, Recurrence: "FREQ=WEEKLY",
+				DueDate: time.Date(2026, time.October, 20, 17, 0, 0, 0, newYork), DueTimeZone: "America/New_York",
+				Tags: []string{"ops", "q4"}, Assignees: []string{"alice", "bob"},
+				ProjectID: &project, ParentID: &parent, CreatedAt: created, UpdatedAt: created.Add(time.Hour),
+				CustomFields: map[string]interface{}{
+					"points": 3.0,
+					"labels": []interface{}{"a", "b c"},
+					"note":   "x | y",
+				},
+			},
+			want: map[string]string{
+				"id": "task-1", "title": "Ship, then \"celebrate\"", "description": "Line one\nLine two",
+				"status": "in_progress", "priority": "high", "estimate": "2.5", "recurrence": "FREQ=WEEKLY",
+				"due_date": "2026-10-20T17:00:00-04:00", "due_time_zone": "America/New_York",
+				"tags": "ops,q4", "assignees": "alice,bob", "project_id": "project-1", "parent_id": "task-0",
+				"created_at": "2026-10-14T13:30:00Z", "updated_at": "2026-10-14T14:30:00Z",
+				"cf.points": "3", "cf.labels": "a,b c", "cf.note": "x | y",
+			},
+		},
+		{
+			name: "due all day",
+			task: models.Task{
+				ID: "task-2", Title: "Renew", Status: models.StatusTodo, Priority: "none",
+				DueDate: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), CreatedAt: created, UpdatedAt: created,
+			},
+			want: map[string]string{
+				"id": "task-2", "title": "Renew", "status": "todo", "priority": "none", "due_date": "2026-11-01",
+				"created_at": "2026-10-14T13:30:00Z", "updated_at": "2026-10-14T13:30:00Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, row := Header(fieldKeys), Row(tt.task, fieldKeys)
+			if len(row) != len(header) {
+				t.Fatalf("Row() has %d cells, want %d", len(row), len(header))
+			}
+			got := make(map[string]string)
+			for i, cell := range row {
+				if cell != "" {
+					got[header[i]] = cell
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Row() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeader(t *testing.T) {
+	header := Header([]string{"points", "labels"})
+	if !reflect.DeepEqual(header[:len(Columns)], Columns) {
+		t.Errorf("Header() starts with %v, want Columns", header[:len(Columns)])
+	}
+	if got := header[len(Columns):]; !reflect.DeepEqual(got, []string{"cf.points", "cf.labels"}) {
+		t.Errorf("Header() ends with %v, want the custom field columns", got)
+	}
+	if len(Header(nil)) != len(Columns) {
+		t.Errorf("Header(nil) = %v, want Columns", Header(nil))
+	}
+}
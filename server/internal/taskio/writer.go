@@ -0,0 +1,164 @@
+package taskio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"taskmanager/internal/models"
+)
+
+// Writer writes tasks one at a time, so that long lists need not be held
+// in memory.
+type Writer interface {
+	Write(task models.Task) error
+	// Close ends the file. It does not close the underlying writer.
+	Close() error
+}
+
+// NewWriter returns a Writer of a format. CSV and Markdown files have a
+// column for each of the custom fields with fieldKeys.
+func NewWriter(format string, w io.Writer, fieldKeys []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w), fieldKeys: fieldKeys}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatMarkdown:
+		return &markdownWriter{w: bufio.NewWriter(w), fieldKeys: fieldKeys}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w         *csv.Writer
+	fieldKeys []string
+	started   bool
+}
+
+func (w *csvWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.w.Write(Header(w.fieldKeys))
+}
+
+func (w *csvWriter) Write(task models.Task) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.w.Write(Row(task, w.fieldKeys)); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// jsonWriter writes an array of tasks
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (w *jsonWriter) Write(task models.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if w.count == 0 {
+		separator = "[\n"
+	}
+	w.count++
+	if _, err := io.WriteString(w.w, separator); err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return err
+}
+
+// ndjsonWriter writes one task per line
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(task models.Task) error {
+	return w.enc.Encode(task)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// markdownWriter writes a table of tasks
+type markdownWriter struct {
+	w         *bufio.Writer
+	fieldKeys []string
+	started   bool
+}
+
+func (w *markdownWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	header := Header(w.fieldKeys)
+	w.row(header)
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	w.row(separators)
+}
+
+func (w *markdownWriter) row(cells []string) {
+	w.w.WriteString("|")
+	for _, cell := range cells {
+		w.w.WriteString(" ")
+		w.w.WriteString(escapeCell(cell))
+		w.w.WriteString(" |")
+	}
+	w.w.WriteString("\n")
+}
+
+func (w *markdownWriter) Write(task models.Task) error {
+	w.start()
+	w.row(Row(task, w.fieldKeys))
+	return w.w.Flush()
+}
+
+func (w *markdownWriter) Close() error {
+	w.start()
+	return w.w.Flush()
+}
+
+// cellEscaper keeps a table cell on one line and its pipes in the cell
+var cellEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func escapeCell(cell string) string {
+	return cellEscaper.Replace(cell)
+}
@@ -0,0 +1,144 @@
+package taskio
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmanager/internal/models"
+)
+
+// sampleTasks have the characters each format escapes.
+func sampleTasks() []models.Task {
+	project := "project-1"
+	created := time.Date(2026, time.October, 14, 9, 30, 0, 0, time.UTC)
+	return []models.Task{
+		{
+			ID: "task-1", Title: "Ship, then \"celebrate\"", Description: "Pipes | and \\ backslashes\nover two lines",
+			Status: models.StatusTodo, Priority: "high", Estimate: 3, Tags: []string{"ops", "q4"},
+			ProjectID: &project, CreatedAt: created, UpdatedAt: created,
+			CustomFields: map[string]interface{}{"points": 2.5, "labels": []interface{}{"a", "b c"}, "note": "x | y\r\nz"},
+		},
+		{
+			ID: "task-2", Title: "Ünïcode ✔ and a trailing \\", Status: models.StatusDone, Priority: "none",
+			DueDate: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), ProjectID: &project,
+			CreatedAt: created, UpdatedAt: created, CustomFields: map[string]interface{}{},
+		},
+	}
+}
+
+func write(t *testing.T, format string, tasks []models.Task, fieldKeys []string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf, fieldKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range tasks {
+		if err := writer.Write(task); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteEmpty(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: FormatCSV, want: "id,external_id,title,description,status,priority,due_date,due_time_zone,estimate,recurrence,tags,project_id,parent_id,sprint_id,assignees,created_at,updated_at,cf.points\n"},
+		{format: FormatJSON, want: "[]\n"},
+		{format: FormatNDJSON, want: ""},
+		{format: FormatMarkdown, want: "| id | external_id | title | description | status | priority | due_date | due_time_zone | estimate | recurrence | tags | project_id | parent_id | sprint_id | assignees | created_at | updated_at | cf.points |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := write(t, tt.format, nil, []string{"points"}); got != tt.want {
+				t.Errorf("output %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	got := write(t, FormatMarkdown, sampleTasks()[:1], []string{"note"})
+	want := "| id | external_id | title | description | status | priority | due_date | due_time_zone | estimate | recurrence | tags | project_id | parent_id | sprint_id | assignees | created_at | updated_at | cf.note |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		`| task-1 |  | Ship, then "celebrate" | Pipes \| and \\ backslashes<br>over two lines | todo | high |  |  | 3 |  | ops,q4 | project-1 |  |  |  | 2026-10-14T09:30:00Z | 2026-10-14T09:30:00Z | x \| y<br>z |` + "\n"
+	if got != want {
+		t.Errorf("output\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("xml", &bytes.Buffer{}, nil); err == nil {
+		t.Error("NewWriter() error = nil, want an error")
+	}
+}
+
+// TestRoundTrip reads back what each format writes.
+func TestRoundTrip(t *testing.T) {
+	fieldKeys := []string{"points", "labels", "note"}
+	tasks := sampleTasks()
+
+	// CSV and Markdown read back the non-empty cells of each row, with
+	// CRLF line breaks as LF. JSON reads back the whole task.
+	cells := make([]map[string]interface{}, len(tasks))
+	objects := make([]map[string]interface{}, len(tasks))
+	for i, task := range tasks {
+		header, row := Header(fieldKeys), Row(task, fieldKeys)
+		cells[i] = make(map[string]interface{})
+		for j, cell := range row {
+			if cell != "" {
+				cells[i][header[j]] = strings.ReplaceAll(cell, "\r\n", "\n")
+			}
+		}
+		data, err := json.Marshal(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &objects[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format string
+		want   []map[string]interface{}
+		rows   []int
+	}{
+		{format: FormatCSV, want: cells, rows: []int{2, 5}},
+		{format: FormatJSON, want: objects, rows: []int{1, 2}},
+		{format: FormatNDJSON, want: objects, rows: []int{1, 2}},
+		{format: FormatMarkdown, want: cells, rows: []int{3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			records, err := readAll(tt.format, write(t, tt.format, tasks, fieldKeys))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(records) != len(tt.want) {
+				t.Fatalf("read %d records, want %d", len(records), len(tt.want))
+			}
+			for i, record := range records {
+				if record.Row != tt.rows[i] {
+					t.Errorf("record %d on row %d, want %d", i, record.Row, tt.rows[i])
+				}
+				if !reflect.DeepEqual(record.Fields, tt.want[i]) {
+					t.Errorf("record %d = %q, want %q", i, record.Fields, tt.want[i])
+				}
+			}
+		})
+	}
+}